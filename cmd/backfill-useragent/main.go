@@ -0,0 +1,41 @@
+// cmd/backfill-useragent/main.go
+package main
+
+import (
+	"GoShort/config"
+	"GoShort/internal/datastore"
+	"GoShort/internal/server"
+	"GoShort/internal/stats"
+	"GoShort/pkg/database"
+	"GoShort/pkg/logger"
+	"context"
+	"flag"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 1000, "number of link_stats rows to process per batch")
+	flag.Parse()
+
+	// Load environment variables
+	server.LoadEnv()
+
+	// Load configuration
+	cfg := config.Load()
+
+	// Initialize logger
+	log := logger.New(cfg)
+
+	// Initialize PostgreSQL
+	db, err := database.NewPostgres(cfg, log)
+	if err != nil {
+		log.Fatalf("Failed to initialize PostgreSQL: %v", err)
+	}
+	defer db.Close()
+
+	updated, err := stats.BackfillUserAgents(context.Background(), datastore.New(db.DB), *batchSize, log)
+	if err != nil {
+		log.Fatalf("User-agent backfill failed after %d rows: %v", updated, err)
+	}
+
+	log.Infof("User-agent backfill completed, %d rows updated", updated)
+}
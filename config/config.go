@@ -7,16 +7,139 @@ import (
 
 // AppConfig holds all application configuration
 type AppConfig struct {
-	Server      ServerConfig
-	Database    DatabaseConfig
-	Redis       RedisConfig
-	Logger      LoggerConfig
-	JWT         JWT
-	SwaggerAuth SwaggerAuthConfig
-	BasicAuth   BasicAuthConfig
-	RateLimit   RateLimitConfig
-	SendGrid    SendGridConfig
-	GoogleSMTP  GoogleSMTPConfig `mapstructure:"GOOGLE_SMTP"`
+	Server       ServerConfig
+	Database     DatabaseConfig
+	Redis        RedisConfig
+	Logger       LoggerConfig
+	JWT          JWT
+	SwaggerAuth  SwaggerAuthConfig
+	BasicAuth    BasicAuthConfig
+	RateLimit    RateLimitConfig
+	SendGrid     SendGridConfig
+	GoogleSMTP   GoogleSMTPConfig `mapstructure:"GOOGLE_SMTP"`
+	LinkCache    LinkCacheConfig
+	Clicks       ClickPipelineConfig
+	GeoIP        GeoIPConfig
+	LinkPassword LinkPasswordConfig
+	Schedule     ScheduleConfig
+	ShortCode    ShortCodeConfig
+	Idempotency  IdempotencyConfig
+	URLPolicy    URLPolicyConfig
+	LinkHealth   LinkHealthConfig
+	LinkMetadata LinkMetadataConfig
+}
+
+// LinkMetadataConfig controls how the title, Open Graph tags and favicon of link
+// destinations are fetched. When Enabled, new destinations are fetched in the background
+// by Workers, with up to QueueSize waiting; owners can refresh them on demand either way.
+// A fetch gives up after Timeout and reads at most MaxBodyBytes of the page.
+type LinkMetadataConfig struct {
+	Enabled      bool
+	Workers      int
+	QueueSize    int
+	Timeout      time.Duration
+	MaxBodyBytes int64
+	UserAgent    string
+}
+
+// LinkHealthConfig controls the worker checking that link destinations still respond.
+// Every Interval it checks up to BatchSize links that are due, Concurrency at a time and
+// at most PerHostConcurrency per host. A working destination is checked again after
+// RecheckInterval, a failing one after RetryInterval, doubled with each failure; after
+// FailureThreshold failures in a row the link is flagged as broken.
+type LinkHealthConfig struct {
+	Enabled            bool
+	Interval           time.Duration
+	BatchSize          int
+	Concurrency        int
+	PerHostConcurrency int
+	Timeout            time.Duration
+	RecheckInterval    time.Duration
+	RetryInterval      time.Duration
+	FailureThreshold   int
+	UserAgent          string
+}
+
+// URLPolicyConfig controls which destinations links may point to. Destinations on a
+// private network are blocked when BlockPrivateNetworks is set; DNS lookups and chain
+// resolution give up after Timeout. Destinations on ShortenerDomains are rejected, or
+// followed up to MaxChainHops redirects and replaced by where they lead when
+// FlattenChains is set. BlocklistFile lists blocked domains and URL hashes, one per line.
+// Destinations longer than MaxURLLength are rejected. TrackingParams are left out of the
+// canonical form of a destination, and out of the stored one too when
+// StripTrackingParams is set.
+type URLPolicyConfig struct {
+	AllowedSchemes       []string
+	BlockPrivateNetworks bool
+	ShortenerDomains     []string
+	FlattenChains        bool
+	MaxChainHops         int
+	Timeout              time.Duration
+	BlocklistFile        string
+	MaxURLLength         int
+	TrackingParams       []string
+	StripTrackingParams  bool
+}
+
+// IdempotencyConfig controls the Idempotency-Key support of link creation. The response
+// to a key is replayed for TTL; a retry after that creates a new link.
+type IdempotencyConfig struct {
+	TTL time.Duration
+}
+
+// ShortCodeConfig controls how codes are generated for links created without one.
+// Strategy is random, no-confusables, sequential or hashid. A generated code that is
+// taken is retried one character longer, up to MaxLength, at most MaxAttempts times.
+// CaseInsensitive stores and matches codes in lowercase; codes stored with uppercase
+// letters before it was enabled are no longer reachable.
+type ShortCodeConfig struct {
+	Strategy        string
+	Length          int
+	MaxLength       int
+	MaxAttempts     int
+	CaseInsensitive bool
+}
+
+// ScheduleConfig controls the worker that applies scheduled destination changes. Every
+// Interval it applies up to BatchSize changes that are due.
+type ScheduleConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// LinkPasswordConfig controls throttling of password attempts on protected links.
+// After MaxAttempts wrong passwords from one IP the link is locked for that IP until
+// LockoutWindow has passed since the first failure. MaxLinkAttempts caps the wrong
+// passwords for a link from all IPs together in the same window.
+type LinkPasswordConfig struct {
+	MaxAttempts     int
+	MaxLinkAttempts int
+	LockoutWindow   time.Duration
+}
+
+// GeoIPConfig holds configuration for offline IP geolocation of clicks.
+// Provider is one of "mmdb", "csv" or "none".
+type GeoIPConfig struct {
+	Provider        string
+	DatabasePath    string
+	ASNDatabasePath string
+	CacheSize       int
+}
+
+// ClickPipelineConfig holds configuration for the asynchronous click ingestion pipeline
+type ClickPipelineConfig struct {
+	QueueSize     int
+	Workers       int
+	BatchSize     int
+	FlushInterval time.Duration
+	FlushTimeout  time.Duration
+}
+
+// LinkCacheConfig holds configuration for the Redis-backed redirect cache
+type LinkCacheConfig struct {
+	Enabled     bool
+	TTL         time.Duration
+	NegativeTTL time.Duration
 }
 
 type GoogleSMTPConfig struct {
@@ -73,6 +196,17 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	BaseURL      string
+	// TemplateDir holds HTML pages overriding the embedded redirect pages, by file name
+	TemplateDir string
+	// RedirectStatus is used for links without their own status: 301, 302, 307 or 308
+	RedirectStatus int
+	// PermanentRedirectMaxAge is how long clients may cache 301 and 308 redirects
+	PermanentRedirectMaxAge time.Duration
+	// ProxyHeader holds the client IP set by the reverse proxy, e.g. CF-Connecting-IP or
+	// X-Real-IP. It is only read on requests from TrustedProxies; any other request is
+	// identified by its remote address.
+	ProxyHeader    string
+	TrustedProxies []string
 }
 
 // RedisConfig Config holds Redis connection configuration
@@ -106,10 +240,15 @@ func Load() *AppConfig {
 			Password: getEnv("BASIC_AUTH_PASSWORD", "admin123"),
 		},
 		Server: ServerConfig{
-			Port:         getEnv("SERVER_PORT", "8080"),
-			ReadTimeout:  getDuration("SERVER_READ_TIMEOUT", 10*time.Second),
-			WriteTimeout: getDuration("SERVER_WRITE_TIMEOUT", 10*time.Second),
-			BaseURL:      getEnv("SERVER_BASE_URL", "http://localhost:8080"),
+			Port:                    getEnv("SERVER_PORT", "8080"),
+			ReadTimeout:             getDuration("SERVER_READ_TIMEOUT", 10*time.Second),
+			WriteTimeout:            getDuration("SERVER_WRITE_TIMEOUT", 10*time.Second),
+			BaseURL:                 getEnv("SERVER_BASE_URL", "http://localhost:8080"),
+			TemplateDir:             getEnv("SERVER_TEMPLATE_DIR", ""),
+			RedirectStatus:          getInt("SERVER_REDIRECT_STATUS", 302),
+			PermanentRedirectMaxAge: getDuration("SERVER_PERMANENT_REDIRECT_MAX_AGE", 24*time.Hour),
+			ProxyHeader:             getEnv("SERVER_PROXY_HEADER", ""),
+			TrustedProxies:          getList("SERVER_TRUSTED_PROXIES", nil),
 		},
 		Database: DatabaseConfig{
 			Host:        getEnv("DB_HOST", "localhost"),
@@ -157,5 +296,79 @@ func Load() *AppConfig {
 			Host:        getEnv("GOOGLE_SMTP_HOST", "smtp.gmail.com"),
 			Port:        getInt("GOOGLE_SMTP_PORT", 587),
 		},
+		LinkCache: LinkCacheConfig{
+			Enabled:     getBool("LINK_CACHE_ENABLED", true),
+			TTL:         getDuration("LINK_CACHE_TTL", 10*time.Minute),
+			NegativeTTL: getDuration("LINK_CACHE_NEGATIVE_TTL", 30*time.Second),
+		},
+		Clicks: ClickPipelineConfig{
+			QueueSize:     getInt("CLICK_QUEUE_SIZE", 10000),
+			Workers:       getInt("CLICK_WORKERS", 4),
+			BatchSize:     getInt("CLICK_BATCH_SIZE", 500),
+			FlushInterval: getDuration("CLICK_FLUSH_INTERVAL", 1*time.Second),
+			FlushTimeout:  getDuration("CLICK_FLUSH_TIMEOUT", 10*time.Second),
+		},
+		GeoIP: GeoIPConfig{
+			Provider:        getEnv("GEOIP_PROVIDER", "none"),
+			DatabasePath:    getEnv("GEOIP_DATABASE_PATH", ""),
+			ASNDatabasePath: getEnv("GEOIP_ASN_DATABASE_PATH", ""),
+			CacheSize:       getInt("GEOIP_CACHE_SIZE", 10000),
+		},
+		LinkPassword: LinkPasswordConfig{
+			MaxAttempts:     getInt("LINK_PASSWORD_MAX_ATTEMPTS", 5),
+			MaxLinkAttempts: getInt("LINK_PASSWORD_MAX_LINK_ATTEMPTS", 100),
+			LockoutWindow:   getDuration("LINK_PASSWORD_LOCKOUT_WINDOW", 15*time.Minute),
+		},
+		Schedule: ScheduleConfig{
+			Interval:  getDuration("LINK_SCHEDULE_INTERVAL", 30*time.Second),
+			BatchSize: getInt("LINK_SCHEDULE_BATCH_SIZE", 100),
+		},
+		ShortCode: ShortCodeConfig{
+			Strategy:        getEnv("SHORT_CODE_STRATEGY", "random"),
+			Length:          getInt("SHORT_CODE_LENGTH", 7),
+			MaxLength:       getInt("SHORT_CODE_MAX_LENGTH", 10),
+			MaxAttempts:     getInt("SHORT_CODE_MAX_ATTEMPTS", 5),
+			CaseInsensitive: getBool("SHORT_CODE_CASE_INSENSITIVE", false),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: getDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		},
+		URLPolicy: URLPolicyConfig{
+			AllowedSchemes:       getList("URL_POLICY_ALLOWED_SCHEMES", []string{"http", "https"}),
+			BlockPrivateNetworks: getBool("URL_POLICY_BLOCK_PRIVATE_NETWORKS", true),
+			ShortenerDomains: getList("URL_POLICY_SHORTENER_DOMAINS", []string{
+				"bit.ly", "tinyurl.com", "t.co", "goo.gl", "ow.ly", "is.gd", "buff.ly",
+				"rebrand.ly", "cutt.ly", "shorturl.at", "rb.gy", "tiny.cc", "t.ly",
+			}),
+			FlattenChains: getBool("URL_POLICY_FLATTEN_CHAINS", false),
+			MaxChainHops:  getInt("URL_POLICY_MAX_CHAIN_HOPS", 5),
+			Timeout:       getDuration("URL_POLICY_TIMEOUT", 5*time.Second),
+			BlocklistFile: getEnv("URL_POLICY_BLOCKLIST_FILE", "data/url_blocklist.txt"),
+			MaxURLLength:  getInt("URL_POLICY_MAX_URL_LENGTH", 2048),
+			TrackingParams: getList("URL_POLICY_TRACKING_PARAMS", []string{
+				"fbclid", "gclid", "mc_eid",
+			}),
+			StripTrackingParams: getBool("URL_POLICY_STRIP_TRACKING_PARAMS", false),
+		},
+		LinkHealth: LinkHealthConfig{
+			Enabled:            getBool("LINK_HEALTH_ENABLED", true),
+			Interval:           getDuration("LINK_HEALTH_INTERVAL", time.Minute),
+			BatchSize:          getInt("LINK_HEALTH_BATCH_SIZE", 100),
+			Concurrency:        getInt("LINK_HEALTH_CONCURRENCY", 10),
+			PerHostConcurrency: getInt("LINK_HEALTH_PER_HOST_CONCURRENCY", 2),
+			Timeout:            getDuration("LINK_HEALTH_TIMEOUT", 10*time.Second),
+			RecheckInterval:    getDuration("LINK_HEALTH_RECHECK_INTERVAL", 24*time.Hour),
+			RetryInterval:      getDuration("LINK_HEALTH_RETRY_INTERVAL", 15*time.Minute),
+			FailureThreshold:   getInt("LINK_HEALTH_FAILURE_THRESHOLD", 3),
+			UserAgent:          getEnv("LINK_HEALTH_USER_AGENT", "GoShort-LinkChecker/1.0"),
+		},
+		LinkMetadata: LinkMetadataConfig{
+			Enabled:      getBool("LINK_METADATA_ENABLED", true),
+			Workers:      getInt("LINK_METADATA_WORKERS", 4),
+			QueueSize:    getInt("LINK_METADATA_QUEUE_SIZE", 1000),
+			Timeout:      getDuration("LINK_METADATA_TIMEOUT", 5*time.Second),
+			MaxBodyBytes: int64(getInt("LINK_METADATA_MAX_BODY_BYTES", 512*1024)),
+			UserAgent:    getEnv("LINK_METADATA_USER_AGENT", "GoShort-LinkPreview/1.0"),
+		},
 	}
 }
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -49,3 +50,18 @@ func getDuration(key string, fallback time.Duration) time.Duration {
 	}
 	return value
 }
+
+// getList reads a comma-separated list; empty items are skipped.
+func getList(key string, fallback []string) []string {
+	strValue := getEnv(key, "")
+	if strValue == "" {
+		return fallback
+	}
+	var values []string
+	for _, item := range strings.Split(strValue, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -0,0 +1,4 @@
+ALTER TABLE link_stats
+    DROP COLUMN asn,
+    DROP COLUMN city,
+    DROP COLUMN region;
@@ -0,0 +1,4 @@
+ALTER TABLE link_stats
+    ADD COLUMN region TEXT,
+    ADD COLUMN city   TEXT,
+    ADD COLUMN asn    BIGINT;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_link_stats_browser_null;
+
+ALTER TABLE link_stats
+    DROP COLUMN is_bot,
+    DROP COLUMN os,
+    DROP COLUMN browser_version,
+    DROP COLUMN browser;
@@ -0,0 +1,9 @@
+ALTER TABLE link_stats
+    ADD COLUMN browser         TEXT,
+    ADD COLUMN browser_version TEXT,
+    ADD COLUMN os              TEXT,
+    ADD COLUMN is_bot          BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Rows written before the user-agent parser have browser = NULL and are filled in by
+-- `make backfill-useragent`.
+CREATE INDEX idx_link_stats_browser_null ON link_stats (id) WHERE browser IS NULL;
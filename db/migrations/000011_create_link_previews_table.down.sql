@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_link_previews_preview_time;
+DROP INDEX IF EXISTS idx_link_previews_link_id;
+
+DROP TABLE IF EXISTS link_previews;
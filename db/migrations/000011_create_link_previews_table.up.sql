@@ -0,0 +1,18 @@
+-- Requests from link unfurlers, crawlers and browser prefetches. They do not count as
+-- clicks and are kept out of link_stats.
+CREATE TABLE IF NOT EXISTS link_previews (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    link_id UUID NOT NULL,
+    preview_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reason TEXT NOT NULL,
+    bot_name TEXT,
+    ip_address TEXT,
+    user_agent TEXT,
+    referrer TEXT,
+
+    CONSTRAINT fk_link_previews_link_id FOREIGN KEY (link_id)
+        REFERENCES short_links(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_previews_link_id ON link_previews(link_id);
+CREATE INDEX IF NOT EXISTS idx_link_previews_preview_time ON link_previews(preview_time);
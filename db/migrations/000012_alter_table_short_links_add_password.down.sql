@@ -0,0 +1,2 @@
+ALTER TABLE short_links
+    DROP COLUMN password_hash;
@@ -0,0 +1,2 @@
+ALTER TABLE short_links
+    ADD COLUMN password_hash TEXT;
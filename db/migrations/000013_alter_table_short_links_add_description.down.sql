@@ -0,0 +1,3 @@
+ALTER TABLE short_links
+    DROP COLUMN force_interstitial,
+    DROP COLUMN description;
@@ -0,0 +1,3 @@
+ALTER TABLE short_links
+    ADD COLUMN description TEXT,
+    ADD COLUMN force_interstitial BOOLEAN NOT NULL DEFAULT FALSE;
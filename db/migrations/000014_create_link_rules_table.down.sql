@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_link_rules_updated_at ON link_rules;
+DROP TABLE IF EXISTS link_rules;
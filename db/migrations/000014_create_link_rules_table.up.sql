@@ -0,0 +1,31 @@
+-- Ordered routing rules of a short link. The first rule matching a visitor decides
+-- where they are sent; without a match the link's original_url is used.
+CREATE TABLE IF NOT EXISTS link_rules (
+    id UUID PRIMARY KEY,
+    link_id UUID NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    countries TEXT[] NOT NULL DEFAULT '{}',
+    device_types TEXT[] NOT NULL DEFAULT '{}',
+    operating_systems TEXT[] NOT NULL DEFAULT '{}',
+    languages TEXT[] NOT NULL DEFAULT '{}',
+    days_of_week SMALLINT[] NOT NULL DEFAULT '{}',
+    start_time TIME,
+    end_time TIME,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    action TEXT NOT NULL DEFAULT 'redirect',
+    destination_url TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_link_rules_link_id FOREIGN KEY (link_id)
+        REFERENCES short_links(id) ON DELETE CASCADE,
+    CONSTRAINT link_rules_action_check CHECK (action IN ('redirect', 'block')),
+    CONSTRAINT link_rules_destination_check CHECK (action = 'block' OR destination_url IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_rules_link_id ON link_rules(link_id, priority);
+
+CREATE TRIGGER update_link_rules_updated_at
+    BEFORE UPDATE ON link_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_link_stats_variant_id;
+
+ALTER TABLE link_stats
+    DROP COLUMN variant_id;
+
+ALTER TABLE short_links
+    DROP COLUMN variant_assignment;
+
+DROP TRIGGER IF EXISTS update_link_variants_updated_at ON link_variants;
+DROP TABLE IF EXISTS link_variants;
@@ -0,0 +1,33 @@
+-- Weighted destinations of a short link for A/B tests. When a link has variants every
+-- click is sent to one of them, unless a routing rule matched first.
+CREATE TABLE IF NOT EXISTS link_variants (
+    id UUID PRIMARY KEY,
+    link_id UUID NOT NULL,
+    label TEXT NOT NULL,
+    destination_url TEXT NOT NULL,
+    weight INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_link_variants_link_id FOREIGN KEY (link_id)
+        REFERENCES short_links(id) ON DELETE CASCADE,
+    CONSTRAINT link_variants_weight_check CHECK (weight > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_variants_link_id ON link_variants(link_id);
+
+CREATE TRIGGER update_link_variants_updated_at
+    BEFORE UPDATE ON link_variants
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- random picks a variant on every click, sticky keeps a visitor on the same variant
+ALTER TABLE short_links
+    ADD COLUMN variant_assignment TEXT NOT NULL DEFAULT 'random'
+        CONSTRAINT short_links_variant_assignment_check CHECK (variant_assignment IN ('random', 'sticky'));
+
+-- No foreign key: clicks still queued for a deleted variant must not fail their batch
+ALTER TABLE link_stats
+    ADD COLUMN variant_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_link_stats_variant_id ON link_stats(variant_id) WHERE variant_id IS NOT NULL;
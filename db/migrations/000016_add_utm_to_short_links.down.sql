@@ -0,0 +1,7 @@
+ALTER TABLE short_links
+    DROP COLUMN query_passthrough,
+    DROP COLUMN utm_content,
+    DROP COLUMN utm_term,
+    DROP COLUMN utm_campaign,
+    DROP COLUMN utm_medium,
+    DROP COLUMN utm_source;
@@ -0,0 +1,9 @@
+-- UTM parameters merged into the destination on redirect, and whether the query string
+-- of the short URL is passed on to the destination
+ALTER TABLE short_links
+    ADD COLUMN utm_source TEXT,
+    ADD COLUMN utm_medium TEXT,
+    ADD COLUMN utm_campaign TEXT,
+    ADD COLUMN utm_term TEXT,
+    ADD COLUMN utm_content TEXT,
+    ADD COLUMN query_passthrough BOOLEAN NOT NULL DEFAULT FALSE;
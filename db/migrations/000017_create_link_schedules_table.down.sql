@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS link_schedules;
+
+ALTER TABLE short_links
+    DROP COLUMN starts_at;
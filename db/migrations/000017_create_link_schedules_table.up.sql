@@ -0,0 +1,21 @@
+-- A link can be created ahead of a launch; before starts_at visitors see a
+-- "not yet available" page
+ALTER TABLE short_links
+    ADD COLUMN starts_at TIMESTAMP;
+
+-- Scheduled destination changes. The redirect path follows a change as soon as it is
+-- due and a background worker then writes it to short_links.original_url.
+CREATE TABLE IF NOT EXISTS link_schedules (
+    id UUID PRIMARY KEY,
+    link_id UUID NOT NULL,
+    destination_url TEXT NOT NULL,
+    switch_at TIMESTAMP NOT NULL,
+    applied_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_link_schedules_link_id FOREIGN KEY (link_id)
+        REFERENCES short_links(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_schedules_link_id ON link_schedules(link_id, switch_at);
+CREATE INDEX IF NOT EXISTS idx_link_schedules_pending ON link_schedules(switch_at) WHERE applied_at IS NULL;
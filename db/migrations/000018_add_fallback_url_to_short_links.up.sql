@@ -0,0 +1,2 @@
+-- Visitors of an inactive, expired or exhausted link are sent here instead of an error page
+ALTER TABLE short_links ADD COLUMN fallback_url TEXT;
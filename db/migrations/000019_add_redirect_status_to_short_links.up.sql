@@ -0,0 +1,3 @@
+-- HTTP status of the redirect; NULL uses the server default
+ALTER TABLE short_links
+    ADD COLUMN redirect_status SMALLINT CHECK (redirect_status IN (301, 302, 307, 308));
@@ -0,0 +1,10 @@
+-- Codes are only unique per domain, links on custom domains cannot be kept
+DELETE FROM short_links WHERE domain_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_short_links_domain_code;
+DROP INDEX IF EXISTS idx_short_links_default_domain_code;
+ALTER TABLE short_links ADD CONSTRAINT short_code_unique UNIQUE (short_code);
+
+ALTER TABLE short_links DROP COLUMN IF EXISTS domain_id;
+
+DROP TABLE IF EXISTS domains;
@@ -0,0 +1,25 @@
+-- Custom domains users serve their links on. A domain is claimed by adding it and only
+-- routes links once the owner proved control of it with a DNS TXT record or an HTTP
+-- well-known file.
+CREATE TABLE IF NOT EXISTS domains (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hostname VARCHAR(253) NOT NULL,
+    verification_token TEXT NOT NULL,
+    verified_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT domains_user_hostname_unique UNIQUE (user_id, hostname)
+);
+
+-- Several users may claim a hostname, only one can verify it
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_verified_hostname ON domains(hostname) WHERE verified_at IS NOT NULL;
+
+-- Links without a domain live on the server's own domain
+ALTER TABLE short_links
+    ADD COLUMN domain_id UUID REFERENCES domains(id);
+
+-- Short codes are unique per domain
+ALTER TABLE short_links DROP CONSTRAINT short_code_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_short_links_default_domain_code ON short_links(short_code) WHERE domain_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_short_links_domain_code ON short_links(domain_id, short_code) WHERE domain_id IS NOT NULL;
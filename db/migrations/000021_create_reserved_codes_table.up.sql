@@ -0,0 +1,8 @@
+-- Short codes nobody can claim, managed by admins. Codes are stored lowercase and
+-- matched case-insensitively.
+CREATE TABLE IF NOT EXISTS reserved_codes (
+    code VARCHAR(50) PRIMARY KEY,
+    reason TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- Counter of the sequential short code strategy
+CREATE SEQUENCE IF NOT EXISTS short_code_seq START 1;
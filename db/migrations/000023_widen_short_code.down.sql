@@ -0,0 +1,3 @@
+-- Fails while codes longer than 10 characters exist
+ALTER TABLE reserved_codes ALTER COLUMN code TYPE VARCHAR(50);
+ALTER TABLE short_links ALTER COLUMN short_code TYPE VARCHAR(10);
@@ -0,0 +1,4 @@
+-- Room for long and path-style slugs; the limit is checked in characters by the
+-- application, so multibyte Unicode slugs fit too
+ALTER TABLE short_links ALTER COLUMN short_code TYPE VARCHAR(100);
+ALTER TABLE reserved_codes ALTER COLUMN code TYPE VARCHAR(100);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_link_stats_alias_id;
+
+ALTER TABLE link_stats
+    DROP COLUMN alias_id;
+
+DROP TABLE IF EXISTS link_aliases;
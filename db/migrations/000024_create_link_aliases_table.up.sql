@@ -0,0 +1,24 @@
+-- Extra short codes of a link, such as the previous code kept after a rename. An alias
+-- lives on the domain of its link and shares the codes of that domain with the links,
+-- which the application checks before adding either.
+CREATE TABLE IF NOT EXISTS link_aliases (
+    id UUID PRIMARY KEY,
+    link_id UUID NOT NULL,
+    domain_id UUID REFERENCES domains(id),
+    short_code VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_link_aliases_link_id FOREIGN KEY (link_id)
+        REFERENCES short_links(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_aliases_link_id ON link_aliases(link_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_link_aliases_default_domain_code ON link_aliases(short_code) WHERE domain_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_link_aliases_domain_code ON link_aliases(domain_id, short_code) WHERE domain_id IS NOT NULL;
+
+-- The alias a click came through, NULL for the link's own code. No foreign key: clicks
+-- still queued for a deleted alias must not fail their batch
+ALTER TABLE link_stats
+    ADD COLUMN alias_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_link_stats_alias_id ON link_stats(alias_id) WHERE alias_id IS NOT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE link_schedules
+    DROP COLUMN IF EXISTS canonical_url;
+
+DROP INDEX IF EXISTS idx_short_links_user_canonical_url;
+
+ALTER TABLE short_links
+    DROP COLUMN IF EXISTS canonical_url;
@@ -0,0 +1,21 @@
+-- Canonical form of the destination, used to find duplicates, match the blocklist and
+-- search. original_url keeps the destination as entered and is what visitors are sent
+-- to. Existing rows start with their destination as entered.
+ALTER TABLE short_links
+    ADD COLUMN canonical_url TEXT NOT NULL DEFAULT '';
+
+UPDATE short_links SET canonical_url = original_url;
+
+ALTER TABLE short_links
+    ALTER COLUMN canonical_url DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_short_links_user_canonical_url ON short_links(user_id, canonical_url);
+
+-- Scheduled destinations carry their canonical form to the link when applied
+ALTER TABLE link_schedules
+    ADD COLUMN canonical_url TEXT NOT NULL DEFAULT '';
+
+UPDATE link_schedules SET canonical_url = destination_url;
+
+ALTER TABLE link_schedules
+    ALTER COLUMN canonical_url DROP DEFAULT;
@@ -0,0 +1,22 @@
+-- Result of the latest check of a link's destination by the health worker. The row
+-- belongs to destination_url; once the link points elsewhere it is stale and the link
+-- is checked again.
+CREATE TABLE IF NOT EXISTS link_health (
+    link_id UUID PRIMARY KEY,
+    destination_url TEXT NOT NULL,
+    -- NULL when no response was received
+    status_code INT,
+    latency_ms INT,
+    error TEXT,
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    is_broken BOOLEAN NOT NULL DEFAULT false,
+    -- NULL while the first check waits for a throttling host
+    checked_at TIMESTAMP,
+    next_check_at TIMESTAMP NOT NULL,
+
+    CONSTRAINT fk_link_health_link_id FOREIGN KEY (link_id)
+        REFERENCES short_links(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_health_next_check_at ON link_health(next_check_at);
+CREATE INDEX IF NOT EXISTS idx_link_health_broken ON link_health(link_id) WHERE is_broken;
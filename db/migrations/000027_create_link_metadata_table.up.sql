@@ -0,0 +1,18 @@
+-- Title, Open Graph tags and favicon read from the page a link points to. The row
+-- belongs to destination_url; once the link points elsewhere it is stale until the page
+-- is fetched again.
+CREATE TABLE IF NOT EXISTS link_metadata (
+    link_id UUID PRIMARY KEY,
+    destination_url TEXT NOT NULL,
+    title TEXT,
+    og_title TEXT,
+    og_description TEXT,
+    og_image TEXT,
+    favicon_url TEXT,
+    -- Why the page could not be read, NULL when it was
+    error TEXT,
+    fetched_at TIMESTAMP NOT NULL,
+
+    CONSTRAINT fk_link_metadata_link_id FOREIGN KEY (link_id)
+        REFERENCES short_links(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+ALTER TABLE short_links
+    DROP COLUMN IF EXISTS social_image,
+    DROP COLUMN IF EXISTS social_description,
+    DROP COLUMN IF EXISTS social_title;
@@ -0,0 +1,7 @@
+-- Social preview card of a link, shown by chat apps and social networks instead of the
+-- destination's own tags. NULL fields fall back to the title and description of the
+-- link.
+ALTER TABLE short_links
+    ADD COLUMN social_title TEXT,
+    ADD COLUMN social_description TEXT,
+    ADD COLUMN social_image TEXT;
@@ -0,0 +1,34 @@
+-- name: CountDomainShortLinks :one
+SELECT COUNT(*) FROM short_links
+WHERE domain_id = $1;
+
+-- name: CreateDomain :one
+INSERT INTO domains (
+  id, user_id, hostname, verification_token
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: DeleteDomain :exec
+DELETE FROM domains
+WHERE id = $1;
+
+-- name: GetDomain :one
+SELECT * FROM domains
+WHERE id = $1 LIMIT 1;
+
+-- name: GetVerifiedDomainByHostname :one
+SELECT * FROM domains
+WHERE hostname = $1 AND verified_at IS NOT NULL LIMIT 1;
+
+-- name: ListUserDomains :many
+SELECT * FROM domains
+WHERE user_id = $1
+ORDER BY hostname;
+
+-- name: MarkDomainVerified :one
+UPDATE domains
+SET verified_at = $2
+WHERE id = $1
+RETURNING *;
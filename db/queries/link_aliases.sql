@@ -0,0 +1,27 @@
+-- name: CreateLinkAlias :one
+INSERT INTO link_aliases (
+  id, link_id, domain_id, short_code
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: DeleteLinkAlias :execrows
+DELETE FROM link_aliases
+WHERE id = $1 AND link_id = $2;
+
+-- name: GetLinkAliasByCode :one
+SELECT la.id, la.link_id, sl.short_code AS link_short_code
+FROM link_aliases la
+JOIN short_links sl ON sl.id = la.link_id
+WHERE la.short_code = $1
+  AND la.domain_id IS NOT DISTINCT FROM sqlc.narg(domain_id)::uuid;
+
+-- name: ListLinkAliases :many
+SELECT la.id, la.link_id, la.domain_id, la.short_code, la.created_at,
+       count(ls.id)::bigint AS clicks
+FROM link_aliases la
+LEFT JOIN link_stats ls ON ls.alias_id = la.id AND NOT ls.is_bot
+WHERE la.link_id = $1
+GROUP BY la.id
+ORDER BY la.created_at;
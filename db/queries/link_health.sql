@@ -0,0 +1,44 @@
+-- name: DeferLinkHealthCheck :exec
+-- Postpones the check of a destination whose host asked to slow down, keeping the
+-- result of the last check.
+INSERT INTO link_health (
+  link_id, destination_url, next_check_at
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (link_id) DO UPDATE
+SET next_check_at = EXCLUDED.next_check_at
+WHERE link_health.destination_url = EXCLUDED.destination_url;
+
+-- name: ListDueLinkHealthChecks :many
+-- Active links whose destination was never checked, changed since its last check or is
+-- due for another one. The failure count starts over with a new destination.
+SELECT sl.id AS link_id, sl.original_url,
+       (CASE WHEN lh.destination_url = sl.original_url THEN lh.consecutive_failures ELSE 0 END)::int AS consecutive_failures
+FROM short_links sl
+         LEFT JOIN link_health lh ON lh.link_id = sl.id
+WHERE sl.is_active = true
+  AND (sl.expired_at IS NULL OR sl.expired_at > sqlc.arg(now)::timestamp)
+  AND (lh.link_id IS NULL OR lh.destination_url <> sl.original_url OR lh.next_check_at <= sqlc.arg(now)::timestamp)
+ORDER BY lh.next_check_at NULLS FIRST, sl.id
+LIMIT $2;
+
+-- name: ListLinkHealthByLinkIDs :many
+SELECT * FROM link_health
+WHERE link_id = ANY(sqlc.arg(link_ids)::uuid[]);
+
+-- name: UpsertLinkHealth :exec
+INSERT INTO link_health (
+  link_id, destination_url, status_code, latency_ms, error, consecutive_failures, is_broken, checked_at, next_check_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (link_id) DO UPDATE
+SET destination_url = EXCLUDED.destination_url,
+    status_code = EXCLUDED.status_code,
+    latency_ms = EXCLUDED.latency_ms,
+    error = EXCLUDED.error,
+    consecutive_failures = EXCLUDED.consecutive_failures,
+    is_broken = EXCLUDED.is_broken,
+    checked_at = EXCLUDED.checked_at,
+    next_check_at = EXCLUDED.next_check_at;
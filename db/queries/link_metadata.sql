@@ -0,0 +1,27 @@
+-- name: ListLinkMetadataByLinkIDs :many
+SELECT * FROM link_metadata
+WHERE link_id = ANY(sqlc.arg(link_ids)::uuid[]);
+
+-- name: SetFetchedLinkTitle :exec
+-- Names a link after its page unless the owner gave it a title. A title set by an
+-- earlier fetch is replaced.
+UPDATE short_links
+SET title = sqlc.arg(title)
+WHERE id = sqlc.arg(id)
+  AND (COALESCE(title, '') = '' OR title = sqlc.narg(previous_title)::text);
+
+-- name: UpsertLinkMetadata :exec
+INSERT INTO link_metadata (
+  link_id, destination_url, title, og_title, og_description, og_image, favicon_url, error, fetched_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (link_id) DO UPDATE
+SET destination_url = EXCLUDED.destination_url,
+    title = EXCLUDED.title,
+    og_title = EXCLUDED.og_title,
+    og_description = EXCLUDED.og_description,
+    og_image = EXCLUDED.og_image,
+    favicon_url = EXCLUDED.favicon_url,
+    error = EXCLUDED.error,
+    fetched_at = EXCLUDED.fetched_at;
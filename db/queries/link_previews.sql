@@ -0,0 +1,3 @@
+-- name: CreateLinkPreviews :copyfrom
+INSERT INTO link_previews (id, link_id, preview_time, reason, bot_name, ip_address, user_agent, referrer)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
@@ -0,0 +1,42 @@
+-- name: CreateLinkRule :one
+INSERT INTO link_rules (
+  id, link_id, priority, countries, device_types, operating_systems, languages, days_of_week, start_time, end_time, timezone, action, destination_url
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+)
+RETURNING *;
+
+-- name: DeleteLinkRule :exec
+DELETE FROM link_rules
+WHERE id = $1 AND link_id = $2;
+
+-- name: GetLinkRule :one
+SELECT * FROM link_rules
+WHERE id = $1 AND link_id = $2;
+
+-- name: ListLinkRules :many
+SELECT * FROM link_rules
+WHERE link_id = $1
+ORDER BY priority, created_at;
+
+-- name: ListLinkRulesByLinkIDs :many
+SELECT * FROM link_rules
+WHERE link_id = ANY(sqlc.arg(link_ids)::uuid[])
+ORDER BY link_id, priority, created_at;
+
+-- name: UpdateLinkRule :one
+UPDATE link_rules
+SET
+  priority = $3,
+  countries = $4,
+  device_types = $5,
+  operating_systems = $6,
+  languages = $7,
+  days_of_week = $8,
+  start_time = $9,
+  end_time = $10,
+  timezone = $11,
+  action = $12,
+  destination_url = $13
+WHERE id = $1 AND link_id = $2
+RETURNING *;
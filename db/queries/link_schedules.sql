@@ -0,0 +1,43 @@
+-- name: ApplyLinkSchedule :one
+-- Writes a due destination change to its link. A change is applied only once, even
+-- when several workers pick it up.
+WITH applied AS (
+    UPDATE link_schedules
+    SET applied_at = $2
+    WHERE link_schedules.id = $1 AND applied_at IS NULL
+    RETURNING link_id, destination_url, canonical_url
+)
+UPDATE short_links
+SET original_url = applied.destination_url,
+    canonical_url = applied.canonical_url
+FROM applied
+WHERE short_links.id = applied.link_id
+RETURNING short_links.short_code, short_links.domain_id;
+
+-- name: CreateLinkSchedule :one
+INSERT INTO link_schedules (
+  id, link_id, destination_url, switch_at, canonical_url
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: DeletePendingLinkSchedules :exec
+DELETE FROM link_schedules
+WHERE link_id = $1 AND applied_at IS NULL;
+
+-- name: ListDueLinkSchedules :many
+SELECT * FROM link_schedules
+WHERE applied_at IS NULL AND switch_at <= $1
+ORDER BY switch_at, id
+LIMIT $2;
+
+-- name: ListLinkSchedules :many
+SELECT * FROM link_schedules
+WHERE link_id = $1
+ORDER BY switch_at, id;
+
+-- name: ListLinkSchedulesByLinkIDs :many
+SELECT * FROM link_schedules
+WHERE link_id = ANY(sqlc.arg(link_ids)::uuid[])
+ORDER BY link_id, switch_at, id;
@@ -0,0 +1,25 @@
+-- name: CreateLinkVariant :one
+INSERT INTO link_variants (
+  id, link_id, label, destination_url, weight
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: DeleteLinkVariant :exec
+DELETE FROM link_variants
+WHERE id = $1 AND link_id = $2;
+
+-- name: ListLinkVariants :many
+SELECT * FROM link_variants
+WHERE link_id = $1
+ORDER BY created_at, id;
+
+-- name: UpdateLinkVariant :one
+UPDATE link_variants
+SET
+  label = $3,
+  destination_url = $4,
+  weight = $5
+WHERE id = $1 AND link_id = $2
+RETURNING *;
@@ -5,7 +5,7 @@ SELECT
     (SELECT count(ls.id)
      FROM link_stats ls
               JOIN short_links sl ON ls.link_id = sl.id
-     WHERE sl.user_id = sqlc.arg(user_id)
+     WHERE sl.user_id = sqlc.arg(user_id) AND NOT ls.is_bot
     )::int AS total_clicks;
 
 -- name: GetUserLinksWithStats :many
@@ -19,7 +19,7 @@ SELECT
     sl.created_at,
     count(ls.id)::int as click_count
 FROM short_links sl
-         LEFT JOIN link_stats ls ON sl.id = ls.link_id
+         LEFT JOIN link_stats ls ON sl.id = ls.link_id AND NOT ls.is_bot
 WHERE sl.user_id = $1
 GROUP BY sl.id
 ORDER BY sl.created_at DESC
@@ -34,7 +34,7 @@ SELECT
     count(ls.id)::int as clicks
 FROM link_stats ls
          JOIN short_links sl ON ls.link_id = sl.id
-WHERE sl.user_id = $1 AND ls.country IS NOT NULL
+WHERE sl.user_id = $1 AND NOT ls.is_bot AND ls.country IS NOT NULL
 GROUP BY ls.country
 ORDER BY clicks DESC;
 
@@ -46,7 +46,7 @@ SELECT
     count(ls.id)::int as clicks
 FROM link_stats ls
          JOIN short_links sl ON ls.link_id = sl.id
-WHERE sl.user_id = $1 AND ls.referrer IS NOT NULL AND ls.referrer != ''
+WHERE sl.user_id = $1 AND NOT ls.is_bot AND ls.referrer IS NOT NULL AND ls.referrer != ''
 GROUP BY ls.referrer
 ORDER BY clicks DESC
 LIMIT $2;
@@ -62,6 +62,7 @@ FROM link_stats ls
          JOIN short_links sl ON ls.link_id = sl.id
 WHERE
     sl.user_id = $1 AND
+    NOT ls.is_bot AND
     ls.click_time >= $2 AND
     ls.click_time <= $3
 GROUP BY click_date
@@ -80,3 +81,46 @@ VALUES (
            sqlc.arg(device_type)
        )
 ON CONFLICT (id) DO NOTHING;
+
+-- name: CreateLinkStats :copyfrom
+INSERT INTO link_stats (id, link_id, click_time, ip_address, user_agent, referrer, country, device_type, region, city, asn, browser, browser_version, os, is_bot, variant_id, alias_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17);
+
+-- name: ListLinkStatsWithoutClientInfo :many
+SELECT id, user_agent
+FROM link_stats
+WHERE browser IS NULL AND id > $1
+ORDER BY id
+LIMIT $2;
+
+-- name: UpdateLinkStatClientInfo :exec
+UPDATE link_stats
+SET browser         = $2,
+    browser_version = $3,
+    os              = $4,
+    device_type     = $5,
+    is_bot          = $6
+WHERE id = $1;
+
+-- name: GetLinkClickTotals :one
+-- Total, unique and variant-less clicks of one link. Bot hits are excluded.
+SELECT
+    count(*)::bigint AS total_clicks,
+    count(DISTINCT ip_address)::bigint AS unique_visitors,
+    count(*) FILTER (WHERE variant_id IS NULL)::bigint AS unassigned_clicks
+FROM link_stats
+WHERE link_id = $1 AND NOT is_bot;
+
+-- name: GetLinkVariantClicks :many
+-- Clicks served by each A/B variant of a link, including variants without clicks.
+SELECT
+    lv.id,
+    lv.label,
+    lv.destination_url,
+    lv.weight,
+    count(ls.id)::bigint AS clicks
+FROM link_variants lv
+         LEFT JOIN link_stats ls ON ls.variant_id = lv.id AND NOT ls.is_bot
+WHERE lv.link_id = $1
+GROUP BY lv.id
+ORDER BY lv.created_at, lv.id;
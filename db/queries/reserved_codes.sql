@@ -0,0 +1,21 @@
+-- name: CreateReservedCode :one
+INSERT INTO reserved_codes (
+  code, reason, created_by
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: DeleteReservedCode :execrows
+DELETE FROM reserved_codes
+WHERE code = $1;
+
+-- name: IsReservedCode :one
+SELECT EXISTS(
+  SELECT 1 FROM reserved_codes
+  WHERE code = $1
+) AS reserved;
+
+-- name: ListReservedCodes :many
+SELECT * FROM reserved_codes
+ORDER BY code;
@@ -4,11 +4,16 @@ WHERE id = $1 LIMIT 1;
 
 -- name: GetShortLinkByCode :one
 SELECT * FROM short_links
-WHERE short_code = $1 LIMIT 1;
+WHERE short_code = $1 AND domain_id IS NULL LIMIT 1;
+
+-- name: GetShortLinkByDomainAndCode :one
+SELECT * FROM short_links
+WHERE domain_id = $1 AND short_code = $2 LIMIT 1;
 
 -- name: GetActiveShortLinkByCode :one
 SELECT * FROM short_links
 WHERE short_code = $1
+AND domain_id IS NULL
 AND is_active = true
 AND (expired_at IS NULL OR expired_at > NOW())
 AND (click_limit IS NULL OR click_limit > 0)
@@ -21,14 +26,38 @@ LIMIT $1 OFFSET $2;
 
 
 
+-- name: ListExistingShortLinkIDs :many
+-- The IDs among the given ones whose link still exists.
+SELECT id FROM short_links
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetUserActiveLinkByCanonicalURL :one
+-- The most recent usable link of a user on a domain leading to the canonical destination,
+-- for reuse_existing.
+SELECT * FROM short_links
+WHERE user_id = $1
+AND domain_id IS NOT DISTINCT FROM sqlc.narg(domain_id)::uuid
+AND canonical_url = sqlc.arg(canonical_url)
+AND is_active = true
+AND (expired_at IS NULL OR expired_at > NOW())
+AND (click_limit IS NULL OR click_limit > 0)
+ORDER BY created_at DESC
+LIMIT 1;
+
 -- name: ListUserShortLinks :many
 SELECT * FROM short_links
 WHERE user_id = $1
-  -- Search functionality - search by title (handles NULL titles)
-  AND (@search_text::text = '' OR (title IS NOT NULL AND title ILIKE '%' || @search_text || '%'))
+  -- Search functionality - search by title (handles NULL titles) or destination
+  AND (@search_text::text = '' OR (title IS NOT NULL AND title ILIKE '%' || @search_text || '%')
+       OR canonical_url ILIKE '%' || @search_text || '%')
   -- Date range filtering for created_at
   AND (@start_date::timestamptz IS NULL OR created_at >= @start_date)
   AND (@end_date::timestamptz IS NULL OR created_at <= @end_date)
+  -- Links whose current destination is broken, or the others
+  AND (sqlc.narg(broken)::bool IS NULL OR EXISTS (
+      SELECT 1 FROM link_health lh
+      WHERE lh.link_id = short_links.id AND lh.destination_url = short_links.original_url AND lh.is_broken
+  ) = sqlc.narg(broken)::bool)
 ORDER BY
     CASE
         WHEN @order_by::shortlink_order_column = 'title' AND @ascending::bool = true THEN title
@@ -66,28 +95,47 @@ LIMIT $2 OFFSET $3;
 SELECT COUNT(*)
 FROM short_links
 WHERE user_id = $1
-  -- Search functionality - search by title (handles NULL titles)
-  AND (@search_text::text = '' OR (title IS NOT NULL AND title ILIKE '%' || @search_text || '%'))
+  -- Search functionality - search by title (handles NULL titles) or destination
+  AND (@search_text::text = '' OR (title IS NOT NULL AND title ILIKE '%' || @search_text || '%')
+       OR canonical_url ILIKE '%' || @search_text || '%')
   -- Date range filtering for created_at
   AND (@start_date::timestamptz IS NULL OR created_at >= @start_date)
-  AND (@end_date::timestamptz IS NULL OR created_at <= @end_date);
+  AND (@end_date::timestamptz IS NULL OR created_at <= @end_date)
+  -- Links whose current destination is broken, or the others
+  AND (sqlc.narg(broken)::bool IS NULL OR EXISTS (
+      SELECT 1 FROM link_health lh
+      WHERE lh.link_id = short_links.id AND lh.destination_url = short_links.original_url AND lh.is_broken
+  ) = sqlc.narg(broken)::bool);
 
 -- name: ListUserShortLinksWithCountClick :many
 SELECT sl.*,
-       COALESCE(ls.click_count, 0) AS total_clicks
+       COALESCE(ls.click_count, 0) AS total_clicks,
+       COALESCE(lp.preview_count, 0) AS total_previews
 FROM short_links sl
          LEFT JOIN (
     SELECT link_id, COUNT(*) AS click_count
     FROM link_stats
+    WHERE NOT is_bot
     GROUP BY link_id
 ) ls ON sl.id = ls.link_id
+         LEFT JOIN (
+    SELECT link_id, COUNT(*) AS preview_count
+    FROM link_previews
+    GROUP BY link_id
+) lp ON sl.id = lp.link_id
 WHERE sl.user_id = $1
-  -- Search functionality - search by title (handles NULL titles)
-  AND (@search_text::text = '' OR (sl.title IS NOT NULL AND sl.title ILIKE '%' || @search_text || '%'))
+  -- Search functionality - search by title (handles NULL titles) or destination
+  AND (@search_text::text = '' OR (sl.title IS NOT NULL AND sl.title ILIKE '%' || @search_text || '%')
+       OR sl.canonical_url ILIKE '%' || @search_text || '%')
   -- Date range filtering for created_at
   AND (@start_date::timestamptz IS NULL OR sl.created_at >= @start_date)
   AND (@end_date::timestamptz IS NULL OR sl.created_at <= @end_date)
-GROUP BY sl.id, ls.click_count
+  -- Links whose current destination is broken, or the others
+  AND (sqlc.narg(broken)::bool IS NULL OR EXISTS (
+      SELECT 1 FROM link_health lh
+      WHERE lh.link_id = sl.id AND lh.destination_url = sl.original_url AND lh.is_broken
+  ) = sqlc.narg(broken)::bool)
+GROUP BY sl.id, ls.click_count, lp.preview_count
 ORDER BY
     CASE
         WHEN @order_by::shortlink_order_column = 'title' AND @ascending::bool = true THEN sl.title
@@ -123,9 +171,9 @@ LIMIT $2 OFFSET $3;
 
 -- name: CreateShortLink :one
 INSERT INTO short_links (
-  id, user_id, original_url, short_code, title, is_active, click_limit, expired_at
+  id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, password_hash, description, force_interstitial, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25
 )
 RETURNING *;
 
@@ -137,7 +185,23 @@ SET
   title = COALESCE($4, title),
   is_active = COALESCE($5, is_active),
   click_limit = COALESCE($6, click_limit),
-  expired_at = COALESCE($7, expired_at)
+  expired_at = COALESCE($7, expired_at),
+  password_hash = $8,
+  description = $9,
+  force_interstitial = $10,
+  utm_source = $11,
+  utm_medium = $12,
+  utm_campaign = $13,
+  utm_term = $14,
+  utm_content = $15,
+  query_passthrough = $16,
+  starts_at = $17,
+  fallback_url = $18,
+  redirect_status = $19,
+  canonical_url = COALESCE($20, canonical_url),
+  social_title = $21,
+  social_description = $22,
+  social_image = $23
 WHERE id = $1
 RETURNING *;
 
@@ -157,10 +221,29 @@ RETURNING *;
 DELETE FROM short_links
 WHERE id = $1;
 
+-- name: DeleteAllUserShortLinks :many
+-- Deletes all links of the user and returns the codes of the links and of their
+-- aliases, so their cache entries can be dropped.
+WITH deleted AS (
+  DELETE FROM short_links
+  WHERE user_id = $1
+  RETURNING id, short_code, domain_id
+)
+SELECT short_code, domain_id FROM deleted
+UNION ALL
+SELECT la.short_code, la.domain_id
+FROM link_aliases la
+JOIN deleted d ON d.id = la.link_id;
+
 -- name: CheckShortCodeExists :one
 SELECT EXISTS(
   SELECT 1 FROM short_links
   WHERE short_code = $1
+  AND domain_id IS NOT DISTINCT FROM sqlc.narg(domain_id)::uuid
+) OR EXISTS(
+  SELECT 1 FROM link_aliases
+  WHERE short_code = $1
+  AND domain_id IS NOT DISTINCT FROM sqlc.narg(domain_id)::uuid
 ) AS exists;
 
 -- name: ToggleShortLinkStatus :one
@@ -169,3 +252,12 @@ SET is_active = NOT is_active
 WHERE id = $1
 RETURNING *;
 
+
+-- name: UpdateShortLinkVariantAssignment :one
+UPDATE short_links
+SET variant_assignment = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: NextShortCodeSequence :one
+SELECT nextval('short_code_seq')::bigint AS value;
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"GoShort/internal/cache"
 	"GoShort/internal/datastore"
 	"GoShort/pkg/helper"
 
@@ -22,14 +23,16 @@ type IService interface {
 }
 
 type Service struct {
-	repo datastore.Querier
-	log  *logger.Logger
+	repo  datastore.Querier
+	cache cache.ILinkCache
+	log   *logger.Logger
 }
 
-func NewService(repo datastore.Querier, log *logger.Logger) IService {
+func NewService(repo datastore.Querier, linkCache cache.ILinkCache, log *logger.Logger) IService {
 	return &Service{
-		repo: repo,
-		log:  log,
+		repo:  repo,
+		cache: linkCache,
+		log:   log,
 	}
 }
 
@@ -107,17 +110,7 @@ func (s *Service) ListAllLinks(ctx context.Context, req shortlink.GetLinksReques
 	response := make([]shortlink.LinkResponse, len(links))
 
 	for i, link := range links {
-		response[i] = shortlink.LinkResponse{
-			ID:          link.ID,
-			OriginalURL: link.OriginalUrl,
-			ShortCode:   link.ShortCode,
-			Title:       link.Title,
-			IsActive:    link.IsActive,
-			ClickLimit:  link.ClickLimit,
-			ExpireAt:    link.ExpiredAt.Time,
-			CreatedAt:   link.CreatedAt.Time,
-			UpdatedAt:   link.UpdatedAt.Time,
-		}
+		response[i] = *shortlink.NewLinkResponse(link)
 	}
 
 	pagination := &helper.Pagination{
@@ -138,17 +131,7 @@ func (s *Service) GetLinkByID(ctx context.Context, id uuid.UUID) (*shortlink.Lin
 		return nil, err
 	}
 
-	response := &shortlink.LinkResponse{
-		ID:          link.ID,
-		OriginalURL: link.OriginalUrl,
-		ShortCode:   link.ShortCode,
-		Title:       link.Title,
-		IsActive:    link.IsActive,
-		ClickLimit:  link.ClickLimit,
-		ExpireAt:    link.ExpiredAt.Time,
-		CreatedAt:   link.CreatedAt.Time,
-		UpdatedAt:   link.UpdatedAt.Time,
-	}
+	response := shortlink.NewLinkResponse(link)
 
 	return response, nil
 }
@@ -228,17 +211,7 @@ func (s *Service) ListUserLinks(ctx context.Context, userID uuid.UUID, req short
 	}
 	response := make([]shortlink.LinkResponse, len(userLinks))
 	for i, link := range userLinks {
-		response[i] = shortlink.LinkResponse{
-			ID:          link.ID,
-			OriginalURL: link.OriginalUrl,
-			ShortCode:   link.ShortCode,
-			Title:       link.Title,
-			IsActive:    link.IsActive,
-			ClickLimit:  link.ClickLimit,
-			ExpireAt:    link.ExpiredAt.Time,
-			CreatedAt:   link.CreatedAt.Time,
-			UpdatedAt:   link.UpdatedAt.Time,
-		}
+		response[i] = *shortlink.NewLinkResponse(link)
 	}
 
 	pagination := &helper.Pagination{
@@ -258,6 +231,14 @@ func (s *Service) ToggleLinkStatus(ctx context.Context, id uuid.UUID) error {
 		s.log.Error("failed to toggle short link status", "error", err)
 		return err
 	}
+
+	link, err := s.repo.AdminGetShortLinkByID(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get short link after toggling status", "error", err)
+		return err
+	}
+	_ = s.cache.Invalidate(ctx, cache.LinkKey(link.DomainID, link.ShortCode))
+
 	return nil
 }
 
@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"GoShort/config"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/helper"
+	"GoShort/pkg/logger"
+	"GoShort/pkg/redis"
+	"GoShort/pkg/rules"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrCacheMiss is returned when a short code has no cached entry.
+var ErrCacheMiss = errors.New("cache miss")
+
+// notFoundMarker is stored in place of a link for short codes that do not exist.
+const notFoundMarker = "__not_found__"
+
+// CachedLink is the subset of a short link needed to resolve a redirect.
+type CachedLink struct {
+	ID          uuid.UUID  `json:"id"`
+	OriginalURL string     `json:"original_url"`
+	IsActive    bool       `json:"is_active"`
+	ExpiredAt   *time.Time `json:"expired_at,omitempty"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	ClickLimit  *int32     `json:"click_limit,omitempty"`
+	// PasswordHash is the bcrypt hash of the link password, nil for unprotected links
+	PasswordHash *string `json:"password_hash,omitempty"`
+	// ForceInterstitial makes visitors confirm the destination on the preview page
+	ForceInterstitial bool `json:"force_interstitial,omitempty"`
+	// Rules are the link's routing rules in evaluation order
+	Rules []rules.Rule `json:"rules,omitempty"`
+	// Variants are the weighted A/B destinations, used when no rule matched
+	Variants          []Variant `json:"variants,omitempty"`
+	VariantAssignment string    `json:"variant_assignment,omitempty"`
+	// UTM are the campaign parameters added to every destination
+	UTM []helper.QueryParam `json:"utm,omitempty"`
+	// QueryPassthrough forwards the query string of the short URL to the destination
+	QueryPassthrough bool `json:"query_passthrough,omitempty"`
+	// Schedule holds the destination changes not yet written to OriginalURL, oldest first
+	Schedule []ScheduledChange `json:"schedule,omitempty"`
+	// FallbackURL receives visitors while the link is inactive, expired or out of clicks
+	FallbackURL *string `json:"fallback_url,omitempty"`
+	// RedirectStatus is the HTTP status chosen by the owner, 0 for the server default
+	RedirectStatus int `json:"redirect_status,omitempty"`
+	// SocialCard is shown to link unfurlers instead of a redirect, nil when the owner
+	// did not set one
+	SocialCard *SocialCard `json:"social_card,omitempty"`
+	// AliasOf is only set on the entry of an alias code: it holds nothing but the code of
+	// the link, whose own entry is the one invalidated when the link changes
+	AliasOf string     `json:"alias_of,omitempty"`
+	AliasID *uuid.UUID `json:"alias_id,omitempty"`
+}
+
+// NewAliasEntry builds the cache entry of an alias code of a link.
+func NewAliasEntry(aliasID uuid.UUID, linkCode string) *CachedLink {
+	return &CachedLink{AliasOf: linkCode, AliasID: &aliasID}
+}
+
+// SocialCard is how a link looks when it is pasted in a chat or on a social network.
+type SocialCard struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+}
+
+// ScheduledChange switches the destination of a link at a given time.
+type ScheduledChange struct {
+	At  time.Time `json:"at"`
+	URL string    `json:"url"`
+}
+
+// CurrentURL returns the destination at now: the latest scheduled change that is due,
+// or the original URL.
+func (l *CachedLink) CurrentURL(now time.Time) string {
+	current := l.OriginalURL
+	for _, change := range l.Schedule {
+		if change.At.After(now) {
+			break
+		}
+		current = change.URL
+	}
+	return current
+}
+
+// Variant is one weighted destination of an A/B test.
+type Variant struct {
+	ID     uuid.UUID `json:"id"`
+	URL    string    `json:"url"`
+	Weight int32     `json:"weight"`
+}
+
+// NewCachedLink builds a cache entry from a datastore short link. Rules, variants and
+// the schedule are loaded separately by the caller.
+func NewCachedLink(link datastore.ShortLink) *CachedLink {
+	cached := &CachedLink{
+		ID:                link.ID,
+		OriginalURL:       link.OriginalUrl,
+		IsActive:          link.IsActive,
+		ClickLimit:        link.ClickLimit,
+		PasswordHash:      link.PasswordHash,
+		ForceInterstitial: link.ForceInterstitial,
+		VariantAssignment: link.VariantAssignment,
+		QueryPassthrough:  link.QueryPassthrough,
+		FallbackURL:       link.FallbackUrl,
+	}
+	utm := []struct {
+		key   string
+		value *string
+	}{
+		{"utm_source", link.UtmSource},
+		{"utm_medium", link.UtmMedium},
+		{"utm_campaign", link.UtmCampaign},
+		{"utm_term", link.UtmTerm},
+		{"utm_content", link.UtmContent},
+	}
+	for _, param := range utm {
+		if param.value != nil {
+			cached.UTM = append(cached.UTM, helper.QueryParam{Key: param.key, Value: *param.value})
+		}
+	}
+	if link.ExpiredAt.Valid {
+		expiredAt := link.ExpiredAt.Time
+		cached.ExpiredAt = &expiredAt
+	}
+	if link.StartsAt.Valid {
+		startsAt := link.StartsAt.Time
+		cached.StartsAt = &startsAt
+	}
+	if link.RedirectStatus != nil {
+		cached.RedirectStatus = int(*link.RedirectStatus)
+	}
+	// Card fields the owner left empty fall back to the title and description of the link
+	if link.SocialTitle != nil || link.SocialDescription != nil || link.SocialImage != nil {
+		cached.SocialCard = &SocialCard{
+			Title:       helper.FirstNonEmpty(link.SocialTitle, link.Title),
+			Description: helper.FirstNonEmpty(link.SocialDescription, link.Description),
+			ImageURL:    helper.FirstNonEmpty(link.SocialImage),
+		}
+	}
+	return cached
+}
+
+// LinkKey is the cache key of a short code. Links on the server's own domain are keyed
+// by their code alone; codes on custom domains are prefixed with the domain ID, because
+// the same code can exist on several domains.
+func LinkKey(domainID pgtype.UUID, code string) string {
+	if !domainID.Valid {
+		return code
+	}
+	return uuid.UUID(domainID.Bytes).String() + "/" + code
+}
+
+type ILinkCache interface {
+	Get(ctx context.Context, code string) (*CachedLink, error)
+	Set(ctx context.Context, code string, link *CachedLink) error
+	SetNotFound(ctx context.Context, code string) error
+	Invalidate(ctx context.Context, codes ...string) error
+}
+
+// LinkCache is a read-through cache of resolved short links keyed by short code.
+type LinkCache struct {
+	rds redis.RdsClient
+	cfg config.LinkCacheConfig
+	log *logger.Logger
+}
+
+func NewLinkCache(rds redis.RdsClient, cfg config.LinkCacheConfig, log *logger.Logger) ILinkCache {
+	return &LinkCache{
+		rds: rds,
+		cfg: cfg,
+		log: log,
+	}
+}
+
+func linkKey(code string) string {
+	return "link:code:" + code
+}
+
+// Get returns the cached link for a short code. It returns commons.ErrLinkNotFound
+// when the code is negatively cached and ErrCacheMiss when nothing is cached.
+func (c *LinkCache) Get(ctx context.Context, code string) (*CachedLink, error) {
+	if !c.cfg.Enabled || c.rds == nil {
+		return nil, ErrCacheMiss
+	}
+
+	value, err := c.rds.Get(ctx, linkKey(code))
+	if err != nil {
+		if !errors.Is(err, redis.ErrNil) {
+			c.log.Warn("failed to read link from cache", "code", code, "error", err)
+		}
+		return nil, ErrCacheMiss
+	}
+
+	if value == notFoundMarker {
+		return nil, commons.ErrLinkNotFound
+	}
+
+	var link CachedLink
+	if err := json.Unmarshal([]byte(value), &link); err != nil {
+		c.log.Warn("failed to decode cached link", "code", code, "error", err)
+		return nil, ErrCacheMiss
+	}
+
+	return &link, nil
+}
+
+// Set stores a resolved link. Entries never outlive the link's own expiry.
+func (c *LinkCache) Set(ctx context.Context, code string, link *CachedLink) error {
+	if !c.cfg.Enabled || c.rds == nil {
+		return nil
+	}
+
+	ttl := c.cfg.TTL
+	if link.ExpiredAt != nil {
+		untilExpiry := time.Until(*link.ExpiredAt)
+		if untilExpiry <= 0 {
+			return nil
+		}
+		if untilExpiry < ttl {
+			ttl = untilExpiry
+		}
+	}
+
+	value, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+
+	if err := c.rds.Set(ctx, linkKey(code), value, ttl); err != nil {
+		c.log.Warn("failed to write link to cache", "code", code, "error", err)
+		return err
+	}
+	return nil
+}
+
+// SetNotFound negatively caches a short code that does not exist.
+func (c *LinkCache) SetNotFound(ctx context.Context, code string) error {
+	if !c.cfg.Enabled || c.rds == nil {
+		return nil
+	}
+
+	if err := c.rds.Set(ctx, linkKey(code), notFoundMarker, c.cfg.NegativeTTL); err != nil {
+		c.log.Warn("failed to negatively cache link", "code", code, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Invalidate removes the cached entries for the given short codes.
+func (c *LinkCache) Invalidate(ctx context.Context, codes ...string) error {
+	if !c.cfg.Enabled || c.rds == nil || len(codes) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(codes))
+	for i, code := range codes {
+		keys[i] = linkKey(code)
+	}
+
+	if err := c.rds.Del(ctx, keys...); err != nil {
+		c.log.Error("failed to invalidate cached links", "codes", codes, "error", err)
+		return err
+	}
+	return nil
+}
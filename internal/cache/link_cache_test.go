@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"GoShort/config"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/logger"
+	"GoShort/pkg/redis"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is an in-memory implementation of redis.RdsClient for testing.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttls map[string]time.Duration
+}
+
+var _ redis.RdsClient = (*fakeRedis)(nil)
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{data: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (f *fakeRedis) Ping(ctx context.Context) error { return nil }
+
+func (f *fakeRedis) Get(ctx context.Context, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.data[key]
+	if !ok {
+		return "", redis.ErrNil
+	}
+	return v, nil
+}
+
+func (f *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch v := value.(type) {
+	case []byte:
+		f.data[key] = string(v)
+	default:
+		f.data[key] = fmt.Sprint(v)
+	}
+	f.ttls[key] = expiration
+	return nil
+}
+
+func (f *fakeRedis) Del(ctx context.Context, keys ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range keys {
+		delete(f.data, k)
+		delete(f.ttls, k)
+	}
+	return nil
+}
+
+func (f *fakeRedis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	f.mu.Lock()
+	_, exists := f.data[key]
+	f.mu.Unlock()
+	if exists {
+		return false, nil
+	}
+	return true, f.Set(ctx, key, value, expiration)
+}
+
+func (f *fakeRedis) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int64
+	fmt.Sscan(f.data[key], &n)
+	n++
+	f.data[key] = fmt.Sprint(n)
+	if n == 1 {
+		f.ttls[key] = window
+	}
+	return n, nil
+}
+
+func (f *fakeRedis) Decr(ctx context.Context, key string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int64
+	fmt.Sscan(f.data[key], &n)
+	n--
+	f.data[key] = fmt.Sprint(n)
+	return n, nil
+}
+
+func (f *fakeRedis) Close() error { return nil }
+
+func newTestLogger() *logger.Logger {
+	return logger.New(&config.AppConfig{Logger: config.LoggerConfig{Output: io.Discard, Level: "info"}})
+}
+
+func newTestCache(rds *fakeRedis) ILinkCache {
+	cfg := config.LinkCacheConfig{Enabled: true, TTL: 10 * time.Minute, NegativeTTL: 30 * time.Second}
+	return NewLinkCache(rds, cfg, newTestLogger())
+}
+
+func TestLinkCache_SetGetInvalidate(t *testing.T) {
+	ctx := context.Background()
+	rds := newFakeRedis()
+	c := newTestCache(rds)
+
+	_, err := c.Get(ctx, "abc")
+	require.ErrorIs(t, err, ErrCacheMiss)
+
+	limit := int32(5)
+	link := &CachedLink{ID: uuid.New(), OriginalURL: "https://example.com", IsActive: true, ClickLimit: &limit}
+	require.NoError(t, c.Set(ctx, "abc", link))
+
+	got, err := c.Get(ctx, "abc")
+	require.NoError(t, err)
+	require.Equal(t, link.ID, got.ID)
+	require.Equal(t, link.OriginalURL, got.OriginalURL)
+	require.Equal(t, int32(5), *got.ClickLimit)
+
+	require.NoError(t, c.Invalidate(ctx, "abc"))
+	_, err = c.Get(ctx, "abc")
+	require.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestLinkCache_NegativeEntry(t *testing.T) {
+	ctx := context.Background()
+	rds := newFakeRedis()
+	c := newTestCache(rds)
+
+	require.NoError(t, c.SetNotFound(ctx, "missing"))
+	require.Equal(t, 30*time.Second, rds.ttls[linkKey("missing")])
+
+	_, err := c.Get(ctx, "missing")
+	require.ErrorIs(t, err, commons.ErrLinkNotFound)
+}
+
+func TestLinkCache_TTLCappedByExpiry(t *testing.T) {
+	ctx := context.Background()
+	rds := newFakeRedis()
+	c := newTestCache(rds)
+
+	soon := time.Now().Add(time.Minute)
+	require.NoError(t, c.Set(ctx, "soon", &CachedLink{ID: uuid.New(), ExpiredAt: &soon}))
+	require.LessOrEqual(t, rds.ttls[linkKey("soon")], time.Minute)
+
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, c.Set(ctx, "past", &CachedLink{ID: uuid.New(), ExpiredAt: &past}))
+	_, err := c.Get(ctx, "past")
+	require.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestLinkCache_Disabled(t *testing.T) {
+	ctx := context.Background()
+	rds := newFakeRedis()
+	c := NewLinkCache(rds, config.LinkCacheConfig{Enabled: false}, newTestLogger())
+
+	require.NoError(t, c.Set(ctx, "abc", &CachedLink{ID: uuid.New()}))
+	require.Empty(t, rds.data)
+
+	_, err := c.Get(ctx, "abc")
+	require.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestNewCachedLink_SocialCard(t *testing.T) {
+	title, description, image := "Spring launch", "All the details", "https://cdn.example.com/launch.png"
+
+	link := datastore.ShortLink{ID: uuid.New(), Title: &title, Description: &description}
+	require.Nil(t, NewCachedLink(link).SocialCard, "links without a card are redirected")
+
+	link.SocialImage = &image
+	require.Equal(t, &SocialCard{Title: title, Description: description, ImageURL: image}, NewCachedLink(link).SocialCard)
+
+	cardTitle := "Launch day"
+	link.SocialTitle = &cardTitle
+	require.Equal(t, "Launch day", NewCachedLink(link).SocialCard.Title)
+}
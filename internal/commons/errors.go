@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 var (
@@ -29,6 +30,7 @@ var (
 var (
 	ErrLinkInactive        = errors.New("link is inactive")
 	ErrLinkExpired         = errors.New("link has expired")
+	ErrLinkNotYetActive    = errors.New("link is not active yet")
 	ErrClickLimitExceeded  = errors.New("click limit exceeded")
 	ErrLinkAlreadyExists   = errors.New("link with this short code already exists")
 	ErrLinkUpdateFailed    = errors.New("failed to update link")
@@ -39,8 +41,91 @@ var (
 	ErrLinkDecrementFailed = errors.New("failed to decrement link click limit")
 	ErrLinkNotActive       = errors.New("link is not active")
 	ErrLinkNotOwnedByUser  = errors.New("link does not belong to the user")
+	ErrClickQueueFull      = errors.New("click ingestion queue is full")
 )
 
+var (
+	ErrLinkPasswordRequired    = errors.New("link is password protected")
+	ErrInvalidLinkPassword     = errors.New("invalid link password")
+	ErrTooManyPasswordAttempts = errors.New("too many password attempts")
+	ErrInterstitialRequired    = errors.New("link requires the preview page before redirecting")
+)
+
+var (
+	ErrRuleNotFound = errors.New("link rule not found")
+	ErrTooManyRules = errors.New("link has too many rules")
+	ErrInvalidRule  = errors.New("invalid link rule")
+	ErrLinkBlocked  = errors.New("link is blocked for this visitor")
+)
+
+var (
+	ErrVariantNotFound = errors.New("link variant not found")
+	ErrTooManyVariants = errors.New("link has too many variants")
+)
+
+var (
+	ErrInvalidSchedule = errors.New("invalid link schedule")
+)
+
+var (
+	ErrInvalidRedirectStatus = errors.New("redirect status must be 301, 302, 307 or 308")
+)
+
+var (
+	ErrInvalidSocialCard = errors.New("invalid social card")
+)
+
+var (
+	ErrInvalidReuse = errors.New("reuse_existing cannot be combined with password, click_limit, starts_at or expire_at")
+)
+
+var (
+	ErrDomainNotFound           = errors.New("domain not found")
+	ErrDomainExists             = errors.New("domain has already been added")
+	ErrDomainTaken              = errors.New("domain is verified by another account")
+	ErrDomainNotVerified        = errors.New("domain is not verified")
+	ErrDomainVerificationFailed = errors.New("domain ownership could not be verified")
+	ErrDomainInUse              = errors.New("domain still has short links")
+	ErrInvalidDomain            = errors.New("invalid domain")
+)
+
+var (
+	ErrShortCodeReserved    = errors.New("short code is reserved")
+	ErrReservedCodeExists   = errors.New("code is already reserved")
+	ErrReservedCodeNotFound = errors.New("reserved code not found")
+	ErrReservedCodeBuiltin  = errors.New("built-in reserved codes cannot be removed")
+)
+
+var (
+	ErrAliasNotFound  = errors.New("link alias not found")
+	ErrTooManyAliases = errors.New("link has too many aliases")
+)
+
+var (
+	ErrURLRejected            = errors.New("destination URL rejected by policy")
+	ErrInvalidBlocklistEntry  = errors.New("invalid blocklist entry")
+	ErrBlocklistEntryExists   = errors.New("blocklist entry already exists")
+	ErrBlocklistEntryNotFound = errors.New("blocklist entry not found")
+)
+
+// uniqueViolation is the Postgres error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// IsUniqueViolation reports whether a database error was caused by a unique constraint.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// foreignKeyViolation is the Postgres error code of a foreign key constraint violation.
+const foreignKeyViolation = "23503"
+
+// IsForeignKeyViolation reports whether a database error was caused by a foreign key constraint.
+func IsForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation
+}
+
 // FieldError is a custom struct to hold detailed validation error information.
 type FieldError struct {
 	Field string `json:"field"`
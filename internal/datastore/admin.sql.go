@@ -13,7 +13,7 @@ import (
 )
 
 const adminGetShortLinkByID = `-- name: AdminGetShortLinkByID :one
-SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at FROM short_links
+SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image FROM short_links
 WHERE id = $1::uuid
 `
 
@@ -31,12 +31,30 @@ func (q *Queries) AdminGetShortLinkByID(ctx context.Context, id uuid.UUID) (Shor
 		&i.ExpiredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
 	)
 	return i, err
 }
 
 const adminGetShortLinksByUserID = `-- name: AdminGetShortLinksByUserID :many
-SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at FROM short_links
+SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image FROM short_links
 WHERE user_id = $1
   -- Search functionality - search by title (handles NULL titles)
   AND ($4::text = '' OR (title IS NOT NULL AND title ILIKE '%' || $4 || '%'))
@@ -117,6 +135,24 @@ func (q *Queries) AdminGetShortLinksByUserID(ctx context.Context, arg AdminGetSh
 			&i.ExpiredAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.PasswordHash,
+			&i.Description,
+			&i.ForceInterstitial,
+			&i.VariantAssignment,
+			&i.UtmSource,
+			&i.UtmMedium,
+			&i.UtmCampaign,
+			&i.UtmTerm,
+			&i.UtmContent,
+			&i.QueryPassthrough,
+			&i.StartsAt,
+			&i.FallbackUrl,
+			&i.RedirectStatus,
+			&i.DomainID,
+			&i.CanonicalUrl,
+			&i.SocialTitle,
+			&i.SocialDescription,
+			&i.SocialImage,
 		); err != nil {
 			return nil, err
 		}
@@ -129,7 +165,7 @@ func (q *Queries) AdminGetShortLinksByUserID(ctx context.Context, arg AdminGetSh
 }
 
 const adminListShortLinks = `-- name: AdminListShortLinks :many
-SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at FROM short_links
+SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image FROM short_links
     WHERE  TRUE
   -- Search functionality - search by title (handles NULL titles)
   AND ($1::text = '' OR (title IS NOT NULL AND title ILIKE '%' || $1 || '%'))
@@ -208,6 +244,24 @@ func (q *Queries) AdminListShortLinks(ctx context.Context, arg AdminListShortLin
 			&i.ExpiredAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.PasswordHash,
+			&i.Description,
+			&i.ForceInterstitial,
+			&i.VariantAssignment,
+			&i.UtmSource,
+			&i.UtmMedium,
+			&i.UtmCampaign,
+			&i.UtmTerm,
+			&i.UtmContent,
+			&i.QueryPassthrough,
+			&i.StartsAt,
+			&i.FallbackUrl,
+			&i.RedirectStatus,
+			&i.DomainID,
+			&i.CanonicalUrl,
+			&i.SocialTitle,
+			&i.SocialDescription,
+			&i.SocialImage,
 		); err != nil {
 			return nil, err
 		}
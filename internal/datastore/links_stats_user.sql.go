@@ -52,6 +52,98 @@ func (q *Queries) CreateLinkStat(ctx context.Context, arg CreateLinkStatParams)
 	return err
 }
 
+type CreateLinkStatsParams struct {
+	ID             uuid.UUID          `json:"id"`
+	LinkID         uuid.UUID          `json:"link_id"`
+	ClickTime      pgtype.Timestamptz `json:"click_time"`
+	IpAddress      *string            `json:"ip_address"`
+	UserAgent      *string            `json:"user_agent"`
+	Referrer       *string            `json:"referrer"`
+	Country        *string            `json:"country"`
+	DeviceType     *string            `json:"device_type"`
+	Region         *string            `json:"region"`
+	City           *string            `json:"city"`
+	Asn            *int64             `json:"asn"`
+	Browser        *string            `json:"browser"`
+	BrowserVersion *string            `json:"browser_version"`
+	Os             *string            `json:"os"`
+	IsBot          bool               `json:"is_bot"`
+	VariantID      pgtype.UUID        `json:"variant_id"`
+	AliasID        pgtype.UUID        `json:"alias_id"`
+}
+
+const getLinkClickTotals = `-- name: GetLinkClickTotals :one
+SELECT
+    count(*)::bigint AS total_clicks,
+    count(DISTINCT ip_address)::bigint AS unique_visitors,
+    count(*) FILTER (WHERE variant_id IS NULL)::bigint AS unassigned_clicks
+FROM link_stats
+WHERE link_id = $1 AND NOT is_bot
+`
+
+type GetLinkClickTotalsRow struct {
+	TotalClicks      int64 `json:"total_clicks"`
+	UniqueVisitors   int64 `json:"unique_visitors"`
+	UnassignedClicks int64 `json:"unassigned_clicks"`
+}
+
+// Total, unique and variant-less clicks of one link. Bot hits are excluded.
+func (q *Queries) GetLinkClickTotals(ctx context.Context, linkID uuid.UUID) (GetLinkClickTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getLinkClickTotals, linkID)
+	var i GetLinkClickTotalsRow
+	err := row.Scan(&i.TotalClicks, &i.UniqueVisitors, &i.UnassignedClicks)
+	return i, err
+}
+
+const getLinkVariantClicks = `-- name: GetLinkVariantClicks :many
+SELECT
+    lv.id,
+    lv.label,
+    lv.destination_url,
+    lv.weight,
+    count(ls.id)::bigint AS clicks
+FROM link_variants lv
+         LEFT JOIN link_stats ls ON ls.variant_id = lv.id AND NOT ls.is_bot
+WHERE lv.link_id = $1
+GROUP BY lv.id
+ORDER BY lv.created_at, lv.id
+`
+
+type GetLinkVariantClicksRow struct {
+	ID             uuid.UUID `json:"id"`
+	Label          string    `json:"label"`
+	DestinationUrl string    `json:"destination_url"`
+	Weight         int32     `json:"weight"`
+	Clicks         int64     `json:"clicks"`
+}
+
+// Clicks served by each A/B variant of a link, including variants without clicks.
+func (q *Queries) GetLinkVariantClicks(ctx context.Context, linkID uuid.UUID) ([]GetLinkVariantClicksRow, error) {
+	rows, err := q.db.Query(ctx, getLinkVariantClicks, linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetLinkVariantClicksRow{}
+	for rows.Next() {
+		var i GetLinkVariantClicksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Label,
+			&i.DestinationUrl,
+			&i.Weight,
+			&i.Clicks,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserClickTimeline = `-- name: GetUserClickTimeline :many
 SELECT
     date_trunc('day', ls.click_time)::date as click_date,
@@ -60,6 +152,7 @@ FROM link_stats ls
          JOIN short_links sl ON ls.link_id = sl.id
 WHERE
     sl.user_id = $1 AND
+    NOT ls.is_bot AND
     ls.click_time >= $2 AND
     ls.click_time <= $3
 GROUP BY click_date
@@ -105,7 +198,7 @@ SELECT
     count(ls.id)::int as clicks
 FROM link_stats ls
          JOIN short_links sl ON ls.link_id = sl.id
-WHERE sl.user_id = $1 AND ls.country IS NOT NULL
+WHERE sl.user_id = $1 AND NOT ls.is_bot AND ls.country IS NOT NULL
 GROUP BY ls.country
 ORDER BY clicks DESC
 `
@@ -143,7 +236,7 @@ SELECT
     count(ls.id)::int as clicks
 FROM link_stats ls
          JOIN short_links sl ON ls.link_id = sl.id
-WHERE sl.user_id = $1 AND ls.referrer IS NOT NULL AND ls.referrer != ''
+WHERE sl.user_id = $1 AND NOT ls.is_bot AND ls.referrer IS NOT NULL AND ls.referrer != ''
 GROUP BY ls.referrer
 ORDER BY clicks DESC
 LIMIT $2
@@ -187,7 +280,7 @@ SELECT
     (SELECT count(ls.id)
      FROM link_stats ls
               JOIN short_links sl ON ls.link_id = sl.id
-     WHERE sl.user_id = $1
+     WHERE sl.user_id = $1 AND NOT ls.is_bot
     )::int AS total_clicks
 `
 
@@ -213,7 +306,7 @@ SELECT
     sl.created_at,
     count(ls.id)::int as click_count
 FROM short_links sl
-         LEFT JOIN link_stats ls ON sl.id = ls.link_id
+         LEFT JOIN link_stats ls ON sl.id = ls.link_id AND NOT ls.is_bot
 WHERE sl.user_id = $1
 GROUP BY sl.id
 ORDER BY sl.created_at DESC
@@ -264,3 +357,72 @@ func (q *Queries) GetUserLinksWithStats(ctx context.Context, arg GetUserLinksWit
 	}
 	return items, nil
 }
+
+const listLinkStatsWithoutClientInfo = `-- name: ListLinkStatsWithoutClientInfo :many
+SELECT id, user_agent
+FROM link_stats
+WHERE browser IS NULL AND id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListLinkStatsWithoutClientInfoParams struct {
+	ID    uuid.UUID `json:"id"`
+	Limit int64     `json:"limit"`
+}
+
+type ListLinkStatsWithoutClientInfoRow struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent *string   `json:"user_agent"`
+}
+
+func (q *Queries) ListLinkStatsWithoutClientInfo(ctx context.Context, arg ListLinkStatsWithoutClientInfoParams) ([]ListLinkStatsWithoutClientInfoRow, error) {
+	rows, err := q.db.Query(ctx, listLinkStatsWithoutClientInfo, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLinkStatsWithoutClientInfoRow{}
+	for rows.Next() {
+		var i ListLinkStatsWithoutClientInfoRow
+		if err := rows.Scan(&i.ID, &i.UserAgent); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateLinkStatClientInfo = `-- name: UpdateLinkStatClientInfo :exec
+UPDATE link_stats
+SET browser         = $2,
+    browser_version = $3,
+    os              = $4,
+    device_type     = $5,
+    is_bot          = $6
+WHERE id = $1
+`
+
+type UpdateLinkStatClientInfoParams struct {
+	ID             uuid.UUID `json:"id"`
+	Browser        *string   `json:"browser"`
+	BrowserVersion *string   `json:"browser_version"`
+	Os             *string   `json:"os"`
+	DeviceType     *string   `json:"device_type"`
+	IsBot          bool      `json:"is_bot"`
+}
+
+func (q *Queries) UpdateLinkStatClientInfo(ctx context.Context, arg UpdateLinkStatClientInfoParams) error {
+	_, err := q.db.Exec(ctx, updateLinkStatClientInfo,
+		arg.ID,
+		arg.Browser,
+		arg.BrowserVersion,
+		arg.Os,
+		arg.DeviceType,
+		arg.IsBot,
+	)
+	return err
+}
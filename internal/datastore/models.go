@@ -186,28 +186,152 @@ func (ns NullUserRole) Value() (driver.Value, error) {
 	return string(ns.UserRole), nil
 }
 
+type Domain struct {
+	ID                uuid.UUID        `json:"id"`
+	UserID            uuid.UUID        `json:"user_id"`
+	Hostname          string           `json:"hostname"`
+	VerificationToken string           `json:"verification_token"`
+	VerifiedAt        pgtype.Timestamp `json:"verified_at"`
+	CreatedAt         pgtype.Timestamp `json:"created_at"`
+}
+
+type LinkAlias struct {
+	ID        uuid.UUID        `json:"id"`
+	LinkID    uuid.UUID        `json:"link_id"`
+	DomainID  pgtype.UUID      `json:"domain_id"`
+	ShortCode string           `json:"short_code"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type LinkHealth struct {
+	LinkID              uuid.UUID        `json:"link_id"`
+	DestinationUrl      string           `json:"destination_url"`
+	StatusCode          *int32           `json:"status_code"`
+	LatencyMs           *int32           `json:"latency_ms"`
+	Error               *string          `json:"error"`
+	ConsecutiveFailures int32            `json:"consecutive_failures"`
+	IsBroken            bool             `json:"is_broken"`
+	CheckedAt           pgtype.Timestamp `json:"checked_at"`
+	NextCheckAt         pgtype.Timestamp `json:"next_check_at"`
+}
+
+type LinkMetadata struct {
+	LinkID         uuid.UUID        `json:"link_id"`
+	DestinationUrl string           `json:"destination_url"`
+	Title          *string          `json:"title"`
+	OgTitle        *string          `json:"og_title"`
+	OgDescription  *string          `json:"og_description"`
+	OgImage        *string          `json:"og_image"`
+	FaviconUrl     *string          `json:"favicon_url"`
+	Error          *string          `json:"error"`
+	FetchedAt      pgtype.Timestamp `json:"fetched_at"`
+}
+
+type LinkPreview struct {
+	ID          uuid.UUID          `json:"id"`
+	LinkID      uuid.UUID          `json:"link_id"`
+	PreviewTime pgtype.Timestamptz `json:"preview_time"`
+	Reason      string             `json:"reason"`
+	BotName     *string            `json:"bot_name"`
+	IpAddress   *string            `json:"ip_address"`
+	UserAgent   *string            `json:"user_agent"`
+	Referrer    *string            `json:"referrer"`
+}
+
+type LinkRule struct {
+	ID               uuid.UUID        `json:"id"`
+	LinkID           uuid.UUID        `json:"link_id"`
+	Priority         int32            `json:"priority"`
+	Countries        []string         `json:"countries"`
+	DeviceTypes      []string         `json:"device_types"`
+	OperatingSystems []string         `json:"operating_systems"`
+	Languages        []string         `json:"languages"`
+	DaysOfWeek       []int16          `json:"days_of_week"`
+	StartTime        pgtype.Time      `json:"start_time"`
+	EndTime          pgtype.Time      `json:"end_time"`
+	Timezone         string           `json:"timezone"`
+	Action           string           `json:"action"`
+	DestinationUrl   *string          `json:"destination_url"`
+	CreatedAt        pgtype.Timestamp `json:"created_at"`
+	UpdatedAt        pgtype.Timestamp `json:"updated_at"`
+}
+
+type LinkSchedule struct {
+	ID             uuid.UUID        `json:"id"`
+	LinkID         uuid.UUID        `json:"link_id"`
+	DestinationUrl string           `json:"destination_url"`
+	SwitchAt       pgtype.Timestamp `json:"switch_at"`
+	AppliedAt      pgtype.Timestamp `json:"applied_at"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	CanonicalUrl   string           `json:"canonical_url"`
+}
+
 type LinkStat struct {
-	ID         uuid.UUID          `json:"id"`
-	LinkID     uuid.UUID          `json:"link_id"`
-	ClickTime  pgtype.Timestamptz `json:"click_time"`
-	IpAddress  *string            `json:"ip_address"`
-	UserAgent  *string            `json:"user_agent"`
-	Referrer   *string            `json:"referrer"`
-	Country    *string            `json:"country"`
-	DeviceType *string            `json:"device_type"`
+	ID             uuid.UUID          `json:"id"`
+	LinkID         uuid.UUID          `json:"link_id"`
+	ClickTime      pgtype.Timestamptz `json:"click_time"`
+	IpAddress      *string            `json:"ip_address"`
+	UserAgent      *string            `json:"user_agent"`
+	Referrer       *string            `json:"referrer"`
+	Country        *string            `json:"country"`
+	DeviceType     *string            `json:"device_type"`
+	Region         *string            `json:"region"`
+	City           *string            `json:"city"`
+	Asn            *int64             `json:"asn"`
+	Browser        *string            `json:"browser"`
+	BrowserVersion *string            `json:"browser_version"`
+	Os             *string            `json:"os"`
+	IsBot          bool               `json:"is_bot"`
+	VariantID      pgtype.UUID        `json:"variant_id"`
+	AliasID        pgtype.UUID        `json:"alias_id"`
+}
+
+type LinkVariant struct {
+	ID             uuid.UUID        `json:"id"`
+	LinkID         uuid.UUID        `json:"link_id"`
+	Label          string           `json:"label"`
+	DestinationUrl string           `json:"destination_url"`
+	Weight         int32            `json:"weight"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+}
+
+type ReservedCode struct {
+	Code      string           `json:"code"`
+	Reason    *string          `json:"reason"`
+	CreatedBy pgtype.UUID      `json:"created_by"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
 }
 
 type ShortLink struct {
-	ID          uuid.UUID        `json:"id"`
-	UserID      uuid.UUID        `json:"user_id"`
-	OriginalUrl string           `json:"original_url"`
-	ShortCode   string           `json:"short_code"`
-	Title       *string          `json:"title"`
-	IsActive    bool             `json:"is_active"`
-	ClickLimit  *int32           `json:"click_limit"`
-	ExpiredAt   pgtype.Timestamp `json:"expired_at"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+	ID                uuid.UUID        `json:"id"`
+	UserID            uuid.UUID        `json:"user_id"`
+	OriginalUrl       string           `json:"original_url"`
+	ShortCode         string           `json:"short_code"`
+	Title             *string          `json:"title"`
+	IsActive          bool             `json:"is_active"`
+	ClickLimit        *int32           `json:"click_limit"`
+	ExpiredAt         pgtype.Timestamp `json:"expired_at"`
+	CreatedAt         pgtype.Timestamp `json:"created_at"`
+	UpdatedAt         pgtype.Timestamp `json:"updated_at"`
+	PasswordHash      *string          `json:"password_hash"`
+	Description       *string          `json:"description"`
+	ForceInterstitial bool             `json:"force_interstitial"`
+	VariantAssignment string           `json:"variant_assignment"`
+	UtmSource         *string          `json:"utm_source"`
+	UtmMedium         *string          `json:"utm_medium"`
+	UtmCampaign       *string          `json:"utm_campaign"`
+	UtmTerm           *string          `json:"utm_term"`
+	UtmContent        *string          `json:"utm_content"`
+	QueryPassthrough  bool             `json:"query_passthrough"`
+	StartsAt          pgtype.Timestamp `json:"starts_at"`
+	FallbackUrl       *string          `json:"fallback_url"`
+	RedirectStatus    *int16           `json:"redirect_status"`
+	DomainID          pgtype.UUID      `json:"domain_id"`
+	CanonicalUrl      string           `json:"canonical_url"`
+	SocialTitle       *string          `json:"social_title"`
+	SocialDescription *string          `json:"social_description"`
+	SocialImage       *string          `json:"social_image"`
 }
 
 type Token struct {
@@ -16,19 +16,43 @@ type Querier interface {
 	AdminGetShortLinksByUserID(ctx context.Context, arg AdminGetShortLinksByUserIDParams) ([]ShortLink, error)
 	AdminListShortLinks(ctx context.Context, arg AdminListShortLinksParams) ([]ShortLink, error)
 	AdminToggleShortLinkStatus(ctx context.Context, id uuid.UUID) error
-	CheckShortCodeExists(ctx context.Context, shortCode string) (bool, error)
+	// Writes a due destination change to its link. A change is applied only once, even
+	// when several workers pick it up.
+	ApplyLinkSchedule(ctx context.Context, arg ApplyLinkScheduleParams) (ApplyLinkScheduleRow, error)
+	CheckShortCodeExists(ctx context.Context, arg CheckShortCodeExistsParams) (bool, error)
 	CountActiveLinks(ctx context.Context) (int64, error)
+	CountDomainShortLinks(ctx context.Context, domainID pgtype.UUID) (int64, error)
 	CountInactiveLinks(ctx context.Context) (int64, error)
 	CountLinks(ctx context.Context) (int64, error)
 	CountUserShortLinks(ctx context.Context, arg CountUserShortLinksParams) (int64, error)
 	CountUsers(ctx context.Context) (int64, error)
+	CreateDomain(ctx context.Context, arg CreateDomainParams) (Domain, error)
+	CreateLinkAlias(ctx context.Context, arg CreateLinkAliasParams) (LinkAlias, error)
+	CreateLinkPreviews(ctx context.Context, arg []CreateLinkPreviewsParams) (int64, error)
+	CreateLinkRule(ctx context.Context, arg CreateLinkRuleParams) (LinkRule, error)
+	CreateLinkSchedule(ctx context.Context, arg CreateLinkScheduleParams) (LinkSchedule, error)
 	CreateLinkStat(ctx context.Context, arg CreateLinkStatParams) error
+	CreateLinkStats(ctx context.Context, arg []CreateLinkStatsParams) (int64, error)
+	CreateLinkVariant(ctx context.Context, arg CreateLinkVariantParams) (LinkVariant, error)
+	CreateReservedCode(ctx context.Context, arg CreateReservedCodeParams) (ReservedCode, error)
 	CreateShortLink(ctx context.Context, arg CreateShortLinkParams) (ShortLink, error)
 	// CreateToken inserts a new token into the database.
 	CreateToken(ctx context.Context, arg CreateTokenParams) (Token, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeactivateShortLink(ctx context.Context, id uuid.UUID) (ShortLink, error)
 	DecrementClickLimit(ctx context.Context, id uuid.UUID) (ShortLink, error)
+	// Postpones the check of a destination whose host asked to slow down, keeping the
+	// result of the last check.
+	DeferLinkHealthCheck(ctx context.Context, arg DeferLinkHealthCheckParams) error
+	// Deletes all links of the user and returns the codes of the links and of their
+	// aliases, so their cache entries can be dropped.
+	DeleteAllUserShortLinks(ctx context.Context, userID uuid.UUID) ([]DeleteAllUserShortLinksRow, error)
+	DeleteDomain(ctx context.Context, id uuid.UUID) error
+	DeleteLinkAlias(ctx context.Context, arg DeleteLinkAliasParams) (int64, error)
+	DeleteLinkRule(ctx context.Context, arg DeleteLinkRuleParams) error
+	DeleteLinkVariant(ctx context.Context, arg DeleteLinkVariantParams) error
+	DeletePendingLinkSchedules(ctx context.Context, linkID uuid.UUID) error
+	DeleteReservedCode(ctx context.Context, code string) (int64, error)
 	// DeleteTokenByID removes a specific token from the database by its ID.
 	// This is typically used after a token has been successfully used.
 	DeleteTokenByID(ctx context.Context, id uuid.UUID) error
@@ -38,15 +62,26 @@ type Querier interface {
 	DeleteUser(ctx context.Context, id uuid.UUID) error
 	DeleteUserShortLink(ctx context.Context, id uuid.UUID) error
 	GetActiveShortLinkByCode(ctx context.Context, shortCode string) (ShortLink, error)
+	GetDomain(ctx context.Context, id uuid.UUID) (Domain, error)
 	// GetLatestTokenByUserIDAndType retrieves the most recent token for a user of a specific type.
 	GetLatestTokenByUserIDAndType(ctx context.Context, arg GetLatestTokenByUserIDAndTypeParams) (Token, error)
+	GetLinkAliasByCode(ctx context.Context, arg GetLinkAliasByCodeParams) (GetLinkAliasByCodeRow, error)
 	GetLinkClickStatsByDateRange(ctx context.Context, arg GetLinkClickStatsByDateRangeParams) ([]GetLinkClickStatsByDateRangeRow, error)
+	// Total, unique and variant-less clicks of one link. Bot hits are excluded.
+	GetLinkClickTotals(ctx context.Context, linkID uuid.UUID) (GetLinkClickTotalsRow, error)
+	GetLinkRule(ctx context.Context, arg GetLinkRuleParams) (LinkRule, error)
+	// Clicks served by each A/B variant of a link, including variants without clicks.
+	GetLinkVariantClicks(ctx context.Context, linkID uuid.UUID) ([]GetLinkVariantClicksRow, error)
 	GetShortLink(ctx context.Context, id uuid.UUID) (ShortLink, error)
 	GetShortLinkByCode(ctx context.Context, shortCode string) (ShortLink, error)
+	GetShortLinkByDomainAndCode(ctx context.Context, arg GetShortLinkByDomainAndCodeParams) (ShortLink, error)
 	// GetTokenByHash retrieves a token and the associated user's active status.
 	// This is useful for verifying a token and checking if the user's account is already active.
 	GetTokenByHash(ctx context.Context, tokenHash string) (GetTokenByHashRow, error)
 	GetUser(ctx context.Context, id uuid.UUID) (User, error)
+	// The most recent usable link of a user on a domain leading to the canonical destination,
+	// for reuse_existing.
+	GetUserActiveLinkByCanonicalURL(ctx context.Context, arg GetUserActiveLinkByCanonicalURLParams) (ShortLink, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
 	// Mengambil data time-series jumlah klik per hari untuk pengguna tertentu dalam rentang waktu.
@@ -64,16 +99,46 @@ type Querier interface {
 	// Mengambil daftar link milik pengguna beserta jumlah klik untuk setiap link, dengan paginasi.
 	// Menggunakan LEFT JOIN untuk memastikan link yang belum pernah diklik (0 klik) tetap muncul.
 	GetUserLinksWithStats(ctx context.Context, arg GetUserLinksWithStatsParams) ([]GetUserLinksWithStatsRow, error)
+	GetVerifiedDomainByHostname(ctx context.Context, hostname string) (Domain, error)
 	// IncrementTokenAttempts increases the attempt count for a specific token by one.
 	IncrementTokenAttempts(ctx context.Context, id uuid.UUID) error
+	IsReservedCode(ctx context.Context, code string) (bool, error)
+	// Active links whose destination was never checked, changed since its last check or is
+	// due for another one. The failure count starts over with a new destination.
+	ListDueLinkHealthChecks(ctx context.Context, arg ListDueLinkHealthChecksParams) ([]ListDueLinkHealthChecksRow, error)
+	ListDueLinkSchedules(ctx context.Context, arg ListDueLinkSchedulesParams) ([]LinkSchedule, error)
+	// The IDs among the given ones whose link still exists.
+	ListExistingShortLinkIDs(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error)
+	ListLinkAliases(ctx context.Context, linkID uuid.UUID) ([]ListLinkAliasesRow, error)
+	ListLinkHealthByLinkIDs(ctx context.Context, linkIds []uuid.UUID) ([]LinkHealth, error)
+	ListLinkMetadataByLinkIDs(ctx context.Context, linkIds []uuid.UUID) ([]LinkMetadata, error)
+	ListLinkRules(ctx context.Context, linkID uuid.UUID) ([]LinkRule, error)
+	ListLinkRulesByLinkIDs(ctx context.Context, linkIds []uuid.UUID) ([]LinkRule, error)
+	ListLinkSchedules(ctx context.Context, linkID uuid.UUID) ([]LinkSchedule, error)
+	ListLinkSchedulesByLinkIDs(ctx context.Context, linkIds []uuid.UUID) ([]LinkSchedule, error)
+	ListLinkStatsWithoutClientInfo(ctx context.Context, arg ListLinkStatsWithoutClientInfoParams) ([]ListLinkStatsWithoutClientInfoRow, error)
+	ListLinkVariants(ctx context.Context, linkID uuid.UUID) ([]LinkVariant, error)
+	ListReservedCodes(ctx context.Context) ([]ReservedCode, error)
 	ListShortLinks(ctx context.Context, arg ListShortLinksParams) ([]ShortLink, error)
+	ListUserDomains(ctx context.Context, userID uuid.UUID) ([]Domain, error)
 	ListUserShortLinks(ctx context.Context, arg ListUserShortLinksParams) ([]ShortLink, error)
 	ListUserShortLinksWithCountClick(ctx context.Context, arg ListUserShortLinksWithCountClickParams) ([]ListUserShortLinksWithCountClickRow, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
 	ListUsersByRole(ctx context.Context, arg ListUsersByRoleParams) ([]User, error)
+	MarkDomainVerified(ctx context.Context, arg MarkDomainVerifiedParams) (Domain, error)
+	NextShortCodeSequence(ctx context.Context) (int64, error)
+	// Names a link after its page unless the owner gave it a title. A title set by an
+	// earlier fetch is replaced.
+	SetFetchedLinkTitle(ctx context.Context, arg SetFetchedLinkTitleParams) error
 	ToggleShortLinkStatus(ctx context.Context, id uuid.UUID) (ShortLink, error)
+	UpdateLinkRule(ctx context.Context, arg UpdateLinkRuleParams) (LinkRule, error)
+	UpdateLinkStatClientInfo(ctx context.Context, arg UpdateLinkStatClientInfoParams) error
+	UpdateLinkVariant(ctx context.Context, arg UpdateLinkVariantParams) (LinkVariant, error)
 	UpdateShortLink(ctx context.Context, arg UpdateShortLinkParams) (ShortLink, error)
+	UpdateShortLinkVariantAssignment(ctx context.Context, arg UpdateShortLinkVariantAssignmentParams) (ShortLink, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpsertLinkHealth(ctx context.Context, arg UpsertLinkHealthParams) error
+	UpsertLinkMetadata(ctx context.Context, arg UpsertLinkMetadataParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -16,11 +16,21 @@ const checkShortCodeExists = `-- name: CheckShortCodeExists :one
 SELECT EXISTS(
   SELECT 1 FROM short_links
   WHERE short_code = $1
+  AND domain_id IS NOT DISTINCT FROM $2::uuid
+) OR EXISTS(
+  SELECT 1 FROM link_aliases
+  WHERE short_code = $1
+  AND domain_id IS NOT DISTINCT FROM $2::uuid
 ) AS exists
 `
 
-func (q *Queries) CheckShortCodeExists(ctx context.Context, shortCode string) (bool, error) {
-	row := q.db.QueryRow(ctx, checkShortCodeExists, shortCode)
+type CheckShortCodeExistsParams struct {
+	ShortCode string      `json:"short_code"`
+	DomainID  pgtype.UUID `json:"domain_id"`
+}
+
+func (q *Queries) CheckShortCodeExists(ctx context.Context, arg CheckShortCodeExistsParams) (bool, error) {
+	row := q.db.QueryRow(ctx, checkShortCodeExists, arg.ShortCode, arg.DomainID)
 	var exists bool
 	err := row.Scan(&exists)
 	return exists, err
@@ -30,11 +40,17 @@ const countUserShortLinks = `-- name: CountUserShortLinks :one
 SELECT COUNT(*)
 FROM short_links
 WHERE user_id = $1
-  -- Search functionality - search by title (handles NULL titles)
-  AND ($2::text = '' OR (title IS NOT NULL AND title ILIKE '%' || $2 || '%'))
+  -- Search functionality - search by title (handles NULL titles) or destination
+  AND ($2::text = '' OR (title IS NOT NULL AND title ILIKE '%' || $2 || '%')
+       OR canonical_url ILIKE '%' || $2 || '%')
   -- Date range filtering for created_at
   AND ($3::timestamptz IS NULL OR created_at >= $3)
   AND ($4::timestamptz IS NULL OR created_at <= $4)
+  -- Links whose current destination is broken, or the others
+  AND ($5::bool IS NULL OR EXISTS (
+      SELECT 1 FROM link_health lh
+      WHERE lh.link_id = short_links.id AND lh.destination_url = short_links.original_url AND lh.is_broken
+  ) = $5::bool)
 `
 
 type CountUserShortLinksParams struct {
@@ -42,6 +58,7 @@ type CountUserShortLinksParams struct {
 	SearchText string             `json:"search_text"`
 	StartDate  pgtype.Timestamptz `json:"start_date"`
 	EndDate    pgtype.Timestamptz `json:"end_date"`
+	Broken     *bool              `json:"broken"`
 }
 
 func (q *Queries) CountUserShortLinks(ctx context.Context, arg CountUserShortLinksParams) (int64, error) {
@@ -50,6 +67,7 @@ func (q *Queries) CountUserShortLinks(ctx context.Context, arg CountUserShortLin
 		arg.SearchText,
 		arg.StartDate,
 		arg.EndDate,
+		arg.Broken,
 	)
 	var count int64
 	err := row.Scan(&count)
@@ -58,22 +76,39 @@ func (q *Queries) CountUserShortLinks(ctx context.Context, arg CountUserShortLin
 
 const createShortLink = `-- name: CreateShortLink :one
 INSERT INTO short_links (
-  id, user_id, original_url, short_code, title, is_active, click_limit, expired_at
+  id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, password_hash, description, force_interstitial, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25
 )
-RETURNING id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at
+RETURNING id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image
 `
 
 type CreateShortLinkParams struct {
-	ID          uuid.UUID        `json:"id"`
-	UserID      uuid.UUID        `json:"user_id"`
-	OriginalUrl string           `json:"original_url"`
-	ShortCode   string           `json:"short_code"`
-	Title       *string          `json:"title"`
-	IsActive    bool             `json:"is_active"`
-	ClickLimit  *int32           `json:"click_limit"`
-	ExpiredAt   pgtype.Timestamp `json:"expired_at"`
+	ID                uuid.UUID        `json:"id"`
+	UserID            uuid.UUID        `json:"user_id"`
+	OriginalUrl       string           `json:"original_url"`
+	ShortCode         string           `json:"short_code"`
+	Title             *string          `json:"title"`
+	IsActive          bool             `json:"is_active"`
+	ClickLimit        *int32           `json:"click_limit"`
+	ExpiredAt         pgtype.Timestamp `json:"expired_at"`
+	PasswordHash      *string          `json:"password_hash"`
+	Description       *string          `json:"description"`
+	ForceInterstitial bool             `json:"force_interstitial"`
+	UtmSource         *string          `json:"utm_source"`
+	UtmMedium         *string          `json:"utm_medium"`
+	UtmCampaign       *string          `json:"utm_campaign"`
+	UtmTerm           *string          `json:"utm_term"`
+	UtmContent        *string          `json:"utm_content"`
+	QueryPassthrough  bool             `json:"query_passthrough"`
+	StartsAt          pgtype.Timestamp `json:"starts_at"`
+	FallbackUrl       *string          `json:"fallback_url"`
+	RedirectStatus    *int16           `json:"redirect_status"`
+	DomainID          pgtype.UUID      `json:"domain_id"`
+	CanonicalUrl      string           `json:"canonical_url"`
+	SocialTitle       *string          `json:"social_title"`
+	SocialDescription *string          `json:"social_description"`
+	SocialImage       *string          `json:"social_image"`
 }
 
 func (q *Queries) CreateShortLink(ctx context.Context, arg CreateShortLinkParams) (ShortLink, error) {
@@ -86,6 +121,23 @@ func (q *Queries) CreateShortLink(ctx context.Context, arg CreateShortLinkParams
 		arg.IsActive,
 		arg.ClickLimit,
 		arg.ExpiredAt,
+		arg.PasswordHash,
+		arg.Description,
+		arg.ForceInterstitial,
+		arg.UtmSource,
+		arg.UtmMedium,
+		arg.UtmCampaign,
+		arg.UtmTerm,
+		arg.UtmContent,
+		arg.QueryPassthrough,
+		arg.StartsAt,
+		arg.FallbackUrl,
+		arg.RedirectStatus,
+		arg.DomainID,
+		arg.CanonicalUrl,
+		arg.SocialTitle,
+		arg.SocialDescription,
+		arg.SocialImage,
 	)
 	var i ShortLink
 	err := row.Scan(
@@ -99,6 +151,24 @@ func (q *Queries) CreateShortLink(ctx context.Context, arg CreateShortLinkParams
 		&i.ExpiredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
 	)
 	return i, err
 }
@@ -107,7 +177,7 @@ const deactivateShortLink = `-- name: DeactivateShortLink :one
 UPDATE short_links
 SET is_active = false
 WHERE id = $1
-RETURNING id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at
+RETURNING id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image
 `
 
 func (q *Queries) DeactivateShortLink(ctx context.Context, id uuid.UUID) (ShortLink, error) {
@@ -124,6 +194,24 @@ func (q *Queries) DeactivateShortLink(ctx context.Context, id uuid.UUID) (ShortL
 		&i.ExpiredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
 	)
 	return i, err
 }
@@ -132,7 +220,7 @@ const decrementClickLimit = `-- name: DecrementClickLimit :one
 UPDATE short_links
 SET click_limit = click_limit - 1
 WHERE id = $1 AND click_limit > 0
-RETURNING id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at
+RETURNING id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image
 `
 
 func (q *Queries) DecrementClickLimit(ctx context.Context, id uuid.UUID) (ShortLink, error) {
@@ -149,10 +237,68 @@ func (q *Queries) DecrementClickLimit(ctx context.Context, id uuid.UUID) (ShortL
 		&i.ExpiredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
 	)
 	return i, err
 }
 
+const deleteAllUserShortLinks = `-- name: DeleteAllUserShortLinks :many
+WITH deleted AS (
+  DELETE FROM short_links
+  WHERE user_id = $1
+  RETURNING id, short_code, domain_id
+)
+SELECT short_code, domain_id FROM deleted
+UNION ALL
+SELECT la.short_code, la.domain_id
+FROM link_aliases la
+JOIN deleted d ON d.id = la.link_id
+`
+
+type DeleteAllUserShortLinksRow struct {
+	ShortCode string      `json:"short_code"`
+	DomainID  pgtype.UUID `json:"domain_id"`
+}
+
+// Deletes all links of the user and returns the codes of the links and of their
+// aliases, so their cache entries can be dropped.
+func (q *Queries) DeleteAllUserShortLinks(ctx context.Context, userID uuid.UUID) ([]DeleteAllUserShortLinksRow, error) {
+	rows, err := q.db.Query(ctx, deleteAllUserShortLinks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DeleteAllUserShortLinksRow{}
+	for rows.Next() {
+		var i DeleteAllUserShortLinksRow
+		if err := rows.Scan(&i.ShortCode, &i.DomainID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deleteUserShortLink = `-- name: DeleteUserShortLink :exec
 DELETE FROM short_links
 WHERE id = $1
@@ -164,8 +310,9 @@ func (q *Queries) DeleteUserShortLink(ctx context.Context, id uuid.UUID) error {
 }
 
 const getActiveShortLinkByCode = `-- name: GetActiveShortLinkByCode :one
-SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at FROM short_links
+SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image FROM short_links
 WHERE short_code = $1
+AND domain_id IS NULL
 AND is_active = true
 AND (expired_at IS NULL OR expired_at > NOW())
 AND (click_limit IS NULL OR click_limit > 0)
@@ -186,12 +333,30 @@ func (q *Queries) GetActiveShortLinkByCode(ctx context.Context, shortCode string
 		&i.ExpiredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
 	)
 	return i, err
 }
 
 const getShortLink = `-- name: GetShortLink :one
-SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at FROM short_links
+SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image FROM short_links
 WHERE id = $1 LIMIT 1
 `
 
@@ -209,13 +374,31 @@ func (q *Queries) GetShortLink(ctx context.Context, id uuid.UUID) (ShortLink, er
 		&i.ExpiredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
 	)
 	return i, err
 }
 
 const getShortLinkByCode = `-- name: GetShortLinkByCode :one
-SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at FROM short_links
-WHERE short_code = $1 LIMIT 1
+SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image FROM short_links
+WHERE short_code = $1 AND domain_id IS NULL LIMIT 1
 `
 
 func (q *Queries) GetShortLinkByCode(ctx context.Context, shortCode string) (ShortLink, error) {
@@ -232,12 +415,158 @@ func (q *Queries) GetShortLinkByCode(ctx context.Context, shortCode string) (Sho
 		&i.ExpiredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
 	)
 	return i, err
 }
 
+const getShortLinkByDomainAndCode = `-- name: GetShortLinkByDomainAndCode :one
+SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image FROM short_links
+WHERE domain_id = $1 AND short_code = $2 LIMIT 1
+`
+
+type GetShortLinkByDomainAndCodeParams struct {
+	DomainID  pgtype.UUID `json:"domain_id"`
+	ShortCode string      `json:"short_code"`
+}
+
+func (q *Queries) GetShortLinkByDomainAndCode(ctx context.Context, arg GetShortLinkByDomainAndCodeParams) (ShortLink, error) {
+	row := q.db.QueryRow(ctx, getShortLinkByDomainAndCode, arg.DomainID, arg.ShortCode)
+	var i ShortLink
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OriginalUrl,
+		&i.ShortCode,
+		&i.Title,
+		&i.IsActive,
+		&i.ClickLimit,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
+	)
+	return i, err
+}
+
+const getUserActiveLinkByCanonicalURL = `-- name: GetUserActiveLinkByCanonicalURL :one
+SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image FROM short_links
+WHERE user_id = $1
+AND domain_id IS NOT DISTINCT FROM $2::uuid
+AND canonical_url = $3
+AND is_active = true
+AND (expired_at IS NULL OR expired_at > NOW())
+AND (click_limit IS NULL OR click_limit > 0)
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetUserActiveLinkByCanonicalURLParams struct {
+	UserID       uuid.UUID   `json:"user_id"`
+	DomainID     pgtype.UUID `json:"domain_id"`
+	CanonicalUrl string      `json:"canonical_url"`
+}
+
+// The most recent usable link of a user on a domain leading to the canonical destination,
+// for reuse_existing.
+func (q *Queries) GetUserActiveLinkByCanonicalURL(ctx context.Context, arg GetUserActiveLinkByCanonicalURLParams) (ShortLink, error) {
+	row := q.db.QueryRow(ctx, getUserActiveLinkByCanonicalURL, arg.UserID, arg.DomainID, arg.CanonicalUrl)
+	var i ShortLink
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OriginalUrl,
+		&i.ShortCode,
+		&i.Title,
+		&i.IsActive,
+		&i.ClickLimit,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
+	)
+	return i, err
+}
+
+const listExistingShortLinkIDs = `-- name: ListExistingShortLinkIDs :many
+SELECT id FROM short_links
+WHERE id = ANY($1::uuid[])
+`
+
+// The IDs among the given ones whose link still exists.
+func (q *Queries) ListExistingShortLinkIDs(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listExistingShortLinkIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listShortLinks = `-- name: ListShortLinks :many
-SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at FROM short_links
+SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image FROM short_links
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
 `
@@ -267,6 +596,24 @@ func (q *Queries) ListShortLinks(ctx context.Context, arg ListShortLinksParams)
 			&i.ExpiredAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.PasswordHash,
+			&i.Description,
+			&i.ForceInterstitial,
+			&i.VariantAssignment,
+			&i.UtmSource,
+			&i.UtmMedium,
+			&i.UtmCampaign,
+			&i.UtmTerm,
+			&i.UtmContent,
+			&i.QueryPassthrough,
+			&i.StartsAt,
+			&i.FallbackUrl,
+			&i.RedirectStatus,
+			&i.DomainID,
+			&i.CanonicalUrl,
+			&i.SocialTitle,
+			&i.SocialDescription,
+			&i.SocialImage,
 		); err != nil {
 			return nil, err
 		}
@@ -279,13 +626,19 @@ func (q *Queries) ListShortLinks(ctx context.Context, arg ListShortLinksParams)
 }
 
 const listUserShortLinks = `-- name: ListUserShortLinks :many
-SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at FROM short_links
+SELECT id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image FROM short_links
 WHERE user_id = $1
-  -- Search functionality - search by title (handles NULL titles)
-  AND ($4::text = '' OR (title IS NOT NULL AND title ILIKE '%' || $4 || '%'))
+  -- Search functionality - search by title (handles NULL titles) or destination
+  AND ($4::text = '' OR (title IS NOT NULL AND title ILIKE '%' || $4 || '%')
+       OR canonical_url ILIKE '%' || $4 || '%')
   -- Date range filtering for created_at
   AND ($5::timestamptz IS NULL OR created_at >= $5)
   AND ($6::timestamptz IS NULL OR created_at <= $6)
+  -- Links whose current destination is broken, or the others
+  AND ($9::bool IS NULL OR EXISTS (
+      SELECT 1 FROM link_health lh
+      WHERE lh.link_id = short_links.id AND lh.destination_url = short_links.original_url AND lh.is_broken
+  ) = $9::bool)
 ORDER BY
     CASE
         WHEN $7::shortlink_order_column = 'title' AND $8::bool = true THEN title
@@ -329,6 +682,7 @@ type ListUserShortLinksParams struct {
 	EndDate    pgtype.Timestamptz   `json:"end_date"`
 	OrderBy    ShortlinkOrderColumn `json:"order_by"`
 	Ascending  bool                 `json:"ascending"`
+	Broken     *bool                `json:"broken"`
 }
 
 func (q *Queries) ListUserShortLinks(ctx context.Context, arg ListUserShortLinksParams) ([]ShortLink, error) {
@@ -341,6 +695,7 @@ func (q *Queries) ListUserShortLinks(ctx context.Context, arg ListUserShortLinks
 		arg.EndDate,
 		arg.OrderBy,
 		arg.Ascending,
+		arg.Broken,
 	)
 	if err != nil {
 		return nil, err
@@ -360,6 +715,24 @@ func (q *Queries) ListUserShortLinks(ctx context.Context, arg ListUserShortLinks
 			&i.ExpiredAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.PasswordHash,
+			&i.Description,
+			&i.ForceInterstitial,
+			&i.VariantAssignment,
+			&i.UtmSource,
+			&i.UtmMedium,
+			&i.UtmCampaign,
+			&i.UtmTerm,
+			&i.UtmContent,
+			&i.QueryPassthrough,
+			&i.StartsAt,
+			&i.FallbackUrl,
+			&i.RedirectStatus,
+			&i.DomainID,
+			&i.CanonicalUrl,
+			&i.SocialTitle,
+			&i.SocialDescription,
+			&i.SocialImage,
 		); err != nil {
 			return nil, err
 		}
@@ -372,21 +745,34 @@ func (q *Queries) ListUserShortLinks(ctx context.Context, arg ListUserShortLinks
 }
 
 const listUserShortLinksWithCountClick = `-- name: ListUserShortLinksWithCountClick :many
-SELECT sl.id, sl.user_id, sl.original_url, sl.short_code, sl.title, sl.is_active, sl.click_limit, sl.expired_at, sl.created_at, sl.updated_at,
-       COALESCE(ls.click_count, 0) AS total_clicks
+SELECT sl.id, sl.user_id, sl.original_url, sl.short_code, sl.title, sl.is_active, sl.click_limit, sl.expired_at, sl.created_at, sl.updated_at, sl.password_hash, sl.description, sl.force_interstitial, sl.variant_assignment, sl.utm_source, sl.utm_medium, sl.utm_campaign, sl.utm_term, sl.utm_content, sl.query_passthrough, sl.starts_at, sl.fallback_url, sl.redirect_status, sl.domain_id, sl.canonical_url, sl.social_title, sl.social_description, sl.social_image,
+       COALESCE(ls.click_count, 0) AS total_clicks,
+       COALESCE(lp.preview_count, 0) AS total_previews
 FROM short_links sl
          LEFT JOIN (
     SELECT link_id, COUNT(*) AS click_count
     FROM link_stats
+    WHERE NOT is_bot
     GROUP BY link_id
 ) ls ON sl.id = ls.link_id
+         LEFT JOIN (
+    SELECT link_id, COUNT(*) AS preview_count
+    FROM link_previews
+    GROUP BY link_id
+) lp ON sl.id = lp.link_id
 WHERE sl.user_id = $1
-  -- Search functionality - search by title (handles NULL titles)
-  AND ($4::text = '' OR (sl.title IS NOT NULL AND sl.title ILIKE '%' || $4 || '%'))
+  -- Search functionality - search by title (handles NULL titles) or destination
+  AND ($4::text = '' OR (sl.title IS NOT NULL AND sl.title ILIKE '%' || $4 || '%')
+       OR sl.canonical_url ILIKE '%' || $4 || '%')
   -- Date range filtering for created_at
   AND ($5::timestamptz IS NULL OR sl.created_at >= $5)
   AND ($6::timestamptz IS NULL OR sl.created_at <= $6)
-GROUP BY sl.id, ls.click_count
+  -- Links whose current destination is broken, or the others
+  AND ($9::bool IS NULL OR EXISTS (
+      SELECT 1 FROM link_health lh
+      WHERE lh.link_id = sl.id AND lh.destination_url = sl.original_url AND lh.is_broken
+  ) = $9::bool)
+GROUP BY sl.id, ls.click_count, lp.preview_count
 ORDER BY
     CASE
         WHEN $7::shortlink_order_column = 'title' AND $8::bool = true THEN sl.title
@@ -430,20 +816,40 @@ type ListUserShortLinksWithCountClickParams struct {
 	EndDate    pgtype.Timestamptz   `json:"end_date"`
 	OrderBy    ShortlinkOrderColumn `json:"order_by"`
 	Ascending  bool                 `json:"ascending"`
+	Broken     *bool                `json:"broken"`
 }
 
 type ListUserShortLinksWithCountClickRow struct {
-	ID          uuid.UUID        `json:"id"`
-	UserID      uuid.UUID        `json:"user_id"`
-	OriginalUrl string           `json:"original_url"`
-	ShortCode   string           `json:"short_code"`
-	Title       *string          `json:"title"`
-	IsActive    bool             `json:"is_active"`
-	ClickLimit  *int32           `json:"click_limit"`
-	ExpiredAt   pgtype.Timestamp `json:"expired_at"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
-	TotalClicks int64            `json:"total_clicks"`
+	ID                uuid.UUID        `json:"id"`
+	UserID            uuid.UUID        `json:"user_id"`
+	OriginalUrl       string           `json:"original_url"`
+	ShortCode         string           `json:"short_code"`
+	Title             *string          `json:"title"`
+	IsActive          bool             `json:"is_active"`
+	ClickLimit        *int32           `json:"click_limit"`
+	ExpiredAt         pgtype.Timestamp `json:"expired_at"`
+	CreatedAt         pgtype.Timestamp `json:"created_at"`
+	UpdatedAt         pgtype.Timestamp `json:"updated_at"`
+	PasswordHash      *string          `json:"password_hash"`
+	Description       *string          `json:"description"`
+	ForceInterstitial bool             `json:"force_interstitial"`
+	VariantAssignment string           `json:"variant_assignment"`
+	UtmSource         *string          `json:"utm_source"`
+	UtmMedium         *string          `json:"utm_medium"`
+	UtmCampaign       *string          `json:"utm_campaign"`
+	UtmTerm           *string          `json:"utm_term"`
+	UtmContent        *string          `json:"utm_content"`
+	QueryPassthrough  bool             `json:"query_passthrough"`
+	StartsAt          pgtype.Timestamp `json:"starts_at"`
+	FallbackUrl       *string          `json:"fallback_url"`
+	RedirectStatus    *int16           `json:"redirect_status"`
+	DomainID          pgtype.UUID      `json:"domain_id"`
+	CanonicalUrl      string           `json:"canonical_url"`
+	SocialTitle       *string          `json:"social_title"`
+	SocialDescription *string          `json:"social_description"`
+	SocialImage       *string          `json:"social_image"`
+	TotalClicks       int64            `json:"total_clicks"`
+	TotalPreviews     int64            `json:"total_previews"`
 }
 
 func (q *Queries) ListUserShortLinksWithCountClick(ctx context.Context, arg ListUserShortLinksWithCountClickParams) ([]ListUserShortLinksWithCountClickRow, error) {
@@ -456,6 +862,7 @@ func (q *Queries) ListUserShortLinksWithCountClick(ctx context.Context, arg List
 		arg.EndDate,
 		arg.OrderBy,
 		arg.Ascending,
+		arg.Broken,
 	)
 	if err != nil {
 		return nil, err
@@ -475,7 +882,26 @@ func (q *Queries) ListUserShortLinksWithCountClick(ctx context.Context, arg List
 			&i.ExpiredAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.PasswordHash,
+			&i.Description,
+			&i.ForceInterstitial,
+			&i.VariantAssignment,
+			&i.UtmSource,
+			&i.UtmMedium,
+			&i.UtmCampaign,
+			&i.UtmTerm,
+			&i.UtmContent,
+			&i.QueryPassthrough,
+			&i.StartsAt,
+			&i.FallbackUrl,
+			&i.RedirectStatus,
+			&i.DomainID,
+			&i.CanonicalUrl,
+			&i.SocialTitle,
+			&i.SocialDescription,
+			&i.SocialImage,
 			&i.TotalClicks,
+			&i.TotalPreviews,
 		); err != nil {
 			return nil, err
 		}
@@ -487,11 +913,22 @@ func (q *Queries) ListUserShortLinksWithCountClick(ctx context.Context, arg List
 	return items, nil
 }
 
+const nextShortCodeSequence = `-- name: NextShortCodeSequence :one
+SELECT nextval('short_code_seq')::bigint AS value
+`
+
+func (q *Queries) NextShortCodeSequence(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, nextShortCodeSequence)
+	var value int64
+	err := row.Scan(&value)
+	return value, err
+}
+
 const toggleShortLinkStatus = `-- name: ToggleShortLinkStatus :one
 UPDATE short_links
 SET is_active = NOT is_active
 WHERE id = $1
-RETURNING id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at
+RETURNING id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image
 `
 
 func (q *Queries) ToggleShortLinkStatus(ctx context.Context, id uuid.UUID) (ShortLink, error) {
@@ -508,6 +945,24 @@ func (q *Queries) ToggleShortLinkStatus(ctx context.Context, id uuid.UUID) (Shor
 		&i.ExpiredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
 	)
 	return i, err
 }
@@ -520,19 +975,51 @@ SET
   title = COALESCE($4, title),
   is_active = COALESCE($5, is_active),
   click_limit = COALESCE($6, click_limit),
-  expired_at = COALESCE($7, expired_at)
+  expired_at = COALESCE($7, expired_at),
+  password_hash = $8,
+  description = $9,
+  force_interstitial = $10,
+  utm_source = $11,
+  utm_medium = $12,
+  utm_campaign = $13,
+  utm_term = $14,
+  utm_content = $15,
+  query_passthrough = $16,
+  starts_at = $17,
+  fallback_url = $18,
+  redirect_status = $19,
+  canonical_url = COALESCE($20, canonical_url),
+  social_title = $21,
+  social_description = $22,
+  social_image = $23
 WHERE id = $1
-RETURNING id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at
+RETURNING id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image
 `
 
 type UpdateShortLinkParams struct {
-	ID          uuid.UUID        `json:"id"`
-	OriginalUrl string           `json:"original_url"`
-	ShortCode   string           `json:"short_code"`
-	Title       *string          `json:"title"`
-	IsActive    bool             `json:"is_active"`
-	ClickLimit  *int32           `json:"click_limit"`
-	ExpiredAt   pgtype.Timestamp `json:"expired_at"`
+	ID                uuid.UUID        `json:"id"`
+	OriginalUrl       string           `json:"original_url"`
+	ShortCode         string           `json:"short_code"`
+	Title             *string          `json:"title"`
+	IsActive          bool             `json:"is_active"`
+	ClickLimit        *int32           `json:"click_limit"`
+	ExpiredAt         pgtype.Timestamp `json:"expired_at"`
+	PasswordHash      *string          `json:"password_hash"`
+	Description       *string          `json:"description"`
+	ForceInterstitial bool             `json:"force_interstitial"`
+	UtmSource         *string          `json:"utm_source"`
+	UtmMedium         *string          `json:"utm_medium"`
+	UtmCampaign       *string          `json:"utm_campaign"`
+	UtmTerm           *string          `json:"utm_term"`
+	UtmContent        *string          `json:"utm_content"`
+	QueryPassthrough  bool             `json:"query_passthrough"`
+	StartsAt          pgtype.Timestamp `json:"starts_at"`
+	FallbackUrl       *string          `json:"fallback_url"`
+	RedirectStatus    *int16           `json:"redirect_status"`
+	CanonicalUrl      string           `json:"canonical_url"`
+	SocialTitle       *string          `json:"social_title"`
+	SocialDescription *string          `json:"social_description"`
+	SocialImage       *string          `json:"social_image"`
 }
 
 func (q *Queries) UpdateShortLink(ctx context.Context, arg UpdateShortLinkParams) (ShortLink, error) {
@@ -544,7 +1031,71 @@ func (q *Queries) UpdateShortLink(ctx context.Context, arg UpdateShortLinkParams
 		arg.IsActive,
 		arg.ClickLimit,
 		arg.ExpiredAt,
+		arg.PasswordHash,
+		arg.Description,
+		arg.ForceInterstitial,
+		arg.UtmSource,
+		arg.UtmMedium,
+		arg.UtmCampaign,
+		arg.UtmTerm,
+		arg.UtmContent,
+		arg.QueryPassthrough,
+		arg.StartsAt,
+		arg.FallbackUrl,
+		arg.RedirectStatus,
+		arg.CanonicalUrl,
+		arg.SocialTitle,
+		arg.SocialDescription,
+		arg.SocialImage,
+	)
+	var i ShortLink
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OriginalUrl,
+		&i.ShortCode,
+		&i.Title,
+		&i.IsActive,
+		&i.ClickLimit,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
 	)
+	return i, err
+}
+
+const updateShortLinkVariantAssignment = `-- name: UpdateShortLinkVariantAssignment :one
+UPDATE short_links
+SET variant_assignment = $2
+WHERE id = $1
+RETURNING id, user_id, original_url, short_code, title, is_active, click_limit, expired_at, created_at, updated_at, password_hash, description, force_interstitial, variant_assignment, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, starts_at, fallback_url, redirect_status, domain_id, canonical_url, social_title, social_description, social_image
+`
+
+type UpdateShortLinkVariantAssignmentParams struct {
+	ID                uuid.UUID `json:"id"`
+	VariantAssignment string    `json:"variant_assignment"`
+}
+
+func (q *Queries) UpdateShortLinkVariantAssignment(ctx context.Context, arg UpdateShortLinkVariantAssignmentParams) (ShortLink, error) {
+	row := q.db.QueryRow(ctx, updateShortLinkVariantAssignment, arg.ID, arg.VariantAssignment)
 	var i ShortLink
 	err := row.Scan(
 		&i.ID,
@@ -557,6 +1108,24 @@ func (q *Queries) UpdateShortLink(ctx context.Context, arg UpdateShortLinkParams
 		&i.ExpiredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Description,
+		&i.ForceInterstitial,
+		&i.VariantAssignment,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.StartsAt,
+		&i.FallbackUrl,
+		&i.RedirectStatus,
+		&i.DomainID,
+		&i.CanonicalUrl,
+		&i.SocialTitle,
+		&i.SocialDescription,
+		&i.SocialImage,
 	)
 	return i, err
 }
@@ -18,6 +18,7 @@ SELECT
   COUNT(*) AS clicks
 FROM link_stats
 WHERE link_id = $1
+  AND NOT is_bot
   AND click_time BETWEEN $3 AND $4
 GROUP BY period
 ORDER BY period DESC
@@ -70,6 +71,7 @@ FROM short_links sl
 LEFT JOIN (
   SELECT link_id, COUNT(*) AS clicks
   FROM link_stats
+  WHERE NOT is_bot
   GROUP BY link_id
 ) ls ON sl.id = ls.link_id
 WHERE sl.user_id = $1
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"GoShort/internal/datastore"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ways to prove ownership of a domain.
+const (
+	// MethodDNS looks for a TXT record on DNSRecordPrefix + hostname
+	MethodDNS = "dns"
+	// MethodHTTP fetches WellKnownPath from the domain
+	MethodHTTP = "http"
+)
+
+const (
+	// DNSRecordPrefix is prepended to the hostname to get the name of the TXT record
+	DNSRecordPrefix = "_goshort-verification."
+	// DNSValuePrefix is prepended to the token in the TXT record value
+	DNSValuePrefix = "goshort-verification="
+	// WellKnownPath is served by the domain with the token as its only content
+	WellKnownPath = "/.well-known/goshort-verification.txt"
+)
+
+type CreateDomainRequest struct {
+	Hostname string `json:"hostname" validate:"required,fqdn,max=253"`
+}
+
+type VerifyDomainRequest struct {
+	Method string `json:"method" validate:"required,oneof=dns http"`
+}
+
+type DomainResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Hostname   string     `json:"hostname"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Verification explains how to prove ownership; it is left out once the domain is verified
+	Verification *VerificationInstructions `json:"verification,omitempty"`
+}
+
+// VerificationInstructions lists both ways to verify a domain. Either one is enough.
+type VerificationInstructions struct {
+	Token          string `json:"token"`
+	DNSRecordName  string `json:"dns_record_name"`
+	DNSRecordValue string `json:"dns_record_value"`
+	HTTPURL        string `json:"http_url"`
+	HTTPBody       string `json:"http_body"`
+}
+
+// NewDomainResponse converts a datastore domain to its API representation.
+func NewDomainResponse(domain datastore.Domain) DomainResponse {
+	response := DomainResponse{
+		ID:        domain.ID,
+		Hostname:  domain.Hostname,
+		Verified:  domain.VerifiedAt.Valid,
+		CreatedAt: domain.CreatedAt.Time,
+	}
+	if domain.VerifiedAt.Valid {
+		verifiedAt := domain.VerifiedAt.Time
+		response.VerifiedAt = &verifiedAt
+		return response
+	}
+
+	response.Verification = &VerificationInstructions{
+		Token:          domain.VerificationToken,
+		DNSRecordName:  DNSRecordPrefix + domain.Hostname,
+		DNSRecordValue: DNSValuePrefix + domain.VerificationToken,
+		HTTPURL:        "http://" + domain.Hostname + WellKnownPath,
+		HTTPBody:       domain.VerificationToken,
+	}
+	return response
+}
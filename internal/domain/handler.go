@@ -0,0 +1,229 @@
+package domain
+
+import (
+	"GoShort/internal/commons"
+	"GoShort/pkg/logger"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	svr       IService
+	log       *logger.Logger
+	validator *validator.Validate
+}
+
+func NewHandler(service IService, log *logger.Logger, validator *validator.Validate) *Handler {
+	return &Handler{
+		svr:       service,
+		log:       log,
+		validator: validator,
+	}
+}
+
+// ListDomains lists the custom domains of the authenticated user
+// @Godoc ListDomains
+// @Summary List custom domains
+// @Description Retrieve the custom domains of the authenticated user, with verification instructions for unverified ones
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.DomainResponse} "Domains retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/domains [get]
+// @Security ApiKeyAuth
+func (h *Handler) ListDomains(c *fiber.Ctx) error {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return nil
+	}
+
+	resp, err := h.svr.ListDomains(c.Context(), userID)
+	if err != nil {
+		return h.serviceError(c, err, "Failed to retrieve domains")
+	}
+
+	return c.JSON(commons.SuccessResponse{
+		Message: "Domains retrieved successfully",
+		Data:    resp,
+	})
+}
+
+// AddDomain adds a custom domain to the authenticated user
+// @Godoc AddDomain
+// @Summary Add a custom domain
+// @Description Claim a domain such as go.acme.com. Links can use it once ownership is verified with a DNS TXT record or an HTTP well-known file
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateDomainRequest true "Create Domain Request"
+// @Success 201 {object} dto.SuccessResponse{data=dto.DomainResponse} "Domain added successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid request body or hostname"
+// @Failure 409 {object} dto.ErrorResponse "Domain has already been added"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/domains [post]
+// @Security ApiKeyAuth
+func (h *Handler) AddDomain(c *fiber.Ctx) error {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return nil
+	}
+
+	var req CreateDomainRequest
+	if !h.parseRequest(c, &req) {
+		return nil
+	}
+
+	resp, err := h.svr.AddDomain(c.Context(), userID, req)
+	if err != nil {
+		return h.serviceError(c, err, "Failed to add domain")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(commons.SuccessResponse{
+		Message: "Domain added successfully",
+		Data:    resp,
+	})
+}
+
+// VerifyDomain checks the ownership of a custom domain
+// @Godoc VerifyDomain
+// @Summary Verify a custom domain
+// @Description Check the DNS TXT record or the HTTP well-known file of a domain and mark it verified
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Param id path string true "Domain ID"
+// @Param request body dto.VerifyDomainRequest true "Verify Domain Request"
+// @Success 200 {object} dto.SuccessResponse{data=dto.DomainResponse} "Domain verified successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid domain ID or request body"
+// @Failure 404 {object} dto.ErrorResponse "Domain not found"
+// @Failure 409 {object} dto.ErrorResponse "Domain is verified by another account"
+// @Failure 422 {object} dto.ErrorResponse "Ownership could not be verified"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/domains/{id}/verify [post]
+// @Security ApiKeyAuth
+func (h *Handler) VerifyDomain(c *fiber.Ctx) error {
+	userID, domainID, ok := parseIDs(c)
+	if !ok {
+		return nil
+	}
+
+	var req VerifyDomainRequest
+	if !h.parseRequest(c, &req) {
+		return nil
+	}
+
+	resp, err := h.svr.VerifyDomain(c.Context(), userID, domainID, req)
+	if err != nil {
+		return h.serviceError(c, err, "Failed to verify domain")
+	}
+
+	return c.JSON(commons.SuccessResponse{
+		Message: "Domain verified successfully",
+		Data:    resp,
+	})
+}
+
+// DeleteDomain removes a custom domain
+// @Godoc DeleteDomain
+// @Summary Delete a custom domain
+// @Description Remove a domain of the authenticated user. Its short links have to be deleted first
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Param id path string true "Domain ID"
+// @Success 204 "Domain deleted successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid domain ID"
+// @Failure 404 {object} dto.ErrorResponse "Domain not found"
+// @Failure 409 {object} dto.ErrorResponse "Domain still has short links"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/domains/{id} [delete]
+// @Security ApiKeyAuth
+func (h *Handler) DeleteDomain(c *fiber.Ctx) error {
+	userID, domainID, ok := parseIDs(c)
+	if !ok {
+		return nil
+	}
+
+	if err := h.svr.DeleteDomain(c.Context(), userID, domainID); err != nil {
+		return h.serviceError(c, err, "Failed to delete domain")
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// parseUserID reads the authenticated user from the request. When it returns false the
+// error response has already been written.
+func parseUserID(c *fiber.Ctx) (uuid.UUID, bool) {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		_ = c.Status(fiber.StatusUnauthorized).JSON(commons.ErrorResponse{Error: "Unauthorized"})
+		return uuid.Nil, false
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid user ID"})
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+// parseIDs reads the authenticated user and the domain ID from the request. When it
+// returns false the error response has already been written.
+func parseIDs(c *fiber.Ctx) (uuid.UUID, uuid.UUID, bool) {
+	userUUID, ok := parseUserID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	domainUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid domain ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userUUID, domainUUID, true
+}
+
+// parseRequest parses and validates the request body. When it returns false the error
+// response has already been written.
+func (h *Handler) parseRequest(c *fiber.Ctx, req any) bool {
+	if err := c.BodyParser(req); err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid request body"})
+		return false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+			Message: "Validation failed",
+			Error:   commons.FormatValidationErrors(err),
+		})
+		return false
+	}
+
+	return true
+}
+
+func (h *Handler) serviceError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, commons.ErrDomainNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(commons.ErrorResponse{Error: "Domain not found"})
+	case errors.Is(err, commons.ErrInvalidDomain):
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "This hostname cannot be used as a custom domain"})
+	case errors.Is(err, commons.ErrDomainExists):
+		return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{Error: "Domain has already been added"})
+	case errors.Is(err, commons.ErrDomainTaken):
+		return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{Error: "Domain is verified by another account"})
+	case errors.Is(err, commons.ErrDomainInUse):
+		return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{Error: "Delete the short links of this domain first"})
+	case errors.Is(err, commons.ErrDomainVerificationFailed):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(commons.ErrorResponse{Error: "Ownership could not be verified, check the DNS record or the well-known file and try again"})
+	default:
+		h.log.Error(message, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{Error: message})
+	}
+}
@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"GoShort/config"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type IService interface {
+	AddDomain(ctx context.Context, userID uuid.UUID, req CreateDomainRequest) (*DomainResponse, error)
+	ListDomains(ctx context.Context, userID uuid.UUID) ([]DomainResponse, error)
+	VerifyDomain(ctx context.Context, userID, domainID uuid.UUID, req VerifyDomainRequest) (*DomainResponse, error)
+	DeleteDomain(ctx context.Context, userID, domainID uuid.UUID) error
+}
+
+type Service struct {
+	repo     datastore.Querier
+	verifier IVerifier
+	// baseHost is the server's own domain, which cannot be added as a custom domain
+	baseHost string
+	log      *logger.Logger
+}
+
+func NewService(repo datastore.Querier, verifier IVerifier, cfg config.ServerConfig, log *logger.Logger) IService {
+	baseHost := ""
+	if base, err := url.Parse(cfg.BaseURL); err == nil {
+		baseHost = strings.ToLower(base.Hostname())
+	}
+	return &Service{repo: repo, verifier: verifier, baseHost: baseHost, log: log}
+}
+
+// NormalizeHostname lowercases a hostname and strips the port and the trailing dot, the
+// form domains are stored and looked up in.
+func NormalizeHostname(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, found := strings.Cut(host, ":"); found {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// AddDomain claims a domain for the user. Links can use it once it is verified.
+func (s *Service) AddDomain(ctx context.Context, userID uuid.UUID, req CreateDomainRequest) (*DomainResponse, error) {
+	hostname := NormalizeHostname(req.Hostname)
+	if hostname == "" || hostname == s.baseHost {
+		return nil, commons.ErrInvalidDomain
+	}
+
+	domains, err := s.repo.ListUserDomains(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to list user domains", "user_id", userID, "error", err)
+		return nil, err
+	}
+	for _, domain := range domains {
+		if domain.Hostname == hostname {
+			return nil, commons.ErrDomainExists
+		}
+	}
+
+	token, err := newVerificationToken()
+	if err != nil {
+		s.log.Error("failed to generate domain verification token", "error", err)
+		return nil, err
+	}
+
+	created, err := s.repo.CreateDomain(ctx, datastore.CreateDomainParams{
+		ID:                uuid.New(),
+		UserID:            userID,
+		Hostname:          hostname,
+		VerificationToken: token,
+	})
+	if err != nil {
+		s.log.Error("failed to create domain", "user_id", userID, "hostname", hostname, "error", err)
+		return nil, err
+	}
+
+	response := NewDomainResponse(created)
+	return &response, nil
+}
+
+func (s *Service) ListDomains(ctx context.Context, userID uuid.UUID) ([]DomainResponse, error) {
+	domains, err := s.repo.ListUserDomains(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to list user domains", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	response := make([]DomainResponse, len(domains))
+	for i, domain := range domains {
+		response[i] = NewDomainResponse(domain)
+	}
+	return response, nil
+}
+
+// VerifyDomain checks that the user controls the domain. A domain can only be verified
+// by one account; verifying an already verified domain is a no-op.
+func (s *Service) VerifyDomain(ctx context.Context, userID, domainID uuid.UUID, req VerifyDomainRequest) (*DomainResponse, error) {
+	domain, err := s.userDomain(ctx, userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if domain.VerifiedAt.Valid {
+		response := NewDomainResponse(domain)
+		return &response, nil
+	}
+
+	owner, err := s.repo.GetVerifiedDomainByHostname(ctx, domain.Hostname)
+	switch {
+	case err == nil && owner.ID != domain.ID:
+		return nil, commons.ErrDomainTaken
+	case err != nil && !errors.Is(err, pgx.ErrNoRows):
+		s.log.Error("failed to look up verified domain", "hostname", domain.Hostname, "error", err)
+		return nil, err
+	}
+
+	ok, err := s.verifier.Verify(ctx, req.Method, domain.Hostname, domain.VerificationToken)
+	if err != nil {
+		s.log.Warn("domain verification lookup failed", "hostname", domain.Hostname, "method", req.Method, "error", err)
+		return nil, commons.ErrDomainVerificationFailed
+	}
+	if !ok {
+		return nil, commons.ErrDomainVerificationFailed
+	}
+
+	verified, err := s.repo.MarkDomainVerified(ctx, datastore.MarkDomainVerifiedParams{
+		ID:         domain.ID,
+		VerifiedAt: pgtype.Timestamp{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		s.log.Error("failed to mark domain verified", "domain_id", domain.ID, "error", err)
+		return nil, err
+	}
+
+	s.log.Info("domain verified", "domain_id", domain.ID, "hostname", domain.Hostname, "method", req.Method)
+
+	response := NewDomainResponse(verified)
+	return &response, nil
+}
+
+// DeleteDomain removes a domain that no longer has short links.
+func (s *Service) DeleteDomain(ctx context.Context, userID, domainID uuid.UUID) error {
+	domain, err := s.userDomain(ctx, userID, domainID)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountDomainShortLinks(ctx, pgtype.UUID{Bytes: domain.ID, Valid: true})
+	if err != nil {
+		s.log.Error("failed to count domain short links", "domain_id", domain.ID, "error", err)
+		return err
+	}
+	if count > 0 {
+		return commons.ErrDomainInUse
+	}
+
+	if err := s.repo.DeleteDomain(ctx, domain.ID); err != nil {
+		s.log.Error("failed to delete domain", "domain_id", domain.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// userDomain loads a domain and checks it belongs to the user.
+func (s *Service) userDomain(ctx context.Context, userID, domainID uuid.UUID) (datastore.Domain, error) {
+	domain, err := s.repo.GetDomain(ctx, domainID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datastore.Domain{}, commons.ErrDomainNotFound
+		}
+		s.log.Error("failed to get domain", "domain_id", domainID, "error", err)
+		return datastore.Domain{}, err
+	}
+	if domain.UserID != userID {
+		// Other users' domains are reported as missing
+		return datastore.Domain{}, commons.ErrDomainNotFound
+	}
+	return domain, nil
+}
+
+func newVerificationToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
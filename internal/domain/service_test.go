@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"GoShort/config"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/internal/testutil"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDomainRepo keeps domains in memory like the domains table.
+type fakeDomainRepo struct {
+	datastore.Querier
+
+	domains    []datastore.Domain
+	linkCounts map[uuid.UUID]int64
+}
+
+func (f *fakeDomainRepo) CreateDomain(ctx context.Context, arg datastore.CreateDomainParams) (datastore.Domain, error) {
+	domain := datastore.Domain{
+		ID:                arg.ID,
+		UserID:            arg.UserID,
+		Hostname:          arg.Hostname,
+		VerificationToken: arg.VerificationToken,
+	}
+	f.domains = append(f.domains, domain)
+	return domain, nil
+}
+
+func (f *fakeDomainRepo) ListUserDomains(ctx context.Context, userID uuid.UUID) ([]datastore.Domain, error) {
+	var domains []datastore.Domain
+	for _, domain := range f.domains {
+		if domain.UserID == userID {
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
+
+func (f *fakeDomainRepo) GetDomain(ctx context.Context, id uuid.UUID) (datastore.Domain, error) {
+	for _, domain := range f.domains {
+		if domain.ID == id {
+			return domain, nil
+		}
+	}
+	return datastore.Domain{}, pgx.ErrNoRows
+}
+
+func (f *fakeDomainRepo) GetVerifiedDomainByHostname(ctx context.Context, hostname string) (datastore.Domain, error) {
+	for _, domain := range f.domains {
+		if domain.Hostname == hostname && domain.VerifiedAt.Valid {
+			return domain, nil
+		}
+	}
+	return datastore.Domain{}, pgx.ErrNoRows
+}
+
+func (f *fakeDomainRepo) MarkDomainVerified(ctx context.Context, arg datastore.MarkDomainVerifiedParams) (datastore.Domain, error) {
+	for i, domain := range f.domains {
+		if domain.ID == arg.ID {
+			f.domains[i].VerifiedAt = arg.VerifiedAt
+			return f.domains[i], nil
+		}
+	}
+	return datastore.Domain{}, pgx.ErrNoRows
+}
+
+func (f *fakeDomainRepo) CountDomainShortLinks(ctx context.Context, domainID pgtype.UUID) (int64, error) {
+	return f.linkCounts[domainID.Bytes], nil
+}
+
+func (f *fakeDomainRepo) DeleteDomain(ctx context.Context, id uuid.UUID) error {
+	for i, domain := range f.domains {
+		if domain.ID == id {
+			f.domains = append(f.domains[:i], f.domains[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func newTestService(repo *fakeDomainRepo, resolver Resolver) IService {
+	return NewService(repo, NewVerifier(resolver, config.URLPolicyConfig{}), config.ServerConfig{BaseURL: "https://gosh.rt"}, testutil.NewLogger())
+}
+
+func TestNormalizeHostname(t *testing.T) {
+	require.Equal(t, "go.acme.com", NormalizeHostname(" Go.Acme.COM. "))
+	require.Equal(t, "go.acme.com", NormalizeHostname("go.acme.com:8443"))
+	require.Equal(t, "", NormalizeHostname(""))
+}
+
+func TestService_AddDomain(t *testing.T) {
+	repo := &fakeDomainRepo{}
+	svc := newTestService(repo, stubResolver{})
+	userID := uuid.New()
+
+	resp, err := svc.AddDomain(context.Background(), userID, CreateDomainRequest{Hostname: "Go.Acme.com"})
+	require.NoError(t, err)
+	require.Equal(t, "go.acme.com", resp.Hostname)
+	require.False(t, resp.Verified)
+	require.NotNil(t, resp.Verification)
+	require.Equal(t, "_goshort-verification.go.acme.com", resp.Verification.DNSRecordName)
+	require.Equal(t, "goshort-verification="+resp.Verification.Token, resp.Verification.DNSRecordValue)
+
+	_, err = svc.AddDomain(context.Background(), userID, CreateDomainRequest{Hostname: "go.acme.com"})
+	require.ErrorIs(t, err, commons.ErrDomainExists)
+
+	_, err = svc.AddDomain(context.Background(), userID, CreateDomainRequest{Hostname: "gosh.rt"})
+	require.ErrorIs(t, err, commons.ErrInvalidDomain)
+
+	// Another account may claim the same hostname until one of them verifies it
+	_, err = svc.AddDomain(context.Background(), uuid.New(), CreateDomainRequest{Hostname: "go.acme.com"})
+	require.NoError(t, err)
+}
+
+func TestService_VerifyDomain(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	repo := &fakeDomainRepo{domains: []datastore.Domain{
+		{ID: uuid.New(), UserID: owner, Hostname: "go.acme.com", VerificationToken: "owner-token"},
+		{ID: uuid.New(), UserID: other, Hostname: "go.acme.com", VerificationToken: "other-token"},
+	}}
+	resolver := stubResolver{"_goshort-verification.go.acme.com": {"goshort-verification=owner-token"}}
+	svc := newTestService(repo, resolver)
+	req := VerifyDomainRequest{Method: MethodDNS}
+
+	_, err := svc.VerifyDomain(context.Background(), other, repo.domains[1].ID, req)
+	require.ErrorIs(t, err, commons.ErrDomainVerificationFailed)
+
+	_, err = svc.VerifyDomain(context.Background(), other, repo.domains[0].ID, req)
+	require.ErrorIs(t, err, commons.ErrDomainNotFound)
+
+	resp, err := svc.VerifyDomain(context.Background(), owner, repo.domains[0].ID, req)
+	require.NoError(t, err)
+	require.True(t, resp.Verified)
+	require.Nil(t, resp.Verification)
+
+	// Once verified, the hostname belongs to the owner
+	resolver["_goshort-verification.go.acme.com"] = []string{"goshort-verification=other-token"}
+	_, err = svc.VerifyDomain(context.Background(), other, repo.domains[1].ID, req)
+	require.ErrorIs(t, err, commons.ErrDomainTaken)
+}
+
+func TestService_DeleteDomain(t *testing.T) {
+	userID := uuid.New()
+	inUse, unused := uuid.New(), uuid.New()
+	repo := &fakeDomainRepo{
+		domains: []datastore.Domain{
+			{ID: inUse, UserID: userID, Hostname: "go.acme.com"},
+			{ID: unused, UserID: userID, Hostname: "links.acme.com"},
+		},
+		linkCounts: map[uuid.UUID]int64{inUse: 2},
+	}
+	svc := newTestService(repo, stubResolver{})
+
+	require.ErrorIs(t, svc.DeleteDomain(context.Background(), userID, inUse), commons.ErrDomainInUse)
+	require.ErrorIs(t, svc.DeleteDomain(context.Background(), uuid.New(), unused), commons.ErrDomainNotFound)
+	require.NoError(t, svc.DeleteDomain(context.Background(), userID, unused))
+	require.Len(t, repo.domains, 1)
+}
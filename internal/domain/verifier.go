@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"GoShort/config"
+	"GoShort/internal/urlpolicy"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Resolver looks up DNS TXT records. *net.Resolver implements it; tests use a local stub.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type IVerifier interface {
+	// Verify reports whether the domain publishes the token with the given method
+	Verify(ctx context.Context, method, hostname, token string) (bool, error)
+}
+
+// maxWellKnownSize bounds how much of the well-known file is read.
+const maxWellKnownSize = 1024
+
+// Verifier checks domain ownership through DNS or the domain's web server.
+type Verifier struct {
+	resolver Resolver
+	client   *http.Client
+}
+
+// verifyTimeout bounds the request for the well-known file.
+const verifyTimeout = 10 * time.Second
+
+// NewVerifier creates a verifier. A nil resolver uses the system resolver. The
+// well-known file is fetched with the URL policy client, so domains resolving to
+// private networks are not requested, and redirects are not followed, so the file has
+// to be served by the domain itself.
+func NewVerifier(resolver Resolver, policyCfg config.URLPolicyConfig) *Verifier {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &Verifier{
+		resolver: resolver,
+		client:   urlpolicy.NewClient(policyCfg, verifyTimeout, 0),
+	}
+}
+
+func (v *Verifier) Verify(ctx context.Context, method, hostname, token string) (bool, error) {
+	switch method {
+	case MethodDNS:
+		return v.verifyDNS(ctx, hostname, token)
+	case MethodHTTP:
+		return v.verifyHTTP(ctx, hostname, token)
+	default:
+		return false, fmt.Errorf("unknown verification method %q", method)
+	}
+}
+
+// verifyDNS looks for the token in the TXT records of DNSRecordPrefix + hostname. A
+// missing record is a failed verification, not an error.
+func (v *Verifier) verifyDNS(ctx context.Context, hostname, token string) (bool, error) {
+	records, err := v.resolver.LookupTXT(ctx, DNSRecordPrefix+hostname)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, record := range records {
+		if strings.TrimSpace(record) == DNSValuePrefix+token {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// verifyHTTP fetches the well-known file from the domain and compares it to the token.
+// Unreachable domains fail the verification.
+func (v *Verifier) verifyHTTP(ctx context.Context, hostname, token string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+hostname+WellKnownPath, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxWellKnownSize))
+	if err != nil {
+		return false, nil
+	}
+	return strings.TrimSpace(string(body)) == token, nil
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"GoShort/config"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// stubResolver answers TXT lookups from a map; missing names fail like NXDOMAIN.
+type stubResolver map[string][]string
+
+func (r stubResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func TestVerifier_DNS(t *testing.T) {
+	resolver := stubResolver{
+		"_goshort-verification.go.acme.com": {"v=spf1 -all", " goshort-verification=token123 "},
+		"_goshort-verification.wrong.com":   {"goshort-verification=other"},
+	}
+	v := NewVerifier(resolver, config.URLPolicyConfig{})
+
+	tests := []struct {
+		name     string
+		hostname string
+		want     bool
+	}{
+		{name: "Record matches", hostname: "go.acme.com", want: true},
+		{name: "Record has another token", hostname: "wrong.com", want: false},
+		{name: "Record missing", hostname: "missing.com", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := v.Verify(context.Background(), MethodDNS, tt.hostname, "token123")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, ok)
+		})
+	}
+}
+
+func TestVerifier_HTTP(t *testing.T) {
+	body := "token123\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != WellKnownPath || r.Host != "go.acme.com" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	// Every hostname is dialed at the test server, the Host header is kept
+	client := server.Client()
+	client.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+		},
+	}
+	v := NewVerifier(stubResolver{}, config.URLPolicyConfig{})
+	v.client = client
+
+	ok, err := v.Verify(context.Background(), MethodHTTP, "go.acme.com", "token123")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = v.Verify(context.Background(), MethodHTTP, "go.acme.com", "other")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = v.Verify(context.Background(), MethodHTTP, "other.com", "token123")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestVerifier_HTTPPrivateNetworkBlocked(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		_, _ = w.Write([]byte("token123"))
+	}))
+	defer server.Close()
+
+	v := NewVerifier(stubResolver{}, config.URLPolicyConfig{BlockPrivateNetworks: true})
+
+	ok, err := v.Verify(context.Background(), MethodHTTP, server.Listener.Addr().String(), "token123")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.False(t, requested)
+}
@@ -0,0 +1,23 @@
+package linkalias
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateAliasRequest adds a short code that leads to an existing link.
+type CreateAliasRequest struct {
+	ShortCode string `json:"short_code" validate:"required,slug"`
+	// OverrideReserved lets admins assign a code reserved by an admin
+	OverrideReserved bool `json:"override_reserved,omitempty"`
+}
+
+type AliasResponse struct {
+	ID        uuid.UUID `json:"id"`
+	ShortCode string    `json:"short_code"`
+	ShortURL  string    `json:"short_url"`
+	// Clicks counts the visits made through this alias, bots excluded
+	Clicks    int64     `json:"clicks"`
+	CreatedAt time.Time `json:"created_at"`
+}
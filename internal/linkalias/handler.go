@@ -0,0 +1,190 @@
+package linkalias
+
+import (
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/logger"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	svr       IService
+	log       *logger.Logger
+	validator *validator.Validate
+}
+
+func NewHandler(service IService, log *logger.Logger, validator *validator.Validate) *Handler {
+	return &Handler{
+		svr:       service,
+		log:       log,
+		validator: validator,
+	}
+}
+
+// ListAliases lists the aliases of a short link
+// @Godoc ListAliases
+// @Summary List the aliases of a short link
+// @Description Retrieve the extra short codes of a link with the clicks made through each
+// @Tags Link Aliases
+// @Accept json
+// @Produce json
+// @Param id path string true "Short link ID"
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.AliasResponse} "Link aliases retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid link ID"
+// @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 404 {object} dto.ErrorResponse "Short link not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/links/{id}/aliases [get]
+// @Security ApiKeyAuth
+func (h *Handler) ListAliases(c *fiber.Ctx) error {
+	userID, linkID, ok := parseIDs(c)
+	if !ok {
+		return nil
+	}
+
+	resp, err := h.svr.ListAliases(c.Context(), userID, linkID)
+	if err != nil {
+		return h.serviceError(c, err, "Failed to retrieve link aliases")
+	}
+
+	return c.JSON(commons.SuccessResponse{
+		Message: "Link aliases retrieved successfully",
+		Data:    resp,
+	})
+}
+
+// CreateAlias adds an alias to a short link
+// @Godoc CreateAlias
+// @Summary Add an alias to a short link
+// @Description Add another short code that redirects to the link, on the link's domain
+// @Tags Link Aliases
+// @Accept json
+// @Produce json
+// @Param id path string true "Short link ID"
+// @Param request body dto.CreateAliasRequest true "Alias Request"
+// @Success 201 {object} dto.SuccessResponse{data=dto.AliasResponse} "Link alias created successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid link ID, request body or short code"
+// @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 404 {object} dto.ErrorResponse "Short link not found"
+// @Failure 409 {object} dto.ErrorResponse "Short code already exists or is reserved"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/links/{id}/aliases [post]
+// @Security ApiKeyAuth
+func (h *Handler) CreateAlias(c *fiber.Ctx) error {
+	userID, linkID, ok := parseIDs(c)
+	if !ok {
+		return nil
+	}
+
+	var req CreateAliasRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid request body"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+			Message: "Validation failed",
+			Error:   commons.FormatValidationErrors(err),
+		})
+	}
+
+	// Only admins may take codes from the managed reserved list
+	if req.OverrideReserved && fmt.Sprintf("%v", c.Locals("role")) != string(datastore.UserRoleAdmin) {
+		return c.Status(fiber.StatusForbidden).JSON(commons.ErrorResponse{
+			Error: "Only admins can use reserved short codes",
+		})
+	}
+
+	resp, err := h.svr.CreateAlias(c.Context(), userID, linkID, req)
+	if err != nil {
+		return h.serviceError(c, err, "Failed to create link alias")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(commons.SuccessResponse{
+		Message: "Link alias created successfully",
+		Data:    resp,
+	})
+}
+
+// DeleteAlias removes an alias from a short link
+// @Godoc DeleteAlias
+// @Summary Delete an alias of a short link
+// @Description Delete an alias; its short code stops redirecting and can be used again
+// @Tags Link Aliases
+// @Accept json
+// @Produce json
+// @Param id path string true "Short link ID"
+// @Param aliasId path string true "Alias ID"
+// @Success 204 "Link alias deleted successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid ID"
+// @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 404 {object} dto.ErrorResponse "Short link or alias not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/links/{id}/aliases/{aliasId} [delete]
+// @Security ApiKeyAuth
+func (h *Handler) DeleteAlias(c *fiber.Ctx) error {
+	userID, linkID, ok := parseIDs(c)
+	if !ok {
+		return nil
+	}
+
+	aliasID, err := uuid.Parse(c.Params("aliasId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid alias ID"})
+	}
+
+	if err := h.svr.DeleteAlias(c.Context(), userID, linkID, aliasID); err != nil {
+		return h.serviceError(c, err, "Failed to delete link alias")
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// parseIDs reads the authenticated user and the link ID from the request. When it
+// returns false the error response has already been written.
+func parseIDs(c *fiber.Ctx) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		_ = c.Status(fiber.StatusUnauthorized).JSON(commons.ErrorResponse{Error: "Unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	linkUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid link ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userUUID, linkUUID, true
+}
+
+func (h *Handler) serviceError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, commons.ErrLinkNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(commons.ErrorResponse{Error: "Short link not found"})
+	case errors.Is(err, commons.ErrAliasNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(commons.ErrorResponse{Error: "Link alias not found"})
+	case errors.Is(err, commons.ErrUnauthorized):
+		return c.Status(fiber.StatusForbidden).JSON(commons.ErrorResponse{Error: "You are not authorized to access this link"})
+	case errors.Is(err, commons.ErrInvalidShortCode):
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Short code must be 3 to 100 letters, digits, emoji, hyphens or underscores, optionally separated by slashes"})
+	case errors.Is(err, commons.ErrTooManyAliases):
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "A link can have at most 20 aliases"})
+	case errors.Is(err, commons.ErrShortCodeExists):
+		return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{Error: "Short code already exists on this domain"})
+	case errors.Is(err, commons.ErrShortCodeReserved):
+		return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{Error: "Short code is reserved"})
+	default:
+		h.log.Error(message, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{Error: message})
+	}
+}
@@ -0,0 +1,215 @@
+package linkalias
+
+import (
+	"GoShort/config"
+	"GoShort/internal/cache"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/internal/reservedcode"
+	"GoShort/pkg/logger"
+	"GoShort/pkg/slug"
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxAliasesPerLink bounds the codes a single link can take from its domain.
+const maxAliasesPerLink = 20
+
+type IService interface {
+	ListAliases(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) ([]AliasResponse, error)
+	CreateAlias(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, req CreateAliasRequest) (*AliasResponse, error)
+	DeleteAlias(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, aliasID uuid.UUID) error
+}
+
+type Service struct {
+	repo     datastore.Querier
+	cache    cache.ILinkCache
+	reserved reservedcode.IService
+	codeCfg  config.ShortCodeConfig
+	// baseURL prefixes the short URLs of links on the default domain
+	baseURL string
+	log     *logger.Logger
+}
+
+func NewService(repo datastore.Querier, linkCache cache.ILinkCache, reserved reservedcode.IService, codeCfg config.ShortCodeConfig, cfg config.ServerConfig, log *logger.Logger) IService {
+	return &Service{
+		repo:     repo,
+		cache:    linkCache,
+		reserved: reserved,
+		codeCfg:  codeCfg,
+		baseURL:  strings.TrimSuffix(cfg.BaseURL, "/"),
+		log:      log,
+	}
+}
+
+// ownedLink returns the link if it exists and belongs to the user.
+func (s *Service) ownedLink(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) (datastore.ShortLink, error) {
+	link, err := s.repo.GetShortLink(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datastore.ShortLink{}, commons.ErrLinkNotFound
+		}
+		s.log.Error("failed to get short link", "link_id", linkID, "error", err)
+		return datastore.ShortLink{}, err
+	}
+
+	if link.UserID != userID {
+		s.log.Warn("unauthorized link alias access", "user_id", userID, "link_id", linkID)
+		return datastore.ShortLink{}, commons.ErrUnauthorized
+	}
+
+	return link, nil
+}
+
+// ListAliases returns the aliases of a link, oldest first, with their click counts.
+func (s *Service) ListAliases(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) ([]AliasResponse, error) {
+	link, err := s.ownedLink(ctx, userID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases, err := s.repo.ListLinkAliases(ctx, linkID)
+	if err != nil {
+		s.log.Error("failed to list link aliases", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	prefix, err := s.shortURLPrefix(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]AliasResponse, len(aliases))
+	for i, alias := range aliases {
+		response[i] = AliasResponse{
+			ID:        alias.ID,
+			ShortCode: alias.ShortCode,
+			ShortURL:  shortURL(prefix, alias.ShortCode),
+			Clicks:    alias.Clicks,
+			CreatedAt: alias.CreatedAt.Time,
+		}
+	}
+	return response, nil
+}
+
+// CreateAlias adds a code on the link's domain. The code follows the same slug and
+// reserved code rules as the link's own code and must be free on the domain.
+func (s *Service) CreateAlias(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, req CreateAliasRequest) (*AliasResponse, error) {
+	link, err := s.ownedLink(ctx, userID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	code := slug.Normalize(req.ShortCode, s.codeCfg.CaseInsensitive)
+	if !slug.Valid(code) {
+		return nil, commons.ErrInvalidShortCode
+	}
+	if err := s.reserved.CheckCode(ctx, code, req.OverrideReserved); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ListLinkAliases(ctx, linkID)
+	if err != nil {
+		s.log.Error("failed to list link aliases", "link_id", linkID, "error", err)
+		return nil, err
+	}
+	if len(existing) >= maxAliasesPerLink {
+		return nil, commons.ErrTooManyAliases
+	}
+
+	exists, err := s.repo.CheckShortCodeExists(ctx, datastore.CheckShortCodeExistsParams{
+		ShortCode: code,
+		DomainID:  link.DomainID,
+	})
+	if err != nil {
+		s.log.Error("failed to check if short code exists", "short_code", code, "error", err)
+		return nil, err
+	}
+	if exists {
+		return nil, commons.ErrShortCodeExists
+	}
+
+	alias, err := s.repo.CreateLinkAlias(ctx, datastore.CreateLinkAliasParams{
+		ID:        uuid.New(),
+		LinkID:    linkID,
+		DomainID:  link.DomainID,
+		ShortCode: code,
+	})
+	if err != nil {
+		if commons.IsUniqueViolation(err) {
+			return nil, commons.ErrShortCodeExists
+		}
+		s.log.Error("failed to create link alias", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	// The code may be cached as unknown
+	_ = s.cache.Invalidate(ctx, cache.LinkKey(alias.DomainID, alias.ShortCode))
+
+	prefix, err := s.shortURLPrefix(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	return &AliasResponse{
+		ID:        alias.ID,
+		ShortCode: alias.ShortCode,
+		ShortURL:  shortURL(prefix, alias.ShortCode),
+		CreatedAt: alias.CreatedAt.Time,
+	}, nil
+}
+
+// DeleteAlias removes an alias; its code stops redirecting and becomes free again.
+func (s *Service) DeleteAlias(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, aliasID uuid.UUID) error {
+	if _, err := s.ownedLink(ctx, userID, linkID); err != nil {
+		return err
+	}
+
+	aliases, err := s.repo.ListLinkAliases(ctx, linkID)
+	if err != nil {
+		s.log.Error("failed to list link aliases", "link_id", linkID, "error", err)
+		return err
+	}
+
+	for _, alias := range aliases {
+		if alias.ID != aliasID {
+			continue
+		}
+
+		deleted, err := s.repo.DeleteLinkAlias(ctx, datastore.DeleteLinkAliasParams{ID: aliasID, LinkID: linkID})
+		if err != nil {
+			s.log.Error("failed to delete link alias", "alias_id", aliasID, "error", err)
+			return err
+		}
+		if deleted == 0 {
+			return commons.ErrAliasNotFound
+		}
+
+		_ = s.cache.Invalidate(ctx, cache.LinkKey(alias.DomainID, alias.ShortCode))
+		return nil
+	}
+
+	return commons.ErrAliasNotFound
+}
+
+// shortURLPrefix is the scheme and host the codes of a link are served on.
+func (s *Service) shortURLPrefix(ctx context.Context, link datastore.ShortLink) (string, error) {
+	if !link.DomainID.Valid {
+		return s.baseURL, nil
+	}
+
+	domain, err := s.repo.GetDomain(ctx, link.DomainID.Bytes)
+	if err != nil {
+		s.log.Error("failed to get link domain", "link_id", link.ID, "error", err)
+		return "", err
+	}
+	return "https://" + domain.Hostname, nil
+}
+
+func shortURL(prefix, code string) string {
+	return prefix + (&url.URL{Path: "/" + code}).EscapedPath()
+}
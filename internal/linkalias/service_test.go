@@ -0,0 +1,125 @@
+package linkalias
+
+import (
+	"GoShort/config"
+	"GoShort/internal/cache"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/internal/reservedcode"
+	"GoShort/internal/testutil"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAliasRepo keeps one link, the codes taken on the default domain and the aliases
+// of the link in memory.
+type fakeAliasRepo struct {
+	testutil.LinkRepo
+
+	taken   map[string]bool
+	aliases []datastore.ListLinkAliasesRow
+}
+
+func (f *fakeAliasRepo) IsReservedCode(ctx context.Context, code string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeAliasRepo) CheckShortCodeExists(ctx context.Context, arg datastore.CheckShortCodeExistsParams) (bool, error) {
+	if f.taken[arg.ShortCode] {
+		return true, nil
+	}
+	for _, alias := range f.aliases {
+		if alias.ShortCode == arg.ShortCode {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeAliasRepo) ListLinkAliases(ctx context.Context, linkID uuid.UUID) ([]datastore.ListLinkAliasesRow, error) {
+	return f.aliases, nil
+}
+
+func (f *fakeAliasRepo) CreateLinkAlias(ctx context.Context, arg datastore.CreateLinkAliasParams) (datastore.LinkAlias, error) {
+	f.aliases = append(f.aliases, datastore.ListLinkAliasesRow{ID: arg.ID, LinkID: arg.LinkID, DomainID: arg.DomainID, ShortCode: arg.ShortCode})
+	return datastore.LinkAlias{ID: arg.ID, LinkID: arg.LinkID, DomainID: arg.DomainID, ShortCode: arg.ShortCode}, nil
+}
+
+func (f *fakeAliasRepo) DeleteLinkAlias(ctx context.Context, arg datastore.DeleteLinkAliasParams) (int64, error) {
+	for i, alias := range f.aliases {
+		if alias.ID == arg.ID && alias.LinkID == arg.LinkID {
+			f.aliases = append(f.aliases[:i], f.aliases[i+1:]...)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func newTestService(repo *fakeAliasRepo) IService {
+	linkCache := cache.NewLinkCache(nil, config.LinkCacheConfig{Enabled: false}, testutil.NewLogger())
+	reserved := reservedcode.NewService(repo, testutil.NewLogger())
+	return NewService(repo, linkCache, reserved, config.ShortCodeConfig{}, config.ServerConfig{BaseURL: "https://go.sh/"}, testutil.NewLogger())
+}
+
+func TestService_CreateAlias(t *testing.T) {
+	owner := uuid.New()
+	repo := &fakeAliasRepo{
+		LinkRepo: testutil.LinkRepo{Link: datastore.ShortLink{ID: uuid.New(), UserID: owner, ShortCode: "launch"}},
+		taken:    map[string]bool{"launch": true, "pricing": true},
+	}
+	svc := newTestService(repo)
+	ctx := context.Background()
+
+	alias, err := svc.CreateAlias(ctx, owner, repo.Link.ID, CreateAliasRequest{ShortCode: "/spring/launch/"})
+	require.NoError(t, err)
+	require.Equal(t, "spring/launch", alias.ShortCode)
+	require.Equal(t, "https://go.sh/spring/launch", alias.ShortURL)
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		code    string
+		wantErr error
+	}{
+		{name: "Code of another link", userID: owner, code: "pricing", wantErr: commons.ErrShortCodeExists},
+		{name: "Code of the link itself", userID: owner, code: "launch", wantErr: commons.ErrShortCodeExists},
+		{name: "Existing alias", userID: owner, code: "spring/launch", wantErr: commons.ErrShortCodeExists},
+		{name: "Invalid code", userID: owner, code: "a b", wantErr: commons.ErrInvalidShortCode},
+		{name: "Route name", userID: owner, code: "api", wantErr: commons.ErrShortCodeReserved},
+		{name: "Someone else's link", userID: uuid.New(), code: "free-code", wantErr: commons.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateAlias(ctx, tt.userID, repo.Link.ID, CreateAliasRequest{ShortCode: tt.code})
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	for len(repo.aliases) < maxAliasesPerLink {
+		repo.aliases = append(repo.aliases, datastore.ListLinkAliasesRow{ID: uuid.New(), ShortCode: uuid.NewString()})
+	}
+	_, err = svc.CreateAlias(ctx, owner, repo.Link.ID, CreateAliasRequest{ShortCode: "one-too-many"})
+	require.ErrorIs(t, err, commons.ErrTooManyAliases)
+}
+
+func TestService_DeleteAlias(t *testing.T) {
+	owner := uuid.New()
+	repo := &fakeAliasRepo{LinkRepo: testutil.LinkRepo{Link: datastore.ShortLink{ID: uuid.New(), UserID: owner, ShortCode: "launch"}}}
+	svc := newTestService(repo)
+	ctx := context.Background()
+
+	alias, err := svc.CreateAlias(ctx, owner, repo.Link.ID, CreateAliasRequest{ShortCode: "old-launch"})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, svc.DeleteAlias(ctx, uuid.New(), repo.Link.ID, alias.ID), commons.ErrUnauthorized)
+	require.NoError(t, svc.DeleteAlias(ctx, owner, repo.Link.ID, alias.ID))
+	require.ErrorIs(t, svc.DeleteAlias(ctx, owner, repo.Link.ID, alias.ID), commons.ErrAliasNotFound)
+
+	aliases, err := svc.ListAliases(ctx, owner, repo.Link.ID)
+	require.NoError(t, err)
+	require.Empty(t, aliases)
+}
@@ -0,0 +1,50 @@
+package linkhealth
+
+import (
+	"GoShort/internal/datastore"
+	"time"
+)
+
+// States of a link destination.
+const (
+	StateHealthy = "healthy"
+	// StateFailing is a destination that failed its latest checks, but fewer times in a
+	// row than the threshold
+	StateFailing = "failing"
+	StateBroken  = "broken"
+)
+
+// Status is the result of the latest check of a link destination.
+type Status struct {
+	State string `json:"state"`
+	// StatusCode is empty when the destination did not respond
+	StatusCode          *int32    `json:"status_code,omitempty"`
+	LatencyMs           *int32    `json:"latency_ms,omitempty"`
+	Error               *string   `json:"error,omitempty"`
+	ConsecutiveFailures int32     `json:"consecutive_failures"`
+	CheckedAt           time.Time `json:"checked_at"`
+}
+
+// NewStatus converts the health of a link to the status of its current destination. It
+// returns nil when that destination has not been checked yet.
+func NewStatus(health datastore.LinkHealth, destination string) *Status {
+	if health.DestinationUrl != destination || !health.CheckedAt.Valid {
+		return nil
+	}
+
+	status := &Status{
+		State:               StateHealthy,
+		StatusCode:          health.StatusCode,
+		LatencyMs:           health.LatencyMs,
+		Error:               health.Error,
+		ConsecutiveFailures: health.ConsecutiveFailures,
+		CheckedAt:           health.CheckedAt.Time,
+	}
+	switch {
+	case health.IsBroken:
+		status.State = StateBroken
+	case health.ConsecutiveFailures > 0:
+		status.State = StateFailing
+	}
+	return status
+}
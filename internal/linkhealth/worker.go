@@ -0,0 +1,370 @@
+package linkhealth
+
+import (
+	"GoShort/config"
+	"GoShort/internal/datastore"
+	"GoShort/internal/urlpolicy"
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxRedirects is how many redirects a check follows before judging the response
+const maxRedirects = 10
+
+type IWorker interface {
+	// CheckDue checks the destinations that are due and reports how many it checked
+	CheckDue(ctx context.Context, now time.Time) (int, error)
+	Close(ctx context.Context) error
+}
+
+// Worker periodically requests link destinations and records whether they still
+// respond, so owners learn about dead pages before their visitors do.
+type Worker struct {
+	repo   datastore.Querier
+	client *http.Client
+	cfg    config.LinkHealthConfig
+	log    *logger.Logger
+
+	// throttled holds the hosts that asked to slow down, until when
+	mu        sync.Mutex
+	throttled map[string]hostBackoff
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+type hostBackoff struct {
+	until time.Time
+	delay time.Duration
+}
+
+// result is the outcome of a single check.
+type result struct {
+	statusCode int
+	latency    time.Duration
+	err        error
+	// retryAfter is set when the host answered 429 Too Many Requests
+	retryAfter time.Duration
+}
+
+func (r result) ok() bool {
+	return r.err == nil && r.statusCode < 400
+}
+
+// NewWorker creates the worker and starts checking due destinations every interval.
+// Requests follow the URL policy, so they never reach a private network when it is
+// blocked.
+func NewWorker(repo datastore.Querier, cfg config.LinkHealthConfig, policyCfg config.URLPolicyConfig, log *logger.Logger) *Worker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 10
+	}
+	if cfg.PerHostConcurrency <= 0 {
+		cfg.PerHostConcurrency = 2
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.RecheckInterval <= 0 {
+		cfg.RecheckInterval = 24 * time.Hour
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = 15 * time.Minute
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 3
+	}
+
+	w := &Worker{
+		repo:      repo,
+		client:    urlpolicy.NewClient(policyCfg, cfg.Timeout, maxRedirects),
+		cfg:       cfg,
+		log:       log,
+		throttled: make(map[string]hostBackoff),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w
+}
+
+func (w *Worker) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Interval)
+			if _, err := w.CheckDue(ctx, time.Now()); err != nil {
+				w.log.Error("failed to check link destinations", "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// CheckDue checks the destinations that are due at now and records the results. Links
+// on a host that asked to slow down are postponed instead.
+func (w *Worker) CheckDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := w.repo.ListDueLinkHealthChecks(ctx, datastore.ListDueLinkHealthChecksParams{
+		Now:   pgtype.Timestamp{Time: now.UTC(), Valid: true},
+		Limit: int32(w.cfg.BatchSize),
+	})
+	if err != nil {
+		return 0, err
+	}
+	w.pruneThrottled(now)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		checked int
+		errs    []error
+	)
+	slots := make(chan struct{}, w.cfg.Concurrency)
+	hostSlots := make(map[string]chan struct{})
+
+	for _, link := range due {
+		host := destinationHost(link.OriginalUrl)
+		hostSlot, ok := hostSlots[host]
+		if !ok {
+			hostSlot = make(chan struct{}, w.cfg.PerHostConcurrency)
+			hostSlots[host] = hostSlot
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hostSlot <- struct{}{}
+			defer func() { <-hostSlot }()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			err := w.checkLink(ctx, link, host, now)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, errThrottled):
+			case err != nil:
+				errs = append(errs, err)
+			default:
+				checked++
+			}
+		}()
+	}
+	wg.Wait()
+
+	return checked, errors.Join(errs...)
+}
+
+// errThrottled marks a check postponed because its host asked to slow down.
+var errThrottled = errors.New("host is throttled")
+
+// checkLink checks one destination and records the result, or postpones the check when
+// its host asked to slow down.
+func (w *Worker) checkLink(ctx context.Context, link datastore.ListDueLinkHealthChecksRow, host string, now time.Time) error {
+	if until, ok := w.throttledUntil(host); ok {
+		return w.postpone(ctx, link, until)
+	}
+
+	res := w.probe(ctx, link.OriginalUrl)
+	if res.retryAfter > 0 {
+		return w.postpone(ctx, link, w.throttle(host, res.retryAfter))
+	}
+	return w.record(ctx, link, res, now)
+}
+
+// probe requests the destination with HEAD, or with GET when the server does not
+// support HEAD, and follows its redirects.
+func (w *Worker) probe(ctx context.Context, destination string) result {
+	start := time.Now()
+	resp, err := w.request(ctx, http.MethodHead, destination)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		start = time.Now()
+		resp, err = w.request(ctx, http.MethodGet, destination)
+	}
+	latency := time.Since(start)
+	if err != nil {
+		return result{latency: latency, err: err}
+	}
+	resp.Body.Close()
+
+	res := result{statusCode: resp.StatusCode, latency: latency}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		res.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if res.retryAfter <= 0 {
+			res.retryAfter = w.cfg.RetryInterval
+		}
+	}
+	return res
+}
+
+func (w *Worker) request(ctx context.Context, method, destination string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, destination, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", w.cfg.UserAgent)
+	return w.client.Do(req)
+}
+
+// record stores the result of a check. A working destination is checked again after the
+// recheck interval; a failing one sooner, backing off with each failure, and it is
+// flagged as broken once it failed often enough in a row.
+func (w *Worker) record(ctx context.Context, link datastore.ListDueLinkHealthChecksRow, res result, now time.Time) error {
+	params := datastore.UpsertLinkHealthParams{
+		LinkID:         link.LinkID,
+		DestinationUrl: link.OriginalUrl,
+		CheckedAt:      pgtype.Timestamp{Time: now.UTC(), Valid: true},
+		NextCheckAt:    pgtype.Timestamp{Time: now.Add(w.cfg.RecheckInterval).UTC(), Valid: true},
+	}
+	if res.err != nil {
+		message := urlpolicy.ErrorMessage(res.err)
+		params.Error = &message
+	} else {
+		statusCode := int32(res.statusCode)
+		latency := int32(res.latency.Milliseconds())
+		params.StatusCode = &statusCode
+		params.LatencyMs = &latency
+	}
+
+	if !res.ok() {
+		params.ConsecutiveFailures = link.ConsecutiveFailures + 1
+		params.IsBroken = params.ConsecutiveFailures >= int32(w.cfg.FailureThreshold)
+		params.NextCheckAt.Time = now.Add(w.retryDelay(params.ConsecutiveFailures)).UTC()
+	}
+
+	if err := w.repo.UpsertLinkHealth(ctx, params); err != nil {
+		return err
+	}
+
+	switch {
+	case params.ConsecutiveFailures == int32(w.cfg.FailureThreshold):
+		w.log.Warn("link destination is broken", "link_id", link.LinkID, "destination_url", link.OriginalUrl, "status_code", res.statusCode, "error", res.err)
+	case res.ok() && link.ConsecutiveFailures >= int32(w.cfg.FailureThreshold):
+		w.log.Info("link destination recovered", "link_id", link.LinkID, "destination_url", link.OriginalUrl)
+	}
+	return nil
+}
+
+// postpone moves the check of a link to when its host accepts requests again.
+func (w *Worker) postpone(ctx context.Context, link datastore.ListDueLinkHealthChecksRow, until time.Time) error {
+	err := w.repo.DeferLinkHealthCheck(ctx, datastore.DeferLinkHealthCheckParams{
+		LinkID:         link.LinkID,
+		DestinationUrl: link.OriginalUrl,
+		NextCheckAt:    pgtype.Timestamp{Time: until.UTC(), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	return errThrottled
+}
+
+// retryDelay is how long to wait before checking a destination again after failures
+// failed checks in a row: the retry interval doubled with each failure, at most the
+// recheck interval.
+func (w *Worker) retryDelay(failures int32) time.Duration {
+	delay := w.cfg.RetryInterval
+	for i := int32(1); i < failures && delay < w.cfg.RecheckInterval; i++ {
+		delay *= 2
+	}
+	return min(delay, w.cfg.RecheckInterval)
+}
+
+// throttle keeps requests away from a host for at least delay, longer when it keeps
+// asking, and returns until when.
+func (w *Worker) throttle(host string, delay time.Duration) time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	backoff := w.throttled[host]
+	if backoff.delay > 0 {
+		delay = max(delay, min(backoff.delay*2, w.cfg.RecheckInterval))
+	}
+	backoff = hostBackoff{until: time.Now().Add(delay), delay: delay}
+	w.throttled[host] = backoff
+
+	w.log.Info("destination host asked to slow down", "host", host, "until", backoff.until)
+	return backoff.until
+}
+
+func (w *Worker) throttledUntil(host string) (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	backoff, ok := w.throttled[host]
+	if !ok || !time.Now().Before(backoff.until) {
+		return time.Time{}, false
+	}
+	return backoff.until, true
+}
+
+// pruneThrottled forgets the hosts whose backoff ended a while ago, so one that asks
+// again starts over.
+func (w *Worker) pruneThrottled(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for host, backoff := range w.throttled {
+		if now.Sub(backoff.until) > backoff.delay {
+			delete(w.throttled, host)
+		}
+	}
+}
+
+func destinationHost(destination string) string {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// Close stops the worker and waits for a running batch to finish.
+func (w *Worker) Close(ctx context.Context) error {
+	w.once.Do(func() { close(w.stop) })
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
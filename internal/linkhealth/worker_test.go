@@ -0,0 +1,225 @@
+package linkhealth
+
+import (
+	"GoShort/config"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/logger"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHealthRepo keeps links and their health in memory and picks due links like the
+// ListDueLinkHealthChecks query.
+type fakeHealthRepo struct {
+	datastore.Querier
+
+	mu     sync.Mutex
+	links  []datastore.ShortLink
+	health map[uuid.UUID]datastore.LinkHealth
+}
+
+func newFakeHealthRepo(destinations ...string) *fakeHealthRepo {
+	repo := &fakeHealthRepo{health: make(map[uuid.UUID]datastore.LinkHealth)}
+	for _, destination := range destinations {
+		repo.links = append(repo.links, datastore.ShortLink{ID: uuid.New(), OriginalUrl: destination, IsActive: true})
+	}
+	return repo
+}
+
+func (f *fakeHealthRepo) ListDueLinkHealthChecks(ctx context.Context, arg datastore.ListDueLinkHealthChecksParams) ([]datastore.ListDueLinkHealthChecksRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var due []datastore.ListDueLinkHealthChecksRow
+	for _, link := range f.links {
+		health, checked := f.health[link.ID]
+		current := checked && health.DestinationUrl == link.OriginalUrl
+		if current && health.NextCheckAt.Time.After(arg.Now.Time) {
+			continue
+		}
+		row := datastore.ListDueLinkHealthChecksRow{LinkID: link.ID, OriginalUrl: link.OriginalUrl}
+		if current {
+			row.ConsecutiveFailures = health.ConsecutiveFailures
+		}
+		if len(due) < int(arg.Limit) {
+			due = append(due, row)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeHealthRepo) UpsertLinkHealth(ctx context.Context, arg datastore.UpsertLinkHealthParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.health[arg.LinkID] = datastore.LinkHealth(arg)
+	return nil
+}
+
+func (f *fakeHealthRepo) DeferLinkHealthCheck(ctx context.Context, arg datastore.DeferLinkHealthCheckParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	health, ok := f.health[arg.LinkID]
+	switch {
+	case !ok:
+		f.health[arg.LinkID] = datastore.LinkHealth{LinkID: arg.LinkID, DestinationUrl: arg.DestinationUrl, NextCheckAt: arg.NextCheckAt}
+	case health.DestinationUrl == arg.DestinationUrl:
+		health.NextCheckAt = arg.NextCheckAt
+		f.health[arg.LinkID] = health
+	}
+	return nil
+}
+
+func (f *fakeHealthRepo) status(i int) *Status {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return NewStatus(f.health[f.links[i].ID], f.links[i].OriginalUrl)
+}
+
+func newTestWorker(t *testing.T, repo datastore.Querier, cfg config.LinkHealthConfig) *Worker {
+	t.Helper()
+	cfg.Interval = time.Hour
+	log := logger.New(&config.AppConfig{
+		Logger: config.LoggerConfig{Output: io.Discard, Level: "info"},
+	})
+	// The test server listens on the loopback address
+	w := NewWorker(repo, cfg, config.URLPolicyConfig{AllowedSchemes: []string{"http", "https"}}, log)
+	t.Cleanup(func() { require.NoError(t, w.Close(context.Background())) })
+	return w
+}
+
+func TestWorker_CheckDue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	repo := newFakeHealthRepo(server.URL+"/ok", server.URL+"/moved", server.URL+"/get-only", server.URL+"/gone", closed.URL+"/down")
+	w := newTestWorker(t, repo, config.LinkHealthConfig{
+		FailureThreshold: 2,
+		RetryInterval:    time.Minute,
+		RecheckInterval:  time.Hour,
+	})
+	ctx := context.Background()
+	now := time.Now()
+
+	checked, err := w.CheckDue(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 5, checked)
+
+	for i := 0; i < 3; i++ {
+		status := repo.status(i)
+		require.Equal(t, StateHealthy, status.State, repo.links[i].OriginalUrl)
+		require.Equal(t, int32(http.StatusOK), *status.StatusCode)
+		require.NotNil(t, status.LatencyMs)
+	}
+	gone := repo.status(3)
+	require.Equal(t, StateFailing, gone.State)
+	require.Equal(t, int32(http.StatusNotFound), *gone.StatusCode)
+	down := repo.status(4)
+	require.Equal(t, StateFailing, down.State)
+	require.Nil(t, down.StatusCode)
+	require.NotEmpty(t, *down.Error)
+
+	// Nothing is due before the failing links are retried
+	checked, err = w.CheckDue(ctx, now.Add(30*time.Second))
+	require.NoError(t, err)
+	require.Zero(t, checked)
+
+	checked, err = w.CheckDue(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 2, checked)
+	require.Equal(t, StateBroken, repo.status(3).State)
+	require.Equal(t, int32(2), repo.status(3).ConsecutiveFailures)
+	require.Equal(t, StateBroken, repo.status(4).State)
+
+	// The second retry waits twice as long
+	require.WithinDuration(t, now.Add(3*time.Minute), repo.health[repo.links[3].ID].NextCheckAt.Time, time.Second)
+
+	// A new destination starts over
+	repo.links[3].OriginalUrl = server.URL + "/ok"
+	require.Nil(t, repo.status(3))
+	checked, err = w.CheckDue(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, checked)
+	require.Equal(t, StateHealthy, repo.status(3).State)
+	require.Zero(t, repo.status(3).ConsecutiveFailures)
+}
+
+func TestWorker_CheckDue_PerHostConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo := newFakeHealthRepo()
+	for i := 0; i < 8; i++ {
+		repo.links = append(repo.links, datastore.ShortLink{ID: uuid.New(), OriginalUrl: server.URL + "/page"})
+	}
+	w := newTestWorker(t, repo, config.LinkHealthConfig{Concurrency: 8, PerHostConcurrency: 2})
+
+	checked, err := w.CheckDue(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 8, checked)
+	require.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestWorker_CheckDue_Throttled(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	repo := newFakeHealthRepo(server.URL+"/a", server.URL+"/b", server.URL+"/c")
+	w := newTestWorker(t, repo, config.LinkHealthConfig{PerHostConcurrency: 1})
+	now := time.Now()
+
+	checked, err := w.CheckDue(context.Background(), now)
+	require.NoError(t, err)
+	require.Zero(t, checked)
+	require.Equal(t, int32(1), requests.Load(), "the host is left alone once it asked to slow down")
+
+	for i := range repo.links {
+		require.Nil(t, repo.status(i), "a throttled check is not a failure")
+		require.WithinDuration(t, now.Add(2*time.Minute), repo.health[repo.links[i].ID].NextCheckAt.Time, 5*time.Second)
+	}
+}
@@ -0,0 +1,37 @@
+package linkmeta
+
+import (
+	"GoShort/internal/datastore"
+	"time"
+)
+
+// Metadata is what was read from the page a link points to.
+type Metadata struct {
+	Title         *string `json:"title,omitempty"`
+	OGTitle       *string `json:"og_title,omitempty"`
+	OGDescription *string `json:"og_description,omitempty"`
+	OGImage       *string `json:"og_image,omitempty"`
+	FaviconURL    *string `json:"favicon_url,omitempty"`
+	// Error tells why the latest fetch failed; the other fields are kept from the last
+	// successful one
+	Error     *string   `json:"error,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// NewMetadata converts the stored metadata of a link to that of its current destination.
+// It returns nil when that destination has not been fetched yet.
+func NewMetadata(row datastore.LinkMetadata, destination string) *Metadata {
+	if row.DestinationUrl != destination || !row.FetchedAt.Valid {
+		return nil
+	}
+
+	return &Metadata{
+		Title:         row.Title,
+		OGTitle:       row.OgTitle,
+		OGDescription: row.OgDescription,
+		OGImage:       row.OgImage,
+		FaviconURL:    row.FaviconUrl,
+		Error:         row.Error,
+		FetchedAt:     row.FetchedAt.Time,
+	}
+}
@@ -0,0 +1,260 @@
+package linkmeta
+
+import (
+	"GoShort/config"
+	"GoShort/internal/datastore"
+	"GoShort/internal/urlpolicy"
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/net/html/charset"
+)
+
+// maxRedirects is how many redirects a fetch follows to reach the page
+const maxRedirects = 5
+
+// maxTitleLength is the longest title a link can have, as accepted by the link API
+const maxTitleLength = 100
+
+var errNotHTML = errors.New("destination is not an HTML page")
+
+type IFetcher interface {
+	// Enqueue fetches the metadata of a link destination in the background. It never
+	// blocks: the fetch is dropped when the queue is full.
+	Enqueue(linkID uuid.UUID, destination string)
+	// Fetch fetches the metadata of a link destination now and stores it
+	Fetch(ctx context.Context, linkID uuid.UUID, destination string) (*Metadata, error)
+	Close(ctx context.Context) error
+}
+
+// Fetcher reads the title, Open Graph tags and favicon of link destinations, so links
+// created without a title still get a meaningful name.
+type Fetcher struct {
+	repo   datastore.Querier
+	client *http.Client
+	cfg    config.LinkMetadataConfig
+	log    *logger.Logger
+
+	queue chan job
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+type job struct {
+	linkID      uuid.UUID
+	destination string
+}
+
+// NewFetcher creates the fetcher and starts its background workers. Requests follow the
+// URL policy, so they never reach a private network when it is blocked.
+func NewFetcher(repo datastore.Querier, cfg config.LinkMetadataConfig, policyCfg config.URLPolicyConfig, log *logger.Logger) *Fetcher {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 512 * 1024
+	}
+
+	f := &Fetcher{
+		repo:   repo,
+		client: urlpolicy.NewClient(policyCfg, cfg.Timeout, maxRedirects),
+		cfg:    cfg,
+		log:    log,
+		queue:  make(chan job, cfg.QueueSize),
+		stop:   make(chan struct{}),
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		f.wg.Add(1)
+		go f.run()
+	}
+
+	return f
+}
+
+func (f *Fetcher) run() {
+	defer f.wg.Done()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case j := <-f.queue:
+			// Leave time to store the result once the request timed out
+			ctx, cancel := context.WithTimeout(context.Background(), 2*f.cfg.Timeout)
+			if _, err := f.Fetch(ctx, j.linkID, j.destination); err != nil {
+				f.log.Error("failed to store link metadata", "link_id", j.linkID, "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Enqueue queues the destination of a link for a background fetch. Nothing is queued
+// when automatic fetching is disabled.
+func (f *Fetcher) Enqueue(linkID uuid.UUID, destination string) {
+	if !f.cfg.Enabled {
+		return
+	}
+
+	select {
+	case f.queue <- job{linkID: linkID, destination: destination}:
+	default:
+		f.log.Warn("link metadata queue is full, dropping fetch", "link_id", linkID)
+	}
+}
+
+// Fetch reads the page at destination and stores its metadata for the link. A page that
+// cannot be read is not an error: the failure is stored along with what an earlier fetch
+// of the same destination found. The link is named after the page unless its owner gave
+// it a title.
+func (f *Fetcher) Fetch(ctx context.Context, linkID uuid.UUID, destination string) (*Metadata, error) {
+	rows, err := f.repo.ListLinkMetadataByLinkIDs(ctx, []uuid.UUID{linkID})
+	if err != nil {
+		return nil, err
+	}
+	var previous *datastore.LinkMetadata
+	if len(rows) > 0 {
+		previous = &rows[0]
+	}
+
+	params := datastore.UpsertLinkMetadataParams{
+		LinkID:         linkID,
+		DestinationUrl: destination,
+		FetchedAt:      pgtype.Timestamp{Time: time.Now().UTC(), Valid: true},
+	}
+
+	p, fetchErr := f.fetchPage(ctx, destination)
+	if fetchErr != nil {
+		message := urlpolicy.ErrorMessage(fetchErr)
+		params.Error = &message
+		if previous != nil && previous.DestinationUrl == destination {
+			params.Title = previous.Title
+			params.OgTitle = previous.OgTitle
+			params.OgDescription = previous.OgDescription
+			params.OgImage = previous.OgImage
+			params.FaviconUrl = previous.FaviconUrl
+		}
+	} else {
+		params.Title = emptyToNil(p.title)
+		params.OgTitle = emptyToNil(p.ogTitle)
+		params.OgDescription = emptyToNil(p.ogDescription)
+		params.OgImage = emptyToNil(p.ogImage)
+		params.FaviconUrl = emptyToNil(p.favicon)
+	}
+
+	if err := f.repo.UpsertLinkMetadata(ctx, params); err != nil {
+		return nil, err
+	}
+
+	if title := linkTitle(p.name()); fetchErr == nil && title != "" {
+		setTitle := datastore.SetFetchedLinkTitleParams{ID: linkID, Title: &title}
+		if previous != nil {
+			setTitle.PreviousTitle = emptyToNil(linkTitle(previousName(*previous)))
+		}
+		if err := f.repo.SetFetchedLinkTitle(ctx, setTitle); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewMetadata(datastore.LinkMetadata(params), destination), nil
+}
+
+// fetchPage requests the destination and parses the head of the page it leads to, read
+// up to the size limit and decoded from its charset.
+func (f *Fetcher) fetchPage(ctx context.Context, destination string) (page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, destination, nil)
+	if err != nil {
+		return page{}, err
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return page{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return page{}, fmt.Errorf("destination responded with status %d", resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+			return page{}, errNotHTML
+		}
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.cfg.MaxBodyBytes), contentType)
+	if err != nil {
+		return page{}, err
+	}
+	return parsePage(body, resp.Request.URL), nil
+}
+
+// previousName is the title an earlier fetch gave the link.
+func previousName(row datastore.LinkMetadata) string {
+	p := page{}
+	if row.Title != nil {
+		p.title = *row.Title
+	}
+	if row.OgTitle != nil {
+		p.ogTitle = *row.OgTitle
+	}
+	return p.name()
+}
+
+// linkTitle cuts a page title to the length of a link title.
+func linkTitle(title string) string {
+	runes := []rune(title)
+	if len(runes) > maxTitleLength {
+		title = strings.TrimSpace(string(runes[:maxTitleLength]))
+	}
+	return title
+}
+
+func emptyToNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// Close stops the workers and waits for the fetches in progress. Queued fetches are
+// dropped.
+func (f *Fetcher) Close(ctx context.Context) error {
+	f.once.Do(func() { close(f.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,296 @@
+package linkmeta
+
+import (
+	"GoShort/config"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/logger"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMetadataRepo keeps link titles and metadata in memory.
+type fakeMetadataRepo struct {
+	datastore.Querier
+
+	mu       sync.Mutex
+	titles   map[uuid.UUID]*string
+	metadata map[uuid.UUID]datastore.LinkMetadata
+}
+
+func newFakeMetadataRepo() *fakeMetadataRepo {
+	return &fakeMetadataRepo{
+		titles:   make(map[uuid.UUID]*string),
+		metadata: make(map[uuid.UUID]datastore.LinkMetadata),
+	}
+}
+
+func (f *fakeMetadataRepo) ListLinkMetadataByLinkIDs(ctx context.Context, linkIds []uuid.UUID) ([]datastore.LinkMetadata, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []datastore.LinkMetadata{}
+	for _, id := range linkIds {
+		if row, ok := f.metadata[id]; ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeMetadataRepo) UpsertLinkMetadata(ctx context.Context, arg datastore.UpsertLinkMetadataParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.metadata[arg.LinkID] = datastore.LinkMetadata(arg)
+	return nil
+}
+
+func (f *fakeMetadataRepo) SetFetchedLinkTitle(ctx context.Context, arg datastore.SetFetchedLinkTitleParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	title := f.titles[arg.ID]
+	if title == nil || *title == "" || (arg.PreviousTitle != nil && *title == *arg.PreviousTitle) {
+		f.titles[arg.ID] = arg.Title
+	}
+	return nil
+}
+
+func (f *fakeMetadataRepo) title(linkID uuid.UUID) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.titles[linkID] == nil {
+		return ""
+	}
+	return *f.titles[linkID]
+}
+
+func newTestFetcher(t *testing.T, repo datastore.Querier, cfg config.LinkMetadataConfig) *Fetcher {
+	t.Helper()
+	log := logger.New(&config.AppConfig{
+		Logger: config.LoggerConfig{Output: io.Discard, Level: "info"},
+	})
+	// The test server listens on the loopback address
+	f := NewFetcher(repo, cfg, config.URLPolicyConfig{AllowedSchemes: []string{"http", "https"}}, log)
+	t.Cleanup(func() { require.NoError(t, f.Close(context.Background())) })
+	return f
+}
+
+func TestParsePage(t *testing.T) {
+	base, err := url.Parse("https://example.com/blog/post?id=1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		html string
+		want page
+	}{
+		{
+			name: "title and open graph tags",
+			html: `<!DOCTYPE html><html><head>
+				<title>  Hello
+				  &amp; welcome </title>
+				<meta property="og:title" content="Hello, OG">
+				<meta property="og:description" content="A post about things">
+				<meta property="og:image" content="/img/cover.png">
+				<link rel="shortcut icon" href="icons/fav.ico">
+			</head><body></body></html>`,
+			want: page{
+				title:         "Hello & welcome",
+				ogTitle:       "Hello, OG",
+				ogDescription: "A post about things",
+				ogImage:       "https://example.com/img/cover.png",
+				favicon:       "https://example.com/blog/icons/fav.ico",
+			},
+		},
+		{
+			name: "open graph tags set with name",
+			html: `<head><meta name="OG:TITLE" content="Named"><meta name="og:image:url" content="https://cdn.example.com/a.jpg"></head>`,
+			want: page{
+				ogTitle: "Named",
+				ogImage: "https://cdn.example.com/a.jpg",
+				favicon: "https://example.com/favicon.ico",
+			},
+		},
+		{
+			name: "first tag wins",
+			html: `<title>One</title><title>Two</title><meta property="og:title" content="A"><meta property="og:title" content="B"><link rel="icon" href="/a.ico"><link rel="icon" href="/b.ico">`,
+			want: page{
+				title:   "One",
+				ogTitle: "A",
+				favicon: "https://example.com/a.ico",
+			},
+		},
+		{
+			name: "tags in the body are ignored",
+			html: `<head></head><body><title>Body title</title><meta property="og:title" content="Late"></body>`,
+			want: page{favicon: "https://example.com/favicon.ico"},
+		},
+		{
+			name: "only http urls are kept",
+			html: `<meta property="og:image" content="javascript:alert(1)"><link rel="icon" href="data:image/png;base64,AAAA">`,
+			want: page{favicon: "https://example.com/favicon.ico"},
+		},
+		{
+			name: "apple touch icon is not a favicon",
+			html: `<link rel="apple-touch-icon" href="/apple.png">`,
+			want: page{favicon: "https://example.com/favicon.ico"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, parsePage(strings.NewReader(tt.html), base))
+		})
+	}
+}
+
+func TestPage_Name(t *testing.T) {
+	require.Equal(t, "Doc", page{title: "Doc", ogTitle: "OG"}.name())
+	require.Equal(t, "OG", page{ogTitle: "OG"}.name())
+	require.Equal(t, strings.Repeat("é", maxTitleLength), linkTitle(strings.Repeat("é", maxTitleLength+5)))
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/latin1":
+			// "Café" in ISO-8859-1
+			w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+			_, _ = w.Write([]byte("<html><head><title>Caf\xe9</title><meta property=\"og:image\" content=\"/cover.png\"></head></html>"))
+		case "/meta-charset":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html><head><meta charset=\"windows-1252\"><title>Price \x80 5</title></head></html>"))
+		case "/moved":
+			http.Redirect(w, r, "/latin1", http.StatusFound)
+		case "/large":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html><head><!--" + strings.Repeat("x", 4096) + "--><title>Too far</title></head></html>"))
+		case "/file.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.4"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	repo := newFakeMetadataRepo()
+	f := newTestFetcher(t, repo, config.LinkMetadataConfig{MaxBodyBytes: 1024})
+	ctx := context.Background()
+
+	t.Run("charset from the header", func(t *testing.T) {
+		linkID := uuid.New()
+		metadata, err := f.Fetch(ctx, linkID, server.URL+"/latin1")
+		require.NoError(t, err)
+		require.Equal(t, "Café", *metadata.Title)
+		require.Equal(t, server.URL+"/cover.png", *metadata.OGImage)
+		require.Equal(t, server.URL+"/favicon.ico", *metadata.FaviconURL)
+		require.Nil(t, metadata.Error)
+		require.Equal(t, "Café", repo.title(linkID))
+	})
+
+	t.Run("charset from the page", func(t *testing.T) {
+		metadata, err := f.Fetch(ctx, uuid.New(), server.URL+"/meta-charset")
+		require.NoError(t, err)
+		require.Equal(t, "Price € 5", *metadata.Title)
+	})
+
+	t.Run("urls resolve against the final page", func(t *testing.T) {
+		metadata, err := f.Fetch(ctx, uuid.New(), server.URL+"/moved")
+		require.NoError(t, err)
+		require.Equal(t, server.URL+"/cover.png", *metadata.OGImage)
+	})
+
+	t.Run("the page is read up to the size limit", func(t *testing.T) {
+		linkID := uuid.New()
+		metadata, err := f.Fetch(ctx, linkID, server.URL+"/large")
+		require.NoError(t, err)
+		require.Nil(t, metadata.Title)
+		require.Empty(t, repo.title(linkID))
+	})
+
+	t.Run("a page that cannot be read is recorded", func(t *testing.T) {
+		for _, path := range []string{"/missing", "/file.pdf"} {
+			metadata, err := f.Fetch(ctx, uuid.New(), server.URL+path)
+			require.NoError(t, err)
+			require.NotNil(t, metadata.Error, path)
+			require.Nil(t, metadata.Title)
+		}
+	})
+
+	t.Run("owner titles are kept", func(t *testing.T) {
+		linkID := uuid.New()
+		title := "My link"
+		repo.titles[linkID] = &title
+
+		_, err := f.Fetch(ctx, linkID, server.URL+"/latin1")
+		require.NoError(t, err)
+		require.Equal(t, "My link", repo.title(linkID))
+	})
+
+	t.Run("a fetched title is replaced", func(t *testing.T) {
+		linkID := uuid.New()
+		_, err := f.Fetch(ctx, linkID, server.URL+"/meta-charset")
+		require.NoError(t, err)
+		require.Equal(t, "Price € 5", repo.title(linkID))
+
+		_, err = f.Fetch(ctx, linkID, server.URL+"/latin1")
+		require.NoError(t, err)
+		require.Equal(t, "Café", repo.title(linkID))
+	})
+
+	t.Run("a failed refresh keeps what was found before", func(t *testing.T) {
+		linkID := uuid.New()
+		destination := server.URL + "/latin1"
+		_, err := f.Fetch(ctx, linkID, destination)
+		require.NoError(t, err)
+
+		down.Store(true)
+		defer down.Store(false)
+		metadata, err := f.Fetch(ctx, linkID, destination)
+		require.NoError(t, err)
+		require.NotNil(t, metadata.Error)
+		require.Equal(t, "Café", *metadata.Title)
+	})
+}
+
+func TestFetcher_Enqueue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.UserAgent() != "GoShort-Test" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte("<title>Queued</title>"))
+	}))
+	defer server.Close()
+
+	repo := newFakeMetadataRepo()
+	linkID := uuid.New()
+
+	disabled := newTestFetcher(t, repo, config.LinkMetadataConfig{UserAgent: "GoShort-Test"})
+	disabled.Enqueue(linkID, server.URL)
+	require.Empty(t, disabled.queue, "nothing is fetched automatically when disabled")
+
+	f := newTestFetcher(t, repo, config.LinkMetadataConfig{Enabled: true, UserAgent: "GoShort-Test"})
+	f.Enqueue(linkID, server.URL)
+	require.Eventually(t, func() bool { return repo.title(linkID) == "Queued" }, 5*time.Second, 10*time.Millisecond)
+}
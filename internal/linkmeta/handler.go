@@ -0,0 +1,70 @@
+package linkmeta
+
+import (
+	"GoShort/internal/commons"
+	"GoShort/pkg/logger"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	svr IService
+	log *logger.Logger
+}
+
+func NewHandler(service IService, log *logger.Logger) *Handler {
+	return &Handler{
+		svr: service,
+		log: log,
+	}
+}
+
+// RefreshMetadata fetches the title and Open Graph tags of a link destination again
+// @Godoc RefreshMetadata
+// @Summary Refresh the metadata of a short link destination
+// @Description Fetch the page a short link points to again and store its title, Open Graph tags and favicon. The link is renamed after the page unless it was given a title. A page that cannot be read is reported in the error field
+// @Tags Short Links
+// @Accept json
+// @Produce json
+// @Param id path string true "Short link ID"
+// @Success 200 {object} dto.SuccessResponse{data=dto.Metadata} "Link metadata refreshed successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid link ID"
+// @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 404 {object} dto.ErrorResponse "Short link not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/links/{id}/metadata/refresh [post]
+// @Security ApiKeyAuth
+func (h *Handler) RefreshMetadata(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(commons.ErrorResponse{Error: "Unauthorized"})
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid user ID"})
+	}
+	linkUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid link ID"})
+	}
+
+	metadata, err := h.svr.RefreshMetadata(c.Context(), userUUID, linkUUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, commons.ErrLinkNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(commons.ErrorResponse{Error: "Short link not found"})
+		case errors.Is(err, commons.ErrUnauthorized):
+			return c.Status(fiber.StatusForbidden).JSON(commons.ErrorResponse{Error: "You are not authorized to access this link"})
+		default:
+			h.log.Error("failed to refresh link metadata", "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{Error: "Failed to refresh link metadata"})
+		}
+	}
+
+	return c.JSON(commons.SuccessResponse{
+		Message: "Link metadata refreshed successfully",
+		Data:    metadata,
+	})
+}
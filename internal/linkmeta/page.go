@@ -0,0 +1,165 @@
+package linkmeta
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxTextLength caps the title and description read from a page, in characters
+const maxTextLength = 500
+
+// maxURLLength drops image and favicon URLs longer than this many bytes
+const maxURLLength = 2048
+
+// page holds the metadata found in the head of an HTML page.
+type page struct {
+	title         string
+	ogTitle       string
+	ogDescription string
+	ogImage       string
+	favicon       string
+}
+
+// name is the title a link is given after its page: the document title, or the Open
+// Graph title when the document has none.
+func (p page) name() string {
+	if p.title != "" {
+		return p.title
+	}
+	return p.ogTitle
+}
+
+// parsePage reads the head of an HTML page and stops at the body, where metadata no
+// longer appears. Image and favicon URLs are resolved against base, the URL the page
+// was served from; the favicon defaults to /favicon.ico.
+func parsePage(r io.Reader, base *url.URL) page {
+	var p page
+	z := html.NewTokenizer(r)
+	inTitle := false
+
+loop:
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// End of the page, or of the part of it that was read
+			break loop
+		case html.TextToken:
+			if inTitle && p.title == "" {
+				p.title = cleanText(string(z.Text()))
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if atom.Lookup(name) == atom.Title {
+				inTitle = false
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := atom.Lookup(name)
+			if tag == atom.Body {
+				break loop
+			}
+			if tag == atom.Title {
+				inTitle = tt == html.StartTagToken
+				continue
+			}
+			if !hasAttr || (tag != atom.Meta && tag != atom.Link) {
+				continue
+			}
+
+			attrs := readAttrs(z)
+			if tag == atom.Meta {
+				p.readMeta(attrs, base)
+			} else {
+				p.readLink(attrs, base)
+			}
+		}
+	}
+
+	if p.favicon == "" {
+		p.favicon = resolveURL(base, "/favicon.ico")
+	}
+	return p
+}
+
+// readMeta picks up the Open Graph tags. Pages set them with property as the protocol
+// says, or with name as many do anyway.
+func (p *page) readMeta(attrs map[string]string, base *url.URL) {
+	key := attrs["property"]
+	if key == "" {
+		key = attrs["name"]
+	}
+	content := attrs["content"]
+
+	switch strings.ToLower(key) {
+	case "og:title":
+		if p.ogTitle == "" {
+			p.ogTitle = cleanText(content)
+		}
+	case "og:description":
+		if p.ogDescription == "" {
+			p.ogDescription = cleanText(content)
+		}
+	case "og:image", "og:image:url":
+		if p.ogImage == "" {
+			p.ogImage = resolveURL(base, content)
+		}
+	}
+}
+
+// readLink picks up the first icon the page declares.
+func (p *page) readLink(attrs map[string]string, base *url.URL) {
+	if p.favicon != "" || attrs["href"] == "" {
+		return
+	}
+	for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+		if rel == "icon" {
+			p.favicon = resolveURL(base, attrs["href"])
+			return
+		}
+	}
+}
+
+func readAttrs(z *html.Tokenizer) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		key, value, more := z.TagAttr()
+		attrs[string(key)] = string(value)
+		if !more {
+			return attrs
+		}
+	}
+}
+
+// cleanText collapses whitespace and cuts text to maxTextLength characters.
+func cleanText(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) > maxTextLength {
+		s = string([]rune(s)[:maxTextLength])
+	}
+	return s
+}
+
+// resolveURL resolves a URL found on a page against the page URL. It returns an empty
+// string for anything but a reasonably short http or https URL.
+func resolveURL(base *url.URL, ref string) string {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	u = base.ResolveReference(u)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+
+	resolved := u.String()
+	if len(resolved) > maxURLLength {
+		return ""
+	}
+	return resolved
+}
@@ -0,0 +1,56 @@
+package linkmeta
+
+import (
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type IService interface {
+	RefreshMetadata(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) (*Metadata, error)
+}
+
+type Service struct {
+	repo    datastore.Querier
+	fetcher IFetcher
+	log     *logger.Logger
+}
+
+func NewService(repo datastore.Querier, fetcher IFetcher, log *logger.Logger) IService {
+	return &Service{
+		repo:    repo,
+		fetcher: fetcher,
+		log:     log,
+	}
+}
+
+// RefreshMetadata fetches the page a link points to again and returns what was found on
+// it. A page that cannot be read is reported in the metadata, not as an error.
+func (s *Service) RefreshMetadata(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) (*Metadata, error) {
+	link, err := s.repo.GetShortLink(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, commons.ErrLinkNotFound
+		}
+		s.log.Error("failed to get short link", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	if link.UserID != userID {
+		s.log.Warn("unauthorized link metadata refresh", "user_id", userID, "link_id", linkID)
+		return nil, commons.ErrUnauthorized
+	}
+
+	metadata, err := s.fetcher.Fetch(ctx, link.ID, link.OriginalUrl)
+	if err != nil {
+		s.log.Error("failed to store link metadata", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	return metadata, nil
+}
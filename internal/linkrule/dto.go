@@ -0,0 +1,124 @@
+package linkrule
+
+import (
+	"GoShort/internal/datastore"
+	"GoShort/pkg/rules"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RuleRequest creates a rule or replaces all fields of an existing one.
+type RuleRequest struct {
+	// Priority orders the rules of a link, lowest first. New rules go last when omitted
+	Priority         *int32   `json:"priority,omitempty" validate:"omitempty,gte=0"`
+	Countries        []string `json:"countries,omitempty" validate:"omitempty,max=250,dive,iso3166_1_alpha2"`
+	DeviceTypes      []string `json:"device_types,omitempty" validate:"omitempty,max=10,dive,min=1,max=20"`
+	OperatingSystems []string `json:"operating_systems,omitempty" validate:"omitempty,max=20,dive,min=1,max=50"`
+	Languages        []string `json:"languages,omitempty" validate:"omitempty,max=50,dive,min=2,max=35"`
+	// Days are weekdays, 0 is Sunday
+	Days      []int   `json:"days,omitempty" validate:"omitempty,max=7,dive,gte=0,lte=6"`
+	StartTime *string `json:"start_time,omitempty" validate:"omitempty,datetime=15:04"`
+	EndTime   *string `json:"end_time,omitempty" validate:"omitempty,datetime=15:04"`
+	Timezone  *string `json:"timezone,omitempty" validate:"omitempty,timezone"`
+	Action    string  `json:"action" validate:"required,oneof=redirect block"`
+	// DestinationURL is required for the redirect action
+	DestinationURL *string `json:"destination_url,omitempty" validate:"required_if=Action redirect,omitempty,url"`
+}
+
+type RuleResponse struct {
+	ID               uuid.UUID `json:"id"`
+	Priority         int32     `json:"priority"`
+	Countries        []string  `json:"countries"`
+	DeviceTypes      []string  `json:"device_types"`
+	OperatingSystems []string  `json:"operating_systems"`
+	Languages        []string  `json:"languages"`
+	Days             []int     `json:"days"`
+	StartTime        *string   `json:"start_time,omitempty"`
+	EndTime          *string   `json:"end_time,omitempty"`
+	Timezone         string    `json:"timezone"`
+	Action           string    `json:"action"`
+	DestinationURL   *string   `json:"destination_url,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// NewRuleResponse converts a datastore link rule to its API representation.
+func NewRuleResponse(rule datastore.LinkRule) RuleResponse {
+	return RuleResponse{
+		ID:               rule.ID,
+		Priority:         rule.Priority,
+		Countries:        rule.Countries,
+		DeviceTypes:      rule.DeviceTypes,
+		OperatingSystems: rule.OperatingSystems,
+		Languages:        rule.Languages,
+		Days:             days(rule.DaysOfWeek),
+		StartTime:        clock(rule.StartTime),
+		EndTime:          clock(rule.EndTime),
+		Timezone:         rule.Timezone,
+		Action:           rule.Action,
+		DestinationURL:   rule.DestinationUrl,
+		CreatedAt:        rule.CreatedAt.Time,
+		UpdatedAt:        rule.UpdatedAt.Time,
+	}
+}
+
+// NewRuleResponses converts the rules of a link, keeping their order.
+func NewRuleResponses(linkRules []datastore.LinkRule) []RuleResponse {
+	responses := make([]RuleResponse, len(linkRules))
+	for i, rule := range linkRules {
+		responses[i] = NewRuleResponse(rule)
+	}
+	return responses
+}
+
+// NewRules converts datastore link rules to the form evaluated on redirect.
+func NewRules(linkRules []datastore.LinkRule) []rules.Rule {
+	if len(linkRules) == 0 {
+		return nil
+	}
+
+	converted := make([]rules.Rule, len(linkRules))
+	for i, rule := range linkRules {
+		converted[i] = rules.Rule{
+			ID:               rule.ID,
+			Countries:        rule.Countries,
+			DeviceTypes:      rule.DeviceTypes,
+			OperatingSystems: rule.OperatingSystems,
+			Languages:        rule.Languages,
+			Days:             days(rule.DaysOfWeek),
+			Timezone:         rule.Timezone,
+			Action:           rule.Action,
+		}
+		if start := clock(rule.StartTime); start != nil {
+			converted[i].StartTime = *start
+		}
+		if end := clock(rule.EndTime); end != nil {
+			converted[i].EndTime = *end
+		}
+		if rule.DestinationUrl != nil {
+			converted[i].DestinationURL = *rule.DestinationUrl
+		}
+	}
+	return converted
+}
+
+func days(daysOfWeek []int16) []int {
+	converted := make([]int, len(daysOfWeek))
+	for i, day := range daysOfWeek {
+		converted[i] = int(day)
+	}
+	return converted
+}
+
+// clock formats a TIME column as "15:04".
+func clock(t pgtype.Time) *string {
+	if !t.Valid {
+		return nil
+	}
+	minutes := t.Microseconds / int64(time.Minute/time.Microsecond)
+	formatted := fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+	return &formatted
+}
@@ -0,0 +1,242 @@
+package linkrule
+
+import (
+	"GoShort/internal/commons"
+	"GoShort/internal/urlpolicy"
+	"GoShort/pkg/logger"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	svr       IService
+	log       *logger.Logger
+	validator *validator.Validate
+}
+
+func NewHandler(service IService, log *logger.Logger, validator *validator.Validate) *Handler {
+	return &Handler{
+		svr:       service,
+		log:       log,
+		validator: validator,
+	}
+}
+
+// ListRules lists the routing rules of a short link
+// @Godoc ListRules
+// @Summary List the routing rules of a short link
+// @Description Retrieve the rules of a short link in the order they are evaluated
+// @Tags Link Rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Short link ID"
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.RuleResponse} "Link rules retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid link ID"
+// @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 404 {object} dto.ErrorResponse "Short link not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/links/{id}/rules [get]
+// @Security ApiKeyAuth
+func (h *Handler) ListRules(c *fiber.Ctx) error {
+	userID, linkID, ok := parseIDs(c)
+	if !ok {
+		return nil
+	}
+
+	resp, err := h.svr.ListRules(c.Context(), userID, linkID)
+	if err != nil {
+		return h.serviceError(c, err, "Failed to retrieve link rules")
+	}
+
+	return c.JSON(commons.SuccessResponse{
+		Message: "Link rules retrieved successfully",
+		Data:    resp,
+	})
+}
+
+// CreateRule adds a routing rule to a short link
+// @Godoc CreateRule
+// @Summary Add a routing rule to a short link
+// @Description Add a rule that sends matching visitors to another destination or blocks them
+// @Tags Link Rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Short link ID"
+// @Param request body dto.RuleRequest true "Rule Request"
+// @Success 201 {object} dto.SuccessResponse{data=dto.RuleResponse} "Link rule created successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid link ID or request body"
+// @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 404 {object} dto.ErrorResponse "Short link not found"
+// @Failure 422 {object} dto.ErrorResponse{error=dto.Violation} "Destination URL rejected by the URL policy"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/links/{id}/rules [post]
+// @Security ApiKeyAuth
+func (h *Handler) CreateRule(c *fiber.Ctx) error {
+	userID, linkID, ok := parseIDs(c)
+	if !ok {
+		return nil
+	}
+
+	var req RuleRequest
+	if !h.parseRequest(c, &req) {
+		return nil
+	}
+
+	resp, err := h.svr.CreateRule(c.Context(), userID, linkID, req)
+	if err != nil {
+		return h.serviceError(c, err, "Failed to create link rule")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(commons.SuccessResponse{
+		Message: "Link rule created successfully",
+		Data:    resp,
+	})
+}
+
+// UpdateRule replaces a routing rule of a short link
+// @Godoc UpdateRule
+// @Summary Replace a routing rule of a short link
+// @Description Replace all fields of a rule; the rule keeps its priority when none is given
+// @Tags Link Rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Short link ID"
+// @Param ruleId path string true "Rule ID"
+// @Param request body dto.RuleRequest true "Rule Request"
+// @Success 200 {object} dto.SuccessResponse{data=dto.RuleResponse} "Link rule updated successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid ID or request body"
+// @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 404 {object} dto.ErrorResponse "Short link or rule not found"
+// @Failure 422 {object} dto.ErrorResponse{error=dto.Violation} "Destination URL rejected by the URL policy"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/links/{id}/rules/{ruleId} [put]
+// @Security ApiKeyAuth
+func (h *Handler) UpdateRule(c *fiber.Ctx) error {
+	userID, linkID, ok := parseIDs(c)
+	if !ok {
+		return nil
+	}
+
+	ruleID, err := uuid.Parse(c.Params("ruleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid rule ID"})
+	}
+
+	var req RuleRequest
+	if !h.parseRequest(c, &req) {
+		return nil
+	}
+
+	resp, err := h.svr.UpdateRule(c.Context(), userID, linkID, ruleID, req)
+	if err != nil {
+		return h.serviceError(c, err, "Failed to update link rule")
+	}
+
+	return c.JSON(commons.SuccessResponse{
+		Message: "Link rule updated successfully",
+		Data:    resp,
+	})
+}
+
+// DeleteRule removes a routing rule from a short link
+// @Godoc DeleteRule
+// @Summary Delete a routing rule of a short link
+// @Description Delete a rule; visitors it matched fall through to the next rule
+// @Tags Link Rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Short link ID"
+// @Param ruleId path string true "Rule ID"
+// @Success 204 "Link rule deleted successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid ID"
+// @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 404 {object} dto.ErrorResponse "Short link or rule not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/links/{id}/rules/{ruleId} [delete]
+// @Security ApiKeyAuth
+func (h *Handler) DeleteRule(c *fiber.Ctx) error {
+	userID, linkID, ok := parseIDs(c)
+	if !ok {
+		return nil
+	}
+
+	ruleID, err := uuid.Parse(c.Params("ruleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid rule ID"})
+	}
+
+	if err := h.svr.DeleteRule(c.Context(), userID, linkID, ruleID); err != nil {
+		return h.serviceError(c, err, "Failed to delete link rule")
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// parseIDs reads the authenticated user and the link ID from the request. When it
+// returns false the error response has already been written.
+func parseIDs(c *fiber.Ctx) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		_ = c.Status(fiber.StatusUnauthorized).JSON(commons.ErrorResponse{Error: "Unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	linkUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid link ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userUUID, linkUUID, true
+}
+
+// parseRequest parses and validates the rule in the request body. When it returns false
+// the error response has already been written.
+func (h *Handler) parseRequest(c *fiber.Ctx, req *RuleRequest) bool {
+	if err := c.BodyParser(req); err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid request body"})
+		return false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+			Message: "Validation failed",
+			Error:   commons.FormatValidationErrors(err),
+		})
+		return false
+	}
+
+	return true
+}
+
+func (h *Handler) serviceError(c *fiber.Ctx, err error, message string) error {
+	var rejected *urlpolicy.Violation
+	switch {
+	case errors.Is(err, commons.ErrLinkNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(commons.ErrorResponse{Error: "Short link not found"})
+	case errors.Is(err, commons.ErrRuleNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(commons.ErrorResponse{Error: "Link rule not found"})
+	case errors.Is(err, commons.ErrUnauthorized):
+		return c.Status(fiber.StatusForbidden).JSON(commons.ErrorResponse{Error: "You are not authorized to access this link"})
+	case errors.Is(err, commons.ErrTooManyRules):
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "A link can have at most 50 rules"})
+	case errors.Is(err, commons.ErrInvalidRule):
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid rule: check the time window, timezone and destination"})
+	case errors.As(err, &rejected):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(commons.ErrorResponse{
+			Message: "Destination URL rejected",
+			Error:   rejected,
+		})
+	default:
+		h.log.Error(message, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{Error: message})
+	}
+}
@@ -0,0 +1,299 @@
+package linkrule
+
+import (
+	"GoShort/internal/cache"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/internal/urlpolicy"
+	"GoShort/pkg/logger"
+	"GoShort/pkg/rules"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxRulesPerLink bounds the work done for every redirect of a link.
+const maxRulesPerLink = 50
+
+type IService interface {
+	ListRules(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) ([]RuleResponse, error)
+	CreateRule(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, req RuleRequest) (*RuleResponse, error)
+	UpdateRule(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, ruleID uuid.UUID, req RuleRequest) (*RuleResponse, error)
+	DeleteRule(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, ruleID uuid.UUID) error
+}
+
+type Service struct {
+	repo   datastore.Querier
+	cache  cache.ILinkCache
+	policy urlpolicy.IService
+	log    *logger.Logger
+}
+
+func NewService(repo datastore.Querier, linkCache cache.ILinkCache, policy urlpolicy.IService, log *logger.Logger) IService {
+	return &Service{
+		repo:   repo,
+		cache:  linkCache,
+		policy: policy,
+		log:    log,
+	}
+}
+
+// ownedLink returns the link if it exists and belongs to the user.
+func (s *Service) ownedLink(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) (datastore.ShortLink, error) {
+	link, err := s.repo.GetShortLink(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datastore.ShortLink{}, commons.ErrLinkNotFound
+		}
+		s.log.Error("failed to get short link", "link_id", linkID, "error", err)
+		return datastore.ShortLink{}, err
+	}
+
+	if link.UserID != userID {
+		s.log.Warn("unauthorized link rule access", "user_id", userID, "link_id", linkID)
+		return datastore.ShortLink{}, commons.ErrUnauthorized
+	}
+
+	return link, nil
+}
+
+// ListRules returns the rules of a link in evaluation order.
+func (s *Service) ListRules(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) ([]RuleResponse, error) {
+	if _, err := s.ownedLink(ctx, userID, linkID); err != nil {
+		return nil, err
+	}
+
+	linkRules, err := s.repo.ListLinkRules(ctx, linkID)
+	if err != nil {
+		s.log.Error("failed to list link rules", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	return NewRuleResponses(linkRules), nil
+}
+
+// CreateRule adds a rule to a link. Without a priority the rule is evaluated last.
+func (s *Service) CreateRule(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, req RuleRequest) (*RuleResponse, error) {
+	link, err := s.ownedLink(ctx, userID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ListLinkRules(ctx, linkID)
+	if err != nil {
+		s.log.Error("failed to list link rules", "link_id", linkID, "error", err)
+		return nil, err
+	}
+	if len(existing) >= maxRulesPerLink {
+		return nil, commons.ErrTooManyRules
+	}
+
+	if req.Priority == nil {
+		priority := int32(0)
+		if len(existing) > 0 {
+			priority = existing[len(existing)-1].Priority + 1
+		}
+		req.Priority = &priority
+	}
+
+	fields, err := ruleFields(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDestination(ctx, &fields); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.repo.CreateLinkRule(ctx, datastore.CreateLinkRuleParams{
+		ID:               uuid.New(),
+		LinkID:           linkID,
+		Priority:         fields.Priority,
+		Countries:        fields.Countries,
+		DeviceTypes:      fields.DeviceTypes,
+		OperatingSystems: fields.OperatingSystems,
+		Languages:        fields.Languages,
+		DaysOfWeek:       fields.DaysOfWeek,
+		StartTime:        fields.StartTime,
+		EndTime:          fields.EndTime,
+		Timezone:         fields.Timezone,
+		Action:           fields.Action,
+		DestinationUrl:   fields.DestinationUrl,
+	})
+	if err != nil {
+		s.log.Error("failed to create link rule", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	// Redirects read the rules from the link cache
+	_ = s.cache.Invalidate(ctx, cache.LinkKey(link.DomainID, link.ShortCode))
+
+	response := NewRuleResponse(rule)
+	return &response, nil
+}
+
+// UpdateRule replaces all fields of a rule. Without a priority the rule keeps its place.
+func (s *Service) UpdateRule(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, ruleID uuid.UUID, req RuleRequest) (*RuleResponse, error) {
+	link, err := s.ownedLink(ctx, userID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.repo.GetLinkRule(ctx, datastore.GetLinkRuleParams{ID: ruleID, LinkID: linkID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, commons.ErrRuleNotFound
+		}
+		s.log.Error("failed to get link rule", "rule_id", ruleID, "error", err)
+		return nil, err
+	}
+
+	if req.Priority == nil {
+		req.Priority = &current.Priority
+	}
+
+	fields, err := ruleFields(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDestination(ctx, &fields); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.repo.UpdateLinkRule(ctx, datastore.UpdateLinkRuleParams{
+		ID:               ruleID,
+		LinkID:           linkID,
+		Priority:         fields.Priority,
+		Countries:        fields.Countries,
+		DeviceTypes:      fields.DeviceTypes,
+		OperatingSystems: fields.OperatingSystems,
+		Languages:        fields.Languages,
+		DaysOfWeek:       fields.DaysOfWeek,
+		StartTime:        fields.StartTime,
+		EndTime:          fields.EndTime,
+		Timezone:         fields.Timezone,
+		Action:           fields.Action,
+		DestinationUrl:   fields.DestinationUrl,
+	})
+	if err != nil {
+		s.log.Error("failed to update link rule", "rule_id", ruleID, "error", err)
+		return nil, err
+	}
+
+	_ = s.cache.Invalidate(ctx, cache.LinkKey(link.DomainID, link.ShortCode))
+
+	response := NewRuleResponse(rule)
+	return &response, nil
+}
+
+// DeleteRule removes a rule from a link.
+func (s *Service) DeleteRule(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, ruleID uuid.UUID) error {
+	link, err := s.ownedLink(ctx, userID, linkID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetLinkRule(ctx, datastore.GetLinkRuleParams{ID: ruleID, LinkID: linkID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return commons.ErrRuleNotFound
+		}
+		s.log.Error("failed to get link rule", "rule_id", ruleID, "error", err)
+		return err
+	}
+
+	if err := s.repo.DeleteLinkRule(ctx, datastore.DeleteLinkRuleParams{ID: ruleID, LinkID: linkID}); err != nil {
+		s.log.Error("failed to delete link rule", "rule_id", ruleID, "error", err)
+		return err
+	}
+
+	_ = s.cache.Invalidate(ctx, cache.LinkKey(link.DomainID, link.ShortCode))
+
+	return nil
+}
+
+// ruleFields converts a validated request to column values. The create and update
+// params share this layout.
+func ruleFields(req RuleRequest) (datastore.CreateLinkRuleParams, error) {
+	fields := datastore.CreateLinkRuleParams{
+		Priority:         *req.Priority,
+		Countries:        nonNil(req.Countries),
+		DeviceTypes:      nonNil(req.DeviceTypes),
+		OperatingSystems: nonNil(req.OperatingSystems),
+		Languages:        nonNil(req.Languages),
+		DaysOfWeek:       make([]int16, len(req.Days)),
+		Timezone:         "UTC",
+		Action:           req.Action,
+	}
+
+	for i, day := range req.Days {
+		fields.DaysOfWeek[i] = int16(day)
+	}
+
+	if req.Timezone != nil && *req.Timezone != "" {
+		if !rules.ValidTimezone(*req.Timezone) {
+			return fields, commons.ErrInvalidRule
+		}
+		fields.Timezone = *req.Timezone
+	}
+
+	var err error
+	if fields.StartTime, err = timeOfDay(req.StartTime); err != nil {
+		return fields, err
+	}
+	if fields.EndTime, err = timeOfDay(req.EndTime); err != nil {
+		return fields, err
+	}
+
+	switch req.Action {
+	case rules.ActionRedirect:
+		if req.DestinationURL == nil || *req.DestinationURL == "" {
+			return fields, commons.ErrInvalidRule
+		}
+		fields.DestinationUrl = req.DestinationURL
+	case rules.ActionBlock:
+		// A blocked visitor is never redirected
+		fields.DestinationUrl = nil
+	default:
+		return fields, commons.ErrInvalidRule
+	}
+
+	return fields, nil
+}
+
+// checkDestination applies the URL policy to the destination of a redirect rule and
+// keeps the destination the policy returns. A rejected destination is a *urlpolicy.Violation.
+func (s *Service) checkDestination(ctx context.Context, fields *datastore.CreateLinkRuleParams) error {
+	if fields.DestinationUrl == nil {
+		return nil
+	}
+	checked, err := s.policy.Check(ctx, *fields.DestinationUrl)
+	if err != nil {
+		return err
+	}
+	fields.DestinationUrl = &checked.URL
+	return nil
+}
+
+func timeOfDay(s *string) (pgtype.Time, error) {
+	if s == nil || *s == "" {
+		return pgtype.Time{}, nil
+	}
+	minutes, err := rules.ParseClock(*s)
+	if err != nil {
+		return pgtype.Time{}, commons.ErrInvalidRule
+	}
+	return pgtype.Time{
+		Microseconds: int64(minutes) * int64(time.Minute/time.Microsecond),
+		Valid:        true,
+	}, nil
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
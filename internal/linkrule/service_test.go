@@ -0,0 +1,135 @@
+package linkrule
+
+import (
+	"GoShort/config"
+	"GoShort/internal/cache"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/internal/testutil"
+	"GoShort/internal/urlpolicy"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRuleRepo keeps the rules of one link in memory.
+type fakeRuleRepo struct {
+	testutil.LinkRepo
+
+	rules []datastore.LinkRule
+}
+
+func (f *fakeRuleRepo) ListLinkRules(ctx context.Context, linkID uuid.UUID) ([]datastore.LinkRule, error) {
+	return f.rules, nil
+}
+
+func (f *fakeRuleRepo) CreateLinkRule(ctx context.Context, arg datastore.CreateLinkRuleParams) (datastore.LinkRule, error) {
+	rule := datastore.LinkRule{
+		ID:               arg.ID,
+		LinkID:           arg.LinkID,
+		Priority:         arg.Priority,
+		Countries:        arg.Countries,
+		DeviceTypes:      arg.DeviceTypes,
+		OperatingSystems: arg.OperatingSystems,
+		Languages:        arg.Languages,
+		DaysOfWeek:       arg.DaysOfWeek,
+		StartTime:        arg.StartTime,
+		EndTime:          arg.EndTime,
+		Timezone:         arg.Timezone,
+		Action:           arg.Action,
+		DestinationUrl:   arg.DestinationUrl,
+	}
+	f.rules = append(f.rules, rule)
+	return rule, nil
+}
+
+func TestService_CreateRule(t *testing.T) {
+	owner := uuid.New()
+	repo := &fakeRuleRepo{LinkRepo: testutil.LinkRepo{Link: datastore.ShortLink{ID: uuid.New(), UserID: owner, ShortCode: "app"}}}
+	linkCache := cache.NewLinkCache(nil, config.LinkCacheConfig{Enabled: false}, testutil.NewLogger())
+	svc := NewService(repo, linkCache, &testutil.Policy{}, testutil.NewLogger())
+	ctx := context.Background()
+
+	appStore := "https://apps.apple.com/app/id1"
+	start, end, zone := "09:00", "17:30", "Asia/Jakarta"
+
+	first, err := svc.CreateRule(ctx, owner, repo.Link.ID, RuleRequest{
+		OperatingSystems: []string{"iOS"},
+		Days:             []int{1, 2, 3, 4, 5},
+		StartTime:        &start,
+		EndTime:          &end,
+		Timezone:         &zone,
+		Action:           "redirect",
+		DestinationURL:   &appStore,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(0), first.Priority)
+	require.Equal(t, "09:00", *first.StartTime)
+	require.Equal(t, "17:30", *first.EndTime)
+	require.Equal(t, []int{1, 2, 3, 4, 5}, first.Days)
+	require.Equal(t, []string{}, first.Countries)
+
+	// A block rule never keeps a destination and goes after the existing rules
+	second, err := svc.CreateRule(ctx, owner, repo.Link.ID, RuleRequest{
+		Countries:      []string{"KP"},
+		Action:         "block",
+		DestinationURL: &appStore,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), second.Priority)
+	require.Nil(t, second.DestinationURL)
+	require.Equal(t, "UTC", second.Timezone)
+
+	// The redirect engine sees the same rules
+	converted := NewRules(repo.rules)
+	require.Len(t, converted, 2)
+	require.Equal(t, "17:30", converted[0].EndTime)
+	require.Equal(t, appStore, converted[0].DestinationURL)
+	require.Equal(t, "block", converted[1].Action)
+
+	badZone := "Mars/Olympus"
+	_, err = svc.CreateRule(ctx, owner, repo.Link.ID, RuleRequest{Timezone: &badZone, Action: "block"})
+	require.ErrorIs(t, err, commons.ErrInvalidRule)
+
+	_, err = svc.CreateRule(ctx, owner, repo.Link.ID, RuleRequest{Action: "redirect"})
+	require.ErrorIs(t, err, commons.ErrInvalidRule)
+
+	_, err = svc.CreateRule(ctx, uuid.New(), repo.Link.ID, RuleRequest{Action: "block"})
+	require.ErrorIs(t, err, commons.ErrUnauthorized)
+
+	_, err = svc.CreateRule(ctx, owner, uuid.New(), RuleRequest{Action: "block"})
+	require.ErrorIs(t, err, commons.ErrLinkNotFound)
+}
+
+func TestService_CreateRule_URLPolicy(t *testing.T) {
+	owner := uuid.New()
+	repo := &fakeRuleRepo{LinkRepo: testutil.LinkRepo{Link: datastore.ShortLink{ID: uuid.New(), UserID: owner, ShortCode: "app"}}}
+	linkCache := cache.NewLinkCache(nil, config.LinkCacheConfig{Enabled: false}, testutil.NewLogger())
+	policy := &testutil.Policy{CheckFunc: func(rawURL string) (*urlpolicy.Destination, error) {
+		switch rawURL {
+		case "http://10.0.0.1/admin":
+			return nil, &urlpolicy.Violation{Reason: urlpolicy.ReasonPrivateNetwork}
+		case "https://bit.ly/app":
+			// A flattened shortener chain
+			return &urlpolicy.Destination{URL: "https://example.com/app", Canonical: "https://example.com/app"}, nil
+		}
+		return &urlpolicy.Destination{URL: rawURL, Canonical: rawURL}, nil
+	}}
+	svc := NewService(repo, linkCache, policy, testutil.NewLogger())
+	ctx := context.Background()
+
+	private := "http://10.0.0.1/admin"
+	_, err := svc.CreateRule(ctx, owner, repo.Link.ID, RuleRequest{Action: "redirect", DestinationURL: &private})
+	var rejected *urlpolicy.Violation
+	require.ErrorAs(t, err, &rejected)
+	require.Equal(t, urlpolicy.ReasonPrivateNetwork, rejected.Reason)
+	require.Empty(t, repo.rules)
+
+	// The rule keeps the destination the policy returns
+	short := "https://bit.ly/app"
+	rule, err := svc.CreateRule(ctx, owner, repo.Link.ID, RuleRequest{Action: "redirect", DestinationURL: &short})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/app", *rule.DestinationURL)
+}
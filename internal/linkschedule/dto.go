@@ -0,0 +1,53 @@
+package linkschedule
+
+import (
+	"GoShort/internal/cache"
+	"GoShort/internal/datastore"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangeRequest switches the destination of a link at a given time.
+type ChangeRequest struct {
+	DestinationURL string    `json:"destination_url" validate:"required,url"`
+	At             time.Time `json:"at" validate:"required"`
+}
+
+type ChangeResponse struct {
+	ID             uuid.UUID `json:"id"`
+	DestinationURL string    `json:"destination_url"`
+	At             time.Time `json:"at"`
+	// AppliedAt is set once the change has been written to the link
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// NewChangeResponse converts a datastore link schedule to its API representation.
+func NewChangeResponse(schedule datastore.LinkSchedule) ChangeResponse {
+	response := ChangeResponse{
+		ID:             schedule.ID,
+		DestinationURL: schedule.DestinationUrl,
+		At:             schedule.SwitchAt.Time,
+	}
+	if schedule.AppliedAt.Valid {
+		appliedAt := schedule.AppliedAt.Time
+		response.AppliedAt = &appliedAt
+	}
+	return response
+}
+
+// NewPendingChanges converts the changes that have not been applied yet to the form
+// followed on redirect, keeping their order.
+func NewPendingChanges(schedules []datastore.LinkSchedule) []cache.ScheduledChange {
+	var pending []cache.ScheduledChange
+	for _, schedule := range schedules {
+		if schedule.AppliedAt.Valid {
+			continue
+		}
+		pending = append(pending, cache.ScheduledChange{
+			At:  schedule.SwitchAt.Time,
+			URL: schedule.DestinationUrl,
+		})
+	}
+	return pending
+}
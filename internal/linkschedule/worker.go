@@ -0,0 +1,123 @@
+package linkschedule
+
+import (
+	"GoShort/config"
+	"GoShort/internal/cache"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type IWorker interface {
+	// ApplyDue writes the changes that are due to their links and reports how many it applied
+	ApplyDue(ctx context.Context, now time.Time) (int, error)
+	Close(ctx context.Context) error
+}
+
+// Worker periodically writes due destination changes to short_links.original_url.
+// Redirects already follow a change once it is due, so the worker only has to catch
+// up within its interval.
+type Worker struct {
+	repo  datastore.Querier
+	cache cache.ILinkCache
+	cfg   config.ScheduleConfig
+	log   *logger.Logger
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewWorker creates the worker and starts applying due changes every interval.
+func NewWorker(repo datastore.Querier, linkCache cache.ILinkCache, cfg config.ScheduleConfig, log *logger.Logger) *Worker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	w := &Worker{
+		repo:  repo,
+		cache: linkCache,
+		cfg:   cfg,
+		log:   log,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w
+}
+
+func (w *Worker) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Interval)
+			if _, err := w.ApplyDue(ctx, time.Now()); err != nil {
+				w.log.Error("failed to apply scheduled link changes", "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// ApplyDue writes the changes that are due at now to their links, oldest first, and
+// drops the cached links so redirects pick up the new destination.
+func (w *Worker) ApplyDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := w.repo.ListDueLinkSchedules(ctx, datastore.ListDueLinkSchedulesParams{
+		SwitchAt: pgtype.Timestamp{Time: now.UTC(), Valid: true},
+		Limit:    int32(w.cfg.BatchSize),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, schedule := range due {
+		link, err := w.repo.ApplyLinkSchedule(ctx, datastore.ApplyLinkScheduleParams{
+			ID:        schedule.ID,
+			AppliedAt: pgtype.Timestamp{Time: now.UTC(), Valid: true},
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				// Another worker applied it first
+				continue
+			}
+			return applied, err
+		}
+
+		applied++
+		_ = w.cache.Invalidate(ctx, cache.LinkKey(link.DomainID, link.ShortCode))
+		w.log.Info("applied scheduled link change", "link_id", schedule.LinkID, "code", link.ShortCode, "destination_url", schedule.DestinationUrl)
+	}
+
+	return applied, nil
+}
+
+// Close stops the worker and waits for a running batch to finish.
+func (w *Worker) Close(ctx context.Context) error {
+	w.once.Do(func() { close(w.stop) })
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
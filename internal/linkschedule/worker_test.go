@@ -0,0 +1,92 @@
+package linkschedule
+
+import (
+	"GoShort/config"
+	"GoShort/internal/cache"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/logger"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScheduleRepo keeps the schedules of one link in memory and applies them like the
+// ApplyLinkSchedule query.
+type fakeScheduleRepo struct {
+	datastore.Querier
+
+	link      datastore.ShortLink
+	schedules []datastore.LinkSchedule
+}
+
+func (f *fakeScheduleRepo) ListDueLinkSchedules(ctx context.Context, arg datastore.ListDueLinkSchedulesParams) ([]datastore.LinkSchedule, error) {
+	var due []datastore.LinkSchedule
+	for _, schedule := range f.schedules {
+		if !schedule.AppliedAt.Valid && !schedule.SwitchAt.Time.After(arg.SwitchAt.Time) && len(due) < int(arg.Limit) {
+			due = append(due, schedule)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeScheduleRepo) ApplyLinkSchedule(ctx context.Context, arg datastore.ApplyLinkScheduleParams) (datastore.ApplyLinkScheduleRow, error) {
+	for i, schedule := range f.schedules {
+		if schedule.ID == arg.ID && !schedule.AppliedAt.Valid {
+			f.schedules[i].AppliedAt = arg.AppliedAt
+			f.link.OriginalUrl = schedule.DestinationUrl
+			return datastore.ApplyLinkScheduleRow{ShortCode: f.link.ShortCode, DomainID: f.link.DomainID}, nil
+		}
+	}
+	return datastore.ApplyLinkScheduleRow{}, pgx.ErrNoRows
+}
+
+func newTestLogger() *logger.Logger {
+	return logger.New(&config.AppConfig{
+		Logger: config.LoggerConfig{Output: io.Discard, Level: "info"},
+	})
+}
+
+func TestWorker_ApplyDue(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(d time.Duration) pgtype.Timestamp {
+		return pgtype.Timestamp{Time: now.Add(d), Valid: true}
+	}
+
+	link := datastore.ShortLink{ID: uuid.New(), ShortCode: "launch", OriginalUrl: "https://example.com/teaser"}
+	repo := &fakeScheduleRepo{
+		link: link,
+		schedules: []datastore.LinkSchedule{
+			{ID: uuid.New(), LinkID: link.ID, DestinationUrl: "https://example.com/live", SwitchAt: at(-time.Hour)},
+			{ID: uuid.New(), LinkID: link.ID, DestinationUrl: "https://example.com/replay", SwitchAt: at(time.Hour)},
+		},
+	}
+
+	linkCache := cache.NewLinkCache(nil, config.LinkCacheConfig{Enabled: false}, newTestLogger())
+	w := NewWorker(repo, linkCache, config.ScheduleConfig{Interval: time.Hour, BatchSize: 10}, newTestLogger())
+	defer func() { require.NoError(t, w.Close(context.Background())) }()
+
+	applied, err := w.ApplyDue(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, applied)
+	require.Equal(t, "https://example.com/live", repo.link.OriginalUrl)
+	require.True(t, repo.schedules[0].AppliedAt.Valid)
+
+	// Applied changes are not picked up again
+	applied, err = w.ApplyDue(context.Background(), now)
+	require.NoError(t, err)
+	require.Zero(t, applied)
+
+	applied, err = w.ApplyDue(context.Background(), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, applied)
+	require.Equal(t, "https://example.com/replay", repo.link.OriginalUrl)
+
+	// The pending changes followed on redirect skip the applied ones
+	require.Empty(t, NewPendingChanges(repo.schedules))
+}
@@ -0,0 +1,101 @@
+package linkvariant
+
+import (
+	"GoShort/internal/cache"
+	"GoShort/internal/datastore"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Assignment modes of a link's variants.
+const (
+	// AssignmentRandom picks a variant on every click
+	AssignmentRandom = "random"
+	// AssignmentSticky keeps a visitor on the same variant, by cookie or IP hash
+	AssignmentSticky = "sticky"
+)
+
+// VariantRequest is one destination of an A/B test.
+type VariantRequest struct {
+	// ID keeps an existing variant and its click history; new variants have none
+	ID             *uuid.UUID `json:"id,omitempty"`
+	Label          string     `json:"label" validate:"required,max=50"`
+	DestinationURL string     `json:"destination_url" validate:"required,url"`
+	// Weight is relative to the other variants, 70 and 30 split traffic 70/30
+	Weight int32 `json:"weight" validate:"required,gte=1,lte=10000"`
+}
+
+// SetVariantsRequest replaces all variants of a link. An empty list turns the A/B test
+// off and the link redirects to its original URL again.
+type SetVariantsRequest struct {
+	Assignment string           `json:"assignment" validate:"required,oneof=random sticky"`
+	Variants   []VariantRequest `json:"variants" validate:"max=20,dive"`
+}
+
+type VariantResponse struct {
+	ID             uuid.UUID `json:"id"`
+	Label          string    `json:"label"`
+	DestinationURL string    `json:"destination_url"`
+	Weight         int32     `json:"weight"`
+	// Share is the percentage of traffic the variant receives
+	Share     float64   `json:"share"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type VariantsResponse struct {
+	Assignment string            `json:"assignment"`
+	Variants   []VariantResponse `json:"variants"`
+}
+
+// NewVariantsResponse converts the variants of a link to their API representation.
+func NewVariantsResponse(assignment string, variants []datastore.LinkVariant) VariantsResponse {
+	var total int64
+	for _, variant := range variants {
+		total += int64(variant.Weight)
+	}
+
+	response := VariantsResponse{
+		Assignment: assignment,
+		Variants:   make([]VariantResponse, len(variants)),
+	}
+	for i, variant := range variants {
+		response.Variants[i] = VariantResponse{
+			ID:             variant.ID,
+			Label:          variant.Label,
+			DestinationURL: variant.DestinationUrl,
+			Weight:         variant.Weight,
+			Share:          Share(int64(variant.Weight), total),
+			CreatedAt:      variant.CreatedAt.Time,
+			UpdatedAt:      variant.UpdatedAt.Time,
+		}
+	}
+	return response
+}
+
+// NewVariants converts datastore link variants to the form picked from on redirect.
+func NewVariants(variants []datastore.LinkVariant) []cache.Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	converted := make([]cache.Variant, len(variants))
+	for i, variant := range variants {
+		converted[i] = cache.Variant{
+			ID:     variant.ID,
+			URL:    variant.DestinationUrl,
+			Weight: variant.Weight,
+		}
+	}
+	return converted
+}
+
+// Share returns part as a percentage of total, rounded to two decimals.
+func Share(part, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
@@ -0,0 +1,149 @@
+package linkvariant
+
+import (
+	"GoShort/internal/commons"
+	"GoShort/internal/urlpolicy"
+	"GoShort/pkg/logger"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	svr       IService
+	log       *logger.Logger
+	validator *validator.Validate
+}
+
+func NewHandler(service IService, log *logger.Logger, validator *validator.Validate) *Handler {
+	return &Handler{
+		svr:       service,
+		log:       log,
+		validator: validator,
+	}
+}
+
+// GetVariants lists the A/B variants of a short link
+// @Godoc GetVariants
+// @Summary List the A/B variants of a short link
+// @Description Retrieve the weighted destinations of a short link and how visitors are assigned to them
+// @Tags Link Variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Short link ID"
+// @Success 200 {object} dto.SuccessResponse{data=dto.VariantsResponse} "Link variants retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid link ID"
+// @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 404 {object} dto.ErrorResponse "Short link not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/links/{id}/variants [get]
+// @Security ApiKeyAuth
+func (h *Handler) GetVariants(c *fiber.Ctx) error {
+	userID, linkID, ok := parseIDs(c)
+	if !ok {
+		return nil
+	}
+
+	resp, err := h.svr.GetVariants(c.Context(), userID, linkID)
+	if err != nil {
+		return h.serviceError(c, err, "Failed to retrieve link variants")
+	}
+
+	return c.JSON(commons.SuccessResponse{
+		Message: "Link variants retrieved successfully",
+		Data:    resp,
+	})
+}
+
+// SetVariants replaces the A/B variants of a short link
+// @Godoc SetVariants
+// @Summary Replace the A/B variants of a short link
+// @Description Replace all weighted destinations of a short link. Variants sent with their ID keep their clicks, variants left out are deleted and an empty list ends the test
+// @Tags Link Variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Short link ID"
+// @Param request body dto.SetVariantsRequest true "Set Variants Request"
+// @Success 200 {object} dto.SuccessResponse{data=dto.VariantsResponse} "Link variants updated successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid link ID or request body"
+// @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 404 {object} dto.ErrorResponse "Short link or variant not found"
+// @Failure 422 {object} dto.ErrorResponse{error=dto.Violation} "Destination URL rejected by the URL policy"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/links/{id}/variants [put]
+// @Security ApiKeyAuth
+func (h *Handler) SetVariants(c *fiber.Ctx) error {
+	userID, linkID, ok := parseIDs(c)
+	if !ok {
+		return nil
+	}
+
+	var req SetVariantsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid request body"})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+			Message: "Validation failed",
+			Error:   commons.FormatValidationErrors(err),
+		})
+	}
+
+	resp, err := h.svr.SetVariants(c.Context(), userID, linkID, req)
+	if err != nil {
+		return h.serviceError(c, err, "Failed to update link variants")
+	}
+
+	return c.JSON(commons.SuccessResponse{
+		Message: "Link variants updated successfully",
+		Data:    resp,
+	})
+}
+
+// parseIDs reads the authenticated user and the link ID from the request. When it
+// returns false the error response has already been written.
+func parseIDs(c *fiber.Ctx) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		_ = c.Status(fiber.StatusUnauthorized).JSON(commons.ErrorResponse{Error: "Unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	linkUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid link ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userUUID, linkUUID, true
+}
+
+func (h *Handler) serviceError(c *fiber.Ctx, err error, message string) error {
+	var rejected *urlpolicy.Violation
+	switch {
+	case errors.Is(err, commons.ErrLinkNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(commons.ErrorResponse{Error: "Short link not found"})
+	case errors.Is(err, commons.ErrVariantNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(commons.ErrorResponse{Error: "Link variant not found"})
+	case errors.Is(err, commons.ErrUnauthorized):
+		return c.Status(fiber.StatusForbidden).JSON(commons.ErrorResponse{Error: "You are not authorized to access this link"})
+	case errors.Is(err, commons.ErrTooManyVariants):
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "A link can have at most 20 variants"})
+	case errors.As(err, &rejected):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(commons.ErrorResponse{
+			Message: "Destination URL rejected",
+			Error:   rejected,
+		})
+	default:
+		h.log.Error(message, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{Error: message})
+	}
+}
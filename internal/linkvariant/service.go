@@ -0,0 +1,171 @@
+package linkvariant
+
+import (
+	"GoShort/internal/cache"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/internal/urlpolicy"
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxVariantsPerLink keeps A/B tests readable; the stats page lists every variant.
+const maxVariantsPerLink = 20
+
+type IService interface {
+	GetVariants(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) (*VariantsResponse, error)
+	SetVariants(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, req SetVariantsRequest) (*VariantsResponse, error)
+}
+
+type Service struct {
+	repo   datastore.Querier
+	cache  cache.ILinkCache
+	policy urlpolicy.IService
+	log    *logger.Logger
+}
+
+func NewService(repo datastore.Querier, linkCache cache.ILinkCache, policy urlpolicy.IService, log *logger.Logger) IService {
+	return &Service{
+		repo:   repo,
+		cache:  linkCache,
+		policy: policy,
+		log:    log,
+	}
+}
+
+// ownedLink returns the link if it exists and belongs to the user.
+func (s *Service) ownedLink(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) (datastore.ShortLink, error) {
+	link, err := s.repo.GetShortLink(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datastore.ShortLink{}, commons.ErrLinkNotFound
+		}
+		s.log.Error("failed to get short link", "link_id", linkID, "error", err)
+		return datastore.ShortLink{}, err
+	}
+
+	if link.UserID != userID {
+		s.log.Warn("unauthorized link variant access", "user_id", userID, "link_id", linkID)
+		return datastore.ShortLink{}, commons.ErrUnauthorized
+	}
+
+	return link, nil
+}
+
+// GetVariants returns the A/B variants of a link and how visitors are assigned to them.
+func (s *Service) GetVariants(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) (*VariantsResponse, error) {
+	link, err := s.ownedLink(ctx, userID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	variants, err := s.repo.ListLinkVariants(ctx, linkID)
+	if err != nil {
+		s.log.Error("failed to list link variants", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	response := NewVariantsResponse(link.VariantAssignment, variants)
+	return &response, nil
+}
+
+// SetVariants replaces the variants of a link. Variants sent with their ID are updated
+// in place and keep their clicks, variants left out are deleted.
+func (s *Service) SetVariants(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, req SetVariantsRequest) (*VariantsResponse, error) {
+	link, err := s.ownedLink(ctx, userID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Variants) > maxVariantsPerLink {
+		return nil, commons.ErrTooManyVariants
+	}
+
+	// Variants go through the URL policy like the link's own destination
+	for i, variant := range req.Variants {
+		checked, err := s.policy.Check(ctx, variant.DestinationURL)
+		if err != nil {
+			return nil, err
+		}
+		req.Variants[i].DestinationURL = checked.URL
+	}
+
+	existing, err := s.repo.ListLinkVariants(ctx, linkID)
+	if err != nil {
+		s.log.Error("failed to list link variants", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	// Check every ID before changing anything
+	stale := make(map[uuid.UUID]bool, len(existing))
+	for _, variant := range existing {
+		stale[variant.ID] = true
+	}
+	for _, variant := range req.Variants {
+		if variant.ID == nil {
+			continue
+		}
+		if !stale[*variant.ID] {
+			return nil, commons.ErrVariantNotFound
+		}
+	}
+
+	for _, variant := range req.Variants {
+		if variant.ID != nil {
+			delete(stale, *variant.ID)
+			_, err = s.repo.UpdateLinkVariant(ctx, datastore.UpdateLinkVariantParams{
+				ID:             *variant.ID,
+				LinkID:         linkID,
+				Label:          variant.Label,
+				DestinationUrl: variant.DestinationURL,
+				Weight:         variant.Weight,
+			})
+		} else {
+			_, err = s.repo.CreateLinkVariant(ctx, datastore.CreateLinkVariantParams{
+				ID:             uuid.New(),
+				LinkID:         linkID,
+				Label:          variant.Label,
+				DestinationUrl: variant.DestinationURL,
+				Weight:         variant.Weight,
+			})
+		}
+		if err != nil {
+			s.log.Error("failed to save link variant", "link_id", linkID, "error", err)
+			return nil, err
+		}
+	}
+
+	for id := range stale {
+		if err := s.repo.DeleteLinkVariant(ctx, datastore.DeleteLinkVariantParams{ID: id, LinkID: linkID}); err != nil {
+			s.log.Error("failed to delete link variant", "variant_id", id, "error", err)
+			return nil, err
+		}
+	}
+
+	if req.Assignment != link.VariantAssignment {
+		link, err = s.repo.UpdateShortLinkVariantAssignment(ctx, datastore.UpdateShortLinkVariantAssignmentParams{
+			ID:                linkID,
+			VariantAssignment: req.Assignment,
+		})
+		if err != nil {
+			s.log.Error("failed to update variant assignment", "link_id", linkID, "error", err)
+			return nil, err
+		}
+	}
+
+	// Redirects read the variants from the link cache
+	_ = s.cache.Invalidate(ctx, cache.LinkKey(link.DomainID, link.ShortCode))
+
+	variants, err := s.repo.ListLinkVariants(ctx, linkID)
+	if err != nil {
+		s.log.Error("failed to list link variants", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	response := NewVariantsResponse(link.VariantAssignment, variants)
+	return &response, nil
+}
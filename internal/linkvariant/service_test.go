@@ -0,0 +1,166 @@
+package linkvariant
+
+import (
+	"GoShort/config"
+	"GoShort/internal/cache"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/internal/testutil"
+	"GoShort/internal/urlpolicy"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVariantRepo keeps the variants of one link in memory.
+type fakeVariantRepo struct {
+	testutil.LinkRepo
+
+	variants []datastore.LinkVariant
+}
+
+func (f *fakeVariantRepo) ListLinkVariants(ctx context.Context, linkID uuid.UUID) ([]datastore.LinkVariant, error) {
+	return append([]datastore.LinkVariant(nil), f.variants...), nil
+}
+
+func (f *fakeVariantRepo) CreateLinkVariant(ctx context.Context, arg datastore.CreateLinkVariantParams) (datastore.LinkVariant, error) {
+	variant := datastore.LinkVariant{
+		ID:             arg.ID,
+		LinkID:         arg.LinkID,
+		Label:          arg.Label,
+		DestinationUrl: arg.DestinationUrl,
+		Weight:         arg.Weight,
+	}
+	f.variants = append(f.variants, variant)
+	return variant, nil
+}
+
+func (f *fakeVariantRepo) UpdateLinkVariant(ctx context.Context, arg datastore.UpdateLinkVariantParams) (datastore.LinkVariant, error) {
+	for i, variant := range f.variants {
+		if variant.ID == arg.ID && variant.LinkID == arg.LinkID {
+			f.variants[i].Label = arg.Label
+			f.variants[i].DestinationUrl = arg.DestinationUrl
+			f.variants[i].Weight = arg.Weight
+			return f.variants[i], nil
+		}
+	}
+	return datastore.LinkVariant{}, pgx.ErrNoRows
+}
+
+func (f *fakeVariantRepo) DeleteLinkVariant(ctx context.Context, arg datastore.DeleteLinkVariantParams) error {
+	for i, variant := range f.variants {
+		if variant.ID == arg.ID && variant.LinkID == arg.LinkID {
+			f.variants = append(f.variants[:i], f.variants[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (f *fakeVariantRepo) UpdateShortLinkVariantAssignment(ctx context.Context, arg datastore.UpdateShortLinkVariantAssignmentParams) (datastore.ShortLink, error) {
+	f.Link.VariantAssignment = arg.VariantAssignment
+	return f.Link, nil
+}
+
+func TestService_SetVariants(t *testing.T) {
+	owner := uuid.New()
+	repo := &fakeVariantRepo{LinkRepo: testutil.LinkRepo{Link: datastore.ShortLink{ID: uuid.New(), UserID: owner, ShortCode: "launch", VariantAssignment: AssignmentRandom}}}
+	linkCache := cache.NewLinkCache(nil, config.LinkCacheConfig{Enabled: false}, testutil.NewLogger())
+	svc := NewService(repo, linkCache, &testutil.Policy{}, testutil.NewLogger())
+	ctx := context.Background()
+
+	resp, err := svc.SetVariants(ctx, owner, repo.Link.ID, SetVariantsRequest{
+		Assignment: AssignmentSticky,
+		Variants: []VariantRequest{
+			{Label: "A", DestinationURL: "https://example.com/a", Weight: 70},
+			{Label: "B", DestinationURL: "https://example.com/b", Weight: 30},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, AssignmentSticky, resp.Assignment)
+	require.Len(t, resp.Variants, 2)
+	require.Equal(t, 70.0, resp.Variants[0].Share)
+	require.Equal(t, 30.0, resp.Variants[1].Share)
+
+	// Keep A with a new weight, drop B and add C
+	keep := resp.Variants[0].ID
+	resp, err = svc.SetVariants(ctx, owner, repo.Link.ID, SetVariantsRequest{
+		Assignment: AssignmentSticky,
+		Variants: []VariantRequest{
+			{ID: &keep, Label: "A", DestinationURL: "https://example.com/a", Weight: 1},
+			{Label: "C", DestinationURL: "https://example.com/c", Weight: 2},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Variants, 2)
+	require.Equal(t, keep, resp.Variants[0].ID)
+	require.Equal(t, 33.33, resp.Variants[0].Share)
+	require.Equal(t, "C", resp.Variants[1].Label)
+
+	// The redirect engine sees the same variants
+	converted := NewVariants(repo.variants)
+	require.Len(t, converted, 2)
+	require.Equal(t, "https://example.com/c", converted[1].URL)
+
+	unknown := uuid.New()
+	_, err = svc.SetVariants(ctx, owner, repo.Link.ID, SetVariantsRequest{
+		Assignment: AssignmentRandom,
+		Variants:   []VariantRequest{{ID: &unknown, Label: "X", DestinationURL: "https://example.com/x", Weight: 1}},
+	})
+	require.ErrorIs(t, err, commons.ErrVariantNotFound)
+	require.Len(t, repo.variants, 2)
+
+	// An empty list ends the test
+	resp, err = svc.SetVariants(ctx, owner, repo.Link.ID, SetVariantsRequest{Assignment: AssignmentRandom})
+	require.NoError(t, err)
+	require.Empty(t, resp.Variants)
+	require.Equal(t, AssignmentRandom, repo.Link.VariantAssignment)
+
+	_, err = svc.SetVariants(ctx, uuid.New(), repo.Link.ID, SetVariantsRequest{Assignment: AssignmentRandom})
+	require.ErrorIs(t, err, commons.ErrUnauthorized)
+
+	_, err = svc.GetVariants(ctx, owner, uuid.New())
+	require.ErrorIs(t, err, commons.ErrLinkNotFound)
+}
+
+func TestService_SetVariants_URLPolicy(t *testing.T) {
+	owner := uuid.New()
+	repo := &fakeVariantRepo{LinkRepo: testutil.LinkRepo{Link: datastore.ShortLink{ID: uuid.New(), UserID: owner, ShortCode: "launch", VariantAssignment: AssignmentRandom}}}
+	linkCache := cache.NewLinkCache(nil, config.LinkCacheConfig{Enabled: false}, testutil.NewLogger())
+	policy := &testutil.Policy{CheckFunc: func(rawURL string) (*urlpolicy.Destination, error) {
+		switch rawURL {
+		case "http://10.0.0.1/admin":
+			return nil, &urlpolicy.Violation{Reason: urlpolicy.ReasonPrivateNetwork}
+		case "https://bit.ly/app":
+			// A flattened shortener chain
+			return &urlpolicy.Destination{URL: "https://example.com/app", Canonical: "https://example.com/app"}, nil
+		}
+		return &urlpolicy.Destination{URL: rawURL, Canonical: rawURL}, nil
+	}}
+	svc := NewService(repo, linkCache, policy, testutil.NewLogger())
+	ctx := context.Background()
+
+	// One rejected destination leaves all variants unchanged
+	_, err := svc.SetVariants(ctx, owner, repo.Link.ID, SetVariantsRequest{
+		Assignment: AssignmentRandom,
+		Variants: []VariantRequest{
+			{Label: "A", DestinationURL: "https://example.com/a", Weight: 1},
+			{Label: "B", DestinationURL: "http://10.0.0.1/admin", Weight: 1},
+		},
+	})
+	var rejected *urlpolicy.Violation
+	require.ErrorAs(t, err, &rejected)
+	require.Equal(t, urlpolicy.ReasonPrivateNetwork, rejected.Reason)
+	require.Empty(t, repo.variants)
+
+	// Variants keep the destination the policy returns
+	resp, err := svc.SetVariants(ctx, owner, repo.Link.ID, SetVariantsRequest{
+		Assignment: AssignmentRandom,
+		Variants:   []VariantRequest{{Label: "A", DestinationURL: "https://bit.ly/app", Weight: 1}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/app", resp.Variants[0].DestinationURL)
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"GoShort/config"
+	"GoShort/internal/commons"
+	"GoShort/pkg/logger"
+	"GoShort/pkg/redis"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// IdempotencyHeader carries the client's key for a request it may retry
+	IdempotencyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// idempotencyLockTTL bounds how long a key stays claimed by a request that never
+// finished, for example because the instance crashed. The claim of a running request is
+// renewed, so requests may take longer than this.
+const idempotencyLockTTL = time.Minute
+
+const maxIdempotencyKeyLength = 255
+
+// idempotentResponse is what Redis holds for a key: a claim while the first request runs,
+// then its response.
+type idempotentResponse struct {
+	// Fingerprint is the hash of the request body; a key cannot be reused for another body
+	Fingerprint string `json:"fingerprint"`
+	Pending     bool   `json:"pending,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// IdempotencyMiddleware lets clients retry a request with the same Idempotency-Key
+// without repeating its effect: the first response is stored in Redis and replayed.
+// Keys are scoped to the user and route. Server errors are not stored, so those requests
+// can be retried. Redis errors fail open and the request runs normally.
+type IdempotencyMiddleware struct {
+	rds     redis.RdsClient
+	cfg     config.IdempotencyConfig
+	lockTTL time.Duration
+	log     *logger.Logger
+}
+
+// NewIdempotencyMiddleware creates a new idempotency middleware
+func NewIdempotencyMiddleware(rds redis.RdsClient, cfg config.IdempotencyConfig, log *logger.Logger) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		rds:     rds,
+		cfg:     cfg,
+		lockTTL: idempotencyLockTTL,
+		log:     log,
+	}
+}
+
+func idempotencyKey(c *fiber.Ctx, key string) string {
+	return fmt.Sprintf("idempotency:%v:%s:%s:%s", c.Locals("user_id"), c.Method(), c.Path(), key)
+}
+
+// Handle must run after Authenticate, keys are scoped to the authenticated user.
+func (m *IdempotencyMiddleware) Handle() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := strings.TrimSpace(c.Get(IdempotencyHeader))
+		if key == "" || m.rds == nil {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: fmt.Sprintf("%s must be at most %d characters", IdempotencyHeader, maxIdempotencyKeyLength),
+			})
+		}
+
+		ctx := c.Context()
+		storeKey := idempotencyKey(c, key)
+		sum := sha256.Sum256(c.Body())
+		fingerprint := hex.EncodeToString(sum[:])
+
+		claim, _ := json.Marshal(idempotentResponse{Fingerprint: fingerprint, Pending: true})
+		claimed, err := m.rds.SetNX(ctx, storeKey, claim, m.lockTTL)
+		if err != nil {
+			m.log.Warn("failed to claim idempotency key", "error", err)
+			return c.Next()
+		}
+		if !claimed {
+			return m.replay(c, storeKey, fingerprint)
+		}
+
+		release := m.holdClaim(storeKey, claim)
+		err = c.Next()
+		release()
+		if err != nil {
+			_ = m.rds.Del(ctx, storeKey)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			_ = m.rds.Del(ctx, storeKey)
+			return nil
+		}
+
+		value, err := json.Marshal(idempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        c.Response().Body(),
+		})
+		if err == nil {
+			err = m.rds.Set(ctx, storeKey, value, m.cfg.TTL)
+		}
+		if err != nil {
+			m.log.Warn("failed to store idempotent response", "error", err)
+			_ = m.rds.Del(ctx, storeKey)
+		}
+		return nil
+	}
+}
+
+// holdClaim renews the claim on the key while the first request runs, so a slow request
+// such as a large bulk create keeps its key. The returned func stops the renewal and
+// returns once no renewal can overwrite the stored response anymore.
+func (m *IdempotencyMiddleware) holdClaim(storeKey string, claim []byte) func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(m.lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := m.rds.Set(context.Background(), storeKey, claim, m.lockTTL); err != nil {
+					m.log.Warn("failed to renew idempotency key claim", "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+// replay answers a request whose key was already used.
+func (m *IdempotencyMiddleware) replay(c *fiber.Ctx, storeKey, fingerprint string) error {
+	value, err := m.rds.Get(c.Context(), storeKey)
+	if err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			// The first request failed and released the key in the meantime
+			return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{
+				Error: "A request with this Idempotency-Key just finished, please retry",
+			})
+		}
+		m.log.Warn("failed to read idempotent response", "error", err)
+		return c.Next()
+	}
+
+	var stored idempotentResponse
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		m.log.Warn("failed to decode idempotent response", "error", err)
+		return c.Next()
+	}
+
+	switch {
+	case stored.Fingerprint != fingerprint:
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(commons.ErrorResponse{
+			Error: "Idempotency-Key was already used for a different request",
+		})
+	case stored.Pending:
+		return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{
+			Error: "A request with this Idempotency-Key is still being processed",
+		})
+	}
+
+	c.Set(IdempotentReplayedHeader, "true")
+	if stored.ContentType != "" {
+		c.Set(fiber.HeaderContentType, stored.ContentType)
+	}
+	return c.Status(stored.Status).Send(stored.Body)
+}
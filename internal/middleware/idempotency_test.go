@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"GoShort/config"
+	"GoShort/pkg/logger"
+	"GoShort/pkg/redis"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is an in-memory implementation of redis.RdsClient for testing.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+var _ redis.RdsClient = (*fakeRedis)(nil)
+
+func (f *fakeRedis) Ping(ctx context.Context) error { return nil }
+
+func (f *fakeRedis) Get(ctx context.Context, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.data[key]
+	if !ok {
+		return "", redis.ErrNil
+	}
+	return v, nil
+}
+
+func (f *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch v := value.(type) {
+	case []byte:
+		f.data[key] = string(v)
+	default:
+		f.data[key] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+func (f *fakeRedis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	f.mu.Lock()
+	_, exists := f.data[key]
+	f.mu.Unlock()
+	if exists {
+		return false, nil
+	}
+	return true, f.Set(ctx, key, value, expiration)
+}
+
+func (f *fakeRedis) Del(ctx context.Context, keys ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range keys {
+		delete(f.data, k)
+	}
+	return nil
+}
+
+func (f *fakeRedis) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeRedis) Decr(ctx context.Context, key string) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeRedis) Close() error { return nil }
+
+// newIdempotencyApp serves POST /links with a handler that counts its calls and answers
+// with the given status.
+func newIdempotencyApp(rds redis.RdsClient, status int) (*fiber.App, *int) {
+	log := logger.New(&config.AppConfig{
+		Logger: config.LoggerConfig{Output: io.Discard, Level: "info"},
+	})
+	m := NewIdempotencyMiddleware(rds, config.IdempotencyConfig{TTL: time.Hour}, log)
+
+	calls := 0
+	app := fiber.New()
+	app.Post("/links", func(c *fiber.Ctx) error {
+		c.Locals("user_id", "user-1")
+		return c.Next()
+	}, m.Handle(), func(c *fiber.Ctx) error {
+		calls++
+		return c.Status(status).JSON(fiber.Map{"call": calls})
+	})
+	return app, &calls
+}
+
+func postLink(t *testing.T, app *fiber.App, key, body string) (*http.Response, string) {
+	req := httptest.NewRequest(http.MethodPost, "/links", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(IdempotencyHeader, key)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(data)
+}
+
+func TestIdempotencyMiddleware_Replay(t *testing.T) {
+	app, calls := newIdempotencyApp(&fakeRedis{data: map[string]string{}}, fiber.StatusCreated)
+
+	first, firstBody := postLink(t, app, "key-1", `{"original_url":"https://example.com"}`)
+	require.Equal(t, fiber.StatusCreated, first.StatusCode)
+	require.Empty(t, first.Header.Get(IdempotentReplayedHeader))
+
+	retry, retryBody := postLink(t, app, "key-1", `{"original_url":"https://example.com"}`)
+	require.Equal(t, fiber.StatusCreated, retry.StatusCode)
+	require.Equal(t, "true", retry.Header.Get(IdempotentReplayedHeader))
+	require.Equal(t, fiber.MIMEApplicationJSON, retry.Header.Get(fiber.HeaderContentType))
+	require.Equal(t, firstBody, retryBody)
+	require.Equal(t, 1, *calls)
+
+	other, _ := postLink(t, app, "key-1", `{"original_url":"https://example.org"}`)
+	require.Equal(t, fiber.StatusUnprocessableEntity, other.StatusCode)
+	require.Equal(t, 1, *calls)
+
+	_, _ = postLink(t, app, "key-2", `{"original_url":"https://example.com"}`)
+	_, _ = postLink(t, app, "", `{"original_url":"https://example.com"}`)
+	require.Equal(t, 3, *calls)
+}
+
+func TestIdempotencyMiddleware_NotStored(t *testing.T) {
+	rds := &fakeRedis{data: map[string]string{}}
+	app, calls := newIdempotencyApp(rds, fiber.StatusInternalServerError)
+
+	_, _ = postLink(t, app, "key-1", `{}`)
+	_, _ = postLink(t, app, "key-1", `{}`)
+	require.Equal(t, 2, *calls, "server errors can be retried")
+	require.Empty(t, rds.data)
+
+	resp, _ := postLink(t, app, strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyMiddleware_InProgress(t *testing.T) {
+	rds := &fakeRedis{data: map[string]string{}}
+	app, calls := newIdempotencyApp(rds, fiber.StatusCreated)
+
+	// A first request with the same key and body is still running
+	claim, err := json.Marshal(idempotentResponse{
+		Fingerprint: "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a",
+		Pending:     true,
+	})
+	require.NoError(t, err)
+	rds.data["idempotency:user-1:POST:/links:key-1"] = string(claim)
+
+	resp, _ := postLink(t, app, "key-1", `{}`)
+	require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	require.Equal(t, 0, *calls)
+}
+
+// claimCountingRedis counts how often a pending claim is written.
+type claimCountingRedis struct {
+	*fakeRedis
+	claims atomic.Int32
+}
+
+func (f *claimCountingRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	if b, ok := value.([]byte); ok && strings.Contains(string(b), `"pending":true`) {
+		f.claims.Add(1)
+	}
+	return f.fakeRedis.Set(ctx, key, value, expiration)
+}
+
+func TestIdempotencyMiddleware_ClaimRenewedWhileRunning(t *testing.T) {
+	rds := &claimCountingRedis{fakeRedis: &fakeRedis{data: map[string]string{}}}
+	log := logger.New(&config.AppConfig{
+		Logger: config.LoggerConfig{Output: io.Discard, Level: "info"},
+	})
+	m := NewIdempotencyMiddleware(rds, config.IdempotencyConfig{TTL: time.Hour}, log)
+	m.lockTTL = 15 * time.Millisecond
+
+	app := fiber.New()
+	app.Post("/links", func(c *fiber.Ctx) error {
+		c.Locals("user_id", "user-1")
+		return c.Next()
+	}, m.Handle(), func(c *fiber.Ctx) error {
+		// A bulk create taking longer than the claim lives
+		time.Sleep(100 * time.Millisecond)
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	resp, _ := postLink(t, app, "key-1", `{}`)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.Positive(t, rds.claims.Load())
+
+	var stored idempotentResponse
+	require.NoError(t, json.Unmarshal([]byte(rds.data["idempotency:user-1:POST:/links:key-1"]), &stored))
+	require.False(t, stored.Pending, "a renewal must not overwrite the stored response")
+	require.Equal(t, fiber.StatusCreated, stored.Status)
+}
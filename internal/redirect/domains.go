@@ -0,0 +1,90 @@
+package redirect
+
+import (
+	"GoShort/internal/cache"
+	"GoShort/internal/datastore"
+	"GoShort/internal/domain"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// hostCacheTTL is how long the domain of a Host header is remembered. A newly verified
+// domain starts serving its links at most this long after verification.
+const hostCacheTTL = time.Minute
+
+// maxHostEntries bounds the host cache, since the Host header is chosen by the client.
+const maxHostEntries = 10000
+
+// linkRef identifies a short link: the same code can exist once on the default domain
+// and once on every custom domain.
+type linkRef struct {
+	domainID pgtype.UUID
+	code     string
+	// aliasID is the alias the visitor came through; code is then the link's own code
+	aliasID *uuid.UUID
+}
+
+// key is the link cache key of the link.
+func (r linkRef) key() string {
+	return cache.LinkKey(r.domainID, r.code)
+}
+
+type hostEntry struct {
+	domainID pgtype.UUID
+	expires  time.Time
+}
+
+// hostDomains maps Host headers to verified custom domains. Lookups are cached in
+// process, hosts that are not custom domains included, since every redirect needs one.
+type hostDomains struct {
+	repo datastore.Querier
+	ttl  time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]hostEntry
+}
+
+func newHostDomains(repo datastore.Querier, ttl time.Duration) *hostDomains {
+	return &hostDomains{repo: repo, ttl: ttl, entries: make(map[string]hostEntry)}
+}
+
+// resolve returns the verified domain served on the host. Unknown hosts, the server's
+// own host among them, serve the default domain and return an invalid UUID.
+func (h *hostDomains) resolve(ctx context.Context, host string) (pgtype.UUID, error) {
+	hostname := domain.NormalizeHostname(host)
+	if hostname == "" {
+		return pgtype.UUID{}, nil
+	}
+
+	now := time.Now()
+	h.mu.RLock()
+	entry, ok := h.entries[hostname]
+	h.mu.RUnlock()
+	if ok && now.Before(entry.expires) {
+		return entry.domainID, nil
+	}
+
+	var domainID pgtype.UUID
+	verified, err := h.repo.GetVerifiedDomainByHostname(ctx, hostname)
+	switch {
+	case err == nil:
+		domainID = pgtype.UUID{Bytes: verified.ID, Valid: true}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return pgtype.UUID{}, err
+	}
+
+	h.mu.Lock()
+	if len(h.entries) >= maxHostEntries {
+		h.entries = make(map[string]hostEntry)
+	}
+	h.entries[hostname] = hostEntry{domainID: domainID, expires: now.Add(h.ttl)}
+	h.mu.Unlock()
+
+	return domainID, nil
+}
@@ -1,29 +1,41 @@
 package redirect
 
 import (
+	"GoShort/config"
+	"GoShort/internal/commons"
 	"GoShort/internal/stats"
 	"GoShort/pkg/helper"
 	"GoShort/pkg/logger"
-	"context"
-	"encoding/json"
+	"GoShort/pkg/slug"
+	"GoShort/pkg/useragent"
 	"errors"
-	"fmt"
-	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type RedirectHandler struct {
-	service IService
-	log     *logger.Logger
+	service   IService
+	templates *Templates
+	cfg       config.ServerConfig
+	codeCfg   config.ShortCodeConfig
+	log       *logger.Logger
 }
 
-func NewRedirectHandler(service IService, log *logger.Logger) *RedirectHandler {
+// NewRedirectHandler creates the handler of short URLs. A nil templates uses the
+// embedded pages.
+func NewRedirectHandler(service IService, templates *Templates, cfg config.ServerConfig, codeCfg config.ShortCodeConfig, log *logger.Logger) *RedirectHandler {
+	if templates == nil {
+		templates = defaultTemplates
+	}
 	return &RedirectHandler{
-		service: service,
-		log:     log,
+		service:   service,
+		templates: templates,
+		cfg:       cfg,
+		codeCfg:   codeCfg,
+		log:       log,
 	}
 }
 
@@ -35,124 +47,376 @@ type CreateLinkStatRequest struct {
 	DeviceType *string
 }
 
-type IPAPIResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
-	Country string `json:"country"`
-	City    string `json:"city"`
-	Mobile  bool   `json:"mobile"`
-	Query   string `json:"query"`
-}
+// RedirectToOriginalURL serves every short URL, path-style codes like "/launch/2026"
+// included. A trailing "+" shows the preview page instead of redirecting.
+func (h *RedirectHandler) RedirectToOriginalURL(c *fiber.Ctx) error {
+	ctx := c.Context()
+	code, preview := h.shortCode(c)
+	if code == "" {
+		return h.errorPage(c, fiber.StatusNotFound, code, "Link not found")
+	}
 
-var (
-	ErrLinkNotFound  = errors.New("link not found")
-	ErrLinkNotActive = errors.New("link is not active")
-)
+	// "?preview" shows the preview page instead of redirecting
+	if preview || c.Context().QueryArgs().Has("preview") {
+		return h.previewPage(c, code)
+	}
 
-func (h *RedirectHandler) RedirectToOriginalURL(c *fiber.Ctx) error {
+	// Unfurlers, crawlers and prefetches are redirected but never counted as clicks
+	if reason := previewReason(c); reason != "" {
+		return h.redirectPreview(c, code, reason)
+	}
+
+	destination, err := h.service.GetOriginalURL(ctx, code, visitor(c))
+	if err != nil {
+		return h.linkError(c, code, err)
+	}
+
+	// User-agent parsing, GeoIP enrichment and the database insert happen in the click pipeline workers
+	if err := h.service.RecordLinkStat(ctx, destination.LinkID, destinationClickInfo(c, destination)); err != nil {
+		h.log.Warn("failed to record link stat", "link_id", destination.LinkID, "error", err)
+	}
+
+	rememberVariant(c, code, destination)
+	return h.redirect(c, destination, h.redirectStatus(destination))
+}
+
+// UnlockProtectedLink handles the forms posted by the password and preview pages. On the
+// right password, or none for unprotected links, the visitor is redirected and the click
+// is recorded.
+func (h *RedirectHandler) UnlockProtectedLink(c *fiber.Ctx) error {
 	ctx := c.Context()
-	code := c.Params("code")
+	code, _ := h.shortCode(c)
 	if code == "" {
-		return c.Status(fiber.StatusNotFound).SendString("Link not found")
+		return h.errorPage(c, fiber.StatusNotFound, code, "Link not found")
 	}
 
-	originalURL, linkID, isActive, err := h.service.GetOriginalURL(ctx, code)
+	destination, err := h.service.UnlockLink(ctx, code, c.FormValue("password"), visitor(c))
 	if err != nil {
-		switch {
-		case errors.Is(err, ErrLinkNotFound):
-			h.log.Warn("link not found", "code", code)
-			return c.Status(fiber.StatusNotFound).SendString("Link not found")
-		case errors.Is(err, ErrLinkNotActive):
-			h.log.Warn("link is inactive", "code", code)
-			return c.Status(fiber.StatusForbidden).SendString("Link is inactive")
-		// Add other error cases here (Expired, ClickLimit, etc.)
-		default:
-			h.log.Println("unexpected error while retrieving original URL", "error", err)
-			return c.Status(fiber.StatusInternalServerError).SendString("Internal app error")
-		}
+		return h.linkError(c, code, err)
 	}
 
-	if !isActive {
-		h.log.Warn("attempted to access inactive link", "code", code)
-		return c.Status(fiber.StatusForbidden).SendString("Link is inactive")
+	if err := h.service.RecordLinkStat(ctx, destination.LinkID, destinationClickInfo(c, destination)); err != nil {
+		h.log.Warn("failed to record link stat", "link_id", destination.LinkID, "error", err)
 	}
 
-	ipAddress := c.Get("CF-Connecting-IP")
-	if ipAddress == "" {
-		// X-Forwarded-For can be a list of IPs. The first one is the original client.
-		ips := strings.Split(c.Get("X-Forwarded-For"), ",")
-		if len(ips) > 0 && ips[0] != "" {
-			ipAddress = strings.TrimSpace(ips[0])
-		}
+	// Our forms continue with a GET; other clients keep their method and body when the
+	// link uses 307 or 308
+	status := fiber.StatusSeeOther
+	if redirectStatus := h.redirectStatus(destination); keepsMethod(redirectStatus) && !isFormPost(c) {
+		status = redirectStatus
+	}
+
+	rememberVariant(c, code, destination)
+	return h.redirect(c, destination, status)
+}
+
+// shortCode reads the code from the wildcard path and reports whether it ends in the
+// "+" of a preview. Unicode codes arrive percent-encoded and are normalized like the
+// codes the link service stores.
+func (h *RedirectHandler) shortCode(c *fiber.Ctx) (string, bool) {
+	raw := c.Params("*")
+	if unescaped, err := url.PathUnescape(raw); err == nil {
+		raw = unescaped
 	}
-	if ipAddress == "" {
-		ipAddress = c.IP()
+	raw, preview := strings.CutSuffix(raw, "+")
+	return slug.Normalize(raw, h.codeCfg.CaseInsensitive), preview
+}
+
+func (h *RedirectHandler) redirectPreview(c *fiber.Ctx, code string, reason string) error {
+	ctx := c.Context()
+
+	destination, err := h.service.GetPreviewURL(ctx, code, visitor(c))
+	if err != nil {
+		return h.linkError(c, code, err)
 	}
 
-	userAgent := c.Get("User-Agent")
-	referrer := c.Get("Referer")
-	country := c.Get("CF-IPCountry")
+	if err := h.service.RecordLinkPreview(ctx, destination.LinkID, reason, clickInfo(c)); err != nil {
+		h.log.Warn("failed to record link preview", "link_id", destination.LinkID, "error", err)
+	}
 
-	deviceType := "Desktop"
-	if strings.Contains(strings.ToLower(userAgent), "mobile") {
-		deviceType = "Mobile"
-	} else if strings.Contains(strings.ToLower(userAgent), "tablet") {
-		deviceType = "Tablet"
+	// Unfurlers show the card the owner chose rather than the tags of the destination
+	if destination.SocialCard != nil && useragent.IsUnfurler(c.Get("User-Agent")) {
+		return h.socialCardPage(c, code, destination)
 	}
 
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-		defer cancel()
+	// Crawlers see the same status as visitors, so permanent links pass on their ranking
+	return h.redirect(c, destination, h.redirectStatus(destination))
+}
+
+// socialCardPage serves the Open Graph and Twitter card tags of a link. A meta refresh
+// takes anyone else who ends up on the page to the destination. Without the page the
+// unfurler is redirected as usual.
+func (h *RedirectHandler) socialCardPage(c *fiber.Ctx, code string, destination *Destination) error {
+	card := destination.SocialCard
+	body, err := h.templates.renderSocialCardPage(SocialCardPageData{
+		Title:       card.Title,
+		Description: card.Description,
+		ImageURL:    card.ImageURL,
+		Destination: destination.URL,
+	})
+	if err != nil {
+		h.log.Error("failed to render social card page", "code", code, "error", err)
+		return h.redirect(c, destination, h.redirectStatus(destination))
+	}
 
-		clickInfo := stats.CreateLinkStatRequest{
-			IpAddress:  helper.StringToPtr(ipAddress),
-			UserAgent:  helper.StringToPtr(userAgent),
-			Referrer:   helper.StringToPtr(referrer),
-			Country:    helper.StringToPtr(country),
-			DeviceType: helper.StringToPtr(deviceType),
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Type("html", "utf-8")
+	return c.Status(fiber.StatusOK).SendString(body)
+}
+
+// redirectStatus is the status chosen for the link, or the server default.
+func (h *RedirectHandler) redirectStatus(destination *Destination) int {
+	switch {
+	case helper.IsRedirectStatus(destination.Status):
+		return destination.Status
+	case helper.IsRedirectStatus(h.cfg.RedirectStatus):
+		return h.cfg.RedirectStatus
+	default:
+		return fiber.StatusFound
+	}
+}
+
+// redirect sends the visitor to the destination. Clients may cache permanent redirects
+// for the configured max age; temporary ones always come back, so every click is seen.
+func (h *RedirectHandler) redirect(c *fiber.Ctx, destination *Destination, status int) error {
+	switch status {
+	case fiber.StatusMovedPermanently, fiber.StatusPermanentRedirect:
+		if maxAge := int(h.cfg.PermanentRedirectMaxAge.Seconds()); maxAge > 0 {
+			c.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(maxAge))
+		} else {
+			c.Set(fiber.HeaderCacheControl, "no-cache")
 		}
+	default:
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	}
+	return c.Redirect(destination.URL, status)
+}
+
+// keepsMethod reports whether clients repeat the request method and body on redirect.
+func keepsMethod(status int) bool {
+	return status == fiber.StatusTemporaryRedirect || status == fiber.StatusPermanentRedirect
+}
+
+// isFormPost reports whether the request was posted by an HTML form, like the password
+// and preview pages.
+func isFormPost(c *fiber.Ctx) bool {
+	contentType := strings.ToLower(c.Get(fiber.HeaderContentType))
+	return strings.HasPrefix(contentType, fiber.MIMEApplicationForm) || strings.HasPrefix(contentType, fiber.MIMEMultipartForm)
+}
+
+// linkError answers a request for a link that cannot be followed: the owner's fallback
+// URL when there is one, otherwise the page matching the reason.
+func (h *RedirectHandler) linkError(c *fiber.Ctx, code string, err error) error {
+	var unavailable *UnavailableError
+	if errors.As(err, &unavailable) {
+		h.log.Info("redirecting unavailable link to its fallback URL", "code", code, "reason", unavailable.Reason)
+		// The link may become available again
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.Redirect(unavailable.FallbackURL, fiber.StatusFound)
+	}
+
+	switch {
+	case errors.Is(err, commons.ErrLinkNotFound):
+		h.log.Warn("link not found", "code", code)
+		return h.errorPage(c, fiber.StatusNotFound, code, "Link not found")
+	case errors.Is(err, commons.ErrLinkNotActive):
+		h.log.Warn("link is inactive", "code", code)
+		return h.errorPage(c, fiber.StatusForbidden, code, "Link is inactive")
+	case errors.Is(err, commons.ErrLinkExpired):
+		h.log.Warn("link has expired", "code", code)
+		return h.errorPage(c, fiber.StatusGone, code, "Link has expired")
+	case errors.Is(err, commons.ErrClickLimitExceeded):
+		h.log.Warn("link click limit exceeded", "code", code)
+		return h.errorPage(c, fiber.StatusGone, code, "Click limit exceeded")
+	case errors.Is(err, commons.ErrLinkNotYetActive):
+		return h.notYetAvailablePage(c, code)
+	case errors.Is(err, commons.ErrLinkBlocked):
+		return h.errorPage(c, fiber.StatusForbidden, code, "This link is not available to you")
+	case errors.Is(err, commons.ErrInterstitialRequired):
+		return h.previewPage(c, code)
+	case errors.Is(err, commons.ErrLinkPasswordRequired):
+		return h.passwordPage(c, fiber.StatusOK, code, "")
+	case errors.Is(err, commons.ErrInvalidLinkPassword):
+		return h.passwordPage(c, fiber.StatusUnauthorized, code, "Incorrect password, please try again.")
+	case errors.Is(err, commons.ErrTooManyPasswordAttempts):
+		return h.passwordPage(c, fiber.StatusTooManyRequests, code, "Too many incorrect attempts. Please try again later.")
+	default:
+		h.log.Error("unexpected error while retrieving original URL", "code", code, "error", err)
+		return c.Status(fiber.StatusInternalServerError).SendString("Internal app error")
+	}
+}
+
+// errorPage renders the 404, 410 or 403 page. The plain message is sent when the
+// template cannot be rendered.
+func (h *RedirectHandler) errorPage(c *fiber.Ctx, status int, code string, message string) error {
+	body, err := h.templates.renderErrorPage(status, ErrorPageData{Code: code, Message: message})
+	if err != nil {
+		h.log.Error("failed to render error page", "status", status, "code", code, "error", err)
+		return c.Status(status).SendString(message)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Type("html", "utf-8")
+	return c.Status(status).SendString(body)
+}
 
-		ipInfo, err := fetchIPInfo(ipAddress)
-		if err == nil && ipInfo != nil {
+func (h *RedirectHandler) passwordPage(c *fiber.Ctx, status int, code string, message string) error {
+	body, err := h.templates.renderPasswordPage(PasswordPageData{Code: code, Error: message})
+	if err != nil {
+		h.log.Error("failed to render password page", "code", code, "error", err)
+		return c.Status(fiber.StatusInternalServerError).SendString("Internal app error")
+	}
 
-			if clickInfo.Country == nil || *clickInfo.Country == "" {
-				clickInfo.Country = helper.StringToPtr(ipInfo.Country)
-			}
-			if ipInfo.Mobile && (clickInfo.DeviceType == nil || *clickInfo.DeviceType != "Mobile") {
-				clickInfo.DeviceType = helper.StringToPtr("Mobile")
-			}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Type("html", "utf-8")
+	return c.Status(status).SendString(body)
+}
+
+func (h *RedirectHandler) previewPage(c *fiber.Ctx, code string) error {
+	ctx := c.Context()
+
+	info, err := h.service.GetLinkInfo(ctx, c.Hostname(), code)
+	if err != nil {
+		if errors.Is(err, commons.ErrLinkNotFound) {
+			return h.errorPage(c, fiber.StatusNotFound, code, "Link not found")
 		}
+		h.log.Error("unexpected error while retrieving link info", "code", code, "error", err)
+		return c.Status(fiber.StatusInternalServerError).SendString("Internal app error")
+	}
+
+	data := PreviewPageData{
+		Code:        info.ShortCode,
+		Destination: info.OriginalURL,
+		CreatedAt:   info.CreatedAt.Format("January 2, 2006"),
+		Status:      availabilityText(info.Unavailable),
+		Active:      info.Unavailable == nil,
+		Protected:   info.Protected,
+	}
+	if info.Title != nil {
+		data.Title = *info.Title
+	}
+	if info.Description != nil {
+		data.Description = *info.Description
+	}
+
+	body, err := h.templates.renderPreviewPage(data)
+	if err != nil {
+		h.log.Error("failed to render preview page", "code", code, "error", err)
+		return c.Status(fiber.StatusInternalServerError).SendString("Internal app error")
+	}
+
+	if err := h.service.RecordLinkPreview(ctx, info.ID, stats.PreviewReasonPage, clickInfo(c)); err != nil {
+		h.log.Warn("failed to record link preview", "link_id", info.ID, "error", err)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Type("html", "utf-8")
+	return c.Status(fiber.StatusOK).SendString(body)
+}
+
+func (h *RedirectHandler) notYetAvailablePage(c *fiber.Ctx, code string) error {
+	data := NotYetAvailablePageData{Code: code}
 
-		if err := h.service.RecordLinkStat(ctx, linkID, clickInfo); err != nil {
-			h.log.Println("failed to record link stat", "link_id", linkID, "error", err)
+	// The page still works without the details
+	if info, err := h.service.GetLinkInfo(c.Context(), c.Hostname(), code); err == nil {
+		if info.Title != nil {
+			data.Title = *info.Title
 		}
-	}()
+		if info.StartsAt != nil {
+			data.StartsAt = info.StartsAt.UTC().Format("January 2, 2006 at 15:04 UTC")
+		}
+	}
+
+	body, err := h.templates.renderNotYetAvailablePage(data)
+	if err != nil {
+		h.log.Error("failed to render not yet available page", "code", code, "error", err)
+		return c.Status(fiber.StatusInternalServerError).SendString("Internal app error")
+	}
 
-	return c.Redirect(originalURL, fiber.StatusFound)
+	// Never cached, the link becomes available at its start time
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Type("html", "utf-8")
+	return c.Status(fiber.StatusForbidden).SendString(body)
 }
 
-func fetchIPInfo(ipAddress string) (*IPAPIResponse, error) {
-	if ipAddress == "127.0.0.1" || ipAddress == "::1" || strings.HasPrefix(ipAddress, "172.") {
-		return nil, errors.New("private IP address")
+func availabilityText(reason error) string {
+	switch {
+	case reason == nil:
+		return "Active"
+	case errors.Is(reason, commons.ErrLinkNotActive):
+		return "Inactive"
+	case errors.Is(reason, commons.ErrLinkExpired):
+		return "Expired"
+	case errors.Is(reason, commons.ErrLinkNotYetActive):
+		return "Not yet available"
+	case errors.Is(reason, commons.ErrClickLimitExceeded):
+		return "Click limit reached"
+	default:
+		return "Unavailable"
 	}
+}
 
-	client := &http.Client{Timeout: 2 * time.Second}
-	url := fmt.Sprintf("http://ip-api.com/json/%s?fields=status,message,country,city,mobile,query", ipAddress)
+// previewReason classifies requests that are not a person following the link: HEAD
+// requests, browser prefetch/prerender and known bots or unfurlers. It returns an
+// empty string for regular clicks.
+func previewReason(c *fiber.Ctx) string {
+	if c.Method() == fiber.MethodHead {
+		return stats.PreviewReasonHead
+	}
 
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
+	purpose := strings.ToLower(c.Get("Sec-Purpose") + " " + c.Get("Purpose") + " " + c.Get("X-Purpose") + " " + c.Get("X-Moz"))
+	if strings.Contains(purpose, "prefetch") || strings.Contains(purpose, "prerender") || strings.Contains(purpose, "preview") {
+		return stats.PreviewReasonPrefetch
 	}
-	defer resp.Body.Close()
 
-	var ipInfo IPAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&ipInfo); err != nil {
-		return nil, err
+	if useragent.IsBot(c.Get("User-Agent")) {
+		return stats.PreviewReasonBot
 	}
 
-	if ipInfo.Status != "success" {
-		return nil, fmt.Errorf("ip-api error: %s", ipInfo.Message)
+	return ""
+}
+
+func clickInfo(c *fiber.Ctx) stats.CreateLinkStatRequest {
+	return stats.CreateLinkStatRequest{
+		IpAddress: helper.StringToPtr(c.IP()),
+		UserAgent: helper.StringToPtr(c.Get("User-Agent")),
+		Referrer:  helper.StringToPtr(c.Get("Referer")),
+		Country:   helper.StringToPtr(c.Get("CF-IPCountry")),
 	}
+}
 
-	return &ipInfo, nil
+// destinationClickInfo is clickInfo plus the A/B variant the click was sent to and the
+// alias it came through.
+func destinationClickInfo(c *fiber.Ctx, destination *Destination) stats.CreateLinkStatRequest {
+	info := clickInfo(c)
+	info.VariantID = destination.VariantID
+	info.AliasID = destination.AliasID
+	return info
+}
+
+// rememberVariant keeps the visitor of a sticky A/B test on the variant they were served.
+func rememberVariant(c *fiber.Ctx, code string, destination *Destination) {
+	if !destination.Sticky || destination.VariantID == nil {
+		return
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     VariantCookie,
+		Value:    destination.VariantID.String(),
+		Path:     (&url.URL{Path: "/" + code}).EscapedPath(),
+		MaxAge:   variantCookieMaxAge,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func visitor(c *fiber.Ctx) Visitor {
+	return Visitor{
+		Host:           c.Hostname(),
+		IP:             c.IP(),
+		Country:        c.Get("CF-IPCountry"),
+		UserAgent:      c.Get("User-Agent"),
+		AcceptLanguage: c.Get("Accept-Language"),
+		VariantCookie:  c.Cookies(VariantCookie),
+		Query:          string(c.Request().URI().QueryString()),
+	}
 }
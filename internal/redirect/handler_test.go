@@ -1,14 +1,17 @@
 package redirect
 
 import (
-	"GoShort/internal/admin"
+	"GoShort/config"
+	"GoShort/internal/cache"
 	"GoShort/internal/commons"
 	"GoShort/internal/stats"
+	"GoShort/internal/testutil"
 	"context"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,21 +22,41 @@ import (
 
 // mockRedirectService adalah implementasi mock dari IService untuk pengujian.
 type mockRedirectService struct {
-	GetOriginalURLFunc func(ctx context.Context, code string) (string, uuid.UUID, bool, error)
-	RecordLinkStatFunc func(ctx context.Context, linkID uuid.UUID, req stats.CreateLinkStatRequest) error
+	GetOriginalURLFunc    func(ctx context.Context, code string, visitor Visitor) (*Destination, error)
+	GetPreviewURLFunc     func(ctx context.Context, code string, visitor Visitor) (*Destination, error)
+	RecordLinkStatFunc    func(ctx context.Context, linkID uuid.UUID, req stats.CreateLinkStatRequest) error
+	RecordLinkPreviewFunc func(ctx context.Context, linkID uuid.UUID, reason string, req stats.CreateLinkStatRequest) error
+	UnlockLinkFunc        func(ctx context.Context, code, password string, visitor Visitor) (*Destination, error)
+	GetLinkInfoFunc       func(ctx context.Context, host, code string) (*LinkInfo, error)
 }
 
 // Memastikan mockRedirectService memenuhi kontrak service.IService.
 var _ IService = (*mockRedirectService)(nil)
 
-func (m *mockRedirectService) GetOriginalURL(ctx context.Context, code string) (string, uuid.UUID, bool, error) {
-	return m.GetOriginalURLFunc(ctx, code)
+func (m *mockRedirectService) GetOriginalURL(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+	return m.GetOriginalURLFunc(ctx, code, visitor)
+}
+
+func (m *mockRedirectService) GetPreviewURL(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+	return m.GetPreviewURLFunc(ctx, code, visitor)
 }
 
 func (m *mockRedirectService) RecordLinkStat(ctx context.Context, linkID uuid.UUID, req stats.CreateLinkStatRequest) error {
 	return m.RecordLinkStatFunc(ctx, linkID, req)
 }
 
+func (m *mockRedirectService) RecordLinkPreview(ctx context.Context, linkID uuid.UUID, reason string, req stats.CreateLinkStatRequest) error {
+	return m.RecordLinkPreviewFunc(ctx, linkID, reason, req)
+}
+
+func (m *mockRedirectService) UnlockLink(ctx context.Context, code, password string, visitor Visitor) (*Destination, error) {
+	return m.UnlockLinkFunc(ctx, code, password, visitor)
+}
+
+func (m *mockRedirectService) GetLinkInfo(ctx context.Context, host, code string) (*LinkInfo, error) {
+	return m.GetLinkInfoFunc(ctx, host, code)
+}
+
 func TestRedirectHandler_RedirectToOriginalURL(t *testing.T) {
 	linkID := uuid.New()
 	originalURL := "https://example.com/very/long/url"
@@ -65,9 +88,9 @@ func TestRedirectHandler_RedirectToOriginalURL(t *testing.T) {
 			name:      "Success",
 			codeParam: testCode,
 			setupMock: func(mock *mockRedirectService, recordCalled chan bool) {
-				mock.GetOriginalURLFunc = func(ctx context.Context, code string) (string, uuid.UUID, bool, error) {
+				mock.GetOriginalURLFunc = func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
 					require.Equal(t, testCode, code)
-					return originalURL, linkID, true, nil
+					return &Destination{URL: originalURL, LinkID: linkID, IsActive: true}, nil
 				}
 				mock.RecordLinkStatFunc = func(ctx context.Context, id uuid.UUID, req stats.CreateLinkStatRequest) error {
 					require.Equal(t, linkID, id)
@@ -84,8 +107,8 @@ func TestRedirectHandler_RedirectToOriginalURL(t *testing.T) {
 			name:      "Link Not Found",
 			codeParam: "notfound",
 			setupMock: func(mock *mockRedirectService, recordCalled chan bool) {
-				mock.GetOriginalURLFunc = func(ctx context.Context, code string) (string, uuid.UUID, bool, error) {
-					return "", uuid.Nil, false, commons.ErrLinkNotFound
+				mock.GetOriginalURLFunc = func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					return nil, commons.ErrLinkNotFound
 				}
 			},
 			expectedStatus:       http.StatusNotFound,
@@ -95,54 +118,79 @@ func TestRedirectHandler_RedirectToOriginalURL(t *testing.T) {
 			name:      "Link Not Active",
 			codeParam: testCode,
 			setupMock: func(mock *mockRedirectService, recordCalled chan bool) {
-				mock.GetOriginalURLFunc = func(ctx context.Context, code string) (string, uuid.UUID, bool, error) {
+				mock.GetOriginalURLFunc = func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
 					// Skenario 1: Service mengembalikan error
-					return "", uuid.Nil, false, commons.ErrLinkNotActive
+					return nil, commons.ErrLinkNotActive
 				}
 			},
 			expectedStatus:       http.StatusForbidden,
 			expectedBodyContains: "Link is inactive",
 		},
 		{
-			name:      "Link Not Active - Second Check",
+			name:      "Link Expired",
 			codeParam: testCode,
 			setupMock: func(mock *mockRedirectService, recordCalled chan bool) {
-				mock.GetOriginalURLFunc = func(ctx context.Context, code string) (string, uuid.UUID, bool, error) {
-					// Skenario 2: Service mengembalikan isActive = false
-					return originalURL, linkID, false, nil
+				mock.GetOriginalURLFunc = func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					return nil, commons.ErrLinkExpired
 				}
 			},
-			expectedStatus:       http.StatusForbidden,
-			expectedBodyContains: "Link is inactive",
+			expectedStatus:       http.StatusGone,
+			expectedBodyContains: "Link has expired",
 		},
 		{
-			name:      "Link Expired",
+			name:      "Link Not Yet Available",
 			codeParam: testCode,
 			setupMock: func(mock *mockRedirectService, recordCalled chan bool) {
-				mock.GetOriginalURLFunc = func(ctx context.Context, code string) (string, uuid.UUID, bool, error) {
-					return "", uuid.Nil, false, commons.ErrLinkExpired
+				mock.GetOriginalURLFunc = func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					return nil, commons.ErrLinkNotYetActive
+				}
+				mock.GetLinkInfoFunc = func(ctx context.Context, host, code string) (*LinkInfo, error) {
+					startsAt := time.Date(2030, time.March, 1, 9, 0, 0, 0, time.UTC)
+					return &LinkInfo{ShortCode: code, StartsAt: &startsAt}, nil
 				}
 			},
-			expectedStatus:       http.StatusGone,
-			expectedBodyContains: "Link has expired",
+			expectedStatus:       http.StatusForbidden,
+			expectedBodyContains: "March 1, 2030 at 09:00 UTC",
 		},
 		{
 			name:      "Click Limit Exceeded",
 			codeParam: testCode,
 			setupMock: func(mock *mockRedirectService, recordCalled chan bool) {
-				mock.GetOriginalURLFunc = func(ctx context.Context, code string) (string, uuid.UUID, bool, error) {
-					return "", uuid.Nil, false, commons.ErrClickLimitExceeded
+				mock.GetOriginalURLFunc = func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					return nil, commons.ErrClickLimitExceeded
 				}
 			},
-			expectedStatus:       http.StatusTooManyRequests,
+			expectedStatus:       http.StatusGone,
 			expectedBodyContains: "Click limit exceeded",
 		},
+		{
+			name:      "Expired Link With Fallback URL",
+			codeParam: testCode,
+			setupMock: func(mock *mockRedirectService, recordCalled chan bool) {
+				mock.GetOriginalURLFunc = func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					return nil, &UnavailableError{Reason: commons.ErrLinkExpired, FallbackURL: "https://example.com/sale-is-over"}
+				}
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://example.com/sale-is-over",
+		},
+		{
+			name:      "Blocked By Rule",
+			codeParam: testCode,
+			setupMock: func(mock *mockRedirectService, recordCalled chan bool) {
+				mock.GetOriginalURLFunc = func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					return nil, commons.ErrLinkBlocked
+				}
+			},
+			expectedStatus:       http.StatusForbidden,
+			expectedBodyContains: "not available",
+		},
 		{
 			name:      "Generic Service Error",
 			codeParam: testCode,
 			setupMock: func(mock *mockRedirectService, recordCalled chan bool) {
-				mock.GetOriginalURLFunc = func(ctx context.Context, code string) (string, uuid.UUID, bool, error) {
-					return "", uuid.Nil, false, errors.New("database connection lost")
+				mock.GetOriginalURLFunc = func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					return nil, errors.New("database connection lost")
 				}
 			},
 			expectedStatus:       http.StatusInternalServerError,
@@ -153,7 +201,7 @@ func TestRedirectHandler_RedirectToOriginalURL(t *testing.T) {
 			codeParam:            "",
 			setupMock:            func(mock *mockRedirectService, recordCalled chan bool) {},
 			expectedStatus:       http.StatusNotFound,
-			expectedBodyContains: "Cannot GET /",
+			expectedBodyContains: "Link not found",
 		},
 	}
 
@@ -163,10 +211,10 @@ func TestRedirectHandler_RedirectToOriginalURL(t *testing.T) {
 			recordCalled := make(chan bool, 1)
 			tc.setupMock(mockService, recordCalled)
 
-			handler := NewRedirectHandler(mockService, admin.newTestLogger())
+			handler := NewRedirectHandler(mockService, nil, config.ServerConfig{}, config.ShortCodeConfig{}, testutil.NewLogger())
 
 			app := fiber.New()
-			app.Get("/:code", handler.RedirectToOriginalURL)
+			app.Get("/*", handler.RedirectToOriginalURL)
 
 			req := httptest.NewRequest(http.MethodGet, "/"+tc.codeParam, nil)
 			// FIX: app.Test timeout adalah int dalam milidetik, bukan time.Duration.
@@ -199,3 +247,538 @@ func TestRedirectHandler_RedirectToOriginalURL(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectHandler_PreviewRequestsDoNotCountAsClicks(t *testing.T) {
+	linkID := uuid.New()
+	originalURL := "https://example.com/very/long/url"
+
+	testCases := []struct {
+		name           string
+		method         string
+		headers        map[string]string
+		expectedReason string
+	}{
+		{
+			name:           "Slack unfurler",
+			method:         http.MethodGet,
+			headers:        map[string]string{"User-Agent": "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"},
+			expectedReason: stats.PreviewReasonBot,
+		},
+		{
+			name:           "Discord unfurler",
+			method:         http.MethodGet,
+			headers:        map[string]string{"User-Agent": "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)"},
+			expectedReason: stats.PreviewReasonBot,
+		},
+		{
+			name:   "Chrome prefetch",
+			method: http.MethodGet,
+			headers: map[string]string{
+				"User-Agent":  "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+				"Sec-Purpose": "prefetch;prerender",
+			},
+			expectedReason: stats.PreviewReasonPrefetch,
+		},
+		{
+			name:           "Purpose header",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Purpose": "prefetch"},
+			expectedReason: stats.PreviewReasonPrefetch,
+		},
+		{
+			name:           "HEAD request",
+			method:         http.MethodHead,
+			expectedReason: stats.PreviewReasonHead,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var recordedReason string
+			mockService := &mockRedirectService{
+				GetOriginalURLFunc: func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					t.Fatal("preview request must not consume a click")
+					return nil, nil
+				},
+				RecordLinkStatFunc: func(ctx context.Context, id uuid.UUID, req stats.CreateLinkStatRequest) error {
+					t.Fatal("preview request must not be recorded as a click")
+					return nil
+				},
+				GetPreviewURLFunc: func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					return &Destination{URL: originalURL, LinkID: linkID, IsActive: true}, nil
+				},
+				RecordLinkPreviewFunc: func(ctx context.Context, id uuid.UUID, reason string, req stats.CreateLinkStatRequest) error {
+					require.Equal(t, linkID, id)
+					recordedReason = reason
+					return nil
+				},
+			}
+
+			handler := NewRedirectHandler(mockService, nil, config.ServerConfig{}, config.ShortCodeConfig{}, testutil.NewLogger())
+			app := fiber.New()
+			app.Get("/*", handler.RedirectToOriginalURL)
+
+			req := httptest.NewRequest(tc.method, "/abcdef", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := app.Test(req, 10000)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusFound, resp.StatusCode)
+			require.Equal(t, originalURL, resp.Header.Get("Location"))
+			require.Equal(t, tc.expectedReason, recordedReason)
+		})
+	}
+}
+
+func TestRedirectHandler_HTTPClientsCountAsClicks(t *testing.T) {
+	linkID := uuid.New()
+	originalURL := "https://api.example.com/v1/hook"
+
+	consumed, recorded := false, false
+	mockService := &mockRedirectService{
+		GetOriginalURLFunc: func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+			consumed = true
+			return &Destination{URL: originalURL, LinkID: linkID, IsActive: true, Status: http.StatusTemporaryRedirect}, nil
+		},
+		RecordLinkStatFunc: func(ctx context.Context, id uuid.UUID, req stats.CreateLinkStatRequest) error {
+			recorded = true
+			return nil
+		},
+		GetPreviewURLFunc: func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+			t.Fatal("a script following a link is a click, not a preview")
+			return nil, nil
+		},
+	}
+
+	handler := NewRedirectHandler(mockService, nil, config.ServerConfig{}, config.ShortCodeConfig{}, testutil.NewLogger())
+	app := fiber.New()
+	app.Get("/*", handler.RedirectToOriginalURL)
+
+	req := httptest.NewRequest(http.MethodGet, "/hook", nil)
+	req.Header.Set("User-Agent", "curl/8.5.0")
+	resp, err := app.Test(req, 10000)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	require.Equal(t, originalURL, resp.Header.Get("Location"))
+	require.True(t, consumed)
+	require.True(t, recorded)
+}
+
+func TestRedirectHandler_SocialCard(t *testing.T) {
+	linkID := uuid.New()
+	originalURL := "https://example.com/sale?a=1&b=2"
+	card := &cache.SocialCard{
+		Title:       `Summer "sale" <now>`,
+		Description: "Everything must go",
+		ImageURL:    "https://cdn.example.com/sale.png",
+	}
+	slackbot := "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"
+
+	testCases := []struct {
+		name      string
+		userAgent string
+		card      *cache.SocialCard
+		wantCard  bool
+	}{
+		{name: "Unfurler gets the card", userAgent: slackbot, card: card, wantCard: true},
+		{name: "Crawler is redirected", userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", card: card},
+		{name: "Link without a card", userAgent: slackbot},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var recorded bool
+			mockService := &mockRedirectService{
+				GetPreviewURLFunc: func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					return &Destination{URL: originalURL, LinkID: linkID, IsActive: true, SocialCard: tc.card}, nil
+				},
+				RecordLinkPreviewFunc: func(ctx context.Context, id uuid.UUID, reason string, req stats.CreateLinkStatRequest) error {
+					recorded = true
+					return nil
+				},
+			}
+
+			handler := NewRedirectHandler(mockService, nil, config.ServerConfig{}, config.ShortCodeConfig{}, testutil.NewLogger())
+			app := fiber.New()
+			app.Get("/*", handler.RedirectToOriginalURL)
+
+			req := httptest.NewRequest(http.MethodGet, "/sale", nil)
+			req.Header.Set("User-Agent", tc.userAgent)
+			resp, err := app.Test(req, 10000)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.True(t, recorded, "the unfurl is recorded as a preview")
+
+			if !tc.wantCard {
+				require.Equal(t, http.StatusFound, resp.StatusCode)
+				require.Equal(t, originalURL, resp.Header.Get("Location"))
+				return
+			}
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			page := string(body)
+			require.Contains(t, page, `<meta property="og:title" content="Summer &#34;sale&#34; &lt;now&gt;">`)
+			require.Contains(t, page, `<meta property="og:description" content="Everything must go">`)
+			require.Contains(t, page, `<meta property="og:image" content="https://cdn.example.com/sale.png">`)
+			require.Contains(t, page, `<meta name="twitter:card" content="summary_large_image">`)
+			require.Contains(t, page, `<meta http-equiv="refresh" content="0; url=https://example.com/sale?a=1&amp;b=2">`)
+		})
+	}
+}
+
+func TestRedirectHandler_PasswordProtectedLink(t *testing.T) {
+	linkID := uuid.New()
+	originalURL := "https://example.com/internal.pdf"
+
+	var recorded bool
+	mockService := &mockRedirectService{
+		GetOriginalURLFunc: func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+			return nil, commons.ErrLinkPasswordRequired
+		},
+		UnlockLinkFunc: func(ctx context.Context, code, password string, visitor Visitor) (*Destination, error) {
+			require.Equal(t, "docs", code)
+			if password != "s3cret" {
+				return nil, commons.ErrInvalidLinkPassword
+			}
+			return &Destination{URL: originalURL, LinkID: linkID, IsActive: true}, nil
+		},
+		RecordLinkStatFunc: func(ctx context.Context, id uuid.UUID, req stats.CreateLinkStatRequest) error {
+			require.Equal(t, linkID, id)
+			recorded = true
+			return nil
+		},
+	}
+
+	handler := NewRedirectHandler(mockService, nil, config.ServerConfig{}, config.ShortCodeConfig{}, testutil.NewLogger())
+	app := fiber.New()
+	app.Get("/*", handler.RedirectToOriginalURL)
+	app.Post("/*", handler.UnlockProtectedLink)
+
+	// The form is shown instead of a redirect
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/docs", nil), 10000)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+	body, _ := io.ReadAll(resp.Body)
+	require.Contains(t, string(body), `<form method="POST" action="/docs">`)
+	require.Empty(t, resp.Header.Get("Location"))
+
+	// A wrong password shows the form again with an error
+	req := httptest.NewRequest(http.MethodPost, "/docs", strings.NewReader("password=nope"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = app.Test(req, 10000)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	body, _ = io.ReadAll(resp.Body)
+	require.Contains(t, string(body), "Incorrect password")
+	require.False(t, recorded)
+
+	// The right password redirects and records the click
+	req = httptest.NewRequest(http.MethodPost, "/docs", strings.NewReader("password=s3cret"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = app.Test(req, 10000)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	require.Equal(t, originalURL, resp.Header.Get("Location"))
+	require.True(t, recorded)
+}
+
+func TestRedirectHandler_PreviewPage(t *testing.T) {
+	linkID := uuid.New()
+	title := "Quarterly report"
+	description := "Shared in #finance"
+
+	tests := []struct {
+		name           string
+		path           string
+		originalURLErr error
+		info           *LinkInfo
+		infoErr        error
+		expectedStatus int
+		expectedBody   []string
+		unexpectedBody []string
+	}{
+		{
+			name: "Plus suffix renders the page",
+			path: "/report+",
+			info: &LinkInfo{
+				ID:          linkID,
+				ShortCode:   "report",
+				OriginalURL: "https://example.com/q3.pdf",
+				Title:       &title,
+				Description: &description,
+				CreatedAt:   time.Date(2025, time.March, 4, 10, 0, 0, 0, time.UTC),
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"https://example.com/q3.pdf", "Quarterly report", "Shared in #finance", "March 4, 2025", "Active", `action="/report"`},
+		},
+		{
+			name: "Preview query renders the page",
+			path: "/report?preview",
+			info: &LinkInfo{
+				ID:          linkID,
+				ShortCode:   "report",
+				OriginalURL: "https://example.com/q3.pdf",
+				Unavailable: commons.ErrLinkExpired,
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"https://example.com/q3.pdf", "Expired"},
+			unexpectedBody: []string{"<form"},
+		},
+		{
+			name:           "Forced interstitial renders the page",
+			path:           "/report",
+			originalURLErr: commons.ErrInterstitialRequired,
+			info: &LinkInfo{
+				ID:          linkID,
+				ShortCode:   "report",
+				OriginalURL: "https://example.com/q3.pdf",
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"https://example.com/q3.pdf", `action="/report"`},
+		},
+		{
+			name: "Protected destination is hidden",
+			path: "/report+",
+			info: &LinkInfo{
+				ID:        linkID,
+				ShortCode: "report",
+				Protected: true,
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"password protected", `href="/report"`},
+		},
+		{
+			name:           "Unknown code",
+			path:           "/missing+",
+			infoErr:        commons.ErrLinkNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   []string{"Link not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var previews []string
+			mockService := &mockRedirectService{
+				GetOriginalURLFunc: func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					require.Equal(t, "report", code)
+					return nil, tt.originalURLErr
+				},
+				GetLinkInfoFunc: func(ctx context.Context, host, code string) (*LinkInfo, error) {
+					return tt.info, tt.infoErr
+				},
+				RecordLinkPreviewFunc: func(ctx context.Context, id uuid.UUID, reason string, req stats.CreateLinkStatRequest) error {
+					previews = append(previews, reason)
+					return nil
+				},
+				RecordLinkStatFunc: func(ctx context.Context, id uuid.UUID, req stats.CreateLinkStatRequest) error {
+					t.Fatal("the preview page must not record a click")
+					return nil
+				},
+			}
+
+			handler := NewRedirectHandler(mockService, nil, config.ServerConfig{}, config.ShortCodeConfig{}, testutil.NewLogger())
+			app := fiber.New()
+			app.Get("/*", handler.RedirectToOriginalURL)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil), 10000)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedStatus, resp.StatusCode)
+			require.Empty(t, resp.Header.Get("Location"))
+
+			body, _ := io.ReadAll(resp.Body)
+			for _, want := range tt.expectedBody {
+				require.Contains(t, string(body), want)
+			}
+			for _, unwanted := range tt.unexpectedBody {
+				require.NotContains(t, string(body), unwanted)
+			}
+
+			if tt.info != nil {
+				require.Equal(t, []string{stats.PreviewReasonPage}, previews)
+			}
+		})
+	}
+}
+
+func TestRedirectHandler_SlugPaths(t *testing.T) {
+	testCases := []struct {
+		name            string
+		path            string
+		caseInsensitive bool
+		expectedCode    string
+	}{
+		{name: "Path-style code", path: "/launch/2026", expectedCode: "launch/2026"},
+		{name: "Trailing slash", path: "/launch/2026/", expectedCode: "launch/2026"},
+		{name: "Percent-encoded emoji", path: "/%F0%9F%9A%80-sale", expectedCode: "🚀-sale"},
+		{name: "Decomposed accent", path: "/cafe%CC%81", expectedCode: "caf\u00e9"},
+		{name: "Case kept by default", path: "/Launch", expectedCode: "Launch"},
+		{name: "Case folded", path: "/Launch/2026", caseInsensitive: true, expectedCode: "launch/2026"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requested string
+			mockService := &mockRedirectService{
+				GetOriginalURLFunc: func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					requested = code
+					return &Destination{URL: "https://example.com", LinkID: uuid.New(), IsActive: true}, nil
+				},
+				RecordLinkStatFunc: func(ctx context.Context, id uuid.UUID, req stats.CreateLinkStatRequest) error {
+					return nil
+				},
+			}
+
+			codeCfg := config.ShortCodeConfig{CaseInsensitive: tc.caseInsensitive}
+			handler := NewRedirectHandler(mockService, nil, config.ServerConfig{}, codeCfg, testutil.NewLogger())
+			app := fiber.New()
+			app.Get("/*", handler.RedirectToOriginalURL)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tc.path, nil), 10000)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusFound, resp.StatusCode)
+			require.Equal(t, tc.expectedCode, requested)
+		})
+	}
+}
+
+func TestRedirectHandler_StickyVariant(t *testing.T) {
+	linkID := uuid.New()
+	variantID := uuid.New()
+	landing := "https://example.com/landing-b"
+
+	var recordedVariant *uuid.UUID
+	var cookieSeen string
+	mockService := &mockRedirectService{
+		GetOriginalURLFunc: func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+			cookieSeen = visitor.VariantCookie
+			return &Destination{URL: landing, LinkID: linkID, IsActive: true, VariantID: &variantID, Sticky: true}, nil
+		},
+		RecordLinkStatFunc: func(ctx context.Context, id uuid.UUID, req stats.CreateLinkStatRequest) error {
+			recordedVariant = req.VariantID
+			return nil
+		},
+	}
+
+	handler := NewRedirectHandler(mockService, nil, config.ServerConfig{}, config.ShortCodeConfig{}, testutil.NewLogger())
+	app := fiber.New()
+	app.Get("/*", handler.RedirectToOriginalURL)
+
+	req := httptest.NewRequest(http.MethodGet, "/launch", nil)
+	req.AddCookie(&http.Cookie{Name: VariantCookie, Value: variantID.String()})
+	resp, err := app.Test(req, 10000)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.Equal(t, landing, resp.Header.Get("Location"))
+	require.Equal(t, variantID.String(), cookieSeen)
+	require.NotNil(t, recordedVariant)
+	require.Equal(t, variantID, *recordedVariant)
+
+	cookie := resp.Header.Get("Set-Cookie")
+	require.Contains(t, cookie, VariantCookie+"="+variantID.String())
+	require.Contains(t, cookie, "path=/launch")
+	require.Contains(t, cookie, "HttpOnly")
+}
+
+func TestRedirectHandler_RedirectStatus(t *testing.T) {
+	linkID := uuid.New()
+	destinationURL := "https://api.example.com/v2/orders"
+
+	testCases := []struct {
+		name                 string
+		method               string
+		contentType          string
+		linkStatus           int
+		defaultStatus        int
+		expectedStatus       int
+		expectedCacheControl string
+	}{
+		{
+			name:                 "Server default is used when the link has no status",
+			method:               http.MethodGet,
+			defaultStatus:        http.StatusTemporaryRedirect,
+			expectedStatus:       http.StatusTemporaryRedirect,
+			expectedCacheControl: "private, no-cache",
+		},
+		{
+			name:                 "Found without any configuration",
+			method:               http.MethodGet,
+			expectedStatus:       http.StatusFound,
+			expectedCacheControl: "private, no-cache",
+		},
+		{
+			name:                 "Permanent redirect may be cached",
+			method:               http.MethodGet,
+			linkStatus:           http.StatusMovedPermanently,
+			defaultStatus:        http.StatusFound,
+			expectedStatus:       http.StatusMovedPermanently,
+			expectedCacheControl: "public, max-age=3600",
+		},
+		{
+			name:                 "API clients keep their POST on 308",
+			method:               http.MethodPost,
+			contentType:          fiber.MIMEApplicationJSON,
+			linkStatus:           http.StatusPermanentRedirect,
+			expectedStatus:       http.StatusPermanentRedirect,
+			expectedCacheControl: "public, max-age=3600",
+		},
+		{
+			name:                 "Form posts continue with a GET",
+			method:               http.MethodPost,
+			contentType:          fiber.MIMEApplicationForm,
+			linkStatus:           http.StatusPermanentRedirect,
+			expectedStatus:       http.StatusSeeOther,
+			expectedCacheControl: "private, no-cache",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := func() (*Destination, error) {
+				return &Destination{URL: destinationURL, LinkID: linkID, IsActive: true, Status: tc.linkStatus}, nil
+			}
+			mockService := &mockRedirectService{
+				GetOriginalURLFunc: func(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+					return destination()
+				},
+				UnlockLinkFunc: func(ctx context.Context, code, password string, visitor Visitor) (*Destination, error) {
+					return destination()
+				},
+				RecordLinkStatFunc: func(ctx context.Context, id uuid.UUID, req stats.CreateLinkStatRequest) error {
+					return nil
+				},
+			}
+
+			cfg := config.ServerConfig{RedirectStatus: tc.defaultStatus, PermanentRedirectMaxAge: time.Hour}
+			handler := NewRedirectHandler(mockService, nil, cfg, config.ShortCodeConfig{}, testutil.NewLogger())
+			app := fiber.New()
+			app.Get("/*", handler.RedirectToOriginalURL)
+			app.Post("/*", handler.UnlockProtectedLink)
+
+			req := httptest.NewRequest(tc.method, "/orders", strings.NewReader(`{"id":1}`))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			resp, err := app.Test(req, 10000)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, tc.expectedStatus, resp.StatusCode)
+			require.Equal(t, destinationURL, resp.Header.Get("Location"))
+			require.Equal(t, tc.expectedCacheControl, resp.Header.Get("Cache-Control"))
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package redirect
+
+import (
+	"GoShort/config"
+	"GoShort/pkg/logger"
+	"GoShort/pkg/redis"
+	"context"
+)
+
+// IPasswordAttempts throttles password attempts on protected links, per client IP and
+// per link.
+type IPasswordAttempts interface {
+	// Attempt counts an attempt before its password is checked and reports whether it may
+	// go ahead. It returns an error when the attempt could not be counted.
+	Attempt(ctx context.Context, code, ip string) (bool, error)
+	// Succeeded clears the failures of the IP and takes back its attempt on the link.
+	Succeeded(ctx context.Context, code, ip string)
+}
+
+// PasswordAttempts keeps the attempt counters in Redis so throttling holds across
+// instances. Every attempt is counted first and the count it reached decides, so
+// concurrent attempts cannot slip past the limit. When Redis cannot count an attempt
+// the attempt is refused.
+type PasswordAttempts struct {
+	rds redis.RdsClient
+	cfg config.LinkPasswordConfig
+	log *logger.Logger
+}
+
+func NewPasswordAttempts(rds redis.RdsClient, cfg config.LinkPasswordConfig, log *logger.Logger) IPasswordAttempts {
+	return &PasswordAttempts{
+		rds: rds,
+		cfg: cfg,
+		log: log,
+	}
+}
+
+func passwordAttemptsKey(code, ip string) string {
+	return "link:password:attempts:" + code + ":" + ip
+}
+
+// linkPasswordAttemptsKey counts the attempts on a link from all IPs together, so a
+// client spreading its guesses over many addresses is still stopped.
+func linkPasswordAttemptsKey(code string) string {
+	return "link:password:link-attempts:" + code
+}
+
+// Attempt counts an attempt against the IP and the link limits.
+func (a *PasswordAttempts) Attempt(ctx context.Context, code, ip string) (bool, error) {
+	if a.rds == nil {
+		return true, nil
+	}
+
+	if a.cfg.MaxAttempts > 0 {
+		attempts, err := a.rds.Incr(ctx, passwordAttemptsKey(code, ip), a.cfg.LockoutWindow)
+		if err != nil {
+			return false, err
+		}
+		if attempts > int64(a.cfg.MaxAttempts) {
+			return false, nil
+		}
+	}
+
+	if a.cfg.MaxLinkAttempts > 0 {
+		attempts, err := a.rds.Incr(ctx, linkPasswordAttemptsKey(code), a.cfg.LockoutWindow)
+		if err != nil {
+			return false, err
+		}
+		if attempts > int64(a.cfg.MaxLinkAttempts) {
+			a.log.Warn("too many password attempts on link from all IPs", "code", code)
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// Succeeded is called after a correct password, so visitors who know it never use up
+// the link limit.
+func (a *PasswordAttempts) Succeeded(ctx context.Context, code, ip string) {
+	if a.rds == nil {
+		return
+	}
+
+	if a.cfg.MaxAttempts > 0 {
+		if err := a.rds.Del(ctx, passwordAttemptsKey(code, ip)); err != nil {
+			a.log.Warn("failed to reset password attempts", "code", code, "error", err)
+		}
+	}
+
+	if a.cfg.MaxLinkAttempts > 0 {
+		if _, err := a.rds.Decr(ctx, linkPasswordAttemptsKey(code)); err != nil {
+			a.log.Warn("failed to take back password attempt", "code", code, "error", err)
+		}
+	}
+}
@@ -1,120 +1,594 @@
 package redirect
 
 import (
+	"GoShort/internal/cache"
 	"GoShort/internal/commons"
 	"GoShort/internal/datastore"
+	"GoShort/internal/linkrule"
+	"GoShort/internal/linkschedule"
+	"GoShort/internal/linkvariant"
+	"GoShort/pkg/geoip"
+	"GoShort/pkg/helper"
+	"GoShort/pkg/rules"
+	"GoShort/pkg/useragent"
 	"errors"
+	"strings"
 
 	"GoShort/internal/stats"
 	"GoShort/pkg/logger"
+	"GoShort/pkg/security"
 	"context"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgtype"
 
 	"time"
 )
 
 type IService interface {
-	GetOriginalURL(ctx context.Context, code string) (originalUrl string, linkID uuid.UUID, isActive bool, err error)
+	GetOriginalURL(ctx context.Context, code string, visitor Visitor) (*Destination, error)
+	GetPreviewURL(ctx context.Context, code string, visitor Visitor) (*Destination, error)
+	UnlockLink(ctx context.Context, code, password string, visitor Visitor) (*Destination, error)
+	GetLinkInfo(ctx context.Context, host, code string) (*LinkInfo, error)
 	RecordLinkStat(ctx context.Context, linkID uuid.UUID, info stats.CreateLinkStatRequest) error
+	RecordLinkPreview(ctx context.Context, linkID uuid.UUID, reason string, info stats.CreateLinkStatRequest) error
+}
+
+// Visitor is the request the routing rules of a link are evaluated against.
+type Visitor struct {
+	// Host is the Host header; custom domains resolve their own short codes
+	Host string
+	IP   string
+	// Country comes from the CDN; the GeoIP resolver is used when it is empty
+	Country        string
+	UserAgent      string
+	AcceptLanguage string
+	// VariantCookie is the variant a sticky A/B test assigned on an earlier visit
+	VariantCookie string
+	// Query is the raw query string of the short URL
+	Query string
+}
+
+// Destination is where a click is sent.
+type Destination struct {
+	URL      string
+	LinkID   uuid.UUID
+	IsActive bool
+	// VariantID is the A/B variant served, nil when the link has none or a rule matched
+	VariantID *uuid.UUID
+	// Sticky asks the handler to remember the variant in the visitor's cookie
+	Sticky bool
+	// Status is the redirect status chosen by the owner, 0 for the server default
+	Status int
+	// AliasID is the alias the visitor came through, nil for the link's own code
+	AliasID *uuid.UUID
+	// SocialCard is set for unfurlers when the owner customized how the link is shared
+	SocialCard *cache.SocialCard
+}
+
+// LinkInfo describes a short link on its preview page.
+type LinkInfo struct {
+	ID        uuid.UUID
+	ShortCode string
+	// OriginalURL is empty for password protected links
+	OriginalURL string
+	Title       *string
+	Description *string
+	CreatedAt   time.Time
+	// StartsAt is when a link created ahead of a launch becomes available
+	StartsAt  *time.Time
+	Protected bool
+	// Unavailable is the reason the link cannot be followed, nil when it is usable
+	Unavailable error
 }
 
 type Service struct {
-	repo datastore.Querier
-	log  *logger.Logger
+	repo     datastore.Querier
+	cache    cache.ILinkCache
+	clicks   stats.IClickPipeline
+	attempts IPasswordAttempts
+	geo      geoip.GeoResolver
+	hosts    *hostDomains
+	log      *logger.Logger
 }
 
-func NewService(repo datastore.Querier, log *logger.Logger) IService {
+func NewService(repo datastore.Querier, linkCache cache.ILinkCache, clicks stats.IClickPipeline, attempts IPasswordAttempts, geo geoip.GeoResolver, log *logger.Logger) IService {
 	return &Service{
-		repo: repo,
-		log:  log,
+		repo:     repo,
+		cache:    linkCache,
+		clicks:   clicks,
+		attempts: attempts,
+		geo:      geo,
+		hosts:    newHostDomains(repo, hostCacheTTL),
+		log:      log,
 	}
 }
 
-func (s *Service) GetOriginalURL(ctx context.Context, code string) (originalUrl string, linkID uuid.UUID, isActive bool, err error) {
-	link, err := s.repo.GetShortLinkByCode(ctx, code)
+// lookup identifies the link a short code refers to on the requested host.
+func (s *Service) lookup(ctx context.Context, host, code string) (linkRef, error) {
+	domainID, err := s.hosts.resolve(ctx, host)
+	if err != nil {
+		s.log.Error("failed to resolve custom domain", "host", host, "error", err)
+		return linkRef{}, err
+	}
+	return linkRef{domainID: domainID, code: code}, nil
+}
+
+// getShortLink reads a link from the database, on its custom domain when it has one.
+func (s *Service) getShortLink(ctx context.Context, ref linkRef) (datastore.ShortLink, error) {
+	if ref.domainID.Valid {
+		return s.repo.GetShortLinkByDomainAndCode(ctx, datastore.GetShortLinkByDomainAndCodeParams{
+			DomainID:  ref.domainID,
+			ShortCode: ref.code,
+		})
+	}
+	return s.repo.GetShortLinkByCode(ctx, ref.code)
+}
+
+// getAlias reads the alias of a code from the database.
+func (s *Service) getAlias(ctx context.Context, ref linkRef) (datastore.GetLinkAliasByCodeRow, error) {
+	return s.repo.GetLinkAliasByCode(ctx, datastore.GetLinkAliasByCodeParams{
+		ShortCode: ref.code,
+		DomainID:  ref.domainID,
+	})
+}
+
+// resolveLink looks the short code up in the cache first and falls back to the database,
+// populating the cache (including negative entries for unknown codes) on a miss. Alias
+// codes resolve to their link; the returned ref then names the link's own code.
+func (s *Service) resolveLink(ctx context.Context, host, code string) (*cache.CachedLink, linkRef, error) {
+	ref, err := s.lookup(ctx, host, code)
+	if err != nil {
+		return nil, ref, err
+	}
+	return s.loadLink(ctx, ref, false)
+}
+
+// loadLink resolves a ref through the cache and the database. viaAlias is set while
+// following an alias, whose link is never looked up as an alias again or negatively
+// cached.
+func (s *Service) loadLink(ctx context.Context, ref linkRef, viaAlias bool) (*cache.CachedLink, linkRef, error) {
+	code := ref.code
+	link, err := s.cache.Get(ctx, ref.key())
+	switch {
+	case err == nil && link.AliasOf == "":
+		return link, ref, nil
+	case err == nil && !viaAlias:
+		return s.followAlias(ctx, ref, *link.AliasID, link.AliasOf)
+	case err == nil:
+		// The link was renamed and the entry of its old code not invalidated yet
+		s.log.Warn("alias points to another alias", "code", code)
+		return nil, ref, commons.ErrLinkNotFound
+	case errors.Is(err, commons.ErrLinkNotFound):
+		s.log.Warn("link not found (cached)", "code", code)
+		return nil, ref, commons.ErrLinkNotFound
+	}
+
+	dbLink, err := s.getShortLink(ctx, ref)
+	if errors.Is(err, pgx.ErrNoRows) && !viaAlias {
+		alias, aliasErr := s.getAlias(ctx, ref)
+		if aliasErr == nil {
+			_ = s.cache.Set(ctx, ref.key(), cache.NewAliasEntry(alias.ID, alias.LinkShortCode))
+			return s.followAlias(ctx, ref, alias.ID, alias.LinkShortCode)
+		}
+		if !errors.Is(aliasErr, pgx.ErrNoRows) {
+			err = aliasErr
+		}
+	}
 	if err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows):
 			s.log.Warn("link not found", "code", code)
-			return "", uuid.Nil, false, commons.ErrLinkNotFound
+			if !viaAlias {
+				_ = s.cache.SetNotFound(ctx, ref.key())
+			}
+			return nil, ref, commons.ErrLinkNotFound
 		default:
 			s.log.Error("failed to retrieve link by code", "code", code, "error", err)
-			return "", uuid.Nil, false, err
+			return nil, ref, err
 		}
 	}
 
+	link = cache.NewCachedLink(dbLink)
+
+	linkRules, err := s.repo.ListLinkRules(ctx, dbLink.ID)
+	if err != nil {
+		s.log.Error("failed to retrieve link rules", "code", code, "link_id", dbLink.ID, "error", err)
+		return nil, ref, err
+	}
+	link.Rules = linkrule.NewRules(linkRules)
+
+	variants, err := s.repo.ListLinkVariants(ctx, dbLink.ID)
+	if err != nil {
+		s.log.Error("failed to retrieve link variants", "code", code, "link_id", dbLink.ID, "error", err)
+		return nil, ref, err
+	}
+	link.Variants = linkvariant.NewVariants(variants)
+
+	schedules, err := s.repo.ListLinkSchedules(ctx, dbLink.ID)
+	if err != nil {
+		s.log.Error("failed to retrieve link schedule", "code", code, "link_id", dbLink.ID, "error", err)
+		return nil, ref, err
+	}
+	link.Schedule = linkschedule.NewPendingChanges(schedules)
+
+	_ = s.cache.Set(ctx, ref.key(), link)
+
+	return link, ref, nil
+}
+
+// followAlias resolves the link an alias code leads to. Clicks are attributed to the
+// alias through the returned ref.
+func (s *Service) followAlias(ctx context.Context, ref linkRef, aliasID uuid.UUID, linkCode string) (*cache.CachedLink, linkRef, error) {
+	link, target, err := s.loadLink(ctx, linkRef{domainID: ref.domainID, code: linkCode}, true)
+	if err != nil {
+		return nil, ref, err
+	}
+	target.aliasID = &aliasID
+	return link, target, nil
+}
+
+// checkLink resolves a short code and verifies the link can currently be followed.
+func (s *Service) checkLink(ctx context.Context, host, code string) (*cache.CachedLink, linkRef, error) {
+	link, ref, err := s.resolveLink(ctx, host, code)
+	if err != nil {
+		return nil, ref, err
+	}
+
+	if err := availability(link); err != nil {
+		s.log.Warn("attempted to access unavailable link", "code", code, "link_id", link.ID, "reason", err)
+		return nil, ref, withFallback(link, err)
+	}
+
+	return link, ref, nil
+}
+
+// UnavailableError is returned instead of the plain reason when the owner of an
+// unavailable link set a fallback URL. It unwraps to the reason, so errors.Is keeps
+// working on it.
+type UnavailableError struct {
+	Reason      error
+	FallbackURL string
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Reason.Error()
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Reason
+}
+
+// withFallback attaches the fallback URL of the link to the reason it cannot be
+// followed. Links that have not started yet keep their own page.
+func withFallback(link *cache.CachedLink, reason error) error {
+	if link.FallbackURL == nil || errors.Is(reason, commons.ErrLinkNotYetActive) {
+		return reason
+	}
+	return &UnavailableError{Reason: reason, FallbackURL: *link.FallbackURL}
+}
+
+// availability reports why a link cannot be followed right now, or nil if it can.
+func availability(link *cache.CachedLink) error {
 	// Check if the link is active
 	if !link.IsActive {
-		s.log.Warn("attempted to access inactive link", "code", code, "link_id", link.ID)
-		return "", uuid.Nil, false, commons.ErrLinkNotActive
+		return commons.ErrLinkNotActive
 	}
 
 	// Check if the link has expired
-	if link.ExpiredAt.Valid {
-		now := time.Now()
-		if link.ExpiredAt.Time.Before(now) {
-			s.log.Warn("attempted to access expired link", "code", code, "link_id", link.ID)
-			return "", uuid.Nil, false, commons.ErrLinkExpired
-		}
+	if link.ExpiredAt != nil && link.ExpiredAt.Before(time.Now()) {
+		return commons.ErrLinkExpired
 	}
 
-	// Check if the link has reached its click limit
+	// Links created ahead of a launch wait for their start time
+	if link.StartsAt != nil && link.StartsAt.After(time.Now()) {
+		return commons.ErrLinkNotYetActive
+	}
+
+	// The cached count only ever lags behind the database, so zero is already final
 	if link.ClickLimit != nil && *link.ClickLimit <= 0 {
-		s.log.Warn("attempted to access link with no remaining clicks ", "code: ", code, " link_id: ", link.ID)
-		return "", uuid.Nil, false, commons.ErrClickLimitExceeded
+		return commons.ErrClickLimitExceeded
+	}
+
+	return nil
+}
+
+func (s *Service) GetOriginalURL(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+	link, ref, err := s.checkLink(ctx, visitor.Host, code)
+	if err != nil {
+		return nil, err
+	}
+
+	// Protected links are only followed through UnlockLink
+	if link.PasswordHash != nil {
+		return nil, commons.ErrLinkPasswordRequired
+	}
+
+	// The owner wants visitors to confirm the destination on the preview page first
+	if link.ForceInterstitial {
+		return nil, commons.ErrInterstitialRequired
+	}
+
+	// Blocked visitors are turned away before a click is taken
+	destination, err := s.route(link, visitor)
+	if err != nil {
+		return nil, err
+	}
+	destination.AliasID = ref.aliasID
+
+	// Check if the link has reached its click limit
+	if link.ClickLimit != nil {
+		if err := s.consumeClick(ctx, ref, link); err != nil {
+			return nil, err
+		}
 	}
 
 	// Log the access
-	s.log.Info("redirecting to original URL", "code", code, "link_id", link.ID, "original_url", link.OriginalUrl)
+	s.log.Info("redirecting to original URL", "code", code, "link_id", link.ID, "original_url", destination.URL)
 
-	// Increment clicks asynchronously
-	go func() {
-		//if err := s.repo.IncrementLinkClicks(context.Background(), link.ID); err != nil {
-		//	s.log.Error("failed to increment link clicks", "error", err)
-		//}
+	return destination, nil
+}
 
-		if link.ClickLimit != nil && *link.ClickLimit > 0 {
-			if _, err := s.repo.DecrementClickLimit(ctx, link.ID); err != nil {
-				s.log.Error("failed to decrement link click limit", "error", err)
-			}
+// GetPreviewURL resolves a short code for an unfurler, crawler or prefetch. It applies
+// the same checks as GetOriginalURL but never takes a click from the click limit.
+func (s *Service) GetPreviewURL(ctx context.Context, code string, visitor Visitor) (*Destination, error) {
+	link, _, err := s.checkLink(ctx, visitor.Host, code)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never reveal the destination of a protected link to unfurlers
+	if link.PasswordHash != nil {
+		return nil, commons.ErrLinkPasswordRequired
+	}
+
+	destination, err := s.route(link, visitor)
+	if err != nil {
+		return nil, err
+	}
+	destination.SocialCard = link.SocialCard
+
+	return destination, nil
+}
+
+// UnlockLink verifies the password of a protected link and, when it matches, takes a
+// click exactly like GetOriginalURL. Password attempts are throttled per client IP and
+// per link.
+func (s *Service) UnlockLink(ctx context.Context, code, password string, visitor Visitor) (*Destination, error) {
+	link, ref, err := s.checkLink(ctx, visitor.Host, code)
+	if err != nil {
+		return nil, err
+	}
+
+	clientIP := visitor.IP
+
+	if link.PasswordHash != nil {
+		allowed, err := s.attempts.Attempt(ctx, ref.key(), clientIP)
+		if err != nil {
+			s.log.Error("failed to count password attempt", "code", code, "error", err)
+			return nil, err
+		}
+		if !allowed {
+			s.log.Warn("too many password attempts for link", "code", code, "ip", clientIP)
+			return nil, commons.ErrTooManyPasswordAttempts
+		}
+
+		if !security.CheckPassword(password, *link.PasswordHash) {
+			s.log.Warn("invalid password for protected link", "code", code, "ip", clientIP)
+			return nil, commons.ErrInvalidLinkPassword
+		}
+
+		s.attempts.Succeeded(ctx, ref.key(), clientIP)
+	}
+
+	destination, err := s.route(link, visitor)
+	if err != nil {
+		return nil, err
+	}
+	destination.AliasID = ref.aliasID
+
+	if link.ClickLimit != nil {
+		if err := s.consumeClick(ctx, ref, link); err != nil {
+			return nil, err
 		}
+	}
 
-	}()
+	s.log.Info("redirecting to original URL", "code", code, "link_id", link.ID, "original_url", destination.URL)
 
-	return link.OriginalUrl, link.ID, link.IsActive, nil
+	return destination, nil
 }
 
-// RecordLinkStat records a click in the link_stats table
-func (s *Service) RecordLinkStat(ctx context.Context, linkID uuid.UUID, info stats.CreateLinkStatRequest) error {
+// route returns where the visitor goes, with the link's UTM parameters and, when the
+// owner enabled passthrough, the visitor's query string added.
+func (s *Service) route(link *cache.CachedLink, visitor Visitor) (*Destination, error) {
+	destination, err := s.destination(link, visitor)
+	if err != nil {
+		return nil, err
+	}
+
+	passthrough := ""
+	if link.QueryPassthrough {
+		passthrough = visitor.Query
+	}
+	destination.URL = helper.MergeQuery(destination.URL, link.UTM, passthrough)
+	destination.Status = link.RedirectStatus
+
+	return destination, nil
+}
+
+// destination evaluates the routing rules of a link. The first matching rule wins;
+// without a match the visitor goes to one of the A/B variants, or to the original URL
+// when the link has none.
+func (s *Service) destination(link *cache.CachedLink, visitor Visitor) (*Destination, error) {
+	destination := &Destination{
+		URL:      link.CurrentURL(time.Now()),
+		LinkID:   link.ID,
+		IsActive: link.IsActive,
+	}
+	if len(link.Rules) == 0 {
+		return s.assignVariant(link, visitor, destination), nil
+	}
+
+	client := useragent.Parse(visitor.UserAgent)
+	rule := rules.Match(link.Rules, rules.Visitor{
+		Country:    s.country(visitor),
+		DeviceType: client.DeviceType,
+		OS:         client.OS,
+		Language:   rules.PreferredLanguage(visitor.AcceptLanguage),
+		Time:       time.Now(),
+	})
+	if rule == nil {
+		return s.assignVariant(link, visitor, destination), nil
+	}
+
+	if rule.Action == rules.ActionBlock {
+		s.log.Warn("visitor blocked by link rule", "link_id", link.ID, "rule_id", rule.ID)
+		return nil, commons.ErrLinkBlocked
+	}
+
+	destination.URL = rule.DestinationURL
+	return destination, nil
+}
+
+// assignVariant sends the visitor to an A/B variant when the link has any.
+func (s *Service) assignVariant(link *cache.CachedLink, visitor Visitor, destination *Destination) *Destination {
+	variant := pickVariant(link, visitor)
+	if variant == nil {
+		return destination
+	}
+
+	variantID := variant.ID
+	destination.URL = variant.URL
+	destination.VariantID = &variantID
+	destination.Sticky = link.VariantAssignment == linkvariant.AssignmentSticky
+	return destination
+}
 
-	recordUUID, err := uuid.NewV7()
+// country prefers the CDN country header and falls back to the GeoIP resolver.
+func (s *Service) country(visitor Visitor) string {
+	country := strings.ToUpper(strings.TrimSpace(visitor.Country))
+	// Cloudflare sends XX for unknown locations and T1 for Tor
+	if country != "" && country != "XX" && country != "T1" {
+		return country
+	}
+
+	if s.geo == nil {
+		return ""
+	}
+	addr, err := geoip.ParseAddr(visitor.IP)
 	if err != nil {
-		s.log.Error("failed to generate UUID for link stat", "error: ", err, "link_id: ", linkID)
-		return err
+		return ""
+	}
+	location, err := s.geo.Lookup(addr)
+	if err != nil {
+		return ""
+	}
+	return location.CountryCode
+}
+
+// GetLinkInfo returns what the preview page shows about a short code. It reads the
+// database directly, because the cache does not hold titles or descriptions, and never
+// takes a click. Inactive and expired links are described rather than rejected.
+func (s *Service) GetLinkInfo(ctx context.Context, host, code string) (*LinkInfo, error) {
+	ref, err := s.lookup(ctx, host, code)
+	if err != nil {
+		return nil, err
+	}
+
+	dbLink, err := s.getShortLink(ctx, ref)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var alias datastore.GetLinkAliasByCodeRow
+		if alias, err = s.getAlias(ctx, ref); err == nil {
+			dbLink, err = s.repo.GetShortLink(ctx, alias.LinkID)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.log.Warn("link not found", "code", code)
+			return nil, commons.ErrLinkNotFound
+		}
+		s.log.Error("failed to retrieve link by code", "code", code, "error", err)
+		return nil, err
+	}
+
+	info := &LinkInfo{
+		ID:          dbLink.ID,
+		ShortCode:   dbLink.ShortCode,
+		Title:       dbLink.Title,
+		Description: dbLink.Description,
+		CreatedAt:   dbLink.CreatedAt.Time,
+		Protected:   dbLink.PasswordHash != nil,
+		Unavailable: availability(cache.NewCachedLink(dbLink)),
+	}
+	if dbLink.StartsAt.Valid {
+		startsAt := dbLink.StartsAt.Time
+		info.StartsAt = &startsAt
 	}
 
-	params := datastore.CreateLinkStatParams{
-		ClickTime:  pgtype.Timestamptz{Time: time.Now(), Valid: true},
-		ID:         recordUUID,
-		LinkID:     linkID,
-		IpAddress:  info.IpAddress,
-		UserAgent:  info.UserAgent,
-		Referrer:   info.Referrer,
-		Country:    info.Country,
-		DeviceType: info.DeviceType,
+	// Never reveal the destination of a protected link
+	if !info.Protected {
+		info.OriginalURL = dbLink.OriginalUrl
 	}
 
-	// Insert the record
-	err = s.repo.CreateLinkStat(ctx, params)
+	return info, nil
+}
+
+// consumeClick takes one click from a limited link. The check and the decrement are a
+// single conditional UPDATE, so concurrent redirects can never overshoot the limit.
+func (s *Service) consumeClick(ctx context.Context, ref linkRef, link *cache.CachedLink) error {
+	// The cached count only ever lags behind the database, so zero is already final
+	if *link.ClickLimit <= 0 {
+		s.log.Warn("attempted to access link with no remaining clicks", "code", ref.code, "link_id", link.ID)
+		return withFallback(link, commons.ErrClickLimitExceeded)
+	}
+
+	_, err := s.repo.DecrementClickLimit(ctx, link.ID)
 	if err != nil {
-		s.log.Error("failed to record link stat", "error", err, "link_id", linkID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The next redirect reloads the exhausted count and stops before the UPDATE
+			s.log.Warn("click limit reached by concurrent redirect", "code", ref.code, "link_id", link.ID)
+			_ = s.cache.Invalidate(ctx, ref.key())
+			return withFallback(link, commons.ErrClickLimitExceeded)
+		}
+		s.log.Error("failed to decrement link click limit", "code", ref.code, "link_id", link.ID, "error", err)
 		return err
 	}
 
-	s.log.Info("record link stat successfully", "link_id", linkID)
+	// The UPDATE decides, so the cached entry stays as it is: a count that lags behind
+	// still lets every redirect through to it. Writing back our copy of the link could
+	// undo an update that invalidated the entry in the meantime.
+	return nil
+}
+
+// RecordLinkStat queues a click for batched insertion into the link_stats table.
+// It never blocks; when the ingestion queue is full the click is dropped.
+func (s *Service) RecordLinkStat(ctx context.Context, linkID uuid.UUID, info stats.CreateLinkStatRequest) error {
+	queued := s.clicks.Enqueue(stats.ClickEvent{
+		LinkID:    linkID,
+		ClickTime: time.Now(),
+		Info:      info,
+	})
+	if !queued {
+		s.log.Warn("dropped link stat, click queue is full", "link_id", linkID)
+		return commons.ErrClickQueueFull
+	}
+
+	return nil
+}
+
+// RecordLinkPreview queues a preview request. Previews are stored apart from link_stats
+// so they never show up as clicks.
+func (s *Service) RecordLinkPreview(ctx context.Context, linkID uuid.UUID, reason string, info stats.CreateLinkStatRequest) error {
+	queued := s.clicks.Enqueue(stats.ClickEvent{
+		LinkID:        linkID,
+		ClickTime:     time.Now(),
+		Info:          info,
+		PreviewReason: reason,
+	})
+	if !queued {
+		s.log.Warn("dropped link preview, click queue is full", "link_id", linkID)
+		return commons.ErrClickQueueFull
+	}
 
 	return nil
 }
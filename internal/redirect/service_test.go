@@ -0,0 +1,1034 @@
+package redirect
+
+import (
+	"GoShort/config"
+	"GoShort/internal/cache"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/internal/testutil"
+	"GoShort/pkg/geoip"
+	"GoShort/pkg/redis"
+	"GoShort/pkg/security"
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLinkRepo emulates the short_links table for a single link. DecrementClickLimit
+// behaves like the conditional UPDATE ... WHERE click_limit > 0 RETURNING * query.
+type fakeLinkRepo struct {
+	datastore.Querier
+
+	mu        sync.Mutex
+	link      datastore.ShortLink
+	rules     []datastore.LinkRule
+	variants  []datastore.LinkVariant
+	schedules []datastore.LinkSchedule
+	// aliases maps alias codes of the link to their IDs
+	aliases map[string]uuid.UUID
+	// onDecrement runs while a click is taken, as a concurrent request would
+	onDecrement func()
+	// lookups counts the links loaded by code
+	lookups int
+}
+
+func (f *fakeLinkRepo) GetShortLinkByCode(ctx context.Context, shortCode string) (datastore.ShortLink, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lookups++
+	if shortCode != f.link.ShortCode {
+		return datastore.ShortLink{}, pgx.ErrNoRows
+	}
+	return f.link, nil
+}
+
+func (f *fakeLinkRepo) GetShortLink(ctx context.Context, id uuid.UUID) (datastore.ShortLink, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id != f.link.ID {
+		return datastore.ShortLink{}, pgx.ErrNoRows
+	}
+	return f.link, nil
+}
+
+func (f *fakeLinkRepo) GetLinkAliasByCode(ctx context.Context, arg datastore.GetLinkAliasByCodeParams) (datastore.GetLinkAliasByCodeRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id, ok := f.aliases[arg.ShortCode]
+	if !ok {
+		return datastore.GetLinkAliasByCodeRow{}, pgx.ErrNoRows
+	}
+	return datastore.GetLinkAliasByCodeRow{ID: id, LinkID: f.link.ID, LinkShortCode: f.link.ShortCode}, nil
+}
+
+func (f *fakeLinkRepo) ListLinkRules(ctx context.Context, linkID uuid.UUID) ([]datastore.LinkRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rules, nil
+}
+
+func (f *fakeLinkRepo) ListLinkVariants(ctx context.Context, linkID uuid.UUID) ([]datastore.LinkVariant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.variants, nil
+}
+
+func (f *fakeLinkRepo) ListLinkSchedules(ctx context.Context, linkID uuid.UUID) ([]datastore.LinkSchedule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.schedules, nil
+}
+
+func (f *fakeLinkRepo) DecrementClickLimit(ctx context.Context, id uuid.UUID) (datastore.ShortLink, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id != f.link.ID || f.link.ClickLimit == nil || *f.link.ClickLimit <= 0 {
+		return datastore.ShortLink{}, pgx.ErrNoRows
+	}
+	if f.onDecrement != nil {
+		f.onDecrement()
+	}
+	remaining := *f.link.ClickLimit - 1
+	f.link.ClickLimit = &remaining
+	return f.link, nil
+}
+
+// fakeLinkCache keeps cache entries in memory.
+type fakeLinkCache struct {
+	mu      sync.Mutex
+	entries map[string]cache.CachedLink
+}
+
+func newFakeLinkCache() *fakeLinkCache {
+	return &fakeLinkCache{entries: map[string]cache.CachedLink{}}
+}
+
+func (f *fakeLinkCache) Get(ctx context.Context, code string) (*cache.CachedLink, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	link, ok := f.entries[code]
+	if !ok {
+		return nil, cache.ErrCacheMiss
+	}
+	return &link, nil
+}
+
+func (f *fakeLinkCache) Set(ctx context.Context, code string, link *cache.CachedLink) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries[code] = *link
+	return nil
+}
+
+func (f *fakeLinkCache) SetNotFound(ctx context.Context, code string) error {
+	return nil
+}
+
+func (f *fakeLinkCache) Invalidate(ctx context.Context, codes ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, code := range codes {
+		delete(f.entries, code)
+	}
+	return nil
+}
+
+// fakeCounters is an in-memory redis.RdsClient holding the password attempt counters.
+// Every call fails with err when it is set.
+type fakeCounters struct {
+	redis.RdsClient
+
+	mu       sync.Mutex
+	counters map[string]int64
+	err      error
+}
+
+func newFakeCounters() *fakeCounters {
+	return &fakeCounters{counters: map[string]int64{}}
+}
+
+func (f *fakeCounters) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.counters[key]++
+	return f.counters[key], nil
+}
+
+func (f *fakeCounters) Decr(ctx context.Context, key string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.counters[key]--
+	return f.counters[key], nil
+}
+
+func (f *fakeCounters) Del(ctx context.Context, keys ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	for _, key := range keys {
+		delete(f.counters, key)
+	}
+	return nil
+}
+
+func newTestPasswordAttempts(rds redis.RdsClient) IPasswordAttempts {
+	return NewPasswordAttempts(rds, config.LinkPasswordConfig{MaxAttempts: 3, MaxLinkAttempts: 5, LockoutWindow: time.Minute}, testutil.NewLogger())
+}
+
+func newTestRedirectService(repo datastore.Querier) IService {
+	linkCache := cache.NewLinkCache(nil, config.LinkCacheConfig{Enabled: false}, testutil.NewLogger())
+	return NewService(repo, linkCache, nil, newTestPasswordAttempts(newFakeCounters()), nil, testutil.NewLogger())
+}
+
+func TestService_GetOriginalURL_ClickLimitKeepsConcurrentUpdates(t *testing.T) {
+	limit := int32(5)
+	repo := &fakeLinkRepo{link: datastore.ShortLink{
+		ID:          uuid.New(),
+		OriginalUrl: "https://example.com/old",
+		ShortCode:   "limited",
+		IsActive:    true,
+		ClickLimit:  &limit,
+	}}
+	linkCache := newFakeLinkCache()
+	svc := NewService(repo, linkCache, nil, newTestPasswordAttempts(newFakeCounters()), nil, testutil.NewLogger())
+	ctx := context.Background()
+
+	destination, err := svc.GetOriginalURL(ctx, "limited", Visitor{})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/old", destination.URL)
+
+	// The owner changes the destination while the next click is being taken
+	repo.onDecrement = func() {
+		repo.link.OriginalUrl = "https://example.com/new"
+		_ = linkCache.Invalidate(ctx, "limited")
+	}
+	_, err = svc.GetOriginalURL(ctx, "limited", Visitor{})
+	require.NoError(t, err)
+	repo.onDecrement = nil
+
+	destination, err = svc.GetOriginalURL(ctx, "limited", Visitor{})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/new", destination.URL, "the old destination is not written back to the cache")
+	require.Equal(t, int32(2), *repo.link.ClickLimit)
+}
+
+func TestService_GetOriginalURL_ClickLimitIsAtomic(t *testing.T) {
+	const clickLimit = 5
+	const concurrentClicks = 50
+
+	limit := int32(clickLimit)
+	repo := &fakeLinkRepo{link: datastore.ShortLink{
+		ID:          uuid.New(),
+		OriginalUrl: "https://example.com",
+		ShortCode:   "once",
+		IsActive:    true,
+		ClickLimit:  &limit,
+	}}
+	svc := newTestRedirectService(repo)
+
+	var (
+		wg        sync.WaitGroup
+		start     = make(chan struct{})
+		succeeded atomic.Int32
+		exceeded  atomic.Int32
+		other     atomic.Int32
+	)
+	for i := 0; i < concurrentClicks; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := svc.GetOriginalURL(context.Background(), "once", Visitor{})
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.Is(err, commons.ErrClickLimitExceeded):
+				exceeded.Add(1)
+			default:
+				other.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	require.Equal(t, int32(clickLimit), succeeded.Load())
+	require.Equal(t, int32(concurrentClicks-clickLimit), exceeded.Load())
+	require.Zero(t, other.Load())
+	require.Equal(t, int32(0), *repo.link.ClickLimit)
+}
+
+func TestService_GetOriginalURL_ClickLimitKeepsCacheEntry(t *testing.T) {
+	limit := int32(3)
+	repo := &fakeLinkRepo{link: datastore.ShortLink{
+		ID:          uuid.New(),
+		OriginalUrl: "https://example.com",
+		ShortCode:   "limited",
+		IsActive:    true,
+		ClickLimit:  &limit,
+	}}
+	svc := NewService(repo, newFakeLinkCache(), nil, newTestPasswordAttempts(newFakeCounters()), nil, testutil.NewLogger())
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.GetOriginalURL(context.Background(), "limited", Visitor{})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, repo.lookups, "clicks on a limited link are served from the cache")
+
+	// The exhausted limit is found by the UPDATE and the entry reloaded
+	_, err := svc.GetOriginalURL(context.Background(), "limited", Visitor{})
+	require.ErrorIs(t, err, commons.ErrClickLimitExceeded)
+	_, err = svc.GetOriginalURL(context.Background(), "limited", Visitor{})
+	require.ErrorIs(t, err, commons.ErrClickLimitExceeded)
+	require.Equal(t, 2, repo.lookups)
+	require.Equal(t, int32(0), *repo.link.ClickLimit)
+}
+
+func TestService_GetOriginalURL_UnlimitedLinkIsNotDecremented(t *testing.T) {
+	repo := &fakeLinkRepo{link: datastore.ShortLink{
+		ID:          uuid.New(),
+		OriginalUrl: "https://example.com",
+		ShortCode:   "free",
+		IsActive:    true,
+	}}
+	svc := newTestRedirectService(repo)
+
+	for i := 0; i < 3; i++ {
+		destination, err := svc.GetOriginalURL(context.Background(), "free", Visitor{})
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com", destination.URL)
+	}
+	require.Nil(t, repo.link.ClickLimit)
+}
+
+func TestService_GetPreviewURL_DoesNotConsumeClicks(t *testing.T) {
+	limit := int32(1)
+	repo := &fakeLinkRepo{link: datastore.ShortLink{
+		ID:          uuid.New(),
+		OriginalUrl: "https://example.com",
+		ShortCode:   "once",
+		IsActive:    true,
+		ClickLimit:  &limit,
+	}}
+	svc := newTestRedirectService(repo)
+
+	for i := 0; i < 3; i++ {
+		destination, err := svc.GetPreviewURL(context.Background(), "once", Visitor{})
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com", destination.URL)
+	}
+	require.Equal(t, int32(1), *repo.link.ClickLimit)
+
+	_, err := svc.GetOriginalURL(context.Background(), "once", Visitor{})
+	require.NoError(t, err)
+
+	_, err = svc.GetPreviewURL(context.Background(), "once", Visitor{})
+	require.ErrorIs(t, err, commons.ErrClickLimitExceeded)
+}
+
+func TestService_UnlockLink(t *testing.T) {
+	hash, err := security.HashPassword("s3cret")
+	require.NoError(t, err)
+
+	limit := int32(5)
+	repo := &fakeLinkRepo{link: datastore.ShortLink{
+		ID:           uuid.New(),
+		OriginalUrl:  "https://example.com/internal.pdf",
+		ShortCode:    "docs",
+		IsActive:     true,
+		ClickLimit:   &limit,
+		PasswordHash: &hash,
+	}}
+	svc := newTestRedirectService(repo)
+	ctx := context.Background()
+
+	_, err = svc.GetOriginalURL(ctx, "docs", Visitor{})
+	require.ErrorIs(t, err, commons.ErrLinkPasswordRequired)
+
+	_, err = svc.GetPreviewURL(ctx, "docs", Visitor{})
+	require.ErrorIs(t, err, commons.ErrLinkPasswordRequired)
+
+	_, err = svc.UnlockLink(ctx, "docs", "wrong", Visitor{IP: "203.0.113.1"})
+	require.ErrorIs(t, err, commons.ErrInvalidLinkPassword)
+	require.Equal(t, int32(5), *repo.link.ClickLimit)
+
+	destination, err := svc.UnlockLink(ctx, "docs", "s3cret", Visitor{IP: "203.0.113.1"})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/internal.pdf", destination.URL)
+	require.Equal(t, repo.link.ID, destination.LinkID)
+	require.Equal(t, int32(4), *repo.link.ClickLimit)
+}
+
+func TestService_UnlockLink_ThrottlesPerIP(t *testing.T) {
+	hash, err := security.HashPassword("s3cret")
+	require.NoError(t, err)
+
+	repo := &fakeLinkRepo{link: datastore.ShortLink{
+		ID:           uuid.New(),
+		OriginalUrl:  "https://example.com",
+		ShortCode:    "docs",
+		IsActive:     true,
+		PasswordHash: &hash,
+	}}
+	svc := newTestRedirectService(repo)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.UnlockLink(ctx, "docs", "guess", Visitor{IP: "203.0.113.1"})
+		require.ErrorIs(t, err, commons.ErrInvalidLinkPassword)
+	}
+
+	// Even the right password is refused once the IP is locked out
+	_, err = svc.UnlockLink(ctx, "docs", "s3cret", Visitor{IP: "203.0.113.1"})
+	require.ErrorIs(t, err, commons.ErrTooManyPasswordAttempts)
+
+	// Other visitors are not affected
+	_, err = svc.UnlockLink(ctx, "docs", "s3cret", Visitor{IP: "198.51.100.7"})
+	require.NoError(t, err)
+}
+
+func TestService_UnlockLink_ThrottlesPerLink(t *testing.T) {
+	hash, err := security.HashPassword("s3cret")
+	require.NoError(t, err)
+
+	repo := &fakeLinkRepo{link: datastore.ShortLink{
+		ID:           uuid.New(),
+		OriginalUrl:  "https://example.com",
+		ShortCode:    "docs",
+		IsActive:     true,
+		PasswordHash: &hash,
+	}}
+	svc := newTestRedirectService(repo)
+	ctx := context.Background()
+
+	// Right passwords do not count towards the link limit
+	for i := 0; i < 10; i++ {
+		_, err := svc.UnlockLink(ctx, "docs", "s3cret", Visitor{IP: "198.51.100.7"})
+		require.NoError(t, err)
+	}
+
+	// A new IP for every guess does not get around the link limit
+	for i := 0; i < 5; i++ {
+		_, err := svc.UnlockLink(ctx, "docs", "guess", Visitor{IP: fmt.Sprintf("203.0.113.%d", i)})
+		require.ErrorIs(t, err, commons.ErrInvalidLinkPassword)
+	}
+	_, err = svc.UnlockLink(ctx, "docs", "s3cret", Visitor{IP: "192.0.2.1"})
+	require.ErrorIs(t, err, commons.ErrTooManyPasswordAttempts)
+}
+
+func TestService_UnlockLink_RefusedWhenAttemptsCannotBeCounted(t *testing.T) {
+	hash, err := security.HashPassword("s3cret")
+	require.NoError(t, err)
+
+	repo := &fakeLinkRepo{link: datastore.ShortLink{
+		ID:           uuid.New(),
+		OriginalUrl:  "https://example.com",
+		ShortCode:    "docs",
+		IsActive:     true,
+		PasswordHash: &hash,
+	}}
+	counters := newFakeCounters()
+	counters.err = errors.New("connection refused")
+	linkCache := cache.NewLinkCache(nil, config.LinkCacheConfig{Enabled: false}, testutil.NewLogger())
+	svc := NewService(repo, linkCache, nil, newTestPasswordAttempts(counters), nil, testutil.NewLogger())
+
+	_, err = svc.UnlockLink(context.Background(), "docs", "s3cret", Visitor{IP: "203.0.113.1"})
+	require.ErrorIs(t, err, counters.err)
+}
+
+func TestService_ForceInterstitial(t *testing.T) {
+	repo := &fakeLinkRepo{link: datastore.ShortLink{
+		ID:                uuid.New(),
+		OriginalUrl:       "https://example.com",
+		ShortCode:         "warn",
+		IsActive:          true,
+		ForceInterstitial: true,
+	}}
+	svc := newTestRedirectService(repo)
+	ctx := context.Background()
+
+	_, err := svc.GetOriginalURL(ctx, "warn", Visitor{})
+	require.ErrorIs(t, err, commons.ErrInterstitialRequired)
+
+	// Continuing from the preview page follows the link
+	destination, err := svc.UnlockLink(ctx, "warn", "", Visitor{IP: "203.0.113.1"})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", destination.URL)
+}
+
+func TestService_GetLinkInfo(t *testing.T) {
+	hash, err := security.HashPassword("s3cret")
+	require.NoError(t, err)
+	limit := int32(0)
+
+	tests := []struct {
+		name        string
+		link        datastore.ShortLink
+		expectedURL string
+		unavailable error
+	}{
+		{
+			name:        "Active link",
+			link:        datastore.ShortLink{OriginalUrl: "https://example.com", IsActive: true},
+			expectedURL: "https://example.com",
+		},
+		{
+			name:        "Inactive link is described",
+			link:        datastore.ShortLink{OriginalUrl: "https://example.com", IsActive: false},
+			expectedURL: "https://example.com",
+			unavailable: commons.ErrLinkNotActive,
+		},
+		{
+			name: "Expired link is described",
+			link: datastore.ShortLink{
+				OriginalUrl: "https://example.com",
+				IsActive:    true,
+				ExpiredAt:   pgtype.Timestamp{Time: time.Now().Add(-time.Hour), Valid: true},
+			},
+			expectedURL: "https://example.com",
+			unavailable: commons.ErrLinkExpired,
+		},
+		{
+			name:        "Exhausted link is described",
+			link:        datastore.ShortLink{OriginalUrl: "https://example.com", IsActive: true, ClickLimit: &limit},
+			expectedURL: "https://example.com",
+			unavailable: commons.ErrClickLimitExceeded,
+		},
+		{
+			name:        "Protected destination is hidden",
+			link:        datastore.ShortLink{OriginalUrl: "https://example.com", IsActive: true, PasswordHash: &hash},
+			expectedURL: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.link.ID = uuid.New()
+			tt.link.ShortCode = "info"
+			svc := newTestRedirectService(&fakeLinkRepo{link: tt.link})
+
+			info, err := svc.GetLinkInfo(context.Background(), "", "info")
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedURL, info.OriginalURL)
+			require.Equal(t, tt.link.PasswordHash != nil, info.Protected)
+			if tt.unavailable == nil {
+				require.NoError(t, info.Unavailable)
+			} else {
+				require.ErrorIs(t, info.Unavailable, tt.unavailable)
+			}
+		})
+	}
+
+	_, err = newTestRedirectService(&fakeLinkRepo{}).GetLinkInfo(context.Background(), "", "missing")
+	require.ErrorIs(t, err, commons.ErrLinkNotFound)
+}
+
+// fakeGeo resolves every public address to one country.
+type fakeGeo struct {
+	country string
+}
+
+func (f fakeGeo) Lookup(addr netip.Addr) (*geoip.Location, error) {
+	return &geoip.Location{CountryCode: f.country}, nil
+}
+
+func TestService_GetOriginalURL_Rules(t *testing.T) {
+	const (
+		iPhone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"
+		android = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36"
+		desktop = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+	)
+	appStore := "https://apps.apple.com/app/id1"
+	playStore := "https://play.google.com/store/apps/details?id=app"
+	localized := "https://example.com/id"
+
+	limit := int32(10)
+	repo := &fakeLinkRepo{
+		link: datastore.ShortLink{
+			ID:          uuid.New(),
+			OriginalUrl: "https://example.com",
+			ShortCode:   "app",
+			IsActive:    true,
+			ClickLimit:  &limit,
+		},
+		rules: []datastore.LinkRule{
+			{ID: uuid.New(), OperatingSystems: []string{"iOS"}, Action: "redirect", DestinationUrl: &appStore},
+			{ID: uuid.New(), OperatingSystems: []string{"Android"}, Action: "redirect", DestinationUrl: &playStore},
+			{ID: uuid.New(), Countries: []string{"KP"}, Action: "block"},
+			{ID: uuid.New(), Countries: []string{"ID"}, Action: "redirect", DestinationUrl: &localized},
+			{ID: uuid.New(), Languages: []string{"id"}, Action: "redirect", DestinationUrl: &localized},
+		},
+	}
+	linkCache := cache.NewLinkCache(nil, config.LinkCacheConfig{Enabled: false}, testutil.NewLogger())
+	svc := NewService(repo, linkCache, nil, newTestPasswordAttempts(newFakeCounters()), fakeGeo{country: "ID"}, testutil.NewLogger())
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		visitor  Visitor
+		expected string
+		err      error
+	}{
+		{name: "iOS goes to the App Store", visitor: Visitor{Country: "ID", UserAgent: iPhone}, expected: appStore},
+		{name: "Android goes to Play", visitor: Visitor{Country: "US", UserAgent: android}, expected: playStore},
+		{name: "Country header", visitor: Visitor{Country: "ID", UserAgent: desktop}, expected: localized},
+		{name: "GeoIP fallback", visitor: Visitor{IP: "8.8.8.8", UserAgent: desktop}, expected: localized},
+		{name: "Accept-Language", visitor: Visitor{Country: "US", UserAgent: desktop, AcceptLanguage: "id-ID,id;q=0.9,en;q=0.8"}, expected: localized},
+		{name: "No rule matches", visitor: Visitor{Country: "US", UserAgent: desktop, AcceptLanguage: "en-US"}, expected: "https://example.com"},
+		{name: "Blocked", visitor: Visitor{Country: "KP", UserAgent: desktop}, err: commons.ErrLinkBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destination, err := svc.GetOriginalURL(ctx, "app", tt.visitor)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, destination.URL)
+		})
+	}
+
+	// Blocked visitors do not use up the click limit
+	require.Equal(t, int32(4), *repo.link.ClickLimit)
+}
+
+func TestService_GetOriginalURL_Variants(t *testing.T) {
+	landingA := "https://example.com/landing-a"
+	landingB := "https://example.com/landing-b"
+	appStore := "https://apps.apple.com/app/id1"
+	iPhone := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"
+
+	variantA := datastore.LinkVariant{ID: uuid.New(), Label: "A", DestinationUrl: landingA, Weight: 70}
+	variantB := datastore.LinkVariant{ID: uuid.New(), Label: "B", DestinationUrl: landingB, Weight: 30}
+	newRepo := func(assignment string) *fakeLinkRepo {
+		return &fakeLinkRepo{
+			link: datastore.ShortLink{
+				ID:                uuid.New(),
+				OriginalUrl:       "https://example.com",
+				ShortCode:         "launch",
+				IsActive:          true,
+				VariantAssignment: assignment,
+			},
+			rules: []datastore.LinkRule{
+				{ID: uuid.New(), OperatingSystems: []string{"iOS"}, Action: "redirect", DestinationUrl: &appStore},
+			},
+			variants: []datastore.LinkVariant{variantA, variantB},
+		}
+	}
+	ctx := context.Background()
+
+	t.Run("Random follows the weights", func(t *testing.T) {
+		svc := newTestRedirectService(newRepo("random"))
+
+		served := map[uuid.UUID]int{}
+		for i := 0; i < 2000; i++ {
+			destination, err := svc.GetOriginalURL(ctx, "launch", Visitor{IP: "203.0.113.1"})
+			require.NoError(t, err)
+			require.NotNil(t, destination.VariantID)
+			require.False(t, destination.Sticky)
+			served[*destination.VariantID]++
+		}
+		require.InDelta(t, 1400, served[variantA.ID], 150)
+		require.InDelta(t, 600, served[variantB.ID], 150)
+	})
+
+	t.Run("Rules take precedence", func(t *testing.T) {
+		svc := newTestRedirectService(newRepo("random"))
+
+		destination, err := svc.GetOriginalURL(ctx, "launch", Visitor{UserAgent: iPhone})
+		require.NoError(t, err)
+		require.Equal(t, appStore, destination.URL)
+		require.Nil(t, destination.VariantID)
+	})
+
+	t.Run("Sticky keeps the visitor on one variant", func(t *testing.T) {
+		svc := newTestRedirectService(newRepo("sticky"))
+
+		first, err := svc.GetOriginalURL(ctx, "launch", Visitor{IP: "198.51.100.7"})
+		require.NoError(t, err)
+		require.True(t, first.Sticky)
+		for i := 0; i < 20; i++ {
+			again, err := svc.GetOriginalURL(ctx, "launch", Visitor{IP: "198.51.100.7"})
+			require.NoError(t, err)
+			require.Equal(t, *first.VariantID, *again.VariantID)
+		}
+
+		// The cookie wins over the IP hash
+		for _, variant := range []datastore.LinkVariant{variantA, variantB} {
+			destination, err := svc.GetOriginalURL(ctx, "launch", Visitor{IP: "198.51.100.7", VariantCookie: variant.ID.String()})
+			require.NoError(t, err)
+			require.Equal(t, variant.DestinationUrl, destination.URL)
+		}
+
+		// A cookie for a deleted variant falls back to the IP hash
+		destination, err := svc.GetOriginalURL(ctx, "launch", Visitor{IP: "198.51.100.7", VariantCookie: uuid.NewString()})
+		require.NoError(t, err)
+		require.Equal(t, *first.VariantID, *destination.VariantID)
+	})
+}
+
+func TestService_GetOriginalURL_QueryParams(t *testing.T) {
+	source, campaign := "newsletter", "spring sale"
+
+	tests := []struct {
+		name        string
+		originalURL string
+		passthrough bool
+		query       string
+		expected    string
+	}{
+		{
+			name:        "UTM parameters are added",
+			originalURL: "https://example.com/shop",
+			expected:    "https://example.com/shop?utm_source=newsletter&utm_campaign=spring+sale",
+		},
+		{
+			name:        "Existing parameters keep their order and UTMs are replaced",
+			originalURL: "https://example.com/shop?b=2&utm_source=typo&a=1#top",
+			expected:    "https://example.com/shop?b=2&a=1&utm_source=newsletter&utm_campaign=spring+sale#top",
+		},
+		{
+			name:        "Query is ignored without passthrough",
+			originalURL: "https://example.com/shop",
+			query:       "ref=twitter",
+			expected:    "https://example.com/shop?utm_source=newsletter&utm_campaign=spring+sale",
+		},
+		{
+			name:        "Passthrough adds the query",
+			originalURL: "https://example.com/shop?a=1",
+			passthrough: true,
+			query:       "ref=twitter&q=red%20shoes",
+			expected:    "https://example.com/shop?a=1&utm_source=newsletter&utm_campaign=spring+sale&ref=twitter&q=red+shoes",
+		},
+		{
+			name:        "Passthrough cannot override the owner's parameters",
+			originalURL: "https://example.com/shop?a=1",
+			passthrough: true,
+			query:       "a=2&utm_source=spam",
+			expected:    "https://example.com/shop?a=1&utm_source=newsletter&utm_campaign=spring+sale",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestRedirectService(&fakeLinkRepo{link: datastore.ShortLink{
+				ID:               uuid.New(),
+				OriginalUrl:      tt.originalURL,
+				ShortCode:        "sale",
+				IsActive:         true,
+				UtmSource:        &source,
+				UtmCampaign:      &campaign,
+				QueryPassthrough: tt.passthrough,
+			}})
+
+			destination, err := svc.GetOriginalURL(context.Background(), "sale", Visitor{Query: tt.query})
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, destination.URL)
+		})
+	}
+}
+
+func TestService_GetOriginalURL_Schedule(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(d time.Duration) pgtype.Timestamp {
+		return pgtype.Timestamp{Time: now.Add(d), Valid: true}
+	}
+
+	link := datastore.ShortLink{
+		ID:          uuid.New(),
+		OriginalUrl: "https://example.com/teaser",
+		ShortCode:   "launch",
+		IsActive:    true,
+	}
+
+	t.Run("Link is not available before starts_at", func(t *testing.T) {
+		notYet := link
+		notYet.StartsAt = at(time.Hour)
+		svc := newTestRedirectService(&fakeLinkRepo{link: notYet})
+
+		_, err := svc.GetOriginalURL(context.Background(), "launch", Visitor{})
+		require.ErrorIs(t, err, commons.ErrLinkNotYetActive)
+
+		info, err := svc.GetLinkInfo(context.Background(), "", "launch")
+		require.NoError(t, err)
+		require.NotNil(t, info.StartsAt)
+	})
+
+	t.Run("Latest due change is followed before the worker applies it", func(t *testing.T) {
+		started := link
+		started.StartsAt = at(-time.Hour)
+		svc := newTestRedirectService(&fakeLinkRepo{
+			link: started,
+			schedules: []datastore.LinkSchedule{
+				{ID: uuid.New(), LinkID: link.ID, DestinationUrl: "https://example.com/old", SwitchAt: at(-2 * time.Hour), AppliedAt: at(-2 * time.Hour)},
+				{ID: uuid.New(), LinkID: link.ID, DestinationUrl: "https://example.com/live", SwitchAt: at(-time.Minute)},
+				{ID: uuid.New(), LinkID: link.ID, DestinationUrl: "https://example.com/replay", SwitchAt: at(time.Hour)},
+			},
+		})
+
+		destination, err := svc.GetOriginalURL(context.Background(), "launch", Visitor{})
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/live", destination.URL)
+	})
+}
+
+func TestService_GetOriginalURL_FallbackURL(t *testing.T) {
+	fallback := "https://example.com/sold-out"
+	exhausted := int32(0)
+
+	tests := []struct {
+		name   string
+		link   datastore.ShortLink
+		reason error
+	}{
+		{
+			name:   "Inactive link",
+			link:   datastore.ShortLink{IsActive: false},
+			reason: commons.ErrLinkNotActive,
+		},
+		{
+			name:   "Expired link",
+			link:   datastore.ShortLink{IsActive: true, ExpiredAt: pgtype.Timestamp{Time: time.Now().Add(-time.Hour), Valid: true}},
+			reason: commons.ErrLinkExpired,
+		},
+		{
+			name:   "Link out of clicks",
+			link:   datastore.ShortLink{IsActive: true, ClickLimit: &exhausted},
+			reason: commons.ErrClickLimitExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := tt.link
+			link.ID = uuid.New()
+			link.ShortCode = "drop"
+			link.OriginalUrl = "https://example.com/drop"
+			link.FallbackUrl = &fallback
+
+			_, err := newTestRedirectService(&fakeLinkRepo{link: link}).GetOriginalURL(context.Background(), "drop", Visitor{})
+			require.ErrorIs(t, err, tt.reason)
+
+			var unavailable *UnavailableError
+			require.ErrorAs(t, err, &unavailable)
+			require.Equal(t, fallback, unavailable.FallbackURL)
+
+			// Without a fallback URL the plain reason is returned
+			link.FallbackUrl = nil
+			_, err = newTestRedirectService(&fakeLinkRepo{link: link}).GetOriginalURL(context.Background(), "drop", Visitor{})
+			require.Equal(t, tt.reason, err)
+		})
+	}
+}
+
+func TestService_GetOriginalURL_RedirectStatus(t *testing.T) {
+	status := int16(308)
+	svc := newTestRedirectService(&fakeLinkRepo{link: datastore.ShortLink{
+		ID:             uuid.New(),
+		OriginalUrl:    "https://api.example.com/v2/orders",
+		ShortCode:      "orders",
+		IsActive:       true,
+		RedirectStatus: &status,
+	}})
+
+	destination, err := svc.GetOriginalURL(context.Background(), "orders", Visitor{})
+	require.NoError(t, err)
+	require.Equal(t, 308, destination.Status)
+}
+
+// fakeDomainLinkRepo holds links on the default domain and on verified custom domains.
+type fakeDomainLinkRepo struct {
+	datastore.Querier
+
+	domains map[string]uuid.UUID
+	links   []datastore.ShortLink
+}
+
+func (f *fakeDomainLinkRepo) GetVerifiedDomainByHostname(ctx context.Context, hostname string) (datastore.Domain, error) {
+	id, ok := f.domains[hostname]
+	if !ok {
+		return datastore.Domain{}, pgx.ErrNoRows
+	}
+	return datastore.Domain{ID: id, Hostname: hostname}, nil
+}
+
+func (f *fakeDomainLinkRepo) GetShortLinkByCode(ctx context.Context, shortCode string) (datastore.ShortLink, error) {
+	return f.GetShortLinkByDomainAndCode(ctx, datastore.GetShortLinkByDomainAndCodeParams{ShortCode: shortCode})
+}
+
+func (f *fakeDomainLinkRepo) GetShortLinkByDomainAndCode(ctx context.Context, arg datastore.GetShortLinkByDomainAndCodeParams) (datastore.ShortLink, error) {
+	for _, link := range f.links {
+		if link.ShortCode == arg.ShortCode && link.DomainID == arg.DomainID {
+			return link, nil
+		}
+	}
+	return datastore.ShortLink{}, pgx.ErrNoRows
+}
+
+func (f *fakeDomainLinkRepo) GetLinkAliasByCode(ctx context.Context, arg datastore.GetLinkAliasByCodeParams) (datastore.GetLinkAliasByCodeRow, error) {
+	return datastore.GetLinkAliasByCodeRow{}, pgx.ErrNoRows
+}
+
+func (f *fakeDomainLinkRepo) ListLinkRules(ctx context.Context, linkID uuid.UUID) ([]datastore.LinkRule, error) {
+	return nil, nil
+}
+
+func (f *fakeDomainLinkRepo) ListLinkVariants(ctx context.Context, linkID uuid.UUID) ([]datastore.LinkVariant, error) {
+	return nil, nil
+}
+
+func (f *fakeDomainLinkRepo) ListLinkSchedules(ctx context.Context, linkID uuid.UUID) ([]datastore.LinkSchedule, error) {
+	return nil, nil
+}
+
+func TestService_GetOriginalURL_CustomDomain(t *testing.T) {
+	acme := uuid.New()
+	repo := &fakeDomainLinkRepo{
+		domains: map[string]uuid.UUID{"go.acme.com": acme},
+		links: []datastore.ShortLink{
+			{ID: uuid.New(), ShortCode: "docs", OriginalUrl: "https://example.com/docs", IsActive: true},
+			{ID: uuid.New(), ShortCode: "blog", OriginalUrl: "https://example.com/blog", IsActive: true},
+			{ID: uuid.New(), ShortCode: "docs", OriginalUrl: "https://acme.com/docs", IsActive: true, DomainID: pgtype.UUID{Bytes: acme, Valid: true}},
+			{ID: uuid.New(), ShortCode: "careers", OriginalUrl: "https://acme.com/careers", IsActive: true, DomainID: pgtype.UUID{Bytes: acme, Valid: true}},
+		},
+	}
+	svc := newTestRedirectService(repo)
+
+	tests := []struct {
+		name    string
+		host    string
+		code    string
+		wantURL string
+		wantErr error
+	}{
+		{name: "Default domain", host: "localhost", code: "docs", wantURL: "https://example.com/docs"},
+		{name: "No host", code: "docs", wantURL: "https://example.com/docs"},
+		{name: "Custom domain", host: "go.acme.com", code: "docs", wantURL: "https://acme.com/docs"},
+		{name: "Custom domain is case insensitive", host: "GO.ACME.COM.", code: "docs", wantURL: "https://acme.com/docs"},
+		{name: "Unknown host serves the default domain", host: "unverified.example.org", code: "docs", wantURL: "https://example.com/docs"},
+		{name: "Custom domain link is not on the default domain", host: "localhost", code: "careers", wantErr: commons.ErrLinkNotFound},
+		{name: "Default domain link is not on custom domains", host: "go.acme.com", code: "blog", wantErr: commons.ErrLinkNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destination, err := svc.GetOriginalURL(context.Background(), tt.code, Visitor{Host: tt.host})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantURL, destination.URL)
+		})
+	}
+}
+
+// memoryLinkCache is an in-memory link cache, so tests see what redirects read back.
+type memoryLinkCache struct {
+	mu      sync.Mutex
+	entries map[string]*cache.CachedLink
+}
+
+func newMemoryLinkCache() *memoryLinkCache {
+	return &memoryLinkCache{entries: map[string]*cache.CachedLink{}}
+}
+
+func (m *memoryLinkCache) Get(ctx context.Context, code string) (*cache.CachedLink, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	link, ok := m.entries[code]
+	switch {
+	case !ok:
+		return nil, cache.ErrCacheMiss
+	case link == nil:
+		return nil, commons.ErrLinkNotFound
+	}
+	copied := *link
+	return &copied, nil
+}
+
+func (m *memoryLinkCache) Set(ctx context.Context, code string, link *cache.CachedLink) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[code] = link
+	return nil
+}
+
+func (m *memoryLinkCache) SetNotFound(ctx context.Context, code string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[code] = nil
+	return nil
+}
+
+func (m *memoryLinkCache) Invalidate(ctx context.Context, codes ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, code := range codes {
+		delete(m.entries, code)
+	}
+	return nil
+}
+
+func TestService_GetOriginalURL_Alias(t *testing.T) {
+	aliasID := uuid.New()
+	repo := &fakeLinkRepo{
+		link: datastore.ShortLink{
+			ID:          uuid.New(),
+			OriginalUrl: "https://example.com/launch",
+			ShortCode:   "launch",
+			IsActive:    true,
+		},
+		aliases: map[string]uuid.UUID{"old-launch": aliasID},
+	}
+	linkCache := newMemoryLinkCache()
+	svc := NewService(repo, linkCache, nil, newTestPasswordAttempts(newFakeCounters()), nil, testutil.NewLogger())
+
+	// The second lookup goes through the cached alias entry
+	for i := 0; i < 2; i++ {
+		destination, err := svc.GetOriginalURL(context.Background(), "old-launch", Visitor{})
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/launch", destination.URL)
+		require.Equal(t, repo.link.ID, destination.LinkID)
+		require.NotNil(t, destination.AliasID)
+		require.Equal(t, aliasID, *destination.AliasID)
+	}
+	require.Equal(t, "launch", linkCache.entries["old-launch"].AliasOf)
+
+	destination, err := svc.GetOriginalURL(context.Background(), "launch", Visitor{})
+	require.NoError(t, err)
+	require.Nil(t, destination.AliasID)
+
+	// Deactivating the link takes effect on its aliases once the link's entry is invalidated
+	repo.link.IsActive = false
+	require.NoError(t, linkCache.Invalidate(context.Background(), "launch"))
+	_, err = svc.GetOriginalURL(context.Background(), "old-launch", Visitor{})
+	require.ErrorIs(t, err, commons.ErrLinkNotActive)
+
+	info, err := svc.GetLinkInfo(context.Background(), "", "old-launch")
+	require.NoError(t, err)
+	require.Equal(t, "launch", info.ShortCode)
+
+	_, err = svc.GetOriginalURL(context.Background(), "missing", Visitor{})
+	require.ErrorIs(t, err, commons.ErrLinkNotFound)
+}
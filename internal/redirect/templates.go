@@ -0,0 +1,187 @@
+package redirect
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// embeddedTemplates are the default pages. A deployment can replace any of them by
+// putting a file with the same name in its template directory.
+//
+//go:embed templates/*.html
+var embeddedTemplates embed.FS
+
+const (
+	passwordTemplateName        = "password.html"
+	previewTemplateName         = "preview.html"
+	notYetAvailableTemplateName = "not_yet_available.html"
+	socialCardTemplateName      = "social_card.html"
+	notFoundTemplateName        = "not_found.html"
+	goneTemplateName            = "gone.html"
+	forbiddenTemplateName       = "forbidden.html"
+)
+
+// Templates holds the parsed pages rendered by the redirect handler.
+type Templates struct {
+	password        *template.Template
+	preview         *template.Template
+	notYetAvailable *template.Template
+	socialCard      *template.Template
+	// errorPages are keyed by HTTP status
+	errorPages map[int]*template.Template
+}
+
+// defaultTemplates are the embedded pages, used when no template directory is configured.
+var defaultTemplates = mustLoadTemplates("")
+
+// PasswordPageData holds the dynamic data for the link password form.
+type PasswordPageData struct {
+	Code  string
+	Error string
+}
+
+// PreviewPageData holds the dynamic data for the link preview page.
+type PreviewPageData struct {
+	Code        string
+	Destination string
+	Title       string
+	Description string
+	CreatedAt   string
+	Status      string
+	Active      bool
+	Protected   bool
+}
+
+// NotYetAvailablePageData holds the dynamic data for links that have not started yet.
+type NotYetAvailablePageData struct {
+	Code     string
+	Title    string
+	StartsAt string
+}
+
+// SocialCardPageData holds the dynamic data for the page served to link unfurlers.
+type SocialCardPageData struct {
+	Title       string
+	Description string
+	ImageURL    string
+	Destination string
+}
+
+// ErrorPageData holds the dynamic data for the 404, 410 and 403 pages.
+type ErrorPageData struct {
+	Code    string
+	Message string
+}
+
+// LoadTemplates parses the redirect pages. Files in dir override the embedded page with
+// the same name; an empty dir uses the embedded pages only.
+func LoadTemplates(dir string) (*Templates, error) {
+	parse := func(name string) (*template.Template, error) {
+		content, err := readTemplate(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(name).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parse template %s: %w", name, err)
+		}
+		return tmpl, nil
+	}
+
+	t := &Templates{errorPages: make(map[int]*template.Template)}
+
+	var err error
+	if t.password, err = parse(passwordTemplateName); err != nil {
+		return nil, err
+	}
+	if t.preview, err = parse(previewTemplateName); err != nil {
+		return nil, err
+	}
+	if t.notYetAvailable, err = parse(notYetAvailableTemplateName); err != nil {
+		return nil, err
+	}
+	if t.socialCard, err = parse(socialCardTemplateName); err != nil {
+		return nil, err
+	}
+
+	for status, name := range map[int]string{
+		http.StatusNotFound:  notFoundTemplateName,
+		http.StatusGone:      goneTemplateName,
+		http.StatusForbidden: forbiddenTemplateName,
+	} {
+		tmpl, err := parse(name)
+		if err != nil {
+			return nil, err
+		}
+		t.errorPages[status] = tmpl
+	}
+
+	return t, nil
+}
+
+func mustLoadTemplates(dir string) *Templates {
+	t, err := LoadTemplates(dir)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// readTemplate reads a page from dir, falling back to the embedded copy when dir is
+// empty or does not contain it.
+func readTemplate(dir, name string) ([]byte, error) {
+	if dir != "" {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return content, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("read template %s: %w", name, err)
+		}
+	}
+	return embeddedTemplates.ReadFile("templates/" + name)
+}
+
+// renderPasswordPage executes the password form template.
+func (t *Templates) renderPasswordPage(data PasswordPageData) (string, error) {
+	return render(t.password, data)
+}
+
+// renderPreviewPage executes the preview page template.
+func (t *Templates) renderPreviewPage(data PreviewPageData) (string, error) {
+	return render(t.preview, data)
+}
+
+// renderNotYetAvailablePage executes the "not yet available" template.
+func (t *Templates) renderNotYetAvailablePage(data NotYetAvailablePageData) (string, error) {
+	return render(t.notYetAvailable, data)
+}
+
+// renderSocialCardPage executes the social card template.
+func (t *Templates) renderSocialCardPage(data SocialCardPageData) (string, error) {
+	return render(t.socialCard, data)
+}
+
+// renderErrorPage executes the error page template of an HTTP status.
+func (t *Templates) renderErrorPage(status int, data ErrorPageData) (string, error) {
+	tmpl, ok := t.errorPages[status]
+	if !ok {
+		return "", fmt.Errorf("no error page for status %d", status)
+	}
+	return render(tmpl, data)
+}
+
+func render(tmpl *template.Template, data any) (string, error) {
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return "", err
+	}
+	return body.String(), nil
+}
@@ -0,0 +1,31 @@
+package redirect
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTemplates_OverridesFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gone.html"), []byte(`<h1>Acme: {{.Message}}</h1>`), 0o644))
+
+	templates, err := LoadTemplates(dir)
+	require.NoError(t, err)
+
+	body, err := templates.renderErrorPage(http.StatusGone, ErrorPageData{Code: "sale", Message: "Link has expired"})
+	require.NoError(t, err)
+	require.Equal(t, "<h1>Acme: Link has expired</h1>", body)
+
+	// Pages missing from the directory fall back to the embedded ones
+	body, err = templates.renderErrorPage(http.StatusNotFound, ErrorPageData{Code: "sale", Message: "Link not found"})
+	require.NoError(t, err)
+	require.Contains(t, body, "<title>Link not found - GoShort</title>")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "forbidden.html"), []byte(`{{.Broken`), 0o644))
+	_, err = LoadTemplates(dir)
+	require.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package redirect
+
+import (
+	"GoShort/internal/cache"
+	"GoShort/internal/linkvariant"
+	"hash/fnv"
+	"math/rand/v2"
+
+	"github.com/google/uuid"
+)
+
+// VariantCookie remembers the variant a visitor was sent to for sticky A/B tests. It is
+// scoped to the short code's path, so every link keeps its own assignment.
+const VariantCookie = "goshort_variant"
+
+// variantCookieMaxAge is long enough to outlast a typical experiment, in seconds.
+const variantCookieMaxAge = 30 * 24 * 60 * 60
+
+// pickVariant chooses the variant a visitor is sent to, proportionally to the weights.
+// Sticky links keep a visitor on the variant in their cookie and otherwise derive it
+// from a hash of the client IP, so the choice survives cleared cookies.
+func pickVariant(link *cache.CachedLink, visitor Visitor) *cache.Variant {
+	var total uint64
+	for _, variant := range link.Variants {
+		total += uint64(variant.Weight)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	var n uint64
+	if link.VariantAssignment == linkvariant.AssignmentSticky {
+		if id, err := uuid.Parse(visitor.VariantCookie); err == nil {
+			for i := range link.Variants {
+				if link.Variants[i].ID == id {
+					return &link.Variants[i]
+				}
+			}
+		}
+		n = stickyHash(link.ID, visitor.IP) % total
+	} else {
+		n = rand.Uint64N(total)
+	}
+
+	for i := range link.Variants {
+		weight := uint64(link.Variants[i].Weight)
+		if n < weight {
+			return &link.Variants[i]
+		}
+		n -= weight
+	}
+	return nil
+}
+
+func stickyHash(linkID uuid.UUID, ip string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(linkID[:])
+	_, _ = h.Write([]byte(ip))
+	return h.Sum64()
+}
@@ -0,0 +1,64 @@
+package reservedcode
+
+import (
+	"GoShort/internal/datastore"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// builtinCodes are served by the application itself at the root, next to the redirect
+// route, or are commonly expected there. A link with one of these codes would shadow a
+// route or never be reachable, so no one can claim them, not even admins.
+var builtinCodes = map[string]struct{}{
+	"api":         {},
+	"health":      {},
+	"metrics":     {},
+	"swagger":     {},
+	"admin":       {},
+	"assets":      {},
+	"static":      {},
+	"login":       {},
+	"logout":      {},
+	"register":    {},
+	"favicon.ico": {},
+	"robots.txt":  {},
+	"sitemap.xml": {},
+	".well-known": {},
+}
+
+// IsBuiltin reports whether the code is reserved by the application.
+func IsBuiltin(code string) bool {
+	_, ok := builtinCodes[normalize(code)]
+	return ok
+}
+
+type CreateReservedCodeRequest struct {
+	Code string `json:"code" validate:"required,max=100"`
+	// Reason explains why the code is reserved, e.g. "brand" or "offensive"
+	Reason *string `json:"reason,omitempty" validate:"omitempty,max=200"`
+}
+
+type ReservedCodeResponse struct {
+	Code   string  `json:"code"`
+	Reason *string `json:"reason,omitempty"`
+	// Builtin codes belong to application routes and cannot be removed
+	Builtin   bool       `json:"builtin"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// NewReservedCodeResponse converts a datastore reserved code to its API representation.
+func NewReservedCodeResponse(code datastore.ReservedCode) ReservedCodeResponse {
+	createdAt := code.CreatedAt.Time
+	response := ReservedCodeResponse{
+		Code:      code.Code,
+		Reason:    code.Reason,
+		CreatedAt: &createdAt,
+	}
+	if code.CreatedBy.Valid {
+		createdBy := uuid.UUID(code.CreatedBy.Bytes)
+		response.CreatedBy = &createdBy
+	}
+	return response
+}
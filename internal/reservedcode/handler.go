@@ -0,0 +1,131 @@
+package reservedcode
+
+import (
+	"GoShort/internal/commons"
+	"GoShort/pkg/logger"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	svr       IService
+	log       *logger.Logger
+	validator *validator.Validate
+}
+
+func NewHandler(service IService, log *logger.Logger, validator *validator.Validate) *Handler {
+	return &Handler{
+		svr:       service,
+		log:       log,
+		validator: validator,
+	}
+}
+
+// ListReservedCodes lists the codes no link can use
+// @Godoc ListReservedCodes
+// @Summary List reserved short codes
+// @Description Retrieve the built-in codes of application routes and the codes reserved by admins
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.ReservedCodeResponse} "Reserved codes retrieved successfully"
+// @Failure 403 {object} dto.ErrorResponse "Forbidden - Admin access required"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve reserved codes"
+// @Router /api/v1/admin/reserved-codes [get]
+// @Security ApiKeyAuth
+func (h *Handler) ListReservedCodes(c *fiber.Ctx) error {
+	codes, err := h.svr.ListReservedCodes(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{
+			Error: "Failed to retrieve reserved codes",
+		})
+	}
+
+	return c.JSON(commons.SuccessResponse{
+		Message: "Reserved codes retrieved successfully",
+		Data:    codes,
+	})
+}
+
+// AddReservedCode reserves a short code
+// @Godoc AddReservedCode
+// @Summary Reserve a short code
+// @Description Block a code, such as a brand word or an offensive term, for new links. Existing links keep working and admins can still assign the code
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateReservedCodeRequest true "Create Reserved Code Request"
+// @Success 201 {object} dto.SuccessResponse{data=dto.ReservedCodeResponse} "Code reserved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid request body"
+// @Failure 403 {object} dto.ErrorResponse "Forbidden - Admin access required"
+// @Failure 409 {object} dto.ErrorResponse "Code is already reserved"
+// @Failure 500 {object} dto.ErrorResponse "Failed to reserve code"
+// @Router /api/v1/admin/reserved-codes [post]
+// @Security ApiKeyAuth
+func (h *Handler) AddReservedCode(c *fiber.Ctx) error {
+	adminID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid user ID"})
+	}
+
+	var req CreateReservedCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid request body"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+			Message: "Validation failed",
+			Error:   commons.FormatValidationErrors(err),
+		})
+	}
+
+	code, err := h.svr.AddReservedCode(c.Context(), adminID, req)
+	if err != nil {
+		if errors.Is(err, commons.ErrReservedCodeExists) {
+			return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{Error: "Code is already reserved"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{
+			Error: "Failed to reserve code",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(commons.SuccessResponse{
+		Message: "Code reserved successfully",
+		Data:    code,
+	})
+}
+
+// DeleteReservedCode releases a reserved short code
+// @Godoc DeleteReservedCode
+// @Summary Release a reserved short code
+// @Description Allow links to use a code reserved by an admin again. Built-in codes cannot be released
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param code path string true "Reserved code"
+// @Success 204 "Code released successfully"
+// @Failure 400 {object} dto.ErrorResponse "Built-in codes cannot be released"
+// @Failure 403 {object} dto.ErrorResponse "Forbidden - Admin access required"
+// @Failure 404 {object} dto.ErrorResponse "Reserved code not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to release code"
+// @Router /api/v1/admin/reserved-codes/{code} [delete]
+// @Security ApiKeyAuth
+func (h *Handler) DeleteReservedCode(c *fiber.Ctx) error {
+	if err := h.svr.DeleteReservedCode(c.Context(), c.Params("code")); err != nil {
+		switch {
+		case errors.Is(err, commons.ErrReservedCodeBuiltin):
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Built-in codes cannot be released"})
+		case errors.Is(err, commons.ErrReservedCodeNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(commons.ErrorResponse{Error: "Reserved code not found"})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{
+				Error: "Failed to release code",
+			})
+		}
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
@@ -0,0 +1,137 @@
+package reservedcode
+
+import (
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/logger"
+	"GoShort/pkg/slug"
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type IService interface {
+	// CheckCode returns commons.ErrShortCodeReserved when a link cannot use the code.
+	// override lets admins assign codes of the managed list; built-in codes stay blocked.
+	CheckCode(ctx context.Context, code string, override bool) error
+	ListReservedCodes(ctx context.Context) ([]ReservedCodeResponse, error)
+	AddReservedCode(ctx context.Context, adminID uuid.UUID, req CreateReservedCodeRequest) (*ReservedCodeResponse, error)
+	DeleteReservedCode(ctx context.Context, code string) error
+}
+
+type Service struct {
+	repo datastore.Querier
+	log  *logger.Logger
+}
+
+func NewService(repo datastore.Querier, log *logger.Logger) IService {
+	return &Service{repo: repo, log: log}
+}
+
+// normalize is the form reserved codes are stored and compared in; matching ignores case
+// so "API" cannot sneak past "api".
+func normalize(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// CheckCode also checks the first segment of path-style codes, "api/launch" would be
+// shadowed by the API routes just like "api".
+func (s *Service) CheckCode(ctx context.Context, code string, override bool) error {
+	candidates := []string{normalize(code)}
+	if first := slug.FirstSegment(candidates[0]); first != candidates[0] {
+		candidates = append(candidates, first)
+	}
+
+	for _, candidate := range candidates {
+		if IsBuiltin(candidate) {
+			return commons.ErrShortCodeReserved
+		}
+	}
+	if override {
+		return nil
+	}
+
+	for _, candidate := range candidates {
+		reserved, err := s.repo.IsReservedCode(ctx, candidate)
+		if err != nil {
+			s.log.Error("failed to check reserved code", "code", code, "error", err)
+			return err
+		}
+		if reserved {
+			return commons.ErrShortCodeReserved
+		}
+	}
+	return nil
+}
+
+// ListReservedCodes returns the built-in codes followed by the codes added by admins.
+func (s *Service) ListReservedCodes(ctx context.Context) ([]ReservedCodeResponse, error) {
+	codes, err := s.repo.ListReservedCodes(ctx)
+	if err != nil {
+		s.log.Error("failed to list reserved codes", "error", err)
+		return nil, err
+	}
+
+	response := make([]ReservedCodeResponse, 0, len(builtinCodes)+len(codes))
+	for code := range builtinCodes {
+		response = append(response, ReservedCodeResponse{Code: code, Builtin: true})
+	}
+	sort.Slice(response, func(i, j int) bool { return response[i].Code < response[j].Code })
+
+	for _, code := range codes {
+		response = append(response, NewReservedCodeResponse(code))
+	}
+	return response, nil
+}
+
+// AddReservedCode blocks a code for future links. Links already using it are kept.
+func (s *Service) AddReservedCode(ctx context.Context, adminID uuid.UUID, req CreateReservedCodeRequest) (*ReservedCodeResponse, error) {
+	code := normalize(req.Code)
+	if IsBuiltin(code) {
+		return nil, commons.ErrReservedCodeExists
+	}
+
+	reserved, err := s.repo.IsReservedCode(ctx, code)
+	if err != nil {
+		s.log.Error("failed to check reserved code", "code", code, "error", err)
+		return nil, err
+	}
+	if reserved {
+		return nil, commons.ErrReservedCodeExists
+	}
+
+	created, err := s.repo.CreateReservedCode(ctx, datastore.CreateReservedCodeParams{
+		Code:      code,
+		Reason:    req.Reason,
+		CreatedBy: pgtype.UUID{Bytes: adminID, Valid: true},
+	})
+	if err != nil {
+		s.log.Error("failed to create reserved code", "code", code, "error", err)
+		return nil, err
+	}
+
+	s.log.Info("short code reserved", "code", code, "admin_id", adminID)
+
+	response := NewReservedCodeResponse(created)
+	return &response, nil
+}
+
+func (s *Service) DeleteReservedCode(ctx context.Context, code string) error {
+	code = normalize(code)
+	if IsBuiltin(code) {
+		return commons.ErrReservedCodeBuiltin
+	}
+
+	deleted, err := s.repo.DeleteReservedCode(ctx, code)
+	if err != nil {
+		s.log.Error("failed to delete reserved code", "code", code, "error", err)
+		return err
+	}
+	if deleted == 0 {
+		return commons.ErrReservedCodeNotFound
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package reservedcode
+
+import (
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/internal/testutil"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReservedRepo keeps the reserved_codes table in memory.
+type fakeReservedRepo struct {
+	datastore.Querier
+
+	codes map[string]datastore.ReservedCode
+}
+
+func (f *fakeReservedRepo) IsReservedCode(ctx context.Context, code string) (bool, error) {
+	_, ok := f.codes[code]
+	return ok, nil
+}
+
+func (f *fakeReservedRepo) CreateReservedCode(ctx context.Context, arg datastore.CreateReservedCodeParams) (datastore.ReservedCode, error) {
+	code := datastore.ReservedCode{Code: arg.Code, Reason: arg.Reason, CreatedBy: arg.CreatedBy}
+	f.codes[arg.Code] = code
+	return code, nil
+}
+
+func (f *fakeReservedRepo) DeleteReservedCode(ctx context.Context, code string) (int64, error) {
+	if _, ok := f.codes[code]; !ok {
+		return 0, nil
+	}
+	delete(f.codes, code)
+	return 1, nil
+}
+
+func (f *fakeReservedRepo) ListReservedCodes(ctx context.Context) ([]datastore.ReservedCode, error) {
+	var codes []datastore.ReservedCode
+	for _, code := range f.codes {
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func TestService_CheckCode(t *testing.T) {
+	repo := &fakeReservedRepo{codes: map[string]datastore.ReservedCode{"acme": {Code: "acme"}}}
+	svc := NewService(repo, testutil.NewLogger())
+
+	tests := []struct {
+		name     string
+		code     string
+		override bool
+		wantErr  error
+	}{
+		{name: "Free code", code: "launch"},
+		{name: "Route name", code: "health", wantErr: commons.ErrShortCodeReserved},
+		{name: "Route name in another case", code: "Swagger", wantErr: commons.ErrShortCodeReserved},
+		{name: "Route name with override", code: "api", override: true, wantErr: commons.ErrShortCodeReserved},
+		{name: "Reserved by an admin", code: "ACME", wantErr: commons.ErrShortCodeReserved},
+		{name: "Reserved by an admin with override", code: "acme", override: true},
+		{name: "Path under a route name", code: "api/launch", wantErr: commons.ErrShortCodeReserved},
+		{name: "Path under an admin reserved code", code: "acme/launch", wantErr: commons.ErrShortCodeReserved},
+		{name: "Path under a free code", code: "launch/2026"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.CheckCode(context.Background(), tt.code, tt.override)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_AddAndDeleteReservedCode(t *testing.T) {
+	repo := &fakeReservedRepo{codes: map[string]datastore.ReservedCode{}}
+	svc := NewService(repo, testutil.NewLogger())
+	adminID := uuid.New()
+
+	created, err := svc.AddReservedCode(context.Background(), adminID, CreateReservedCodeRequest{Code: " Acme "})
+	require.NoError(t, err)
+	require.Equal(t, "acme", created.Code)
+	require.Equal(t, adminID, *created.CreatedBy)
+
+	_, err = svc.AddReservedCode(context.Background(), adminID, CreateReservedCodeRequest{Code: "ACME"})
+	require.ErrorIs(t, err, commons.ErrReservedCodeExists)
+	_, err = svc.AddReservedCode(context.Background(), adminID, CreateReservedCodeRequest{Code: "metrics"})
+	require.ErrorIs(t, err, commons.ErrReservedCodeExists)
+
+	codes, err := svc.ListReservedCodes(context.Background())
+	require.NoError(t, err)
+	require.Len(t, codes, len(builtinCodes)+1)
+	require.True(t, codes[0].Builtin)
+	require.Equal(t, "acme", codes[len(codes)-1].Code)
+
+	require.ErrorIs(t, svc.DeleteReservedCode(context.Background(), "health"), commons.ErrReservedCodeBuiltin)
+	require.NoError(t, svc.DeleteReservedCode(context.Background(), "ACME"))
+	require.ErrorIs(t, svc.DeleteReservedCode(context.Background(), "acme"), commons.ErrReservedCodeNotFound)
+}
@@ -6,11 +6,19 @@ import (
 	"GoShort/internal/auth"
 	"GoShort/internal/commons"
 	"GoShort/internal/datastore"
+	"GoShort/internal/domain"
 	"GoShort/internal/health"
+	"GoShort/internal/linkalias"
+	"GoShort/internal/linkmeta"
+	"GoShort/internal/linkrule"
+	"GoShort/internal/linkvariant"
 	"GoShort/internal/middleware"
 	"GoShort/internal/redirect"
+	"GoShort/internal/reservedcode"
 	"GoShort/internal/shortlink"
 	"GoShort/internal/stats"
+	"GoShort/internal/urlpolicy"
+	"GoShort/pkg/shortcode"
 
 	"runtime"
 	"strconv"
@@ -29,8 +37,8 @@ func SetupRoutes(app *App) {
 	app.FiberApp.Use(cors.New(cors.Config{
 		AllowOrigins:     "https://goshort.agprastyo.me, https://goshort-api.agprastyo.me, http://localhost:5173, http://localhost:5174, http://localhost:3000, http://127.0.0.1:5173",
 		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-		ExposeHeaders:    "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Access-Control-Allow-Methods",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, " + middleware.IdempotencyHeader,
+		ExposeHeaders:    "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Access-Control-Allow-Methods, " + middleware.IdempotentReplayedHeader,
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -51,7 +59,7 @@ func SetupRoutes(app *App) {
 	if app.Config.RateLimit.Enabled {
 		app.FiberApp.Use(limiter.New(limiter.Config{
 			Next: func(c *fiber.Ctx) bool {
-				if c.Path() == "/health" || c.Path() == "/metrics" || c.Path() == "/swagger/doc.json" {
+				if c.Path() == "/health" || c.Path() == "/metrics" || c.Path() == "/metrics/clicks" || c.Path() == "/swagger/doc.json" {
 					return true
 				}
 				return false
@@ -77,20 +85,32 @@ func SetupRoutes(app *App) {
 	})
 	app.FiberApp.Get("/health", healthHandler.Check)
 	app.FiberApp.Get("/metrics", middleware.BasicAuth(app.Config), monitor.New(monitor.Config{Title: "MyService Metrics Page"}))
+	app.FiberApp.Get("/metrics/clicks", middleware.BasicAuth(app.Config), func(c *fiber.Ctx) error {
+		return c.JSON(app.Clicks.Metrics())
+	})
 	app.FiberApp.Get("/swagger/*", middleware.BasicAuth(app.Config), swagger.New(swagger.Config{
 		URL: "/swagger/doc.json",
 	}))
 
-	redirectService := redirect.NewService(datastore.New(app.DB.DB), app.Logger)
-	redirectHandler := redirect.NewRedirectHandler(redirectService, app.Logger)
+	passwordAttempts := redirect.NewPasswordAttempts(app.Redis, app.Config.LinkPassword, app.Logger)
+	redirectService := redirect.NewService(datastore.New(app.DB.DB), app.LinkCache, app.Clicks, passwordAttempts, app.Geo, app.Logger)
+	redirectTemplates, err := redirect.LoadTemplates(app.Config.Server.TemplateDir)
+	if err != nil {
+		app.Logger.Fatalf("Failed to load redirect templates: %v", err)
+	}
+	redirectHandler := redirect.NewRedirectHandler(redirectService, redirectTemplates, app.Config.Server, app.Config.ShortCode, app.Logger)
 
 	api := app.FiberApp.Group("/api/v1")
 
 	registerAuthHandlers(api, app)
 	registerAdminRoutes(api, app)
 	registerUserRoutes(api, app)
+	registerDomainRoutes(api, app)
 
-	app.FiberApp.Get("/:code", redirectHandler.RedirectToOriginalURL)
+	// Short URLs catch every remaining path, so path-style codes like "/launch/2026"
+	// work; these have to stay the last routes
+	app.FiberApp.Get("/*", redirectHandler.RedirectToOriginalURL)
+	app.FiberApp.Post("/*", redirectHandler.UnlockProtectedLink)
 }
 
 // registerAuthHandlers sets up authentication routes
@@ -117,24 +137,61 @@ func registerAuthHandlers(router fiber.Router, app *App) {
 
 // registerUserRoutes sets up routes for authenticated users to manage their short links
 func registerUserRoutes(router fiber.Router, app *App) {
-	shortLinkService := shortlink.NewService(app.Querier, app.Logger)
+	codeGenerator, err := shortcode.New(app.Config.ShortCode.Strategy, app.Querier)
+	if err != nil {
+		app.Logger.Fatalf("Failed to create short code generator: %v", err)
+	}
+	reservedCodeService := reservedcode.NewService(app.Querier, app.Logger)
+	urlPolicyService := urlpolicy.NewService(app.Querier, nil, app.Config.URLPolicy, app.Config.Server, app.Logger)
+	shortLinkService := shortlink.NewService(app.Querier, app.LinkCache, reservedCodeService, urlPolicyService, app.Metadata, codeGenerator, app.Config.ShortCode, app.Config.Server, app.Logger)
 	shortLinkHandler := shortlink.NewHandler(shortLinkService, app.Logger)
 
 	authMiddleware := middleware.NewAuthMiddleware(app.JWTMaker, app.Logger)
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(app.Redis, app.Config.Idempotency, app.Logger)
 
 	userRoutes := router.Group("/links")
 	userRoutes.Use(authMiddleware.Authenticate())
 
 	userRoutes.Get("/", shortLinkHandler.GetUserLinks)
 	userRoutes.Get("/:id", shortLinkHandler.GetUserLinkByID)
-	userRoutes.Get("/code/:shortCode", shortLinkHandler.GetUserLinkByShortCode)
-	userRoutes.Post("/", shortLinkHandler.CreateShortLink)
+	userRoutes.Get("/code/*", shortLinkHandler.GetUserLinkByShortCode)
+	userRoutes.Post("/", idempotencyMiddleware.Handle(), shortLinkHandler.CreateShortLink)
 	userRoutes.Patch("/:id", shortLinkHandler.UpdateLink)
 	userRoutes.Delete("/:id", shortLinkHandler.DeleteLink)
 	userRoutes.Patch("/:id/status", shortLinkHandler.ToggleLinkStatus)
 
+	// Routing rules
+	linkRuleService := linkrule.NewService(app.Querier, app.LinkCache, urlPolicyService, app.Logger)
+	linkRuleHandler := linkrule.NewHandler(linkRuleService, app.Logger, app.validator)
+
+	userRoutes.Get("/:id/rules", linkRuleHandler.ListRules)
+	userRoutes.Post("/:id/rules", linkRuleHandler.CreateRule)
+	userRoutes.Put("/:id/rules/:ruleId", linkRuleHandler.UpdateRule)
+	userRoutes.Delete("/:id/rules/:ruleId", linkRuleHandler.DeleteRule)
+
+	// A/B variants
+	linkVariantService := linkvariant.NewService(app.Querier, app.LinkCache, urlPolicyService, app.Logger)
+	linkVariantHandler := linkvariant.NewHandler(linkVariantService, app.Logger, app.validator)
+
+	userRoutes.Get("/:id/variants", linkVariantHandler.GetVariants)
+	userRoutes.Put("/:id/variants", linkVariantHandler.SetVariants)
+
+	// Aliases
+	linkAliasService := linkalias.NewService(app.Querier, app.LinkCache, reservedCodeService, app.Config.ShortCode, app.Config.Server, app.Logger)
+	linkAliasHandler := linkalias.NewHandler(linkAliasService, app.Logger, app.validator)
+
+	userRoutes.Get("/:id/aliases", linkAliasHandler.ListAliases)
+	userRoutes.Post("/:id/aliases", linkAliasHandler.CreateAlias)
+	userRoutes.Delete("/:id/aliases/:aliasId", linkAliasHandler.DeleteAlias)
+
+	// Destination metadata
+	linkMetaService := linkmeta.NewService(app.Querier, app.Metadata, app.Logger)
+	linkMetaHandler := linkmeta.NewHandler(linkMetaService, app.Logger)
+
+	userRoutes.Post("/:id/metadata/refresh", linkMetaHandler.RefreshMetadata)
+
 	// Bulk operations
-	userRoutes.Post("/bulk", shortLinkHandler.CreateBulkShortLinks)
+	userRoutes.Post("/bulk", idempotencyMiddleware.Handle(), shortLinkHandler.CreateBulkShortLinks)
 	userRoutes.Delete("/bulk", shortLinkHandler.DeleteBulkShortLinks)
 	userRoutes.Delete("/", shortLinkHandler.DeleteAllLinks)
 
@@ -142,15 +199,31 @@ func registerUserRoutes(router fiber.Router, app *App) {
 	shortLinksStatsHandler := stats.NewShortLinksStatsHandler(shortLinkStatsService, app.Logger)
 
 	userRoutes.Get("/stats", shortLinksStatsHandler.GetUserStats)
-	//userRoutes.Get("/:id/stats", shortLinkHandler.GetLinkStats)
+	userRoutes.Get("/:id/stats", shortLinksStatsHandler.GetLinkStats)
 	//userRoutes.Get("/export", shortLinkHandler.ExportLinks)
 	//userRoutes.Post("/import", shortLinkHandler.ImportLinks)
 }
 
+// registerDomainRoutes sets up routes for users to manage their custom domains
+func registerDomainRoutes(router fiber.Router, app *App) {
+	domainService := domain.NewService(app.Querier, domain.NewVerifier(nil, app.Config.URLPolicy), app.Config.Server, app.Logger)
+	domainHandler := domain.NewHandler(domainService, app.Logger, app.validator)
+
+	authMiddleware := middleware.NewAuthMiddleware(app.JWTMaker, app.Logger)
+
+	domainRoutes := router.Group("/domains")
+	domainRoutes.Use(authMiddleware.Authenticate())
+
+	domainRoutes.Get("/", domainHandler.ListDomains)
+	domainRoutes.Post("/", domainHandler.AddDomain)
+	domainRoutes.Post("/:id/verify", domainHandler.VerifyDomain)
+	domainRoutes.Delete("/:id", domainHandler.DeleteDomain)
+}
+
 // registerAdminRoutes sets up routes for admin users to manage the application
 func registerAdminRoutes(router fiber.Router, app *App) {
 
-	adminService := admin.NewService(app.Querier, app.Logger)
+	adminService := admin.NewService(app.Querier, app.LinkCache, app.Logger)
 	adminHandler := admin.NewHandler(adminService, app.Logger, app.validator)
 
 	authMiddleware := middleware.NewAuthMiddleware(app.JWTMaker, app.Logger)
@@ -165,4 +238,20 @@ func registerAdminRoutes(router fiber.Router, app *App) {
 	adminRoutes.Get("/users/:userId/links", adminHandler.ListUserLinks)
 	adminRoutes.Patch("/links/:id/status", adminHandler.ToggleLinkStatus)
 	adminRoutes.Get("/stats", adminHandler.GetSystemStats)
+
+	// Reserved short codes
+	reservedCodeService := reservedcode.NewService(app.Querier, app.Logger)
+	reservedCodeHandler := reservedcode.NewHandler(reservedCodeService, app.Logger, app.validator)
+
+	adminRoutes.Get("/reserved-codes", reservedCodeHandler.ListReservedCodes)
+	adminRoutes.Post("/reserved-codes", reservedCodeHandler.AddReservedCode)
+	adminRoutes.Delete("/reserved-codes/:code", reservedCodeHandler.DeleteReservedCode)
+
+	// Destination URL blocklist
+	urlPolicyService := urlpolicy.NewService(app.Querier, nil, app.Config.URLPolicy, app.Config.Server, app.Logger)
+	urlPolicyHandler := urlpolicy.NewHandler(urlPolicyService, app.Logger, app.validator)
+
+	adminRoutes.Get("/url-blocklist", urlPolicyHandler.ListBlocklist)
+	adminRoutes.Post("/url-blocklist", urlPolicyHandler.AddBlocklistEntry)
+	adminRoutes.Delete("/url-blocklist/:value", urlPolicyHandler.RemoveBlocklistEntry)
 }
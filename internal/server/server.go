@@ -2,11 +2,18 @@ package server
 
 import (
 	"GoShort/config"
+	"GoShort/internal/cache"
 	"GoShort/internal/datastore"
+	"GoShort/internal/linkhealth"
+	"GoShort/internal/linkmeta"
+	"GoShort/internal/linkschedule"
+	"GoShort/internal/stats"
 	"GoShort/pkg/database"
+	"GoShort/pkg/geoip"
 	"GoShort/pkg/logger"
 	"GoShort/pkg/mail"
 	"GoShort/pkg/redis"
+	"GoShort/pkg/slug"
 	"GoShort/pkg/token"
 	"context"
 	"errors"
@@ -35,6 +42,12 @@ type App struct {
 	Querier   datastore.Querier
 	validator *validator.Validate
 	Mail      mail.IGoogleSMTPService
+	LinkCache cache.ILinkCache
+	Clicks    stats.IClickPipeline
+	Geo       geoip.GeoResolver
+	Schedules linkschedule.IWorker
+	Health    linkhealth.IWorker
+	Metadata  linkmeta.IFetcher
 }
 
 func LoadEnv() {
@@ -68,16 +81,60 @@ func InitApp() *App {
 		log.Fatalf("Failed to initialize Redis: %v", err)
 	}
 
+	// Initialize redirect cache
+	linkCache := cache.NewLinkCache(redisClient, cfg.LinkCache, log)
+
+	// Initialize offline GeoIP resolver
+	geoResolver, err := geoip.New(cfg.GeoIP, log)
+	if err != nil {
+		log.Fatalf("Failed to initialize GeoIP resolver: %v", err)
+	}
+
+	// Start click ingestion pipeline
+	clickPipeline := stats.NewClickPipeline(querier, geoResolver, cfg.Clicks, log)
+
+	// Start applying scheduled destination changes
+	scheduleWorker := linkschedule.NewWorker(querier, linkCache, cfg.Schedule, log)
+
+	// Start checking that link destinations still respond
+	var healthWorker linkhealth.IWorker
+	if cfg.LinkHealth.Enabled {
+		healthWorker = linkhealth.NewWorker(querier, cfg.LinkHealth, cfg.URLPolicy, log)
+	}
+
+	// Start fetching the title and Open Graph tags of link destinations
+	metadataFetcher := linkmeta.NewFetcher(querier, cfg.LinkMetadata, cfg.URLPolicy, log)
+
+	// Behind a reverse proxy without these, every visitor shares the proxy's IP in click
+	// stats, GeoIP and the password lockout
+	switch {
+	case cfg.Server.ProxyHeader == "":
+		log.Warn("SERVER_PROXY_HEADER is not set, client IPs are taken from the connection; set it and SERVER_TRUSTED_PROXIES when running behind a reverse proxy")
+	case len(cfg.Server.TrustedProxies) == 0:
+		log.Warn("SERVER_TRUSTED_PROXIES is not set, the proxy header is ignored and client IPs are taken from the connection",
+			"proxy_header", cfg.Server.ProxyHeader)
+	}
+
 	// Create Fiber app
+	// c.IP() reads the proxy header only on requests from the trusted proxies, so clients
+	// cannot pick their own IP for rate limits and password lockouts
 	fiberApp := fiber.New(fiber.Config{
-		AppName:      "GoShort",
-		ErrorHandler: CustomErrorHandler(log),
+		AppName:                 "GoShort",
+		ErrorHandler:            CustomErrorHandler(log),
+		ProxyHeader:             cfg.Server.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.Server.TrustedProxies,
+		EnableIPValidation:      true,
 	})
 
 	// Initialize JWT Maker
 	jwtMaker := token.NewJWTMaker(cfg)
 
 	val := validator.New()
+	// slug checks short codes against the same policy the link service enforces
+	_ = val.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slug.Valid(slug.Normalize(fl.Field().String(), false))
+	})
 
 	// Initialize mail service
 	mailService := mail.NewGoogleSMTPService(cfg, log)
@@ -92,6 +149,12 @@ func InitApp() *App {
 		Querier:   querier,
 		validator: val,
 		Mail:      mailService,
+		LinkCache: linkCache,
+		Clicks:    clickPipeline,
+		Geo:       geoResolver,
+		Schedules: scheduleWorker,
+		Health:    healthWorker,
+		Metadata:  metadataFetcher,
 	}
 }
 
@@ -116,6 +179,42 @@ func SetupMiddleware(app *App) {
 }
 
 func Cleanup(app *App) {
+	// Flush queued clicks before the database connection goes away
+	if app.Clicks != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), app.Config.Clicks.FlushTimeout)
+		if err := app.Clicks.Close(ctx); err != nil {
+			app.Logger.Errorf("Error flushing click pipeline: %v", err)
+		}
+		cancel()
+	}
+
+	// Let a running batch of scheduled changes finish
+	if app.Schedules != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), app.Config.Schedule.Interval)
+		if err := app.Schedules.Close(ctx); err != nil {
+			app.Logger.Errorf("Error stopping link schedule worker: %v", err)
+		}
+		cancel()
+	}
+
+	// Let a running batch of destination checks finish
+	if app.Health != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), app.Config.LinkHealth.Timeout)
+		if err := app.Health.Close(ctx); err != nil {
+			app.Logger.Errorf("Error stopping link health worker: %v", err)
+		}
+		cancel()
+	}
+
+	// Let the metadata fetches in progress finish
+	if app.Metadata != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), app.Config.LinkMetadata.Timeout)
+		if err := app.Metadata.Close(ctx); err != nil {
+			app.Logger.Errorf("Error stopping link metadata fetcher: %v", err)
+		}
+		cancel()
+	}
+
 	if app.DB != nil {
 		if err := app.DB.Close(); err != nil {
 			app.Logger.Errorf("Error closing DB: %v", err)
@@ -2,9 +2,14 @@ package shortlink
 
 import (
 	"GoShort/internal/datastore"
+	"GoShort/internal/linkhealth"
+	"GoShort/internal/linkmeta"
+	"GoShort/internal/linkrule"
+	"GoShort/internal/linkschedule"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type GetLinksRequest struct {
@@ -15,48 +20,232 @@ type GetLinksRequest struct {
 	Ascending *bool                           `json:"ascending,omitempty" query:"ascending,omitempty" validate:"omitempty"`
 	StartDate *time.Time                      `json:"start_date,omitempty" query:"start_date,omitempty" validate:"omitempty"`
 	EndDate   *time.Time                      `json:"end_date,omitempty" query:"end_date,omitempty" validate:"omitempty"`
+	// Broken keeps only the links whose destination is broken, or only the others
+	Broken *bool `json:"broken,omitempty" query:"broken,omitempty" validate:"omitempty"`
 }
 
 type CreateLinkRequest struct {
 	OriginalURL string     `json:"original_url" validate:"required,url"`
-	ShortCode   *string    `json:"short_code,omitempty" validate:"omitempty,alphanum,min=3,max=20"`
+	ShortCode   *string    `json:"short_code,omitempty" validate:"omitempty,slug"`
 	Title       *string    `json:"title,omitempty" validate:"omitempty,min=1,max=100"`
 	ClickLimit  *int32     `json:"click_limit,omitempty" validate:"omitempty,gte=0"`
 	ExpireAt    *time.Time `json:"expire_at,omitempty" validate:"omitempty"`
+	Password    *string    `json:"password,omitempty" validate:"omitempty,min=4,max=72"`
+	Description *string    `json:"description,omitempty" validate:"omitempty,max=500"`
+	// ForceInterstitial always shows the preview page before redirecting
+	ForceInterstitial bool `json:"force_interstitial,omitempty"`
+	// UTM parameters are added to the destination on redirect
+	UTM *UTMParams `json:"utm,omitempty" validate:"omitempty"`
+	// QueryPassthrough forwards the query string of the short URL to the destination
+	QueryPassthrough bool `json:"query_passthrough,omitempty"`
+	// StartsAt creates the link ahead of a launch; visitors see a "not yet available" page until then
+	StartsAt *time.Time `json:"starts_at,omitempty" validate:"omitempty"`
+	// Schedule switches the destination at the given times
+	Schedule []linkschedule.ChangeRequest `json:"schedule,omitempty" validate:"omitempty,max=20,dive"`
+	// FallbackURL receives visitors while the link is inactive, expired or out of clicks
+	FallbackURL *string `json:"fallback_url,omitempty" validate:"omitempty,url"`
+	// RedirectStatus is 301, 302, 307 or 308; the server default is used when empty
+	RedirectStatus *int `json:"redirect_status,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+	// DomainID puts the link on a verified custom domain instead of the default one
+	DomainID *uuid.UUID `json:"domain_id,omitempty" validate:"omitempty"`
+	// OverrideReserved lets admins assign a code reserved by an admin
+	OverrideReserved bool `json:"override_reserved,omitempty"`
+	// ReuseExisting returns the caller's active link to the same destination on the same
+	// domain, when there is one, instead of creating a link. Ignored when ShortCode is set;
+	// cannot be combined with Password, ClickLimit, StartsAt or ExpireAt otherwise
+	ReuseExisting bool `json:"reuse_existing,omitempty"`
+	// SocialCard is shown instead of the destination's own tags when the link is shared
+	SocialCard *SocialCard `json:"social_card,omitempty" validate:"omitempty"`
 }
 
 type UpdateLinkRequest struct {
 	OriginalURL *string    `json:"original_url,omitempty" validate:"omitempty,url"`
-	ShortCode   *string    `json:"short_code,omitempty" validate:"omitempty,alphanum,min=3,max=20"`
+	ShortCode   *string    `json:"short_code,omitempty" validate:"omitempty,slug"`
 	Title       *string    `json:"title,omitempty" validate:"omitempty,min=1,max=100"`
 	IsActive    *bool      `json:"is_active,omitempty" validate:"omitempty"`
 	ClickLimit  *int32     `json:"click_limit,omitempty" validate:"omitempty,gte=0"`
 	ExpireAt    *time.Time `json:"expire_at,omitempty" validate:"omitempty"`
+	// Password protects the link; an empty string removes the protection
+	Password *string `json:"password,omitempty" validate:"omitempty,min=4,max=72"`
+	// Description is shown on the preview page; an empty string removes it
+	Description       *string `json:"description,omitempty" validate:"omitempty,max=500"`
+	ForceInterstitial *bool   `json:"force_interstitial,omitempty" validate:"omitempty"`
+	// UTM replaces all UTM parameters; fields left empty are removed
+	UTM              *UTMParams `json:"utm,omitempty" validate:"omitempty"`
+	QueryPassthrough *bool      `json:"query_passthrough,omitempty" validate:"omitempty"`
+	// StartsAt moves the start of the link; a time in the past makes it available right away
+	StartsAt *time.Time `json:"starts_at,omitempty" validate:"omitempty"`
+	// ClearStartsAt removes the start so the link is available right away. It cannot be
+	// combined with StartsAt
+	ClearStartsAt bool `json:"clear_starts_at,omitempty"`
+	// Schedule replaces the pending destination changes; an empty list removes them
+	Schedule []linkschedule.ChangeRequest `json:"schedule,omitempty" validate:"omitempty,max=20,dive"`
+	// FallbackURL replaces the fallback URL; an empty string removes it
+	FallbackURL *string `json:"fallback_url,omitempty" validate:"omitempty,url"`
+	// RedirectStatus is 301, 302, 307 or 308; 0 goes back to the server default
+	RedirectStatus *int `json:"redirect_status,omitempty" validate:"omitempty,oneof=0 301 302 307 308"`
+	// OverrideReserved lets admins assign a code reserved by an admin
+	OverrideReserved bool `json:"override_reserved,omitempty"`
+	// KeepOldCode keeps the previous short code as an alias when ShortCode changes.
+	// Defaults to true
+	KeepOldCode *bool `json:"keep_old_code,omitempty"`
+	// SocialCard replaces the social card; fields left empty are removed
+	SocialCard *SocialCard `json:"social_card,omitempty" validate:"omitempty"`
+}
+
+// UTMParams are the campaign parameters added to the destination URL. They replace
+// UTM parameters already present in the original URL.
+type UTMParams struct {
+	Source   *string `json:"source,omitempty" validate:"omitempty,max=200"`
+	Medium   *string `json:"medium,omitempty" validate:"omitempty,max=200"`
+	Campaign *string `json:"campaign,omitempty" validate:"omitempty,max=200"`
+	Term     *string `json:"term,omitempty" validate:"omitempty,max=200"`
+	Content  *string `json:"content,omitempty" validate:"omitempty,max=200"`
+}
+
+// newUTMParams returns the UTM parameters of a link, nil when it has none.
+func newUTMParams(source, medium, campaign, term, content *string) *UTMParams {
+	if source == nil && medium == nil && campaign == nil && term == nil && content == nil {
+		return nil
+	}
+	return &UTMParams{
+		Source:   source,
+		Medium:   medium,
+		Campaign: campaign,
+		Term:     term,
+		Content:  content,
+	}
+}
+
+// SocialCard is how a link looks when it is pasted in a chat or on a social network.
+// Fields left empty fall back to the title and description of the link.
+type SocialCard struct {
+	Title       *string `json:"title,omitempty" validate:"omitempty,max=200"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=500"`
+	// ImageURL is an absolute http or https URL
+	ImageURL *string `json:"image_url,omitempty" validate:"omitempty,http_url,max=2048"`
+}
+
+// newSocialCard returns the social card of a link, nil when it has none.
+func newSocialCard(title, description, imageURL *string) *SocialCard {
+	if title == nil && description == nil && imageURL == nil {
+		return nil
+	}
+	return &SocialCard{
+		Title:       title,
+		Description: description,
+		ImageURL:    imageURL,
+	}
 }
 
 type LinkResponse struct {
 	ID          uuid.UUID `json:"id"`
 	OriginalURL string    `json:"original_url"`
-	ShortCode   string    `json:"short_code"`
-	Title       *string   `json:"title,omitempty"`
-	IsActive    bool      `json:"is_active"`
-	ClickLimit  *int32    `json:"click_limit,omitempty"`
-	ExpireAt    time.Time `json:"expire_at,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	// CanonicalURL is the form of the destination used to find duplicates and search
+	CanonicalURL string `json:"canonical_url"`
+	ShortCode    string `json:"short_code"`
+	// ShortURL is the full short URL, on the custom domain of the link when it has one
+	ShortURL    string     `json:"short_url"`
+	DomainID    *uuid.UUID `json:"domain_id,omitempty"`
+	Title       *string    `json:"title,omitempty"`
+	IsActive    bool       `json:"is_active"`
+	ClickLimit  *int32     `json:"click_limit,omitempty"`
+	ExpireAt    time.Time  `json:"expire_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	HasPassword bool       `json:"has_password"`
+	Description *string    `json:"description,omitempty"`
+	// ForceInterstitial is true when visitors always see the preview page first
+	ForceInterstitial bool       `json:"force_interstitial"`
+	UTM               *UTMParams `json:"utm,omitempty"`
+	QueryPassthrough  bool       `json:"query_passthrough"`
+	StartsAt          *time.Time `json:"starts_at,omitempty"`
+	FallbackURL       *string    `json:"fallback_url,omitempty"`
+	// RedirectStatus is empty when the link uses the server default
+	RedirectStatus *int16 `json:"redirect_status,omitempty"`
+	// SocialCard is empty when the link is shared with the destination's own tags
+	SocialCard *SocialCard `json:"social_card,omitempty"`
+	// Rules are the routing rules in evaluation order
+	Rules []linkrule.RuleResponse `json:"rules,omitempty"`
+	// Schedule lists the destination changes, applied ones included, in time order
+	Schedule []linkschedule.ChangeResponse `json:"schedule,omitempty"`
+	// Reused is true when reuse_existing returned an existing link
+	Reused bool `json:"reused,omitempty"`
+	// DestinationStatus is the latest health check of the destination, empty until the
+	// first one
+	DestinationStatus *linkhealth.Status `json:"destination_status,omitempty"`
+	// Metadata is what was read from the destination page, empty until it was fetched
+	Metadata *linkmeta.Metadata `json:"metadata,omitempty"`
+}
+
+// NewLinkResponse converts a datastore short link to its API representation.
+func NewLinkResponse(link datastore.ShortLink) *LinkResponse {
+	return &LinkResponse{
+		ID:                link.ID,
+		OriginalURL:       link.OriginalUrl,
+		CanonicalURL:      link.CanonicalUrl,
+		ShortCode:         link.ShortCode,
+		Title:             link.Title,
+		IsActive:          link.IsActive,
+		ClickLimit:        link.ClickLimit,
+		ExpireAt:          link.ExpiredAt.Time,
+		CreatedAt:         link.CreatedAt.Time,
+		UpdatedAt:         link.UpdatedAt.Time,
+		HasPassword:       link.PasswordHash != nil,
+		Description:       link.Description,
+		ForceInterstitial: link.ForceInterstitial,
+		UTM:               newUTMParams(link.UtmSource, link.UtmMedium, link.UtmCampaign, link.UtmTerm, link.UtmContent),
+		QueryPassthrough:  link.QueryPassthrough,
+		StartsAt:          timestampPtr(link.StartsAt),
+		FallbackURL:       link.FallbackUrl,
+		RedirectStatus:    link.RedirectStatus,
+		DomainID:          uuidPtr(link.DomainID),
+		SocialCard:        newSocialCard(link.SocialTitle, link.SocialDescription, link.SocialImage),
+	}
+}
+
+func uuidPtr(id pgtype.UUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	u := uuid.UUID(id.Bytes)
+	return &u
+}
+
+func timestampPtr(ts pgtype.Timestamp) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
 }
 
 type LinkResponseWithTotalClicks struct {
-	ID          uuid.UUID `json:"id"`
-	OriginalURL string    `json:"original_url"`
-	ShortCode   string    `json:"short_code"`
-	Title       *string   `json:"title,omitempty"`
-	IsActive    bool      `json:"is_active"`
-	ClickLimit  *int32    `json:"click_limit,omitempty"`
-	ExpireAt    time.Time `json:"expire_at,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	TotalClicks int32     `json:"total_clicks"`
+	ID                uuid.UUID                     `json:"id"`
+	OriginalURL       string                        `json:"original_url"`
+	CanonicalURL      string                        `json:"canonical_url"`
+	ShortCode         string                        `json:"short_code"`
+	ShortURL          string                        `json:"short_url"`
+	DomainID          *uuid.UUID                    `json:"domain_id,omitempty"`
+	Title             *string                       `json:"title,omitempty"`
+	IsActive          bool                          `json:"is_active"`
+	ClickLimit        *int32                        `json:"click_limit,omitempty"`
+	ExpireAt          time.Time                     `json:"expire_at,omitempty"`
+	CreatedAt         time.Time                     `json:"created_at"`
+	UpdatedAt         time.Time                     `json:"updated_at"`
+	HasPassword       bool                          `json:"has_password"`
+	Description       *string                       `json:"description,omitempty"`
+	UTM               *UTMParams                    `json:"utm,omitempty"`
+	StartsAt          *time.Time                    `json:"starts_at,omitempty"`
+	FallbackURL       *string                       `json:"fallback_url,omitempty"`
+	RedirectStatus    *int16                        `json:"redirect_status,omitempty"`
+	SocialCard        *SocialCard                   `json:"social_card,omitempty"`
+	Rules             []linkrule.RuleResponse       `json:"rules,omitempty"`
+	Schedule          []linkschedule.ChangeResponse `json:"schedule,omitempty"`
+	DestinationStatus *linkhealth.Status            `json:"destination_status,omitempty"`
+	Metadata          *linkmeta.Metadata            `json:"metadata,omitempty"`
+	TotalClicks       int32                         `json:"total_clicks"`
+	TotalPreviews     int32                         `json:"total_previews"`
 }
 
 type BulkCreateLinkRequest struct {
@@ -74,6 +263,8 @@ type BulkCreateLinkResponse struct {
 type BulkCreateLinkError struct {
 	Index int    `json:"index"`
 	Error string `json:"error"`
+	// Reason is set when the URL policy rejected a destination of the link
+	Reason string `json:"reason,omitempty"`
 }
 
 type BulkDeleteLinkRequest struct {
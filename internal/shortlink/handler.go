@@ -3,8 +3,11 @@ package shortlink
 import (
 	"GoShort/internal/commons"
 	"GoShort/internal/datastore"
+	"GoShort/internal/urlpolicy"
 
 	"errors"
+	"fmt"
+	"net/url"
 
 	"GoShort/pkg/logger"
 
@@ -131,6 +134,12 @@ func (h *Handler) CreateBulkShortLinks(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid user ID"})
 	}
 
+	for _, link := range req.Links {
+		if link.OverrideReserved && !isAdmin(c) {
+			return c.Status(fiber.StatusForbidden).JSON(commons.ErrorResponse{Error: "Only admins can assign reserved codes"})
+		}
+	}
+
 	resp, err := h.svr.CreateBulkShortLinks(ctx, uuidUser, req)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{Error: "Bulk create failed"})
@@ -149,6 +158,7 @@ func (h *Handler) CreateBulkShortLinks(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param shortCode path string true "Short link code"
+// @Param domain_id query string false "Custom domain of the link; the default domain when empty"
 // @Success 200 {object} dto.SuccessResponse{data=dto.LinkResponse} "Short link retrieved successfully"
 // @Failure 400 {object} dto.ErrorResponse "Invalid short code"
 // @Failure 404 {object} dto.ErrorResponse "Short link not found"
@@ -159,7 +169,11 @@ func (h *Handler) CreateBulkShortLinks(c *fiber.Ctx) error {
 func (h *Handler) GetUserLinkByShortCode(c *fiber.Ctx) error {
 	ctx := c.Context()
 	userID := c.Locals("user_id").(string)
-	shortCode := c.Params("shortCode")
+	// The wildcard keeps the slashes of path-style codes
+	shortCode := c.Params("*")
+	if unescaped, err := url.PathUnescape(shortCode); err == nil {
+		shortCode = unescaped
+	}
 	if shortCode == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
 			Error: "Short code is required",
@@ -173,7 +187,18 @@ func (h *Handler) GetUserLinkByShortCode(c *fiber.Ctx) error {
 		})
 	}
 
-	link, err := h.svr.GetUserLinkByShortCode(ctx, userUUID, shortCode)
+	var domainID *uuid.UUID
+	if raw := c.Query("domain_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Invalid domain ID",
+			})
+		}
+		domainID = &parsed
+	}
+
+	link, err := h.svr.GetUserLinkByShortCode(ctx, userUUID, domainID, shortCode)
 	if err != nil {
 		switch {
 		case errors.Is(err, commons.ErrLinkNotFound):
@@ -202,13 +227,19 @@ func (h *Handler) GetUserLinkByShortCode(c *fiber.Ctx) error {
 // CreateShortLink handles creation of a new short link
 // @Godoc CreateShortLink
 // @Summary Create a new short link
-// @Description Create a new short link for the authenticated user
+// @Description Create a new short link for the authenticated user. Requests carrying an Idempotency-Key header
+// @Description are answered once; retries with the same key get the stored response
 // @Tags Short Links
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Key that makes retries of the request safe"
 // @Param request body dto.CreateLinkRequest true "Create Link Request"
+// @Success 200 {object} dto.SuccessResponse{data=dto.LinkResponse} "Existing short link reused"
 // @Success 201 {object} dto.SuccessResponse{data=dto.LinkResponse} "Short link created successfully"
 // @Failure 400 {object} dto.ErrorResponse "Invalid request body or missing required fields"
+// @Failure 403 {object} dto.ErrorResponse "Only admins can assign reserved codes"
+// @Failure 409 {object} dto.ErrorResponse "Short code already exists on the domain or is reserved"
+// @Failure 422 {object} dto.ErrorResponse{error=dto.Violation} "Destination URL rejected by the URL policy"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Router /api/v1/links [post]
 // @Security ApiKeyAuth
@@ -237,14 +268,79 @@ func (h *Handler) CreateShortLink(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.OverrideReserved && !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(commons.ErrorResponse{
+			Error: "Only admins can assign reserved codes",
+		})
+	}
+
 	// Let the service layer handle the creation using the request DTO
 	link, err := h.svr.CreateLinkFromDTO(c.Context(), userUUID, req)
 	if err != nil {
+		if errors.Is(err, commons.ErrInvalidSchedule) {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Scheduled times must be in the future and starts_at must be before expire_at",
+			})
+		}
+		if errors.Is(err, commons.ErrInvalidRedirectStatus) {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Redirect status must be 301, 302, 307 or 308",
+			})
+		}
+		if errors.Is(err, commons.ErrInvalidSocialCard) {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Social card title must be at most 200 characters, description at most 500 and image_url an http or https URL",
+			})
+		}
+		if errors.Is(err, commons.ErrInvalidReuse) {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "reuse_existing cannot be combined with password, click_limit, starts_at or expire_at",
+			})
+		}
+		if errors.Is(err, commons.ErrDomainNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Domain not found",
+			})
+		}
+		if errors.Is(err, commons.ErrDomainNotVerified) {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Domain has to be verified before links can use it",
+			})
+		}
+		if errors.Is(err, commons.ErrInvalidShortCode) {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Short code must be 3 to 100 letters, digits, emoji, hyphens or underscores, optionally separated by slashes",
+			})
+		}
+		var rejected *urlpolicy.Violation
+		if errors.As(err, &rejected) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(commons.ErrorResponse{
+				Message: "Destination URL rejected",
+				Error:   rejected,
+			})
+		}
+		if errors.Is(err, commons.ErrShortCodeExists) {
+			return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{
+				Error: "Short code already exists on this domain",
+			})
+		}
+		if errors.Is(err, commons.ErrShortCodeReserved) {
+			return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{
+				Error: "Short code is reserved",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{
 			Error: "Failed to create short link: " + err.Error(),
 		})
 	}
 
+	if link.Reused {
+		return c.JSON(commons.SuccessResponse{
+			Message: "Existing short link reused",
+			Data:    link,
+		})
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(commons.SuccessResponse{
 		Message: "Short link created successfully",
 		Data:    link,
@@ -265,6 +361,7 @@ func (h *Handler) CreateShortLink(c *fiber.Ctx) error {
 // @Param ascending query bool false "Order direction (true for ascending, false for descending)"
 // @Param start_date query string false "Filter links created after this date (RFC3339 format)"
 // @Param end_date query string false "Filter links created before this date (RFC3339 format)"
+// @Param broken query bool false "Only links whose destination is broken (true) or not broken (false)"
 // @Success 200 {object} dto.SuccessResponse{data=[]dto.LinkResponse} "Short links retrieved successfully"
 // @Failure 400 {object} dto.ErrorResponse "Invalid query parameters"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
@@ -391,6 +488,8 @@ func (h *Handler) GetUserLinkByID(c *fiber.Ctx) error {
 // @Failure 400 {object} dto.ErrorResponse "Invalid link ID or request body"
 // @Failure 404 {object} dto.ErrorResponse "Short link not found"
 // @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 409 {object} dto.ErrorResponse "Short code already exists or is reserved"
+// @Failure 422 {object} dto.ErrorResponse{error=dto.Violation} "Destination URL rejected by the URL policy"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Router /api/v1/links/{id} [put]
 // @Security ApiKeyAuth
@@ -420,8 +519,51 @@ func (h *Handler) UpdateLink(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.OverrideReserved && !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(commons.ErrorResponse{
+			Error: "Only admins can assign reserved codes",
+		})
+	}
+
 	link, err := h.svr.UpdateUserLink(ctx, userUUID, linkUUID, req)
 	if err != nil {
+		if errors.Is(err, commons.ErrInvalidSchedule) {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Scheduled times must be in the future, starts_at must be before expire_at and cannot be combined with clear_starts_at",
+			})
+		}
+		if errors.Is(err, commons.ErrInvalidRedirectStatus) {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Redirect status must be 301, 302, 307 or 308",
+			})
+		}
+		if errors.Is(err, commons.ErrInvalidSocialCard) {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Social card title must be at most 200 characters, description at most 500 and image_url an http or https URL",
+			})
+		}
+		if errors.Is(err, commons.ErrInvalidShortCode) {
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Short code must be 3 to 100 letters, digits, emoji, hyphens or underscores, optionally separated by slashes",
+			})
+		}
+		var rejected *urlpolicy.Violation
+		if errors.As(err, &rejected) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(commons.ErrorResponse{
+				Message: "Destination URL rejected",
+				Error:   rejected,
+			})
+		}
+		if errors.Is(err, commons.ErrShortCodeExists) {
+			return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{
+				Error: "Short code already exists",
+			})
+		}
+		if errors.Is(err, commons.ErrShortCodeReserved) {
+			return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{
+				Error: "Short code is reserved",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{
 			Error: "Failed to update short link: " + err.Error(),
 		})
@@ -536,3 +678,8 @@ func (h *Handler) ToggleLinkStatus(c *fiber.Ctx) error {
 		Data:    link,
 	})
 }
+
+// isAdmin reports whether the authenticated user has the admin role.
+func isAdmin(c *fiber.Ctx) bool {
+	return fmt.Sprintf("%v", c.Locals("role")) == string(datastore.UserRoleAdmin)
+}
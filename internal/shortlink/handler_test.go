@@ -28,7 +28,7 @@ type mockShortLinkService struct {
 	UpdateUserLinkFunc       func(ctx context.Context, userID, linkID uuid.UUID, req UpdateLinkRequest) (*LinkResponse, error)
 	DeleteUserLinkFunc       func(ctx context.Context, userID, linkID uuid.UUID) error
 	ToggleUserLinkStatusFunc func(ctx context.Context, userID, linkID uuid.UUID) (*LinkResponse, error)
-	ShortCodeExistsFunc      func(ctx context.Context, code string) (bool, error)
+	ShortCodeExistsFunc      func(ctx context.Context, domainID *uuid.UUID, code string) (bool, error)
 }
 
 // Ensure mockShortLinkService implements the service.IService interface.
@@ -58,9 +58,9 @@ func (m *mockShortLinkService) ToggleUserLinkStatus(ctx context.Context, userID,
 	return m.ToggleUserLinkStatusFunc(ctx, userID, linkID)
 }
 
-func (m *mockShortLinkService) ShortCodeExists(ctx context.Context, code string) (bool, error) {
+func (m *mockShortLinkService) ShortCodeExists(ctx context.Context, domainID *uuid.UUID, code string) (bool, error) {
 	if m.ShortCodeExistsFunc != nil {
-		return m.ShortCodeExistsFunc(ctx, code)
+		return m.ShortCodeExistsFunc(ctx, domainID, code)
 	}
 	return false, errors.New("ShortCodeExistsFunc not implemented on mock")
 }
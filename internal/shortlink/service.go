@@ -1,19 +1,41 @@
 package shortlink
 
 import (
+	"GoShort/config"
+	"GoShort/internal/cache"
 	"GoShort/internal/commons"
 	"GoShort/internal/datastore"
+	"GoShort/internal/linkhealth"
+	"GoShort/internal/linkmeta"
+	"GoShort/internal/linkrule"
+	"GoShort/internal/linkschedule"
+	"GoShort/internal/reservedcode"
+	"GoShort/internal/urlpolicy"
 	"GoShort/pkg/helper"
 	"GoShort/pkg/logger"
+	"GoShort/pkg/security"
+	"GoShort/pkg/shortcode"
+	"GoShort/pkg/slug"
 	"context"
 	"errors"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// Limits of a social card; unfurlers cut titles and descriptions much shorter anyway.
+const (
+	maxSocialTitleLength       = 200
+	maxSocialDescriptionLength = 500
+	maxSocialImageURLLength    = 2048
+)
+
 type IService interface {
 	GetUserLinkByID(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) (*LinkResponse, error)
 	CreateLinkFromDTO(ctx context.Context, userID uuid.UUID, req CreateLinkRequest) (*LinkResponse, error)
@@ -22,30 +44,54 @@ type IService interface {
 	UpdateUserLink(ctx context.Context, userID uuid.UUID, linkID uuid.UUID, req UpdateLinkRequest) (*LinkResponse, error)
 	DeleteUserLink(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) error
 	ToggleUserLinkStatus(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) (*LinkResponse, error)
-	ShortCodeExists(ctx context.Context, code string) (bool, error)
-	GetUserLinkByShortCode(ctx context.Context, userID uuid.UUID, shortCode string) (*LinkResponse, error)
+	ShortCodeExists(ctx context.Context, domainID *uuid.UUID, code string) (bool, error)
+	GetUserLinkByShortCode(ctx context.Context, userID uuid.UUID, domainID *uuid.UUID, shortCode string) (*LinkResponse, error)
 	CreateBulkShortLinks(ctx context.Context, userID uuid.UUID, links BulkCreateLinkRequest) (BulkCreateLinkResponse, error)
 	DeleteBulkShortLinks(ctx context.Context, userID uuid.UUID, request BulkDeleteLinkRequest) (BulkDeleteLinkResponse, error)
 	DeleteAllLinks(ctx context.Context, userID uuid.UUID) error
 }
 
 type Service struct {
-	repo datastore.Querier
-	log  *logger.Logger
+	repo     datastore.Querier
+	cache    cache.ILinkCache
+	reserved reservedcode.IService
+	policy   urlpolicy.IService
+	metadata linkmeta.IFetcher
+	codes    shortcode.CodeGenerator
+	codeCfg  config.ShortCodeConfig
+	// baseURL prefixes the short URLs of links on the default domain
+	baseURL string
+	log     *logger.Logger
 }
 
-func NewService(repo datastore.Querier, log *logger.Logger) IService {
-	return &Service{repo: repo, log: log}
+func NewService(repo datastore.Querier, linkCache cache.ILinkCache, reserved reservedcode.IService, policy urlpolicy.IService, metadata linkmeta.IFetcher, codes shortcode.CodeGenerator, codeCfg config.ShortCodeConfig, cfg config.ServerConfig, log *logger.Logger) IService {
+	return &Service{
+		repo:     repo,
+		cache:    linkCache,
+		reserved: reserved,
+		policy:   policy,
+		metadata: metadata,
+		codes:    codes,
+		codeCfg:  codeCfg,
+		baseURL:  strings.TrimSuffix(cfg.BaseURL, "/"),
+		log:      log,
+	}
 }
 
 // DeleteAllLinks deletes all short links for a user
 func (s *Service) DeleteAllLinks(ctx context.Context, userID uuid.UUID) error {
 	// Call datastore to delete all links for the user
-	err := s.repo.DeleteUserShortLink(ctx, userID)
+	deleted, err := s.repo.DeleteAllUserShortLinks(ctx, userID)
 	if err != nil {
 		s.log.Error("failed to delete all short links for user", "user_id", userID.String(), "error", err)
 		return err
 	}
+
+	keys := make([]string, len(deleted))
+	for i, link := range deleted {
+		keys[i] = cache.LinkKey(link.DomainID, link.ShortCode)
+	}
+	_ = s.cache.Invalidate(ctx, keys...)
 	return nil
 }
 
@@ -92,10 +138,15 @@ func (s *Service) CreateBulkShortLinks(ctx context.Context, userID uuid.UUID, re
 		createdLink, err := s.CreateLinkFromDTO(ctx, userID, link)
 		if err != nil {
 			s.log.Error("failed to create link from DTO", "error", err)
-			failed = append(failed, BulkCreateLinkError{
+			failure := BulkCreateLinkError{
 				Index: i,
 				Error: err.Error(),
-			})
+			}
+			var rejected *urlpolicy.Violation
+			if errors.As(err, &rejected) {
+				failure.Reason = rejected.Reason
+			}
+			failed = append(failed, failure)
 			continue
 		}
 		created = append(created, *createdLink)
@@ -110,11 +161,22 @@ func (s *Service) CreateBulkShortLinks(ctx context.Context, userID uuid.UUID, re
 	}, nil
 }
 
-// GetUserLinkByShortCode retrieves a short link by its short code for a specific user
-func (s *Service) GetUserLinkByShortCode(ctx context.Context, userID uuid.UUID, shortCode string) (*LinkResponse, error) {
+// GetUserLinkByShortCode retrieves a short link by its short code for a specific user.
+// A nil domainID looks the code up on the default domain.
+func (s *Service) GetUserLinkByShortCode(ctx context.Context, userID uuid.UUID, domainID *uuid.UUID, shortCode string) (*LinkResponse, error) {
+	shortCode = slug.Normalize(shortCode, s.codeCfg.CaseInsensitive)
 
 	// Call datastore to get the short link by code
-	link, err := s.repo.GetShortLinkByCode(ctx, shortCode)
+	var link datastore.ShortLink
+	var err error
+	if domainID != nil {
+		link, err = s.repo.GetShortLinkByDomainAndCode(ctx, datastore.GetShortLinkByDomainAndCodeParams{
+			DomainID:  pgtype.UUID{Bytes: *domainID, Valid: true},
+			ShortCode: shortCode,
+		})
+	} else {
+		link, err = s.repo.GetShortLinkByCode(ctx, shortCode)
+	}
 	if err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows):
@@ -135,16 +197,9 @@ func (s *Service) GetUserLinkByShortCode(ctx context.Context, userID uuid.UUID,
 	}
 
 	// Convert to response DTO
-	response := &LinkResponse{
-		ID:          link.ID,
-		OriginalURL: link.OriginalUrl,
-		ShortCode:   link.ShortCode,
-		Title:       link.Title,
-		IsActive:    link.IsActive,
-		ClickLimit:  link.ClickLimit,
-		ExpireAt:    link.ExpiredAt.Time,
-		CreatedAt:   link.CreatedAt.Time,
-		UpdatedAt:   link.UpdatedAt.Time,
+	response := NewLinkResponse(link)
+	if err := s.attachDetails(ctx, response); err != nil {
+		return nil, err
 	}
 
 	return response, nil
@@ -174,22 +229,20 @@ func (s *Service) GetUserLinkByID(ctx context.Context, userID uuid.UUID, linkID
 	}
 
 	// Convert to response DTO
-	response := &LinkResponse{
-		ID:          link.ID,
-		OriginalURL: link.OriginalUrl,
-		ShortCode:   link.ShortCode,
-		Title:       link.Title,
-		IsActive:    link.IsActive,
-		ClickLimit:  link.ClickLimit,
-		ExpireAt:    link.ExpiredAt.Time,
-		CreatedAt:   link.CreatedAt.Time,
-		UpdatedAt:   link.UpdatedAt.Time,
+	response := NewLinkResponse(link)
+	if err := s.attachDetails(ctx, response); err != nil {
+		return nil, err
 	}
 
 	return response, nil
 }
 
 func (s *Service) CreateLinkFromDTO(ctx context.Context, userID uuid.UUID, req CreateLinkRequest) (*LinkResponse, error) {
+	// A reused link keeps its own limits, so asking for other ones cannot be honored
+	if req.ReuseExisting && req.ShortCode == nil &&
+		(req.Password != nil || req.ClickLimit != nil || req.ExpireAt != nil || req.StartsAt != nil) {
+		return nil, commons.ErrInvalidReuse
+	}
 
 	linkID, err := uuid.NewV7()
 	if err != nil {
@@ -197,8 +250,52 @@ func (s *Service) CreateLinkFromDTO(ctx context.Context, userID uuid.UUID, req C
 		return nil, err
 	}
 
+	var linkDomain *datastore.Domain
+	if req.DomainID != nil {
+		domain, err := s.userDomain(ctx, userID, *req.DomainID)
+		if err != nil {
+			return nil, err
+		}
+		linkDomain = &domain
+	}
+
+	destinations := []*string{&req.OriginalURL, req.FallbackURL}
+	for i := range req.Schedule {
+		destinations = append(destinations, &req.Schedule[i].DestinationURL)
+	}
+	canonical, err := s.checkDestinations(ctx, destinations...)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ReuseExisting && req.ShortCode == nil {
+		existing, err := s.findReusableLink(ctx, userID, req.DomainID, canonical[0])
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			response := NewLinkResponse(*existing)
+			if err := s.attachDetails(ctx, response); err != nil {
+				return nil, err
+			}
+			response.ShortURL = s.shortURL(linkDomain, existing.ShortCode)
+			response.Reused = true
+			return response, nil
+		}
+	}
+
 	if req.ShortCode != nil {
-		exists, err := s.ShortCodeExists(ctx, *req.ShortCode)
+		code, err := s.normalizeCode(*req.ShortCode)
+		if err != nil {
+			return nil, err
+		}
+		req.ShortCode = &code
+
+		if err := s.reserved.CheckCode(ctx, code, req.OverrideReserved); err != nil {
+			return nil, err
+		}
+
+		exists, err := s.ShortCodeExists(ctx, req.DomainID, code)
 		if err != nil {
 			s.log.Error("failed to check short code exists: %v", err)
 			return nil, err
@@ -207,10 +304,8 @@ func (s *Service) CreateLinkFromDTO(ctx context.Context, userID uuid.UUID, req C
 			return nil, commons.ErrShortCodeExists
 		}
 	} else {
-		// Generate a random short code if not provided from helper function
-		shortCode, err := helper.GenerateShortCode(7)
+		shortCode, err := s.generateCode(ctx, linkID, req.DomainID)
 		if err != nil {
-			s.log.Error("failed to generate short code", "error", err)
 			return nil, err
 		}
 		req.ShortCode = &shortCode
@@ -231,39 +326,98 @@ func (s *Service) CreateLinkFromDTO(ctx context.Context, userID uuid.UUID, req C
 		req.ExpireAt = &defaultExpire
 	}
 
+	var passwordHash *string
+	if req.Password != nil && *req.Password != "" {
+		hash, err := security.HashPassword(*req.Password)
+		if err != nil {
+			s.log.Error("failed to hash link password", "error", err)
+			return nil, err
+		}
+		passwordHash = &hash
+	}
+
 	params := datastore.CreateShortLinkParams{
-		ID:          linkID,
-		UserID:      userID,
-		OriginalUrl: req.OriginalURL,
-		ShortCode:   *req.ShortCode,
-		Title:       req.Title,
-		IsActive:    true,
-		ClickLimit:  req.ClickLimit,
+		ID:           linkID,
+		UserID:       userID,
+		OriginalUrl:  req.OriginalURL,
+		CanonicalUrl: canonical[0],
+		ShortCode:    *req.ShortCode,
+		Title:        req.Title,
+		IsActive:     true,
+		ClickLimit:   req.ClickLimit,
 		ExpiredAt: pgtype.Timestamp{
-			Time: *req.ExpireAt,
+			Time:  req.ExpireAt.UTC(),
+			Valid: true,
 		},
+		PasswordHash:      passwordHash,
+		Description:       helper.EmptyToNil(req.Description),
+		ForceInterstitial: req.ForceInterstitial,
+		QueryPassthrough:  req.QueryPassthrough,
+		FallbackUrl:       helper.EmptyToNil(req.FallbackURL),
+	}
+	if linkDomain != nil {
+		params.DomainID = pgtype.UUID{Bytes: linkDomain.ID, Valid: true}
+	}
+	if req.RedirectStatus != nil {
+		if !helper.IsRedirectStatus(*req.RedirectStatus) {
+			return nil, commons.ErrInvalidRedirectStatus
+		}
+		status := int16(*req.RedirectStatus)
+		params.RedirectStatus = &status
+	}
+	if req.StartsAt != nil {
+		if !req.StartsAt.Before(*req.ExpireAt) {
+			return nil, commons.ErrInvalidSchedule
+		}
+		params.StartsAt = pgtype.Timestamp{Time: req.StartsAt.UTC(), Valid: true}
+	}
+	if err := validateSchedule(req.Schedule); err != nil {
+		return nil, err
+	}
+	if req.UTM != nil {
+		params.UtmSource = helper.EmptyToNil(req.UTM.Source)
+		params.UtmMedium = helper.EmptyToNil(req.UTM.Medium)
+		params.UtmCampaign = helper.EmptyToNil(req.UTM.Campaign)
+		params.UtmTerm = helper.EmptyToNil(req.UTM.Term)
+		params.UtmContent = helper.EmptyToNil(req.UTM.Content)
+	}
+
+	if req.SocialCard != nil {
+		if err := validateSocialCard(req.SocialCard); err != nil {
+			return nil, err
+		}
+		params.SocialTitle = helper.EmptyToNil(req.SocialCard.Title)
+		params.SocialDescription = helper.EmptyToNil(req.SocialCard.Description)
+		params.SocialImage = helper.EmptyToNil(req.SocialCard.ImageURL)
 	}
 
 	// Create the short link in the datastore
 	createdLink, err := s.repo.CreateShortLink(ctx, params)
 	if err != nil {
+		// Another link took the code since it was checked
+		if commons.IsUniqueViolation(err) {
+			return nil, commons.ErrShortCodeExists
+		}
 		s.log.Error("failed to create short link", "error", err)
 		return nil, err
 	}
 
-	// Convert to response DTO
-	response := &LinkResponse{
-		ID:          createdLink.ID,
-		OriginalURL: createdLink.OriginalUrl,
-		ShortCode:   createdLink.ShortCode,
-		Title:       createdLink.Title,
-		IsActive:    createdLink.IsActive,
-		ClickLimit:  createdLink.ClickLimit,
-		ExpireAt:    createdLink.ExpiredAt.Time,
-		CreatedAt:   createdLink.CreatedAt.Time,
-		UpdatedAt:   createdLink.UpdatedAt.Time,
+	schedule, err := s.replaceSchedule(ctx, createdLink.ID, req.Schedule, canonical[2:])
+	if err != nil {
+		return nil, err
 	}
 
+	// Drop any negative cache entry left behind by earlier lookups of this code
+	_ = s.cache.Invalidate(ctx, cache.LinkKey(createdLink.DomainID, createdLink.ShortCode))
+
+	// Name the link after its page, unless it was given a title, once the page is read
+	s.metadata.Enqueue(createdLink.ID, createdLink.OriginalUrl)
+
+	// Convert to response DTO
+	response := NewLinkResponse(createdLink)
+	response.Schedule = schedule
+	response.ShortURL = s.shortURL(linkDomain, createdLink.ShortCode)
+
 	return response, nil
 
 }
@@ -321,26 +475,48 @@ func (s *Service) GetUserLinks(ctx context.Context, userID uuid.UUID, req GetLin
 		params.EndDate = endTime
 	}
 
+	params.Broken = req.Broken
+
 	// Call datastore
 	links, err := s.repo.ListUserShortLinks(ctx, params)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	linkIDs := make([]uuid.UUID, len(links))
+	for i, link := range links {
+		linkIDs[i] = link.ID
+	}
+	linkRules, err := s.rulesByLink(ctx, linkIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	schedules, err := s.schedulesByLink(ctx, linkIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	health, err := s.healthByLink(ctx, linkIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata, err := s.metadataByLink(ctx, linkIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	domains, err := s.userDomains(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Convert datastore results to DTOs
 	response := make([]LinkResponse, len(links))
 	for i, link := range links {
-		response[i] = LinkResponse{
-			ID:          link.ID,
-			OriginalURL: link.OriginalUrl,
-			ShortCode:   link.ShortCode,
-			Title:       link.Title,
-			IsActive:    link.IsActive,
-			ClickLimit:  link.ClickLimit,
-			ExpireAt:    link.ExpiredAt.Time,
-			CreatedAt:   link.CreatedAt.Time,
-			UpdatedAt:   link.UpdatedAt.Time,
-		}
+		response[i] = *NewLinkResponse(link)
+		response[i].Rules = linkRules[link.ID]
+		response[i].Schedule = schedules[link.ID]
+		response[i].DestinationStatus = destinationStatus(health, link.ID, link.OriginalUrl)
+		response[i].Metadata = destinationMetadata(metadata, link.ID, link.OriginalUrl)
+		response[i].ShortURL = s.shortURL(domains[link.DomainID.Bytes], link.ShortCode)
 	}
 
 	// Use the global helper for pagination
@@ -368,6 +544,7 @@ func (s *Service) GetUserLinksWithCount(ctx context.Context, userID uuid.UUID, r
 		SearchText: params.SearchText,
 		StartDate:  params.StartDate,
 		EndDate:    params.EndDate,
+		Broken:     req.Broken,
 	}
 
 	totalCount, err := s.repo.CountUserShortLinks(ctx, countParams)
@@ -418,6 +595,8 @@ func (s *Service) GetUserLinksWithCount(ctx context.Context, userID uuid.UUID, r
 		params.EndDate = endTime
 	}
 
+	params.Broken = req.Broken
+
 	// Call datastore
 	results, err := s.repo.ListUserShortLinksWithCountClick(ctx, params)
 	s.log.Infof("result : %v", results)
@@ -425,19 +604,59 @@ func (s *Service) GetUserLinksWithCount(ctx context.Context, userID uuid.UUID, r
 		return nil, nil, err
 	}
 
+	linkIDs := make([]uuid.UUID, len(results))
+	for i, link := range results {
+		linkIDs[i] = link.ID
+	}
+	linkRules, err := s.rulesByLink(ctx, linkIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	schedules, err := s.schedulesByLink(ctx, linkIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	health, err := s.healthByLink(ctx, linkIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata, err := s.metadataByLink(ctx, linkIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	domains, err := s.userDomains(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	response := make([]LinkResponseWithTotalClicks, len(results))
 	for i, link := range results {
 		response[i] = LinkResponseWithTotalClicks{
-			ID:          link.ID,
-			OriginalURL: link.OriginalUrl,
-			ShortCode:   link.ShortCode,
-			Title:       link.Title,
-			IsActive:    link.IsActive,
-			ClickLimit:  link.ClickLimit,
-			ExpireAt:    link.ExpiredAt.Time,
-			CreatedAt:   link.CreatedAt.Time,
-			UpdatedAt:   link.UpdatedAt.Time,
-			TotalClicks: int32(link.TotalClicks),
+			ID:                link.ID,
+			OriginalURL:       link.OriginalUrl,
+			CanonicalURL:      link.CanonicalUrl,
+			ShortCode:         link.ShortCode,
+			ShortURL:          s.shortURL(domains[link.DomainID.Bytes], link.ShortCode),
+			DomainID:          uuidPtr(link.DomainID),
+			Title:             link.Title,
+			IsActive:          link.IsActive,
+			ClickLimit:        link.ClickLimit,
+			ExpireAt:          link.ExpiredAt.Time,
+			CreatedAt:         link.CreatedAt.Time,
+			UpdatedAt:         link.UpdatedAt.Time,
+			HasPassword:       link.PasswordHash != nil,
+			Description:       link.Description,
+			UTM:               newUTMParams(link.UtmSource, link.UtmMedium, link.UtmCampaign, link.UtmTerm, link.UtmContent),
+			StartsAt:          timestampPtr(link.StartsAt),
+			FallbackURL:       link.FallbackUrl,
+			RedirectStatus:    link.RedirectStatus,
+			SocialCard:        newSocialCard(link.SocialTitle, link.SocialDescription, link.SocialImage),
+			Rules:             linkRules[link.ID],
+			Schedule:          schedules[link.ID],
+			DestinationStatus: destinationStatus(health, link.ID, link.OriginalUrl),
+			Metadata:          destinationMetadata(metadata, link.ID, link.OriginalUrl),
+			TotalClicks:       int32(link.TotalClicks),
+			TotalPreviews:     int32(link.TotalPreviews),
 		}
 	}
 	// Use the global helper with total count from count query
@@ -464,15 +683,46 @@ func (s *Service) UpdateUserLink(ctx context.Context, userID uuid.UUID, linkID u
 		return nil, commons.ErrUnauthorized
 	}
 
+	destinations := []*string{req.OriginalURL, req.FallbackURL}
+	for i := range req.Schedule {
+		destinations = append(destinations, &req.Schedule[i].DestinationURL)
+	}
+	canonical, err := s.checkDestinations(ctx, destinations...)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ShortCode != nil {
+		code, err := s.normalizeCode(*req.ShortCode)
+		if err != nil {
+			return nil, err
+		}
+		req.ShortCode = &code
+	}
+
 	// Check if the short code is changed and if it already exists
-	if req.ShortCode != nil && *req.ShortCode != link.ShortCode {
-		exists, err := s.ShortCodeExists(ctx, *req.ShortCode)
+	renamed := req.ShortCode != nil && *req.ShortCode != link.ShortCode
+	var reclaimedAlias *uuid.UUID
+	if renamed {
+		if err := s.reserved.CheckCode(ctx, *req.ShortCode, req.OverrideReserved); err != nil {
+			return nil, err
+		}
+
+		exists, err := s.ShortCodeExists(ctx, uuidPtr(link.DomainID), *req.ShortCode)
 		if err != nil {
 			s.log.Error("failed to check short code exists: %v", err)
 			return nil, err
 		}
 		if exists {
-			return nil, commons.ErrShortCodeExists
+			// Renaming a link to one of its own aliases swaps the two codes
+			alias, err := s.repo.GetLinkAliasByCode(ctx, datastore.GetLinkAliasByCodeParams{
+				ShortCode: *req.ShortCode,
+				DomainID:  link.DomainID,
+			})
+			if err != nil || alias.LinkID != link.ID {
+				return nil, commons.ErrShortCodeExists
+			}
+			reclaimedAlias = &alias.ID
 		}
 	}
 
@@ -483,8 +733,10 @@ func (s *Service) UpdateUserLink(ctx context.Context, userID uuid.UUID, linkID u
 
 	if req.OriginalURL != nil {
 		params.OriginalUrl = *req.OriginalURL
+		params.CanonicalUrl = canonical[0]
 	} else {
 		params.OriginalUrl = link.OriginalUrl // Keep existing if not provided
+		params.CanonicalUrl = link.CanonicalUrl
 	}
 
 	if req.ShortCode != nil {
@@ -506,11 +758,28 @@ func (s *Service) UpdateUserLink(ctx context.Context, userID uuid.UUID, linkID u
 	}
 
 	if req.ExpireAt != nil {
-		expiredTime := pgtype.Timestamp{Time: *req.ExpireAt}
+		expiredTime := pgtype.Timestamp{Time: req.ExpireAt.UTC(), Valid: true}
 		params.ExpiredAt = expiredTime
 	} else {
 		params.ExpiredAt = link.ExpiredAt // Keep existing if not provided
 	}
+
+	switch {
+	case req.ClearStartsAt && req.StartsAt != nil:
+		return nil, commons.ErrInvalidSchedule
+	case req.ClearStartsAt:
+		params.StartsAt = pgtype.Timestamp{} // Available right away
+	case req.StartsAt != nil:
+		params.StartsAt = pgtype.Timestamp{Time: req.StartsAt.UTC(), Valid: true}
+	default:
+		params.StartsAt = link.StartsAt // Keep existing if not provided
+	}
+	if params.StartsAt.Valid && params.ExpiredAt.Valid && !params.StartsAt.Time.Before(params.ExpiredAt.Time) {
+		return nil, commons.ErrInvalidSchedule
+	}
+	if err := validateSchedule(req.Schedule); err != nil {
+		return nil, err
+	}
 	// If IsActive is not provided, keep the existing value
 	if req.IsActive != nil {
 		params.IsActive = *req.IsActive
@@ -518,24 +787,120 @@ func (s *Service) UpdateUserLink(ctx context.Context, userID uuid.UUID, linkID u
 		params.IsActive = link.IsActive // Keep existing if not provided
 	}
 
+	switch {
+	case req.Password == nil:
+		params.PasswordHash = link.PasswordHash // Keep existing if not provided
+	case *req.Password == "":
+		params.PasswordHash = nil // Remove password protection
+	default:
+		hash, err := security.HashPassword(*req.Password)
+		if err != nil {
+			s.log.Error("failed to hash link password", "error", err)
+			return nil, err
+		}
+		params.PasswordHash = &hash
+	}
+
+	if req.Description != nil {
+		params.Description = helper.EmptyToNil(req.Description)
+	} else {
+		params.Description = link.Description // Keep existing if not provided
+	}
+
+	if req.ForceInterstitial != nil {
+		params.ForceInterstitial = *req.ForceInterstitial
+	} else {
+		params.ForceInterstitial = link.ForceInterstitial // Keep existing if not provided
+	}
+
+	if req.UTM != nil {
+		params.UtmSource = helper.EmptyToNil(req.UTM.Source)
+		params.UtmMedium = helper.EmptyToNil(req.UTM.Medium)
+		params.UtmCampaign = helper.EmptyToNil(req.UTM.Campaign)
+		params.UtmTerm = helper.EmptyToNil(req.UTM.Term)
+		params.UtmContent = helper.EmptyToNil(req.UTM.Content)
+	} else {
+		// Keep existing if not provided
+		params.UtmSource = link.UtmSource
+		params.UtmMedium = link.UtmMedium
+		params.UtmCampaign = link.UtmCampaign
+		params.UtmTerm = link.UtmTerm
+		params.UtmContent = link.UtmContent
+	}
+
+	if req.QueryPassthrough != nil {
+		params.QueryPassthrough = *req.QueryPassthrough
+	} else {
+		params.QueryPassthrough = link.QueryPassthrough // Keep existing if not provided
+	}
+
+	if req.FallbackURL != nil {
+		params.FallbackUrl = helper.EmptyToNil(req.FallbackURL)
+	} else {
+		params.FallbackUrl = link.FallbackUrl // Keep existing if not provided
+	}
+
+	if req.SocialCard != nil {
+		if err := validateSocialCard(req.SocialCard); err != nil {
+			return nil, err
+		}
+		params.SocialTitle = helper.EmptyToNil(req.SocialCard.Title)
+		params.SocialDescription = helper.EmptyToNil(req.SocialCard.Description)
+		params.SocialImage = helper.EmptyToNil(req.SocialCard.ImageURL)
+	} else {
+		// Keep existing if not provided
+		params.SocialTitle = link.SocialTitle
+		params.SocialDescription = link.SocialDescription
+		params.SocialImage = link.SocialImage
+	}
+
+	switch {
+	case req.RedirectStatus == nil:
+		params.RedirectStatus = link.RedirectStatus // Keep existing if not provided
+	case *req.RedirectStatus == 0:
+		params.RedirectStatus = nil // Back to the server default
+	case helper.IsRedirectStatus(*req.RedirectStatus):
+		status := int16(*req.RedirectStatus)
+		params.RedirectStatus = &status
+	default:
+		return nil, commons.ErrInvalidRedirectStatus
+	}
+
 	// Update the link
 	updatedLink, err := s.repo.UpdateShortLink(ctx, params)
 	if err != nil {
+		if commons.IsUniqueViolation(err) {
+			return nil, commons.ErrShortCodeExists
+		}
 		s.log.Error("failed to update short link: %v", err)
 		return nil, err
 	}
 
+	// A nil schedule keeps the pending changes, an empty one removes them
+	if req.Schedule != nil {
+		if _, err := s.replaceSchedule(ctx, linkID, req.Schedule, canonical[2:]); err != nil {
+			return nil, err
+		}
+	}
+
+	invalidate := []string{cache.LinkKey(link.DomainID, link.ShortCode), cache.LinkKey(updatedLink.DomainID, updatedLink.ShortCode)}
+	if renamed {
+		aliasKeys, err := s.renameAliases(ctx, link, reclaimedAlias, req.KeepOldCode == nil || *req.KeepOldCode)
+		if err != nil {
+			return nil, err
+		}
+		invalidate = append(invalidate, aliasKeys...)
+	}
+	_ = s.cache.Invalidate(ctx, invalidate...)
+
+	if updatedLink.OriginalUrl != link.OriginalUrl {
+		s.metadata.Enqueue(updatedLink.ID, updatedLink.OriginalUrl)
+	}
+
 	// Convert to response DTO
-	response := &LinkResponse{
-		ID:          updatedLink.ID,
-		OriginalURL: updatedLink.OriginalUrl,
-		ShortCode:   updatedLink.ShortCode,
-		Title:       updatedLink.Title,
-		IsActive:    updatedLink.IsActive,
-		ClickLimit:  updatedLink.ClickLimit,
-		ExpireAt:    updatedLink.ExpiredAt.Time,
-		CreatedAt:   updatedLink.CreatedAt.Time,
-		UpdatedAt:   updatedLink.UpdatedAt.Time,
+	response := NewLinkResponse(updatedLink)
+	if err := s.attachDetails(ctx, response); err != nil {
+		return nil, err
 	}
 
 	return response, nil
@@ -557,12 +922,26 @@ func (s *Service) DeleteUserLink(ctx context.Context, userID uuid.UUID, linkID u
 		return commons.ErrUnauthorized
 	}
 
+	// The aliases are deleted with the link, so their codes are needed before
+	aliases, err := s.repo.ListLinkAliases(ctx, linkID)
+	if err != nil {
+		s.log.Error("failed to list link aliases", "link_id", linkID.String(), "error", err)
+		return err
+	}
+
 	// Delete the link
 	err = s.repo.DeleteUserShortLink(ctx, linkID)
 	if err != nil {
 		s.log.Error("failed to delete short link: %v", err)
 		return err
 	}
+
+	keys := make([]string, 0, len(aliases)+1)
+	keys = append(keys, cache.LinkKey(link.DomainID, link.ShortCode))
+	for _, alias := range aliases {
+		keys = append(keys, cache.LinkKey(alias.DomainID, alias.ShortCode))
+	}
+	_ = s.cache.Invalidate(ctx, keys...)
 	return nil
 }
 
@@ -589,28 +968,392 @@ func (s *Service) ToggleUserLinkStatus(ctx context.Context, userID uuid.UUID, li
 		return nil, err
 	}
 
+	_ = s.cache.Invalidate(ctx, cache.LinkKey(updatedLink.DomainID, updatedLink.ShortCode))
+
 	// Convert to response DTO
-	response := &LinkResponse{
-		ID:          updatedLink.ID,
-		OriginalURL: updatedLink.OriginalUrl,
-		ShortCode:   updatedLink.ShortCode,
-		Title:       updatedLink.Title,
-		IsActive:    updatedLink.IsActive,
-		ClickLimit:  updatedLink.ClickLimit,
-		ExpireAt:    updatedLink.ExpiredAt.Time,
-		CreatedAt:   updatedLink.CreatedAt.Time,
-		UpdatedAt:   updatedLink.UpdatedAt.Time,
+	response := NewLinkResponse(updatedLink)
+	if err := s.attachDetails(ctx, response); err != nil {
+		return nil, err
 	}
 
 	return response, nil
 }
 
-func (s *Service) ShortCodeExists(ctx context.Context, code string) (bool, error) {
+// attachDetails adds the short URL, the routing rules, the schedule, the destination
+// status and the page metadata of the link to its response.
+func (s *Service) attachDetails(ctx context.Context, response *LinkResponse) error {
+	var linkDomain *datastore.Domain
+	if response.DomainID != nil {
+		domain, err := s.repo.GetDomain(ctx, *response.DomainID)
+		if err != nil {
+			s.log.Error("failed to get link domain", "domain_id", response.DomainID, "error", err)
+			return err
+		}
+		linkDomain = &domain
+	}
+	response.ShortURL = s.shortURL(linkDomain, response.ShortCode)
+
+	linkRules, err := s.rulesByLink(ctx, []uuid.UUID{response.ID})
+	if err != nil {
+		return err
+	}
+	response.Rules = linkRules[response.ID]
+
+	schedules, err := s.schedulesByLink(ctx, []uuid.UUID{response.ID})
+	if err != nil {
+		return err
+	}
+	response.Schedule = schedules[response.ID]
+
+	health, err := s.healthByLink(ctx, []uuid.UUID{response.ID})
+	if err != nil {
+		return err
+	}
+	response.DestinationStatus = destinationStatus(health, response.ID, response.OriginalURL)
+
+	metadata, err := s.metadataByLink(ctx, []uuid.UUID{response.ID})
+	if err != nil {
+		return err
+	}
+	response.Metadata = destinationMetadata(metadata, response.ID, response.OriginalURL)
+	return nil
+}
+
+// rulesByLink loads the routing rules of several links with a single query.
+func (s *Service) rulesByLink(ctx context.Context, linkIDs []uuid.UUID) (map[uuid.UUID][]linkrule.RuleResponse, error) {
+	if len(linkIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.repo.ListLinkRulesByLinkIDs(ctx, linkIDs)
+	if err != nil {
+		s.log.Error("failed to list link rules", "error", err)
+		return nil, err
+	}
+
+	linkRules := make(map[uuid.UUID][]linkrule.RuleResponse)
+	for _, row := range rows {
+		linkRules[row.LinkID] = append(linkRules[row.LinkID], linkrule.NewRuleResponse(row))
+	}
+	return linkRules, nil
+}
+
+// schedulesByLink loads the scheduled destination changes of several links with a single query.
+func (s *Service) schedulesByLink(ctx context.Context, linkIDs []uuid.UUID) (map[uuid.UUID][]linkschedule.ChangeResponse, error) {
+	if len(linkIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.repo.ListLinkSchedulesByLinkIDs(ctx, linkIDs)
+	if err != nil {
+		s.log.Error("failed to list link schedules", "error", err)
+		return nil, err
+	}
+
+	schedules := make(map[uuid.UUID][]linkschedule.ChangeResponse)
+	for _, row := range rows {
+		schedules[row.LinkID] = append(schedules[row.LinkID], linkschedule.NewChangeResponse(row))
+	}
+	return schedules, nil
+}
+
+// healthByLink loads the latest destination checks of several links with a single query.
+func (s *Service) healthByLink(ctx context.Context, linkIDs []uuid.UUID) (map[uuid.UUID]datastore.LinkHealth, error) {
+	if len(linkIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.repo.ListLinkHealthByLinkIDs(ctx, linkIDs)
+	if err != nil {
+		s.log.Error("failed to list link health", "error", err)
+		return nil, err
+	}
+
+	health := make(map[uuid.UUID]datastore.LinkHealth, len(rows))
+	for _, row := range rows {
+		health[row.LinkID] = row
+	}
+	return health, nil
+}
+
+// destinationStatus returns the status of the current destination of a link, nil when
+// it was not checked yet.
+func destinationStatus(health map[uuid.UUID]datastore.LinkHealth, linkID uuid.UUID, destination string) *linkhealth.Status {
+	row, ok := health[linkID]
+	if !ok {
+		return nil
+	}
+	return linkhealth.NewStatus(row, destination)
+}
+
+// metadataByLink loads the page metadata of several links with a single query.
+func (s *Service) metadataByLink(ctx context.Context, linkIDs []uuid.UUID) (map[uuid.UUID]datastore.LinkMetadata, error) {
+	if len(linkIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.repo.ListLinkMetadataByLinkIDs(ctx, linkIDs)
+	if err != nil {
+		s.log.Error("failed to list link metadata", "error", err)
+		return nil, err
+	}
+
+	metadata := make(map[uuid.UUID]datastore.LinkMetadata, len(rows))
+	for _, row := range rows {
+		metadata[row.LinkID] = row
+	}
+	return metadata, nil
+}
+
+// destinationMetadata returns the metadata of the current destination of a link, nil
+// when its page was not fetched yet.
+func destinationMetadata(metadata map[uuid.UUID]datastore.LinkMetadata, linkID uuid.UUID, destination string) *linkmeta.Metadata {
+	row, ok := metadata[linkID]
+	if !ok {
+		return nil
+	}
+	return linkmeta.NewMetadata(row, destination)
+}
+
+// replaceSchedule replaces the pending destination changes of a link. Applied changes
+// are kept as history. canonical holds the canonical form of each destination.
+func (s *Service) replaceSchedule(ctx context.Context, linkID uuid.UUID, changes []linkschedule.ChangeRequest, canonical []string) ([]linkschedule.ChangeResponse, error) {
+	if err := s.repo.DeletePendingLinkSchedules(ctx, linkID); err != nil {
+		s.log.Error("failed to delete pending link schedules", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	responses := make([]linkschedule.ChangeResponse, 0, len(changes))
+	for i, change := range changes {
+		created, err := s.repo.CreateLinkSchedule(ctx, datastore.CreateLinkScheduleParams{
+			ID:             uuid.New(),
+			LinkID:         linkID,
+			DestinationUrl: change.DestinationURL,
+			SwitchAt:       pgtype.Timestamp{Time: change.At.UTC(), Valid: true},
+			CanonicalUrl:   canonical[i],
+		})
+		if err != nil {
+			s.log.Error("failed to create link schedule", "link_id", linkID, "error", err)
+			return nil, err
+		}
+		responses = append(responses, linkschedule.NewChangeResponse(created))
+	}
+
+	sort.Slice(responses, func(i, j int) bool { return responses[i].At.Before(responses[j].At) })
+	return responses, nil
+}
+
+// validateSchedule rejects destination changes that are not in the future.
+func validateSchedule(changes []linkschedule.ChangeRequest) error {
+	now := time.Now()
+	for _, change := range changes {
+		if !change.At.After(now) {
+			return commons.ErrInvalidSchedule
+		}
+	}
+	return nil
+}
+
+// validateSocialCard rejects social cards with text too long for the card or an image
+// that is not an absolute http or https URL.
+func validateSocialCard(card *SocialCard) error {
+	if card.Title != nil && utf8.RuneCountInString(*card.Title) > maxSocialTitleLength {
+		return commons.ErrInvalidSocialCard
+	}
+	if card.Description != nil && utf8.RuneCountInString(*card.Description) > maxSocialDescriptionLength {
+		return commons.ErrInvalidSocialCard
+	}
+	if card.ImageURL != nil && *card.ImageURL != "" {
+		image, err := url.Parse(*card.ImageURL)
+		if err != nil || (image.Scheme != "http" && image.Scheme != "https") || image.Host == "" || len(*card.ImageURL) > maxSocialImageURLLength {
+			return commons.ErrInvalidSocialCard
+		}
+	}
+	return nil
+}
+
+// userDomain loads a custom domain of the user that links can be created on.
+func (s *Service) userDomain(ctx context.Context, userID, domainID uuid.UUID) (datastore.Domain, error) {
+	domain, err := s.repo.GetDomain(ctx, domainID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datastore.Domain{}, commons.ErrDomainNotFound
+		}
+		s.log.Error("failed to get domain", "domain_id", domainID, "error", err)
+		return datastore.Domain{}, err
+	}
+	if domain.UserID != userID {
+		return datastore.Domain{}, commons.ErrDomainNotFound
+	}
+	if !domain.VerifiedAt.Valid {
+		return datastore.Domain{}, commons.ErrDomainNotVerified
+	}
+	return domain, nil
+}
+
+// userDomains loads the custom domains of a user keyed by ID.
+func (s *Service) userDomains(ctx context.Context, userID uuid.UUID) (map[[16]byte]*datastore.Domain, error) {
+	rows, err := s.repo.ListUserDomains(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to list user domains", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	domains := make(map[[16]byte]*datastore.Domain, len(rows))
+	for i := range rows {
+		domains[rows[i].ID] = &rows[i]
+	}
+	return domains, nil
+}
+
+// shortURL builds the public URL of a short code, on the custom domain when one is given.
+// Custom domains are always served over HTTPS.
+func (s *Service) shortURL(domain *datastore.Domain, code string) string {
+	// Unicode codes are percent-encoded, the slashes of path-style codes are kept
+	path := (&url.URL{Path: "/" + code}).EscapedPath()
+	if domain == nil {
+		return s.baseURL + path
+	}
+	return "https://" + domain.Hostname + path
+}
+
+// renameAliases updates the aliases of a renamed link: the alias the link took its new
+// code from is removed and, when keepOldCode is set, the previous code becomes an alias
+// so printed QR codes and shared URLs keep working. It returns the cache keys of the
+// aliases, whose entries still lead to the previous code.
+func (s *Service) renameAliases(ctx context.Context, link datastore.ShortLink, reclaimedAlias *uuid.UUID, keepOldCode bool) ([]string, error) {
+	if reclaimedAlias != nil {
+		if _, err := s.repo.DeleteLinkAlias(ctx, datastore.DeleteLinkAliasParams{ID: *reclaimedAlias, LinkID: link.ID}); err != nil {
+			s.log.Error("failed to delete reclaimed link alias", "link_id", link.ID, "alias_id", *reclaimedAlias, "error", err)
+			return nil, err
+		}
+	}
+
+	if keepOldCode {
+		_, err := s.repo.CreateLinkAlias(ctx, datastore.CreateLinkAliasParams{
+			ID:        uuid.New(),
+			LinkID:    link.ID,
+			DomainID:  link.DomainID,
+			ShortCode: link.ShortCode,
+		})
+		if err != nil {
+			s.log.Error("failed to keep previous short code as alias", "link_id", link.ID, "short_code", link.ShortCode, "error", err)
+			return nil, err
+		}
+	}
+
+	aliases, err := s.repo.ListLinkAliases(ctx, link.ID)
+	if err != nil {
+		s.log.Error("failed to list link aliases", "link_id", link.ID, "error", err)
+		return nil, err
+	}
+	keys := make([]string, len(aliases))
+	for i, alias := range aliases {
+		keys[i] = cache.LinkKey(alias.DomainID, alias.ShortCode)
+	}
+	return keys, nil
+}
+
+// checkDestinations applies the URL policy to the destinations set by a request and
+// replaces them with the destinations to store. It returns the canonical form of each,
+// in the same order; nil and empty ones are skipped and have none.
+func (s *Service) checkDestinations(ctx context.Context, destinations ...*string) ([]string, error) {
+	canonical := make([]string, len(destinations))
+	for i, destination := range destinations {
+		if destination == nil || *destination == "" {
+			continue
+		}
+		checked, err := s.policy.Check(ctx, *destination)
+		if err != nil {
+			return nil, err
+		}
+		*destination = checked.URL
+		canonical[i] = checked.Canonical
+	}
+	return canonical, nil
+}
+
+// findReusableLink returns the newest active link of the user on the domain that leads
+// to the canonical destination, nil when there is none.
+func (s *Service) findReusableLink(ctx context.Context, userID uuid.UUID, domainID *uuid.UUID, canonicalURL string) (*datastore.ShortLink, error) {
+	params := datastore.GetUserActiveLinkByCanonicalURLParams{
+		UserID:       userID,
+		CanonicalUrl: canonicalURL,
+	}
+	if domainID != nil {
+		params.DomainID = pgtype.UUID{Bytes: *domainID, Valid: true}
+	}
+	link, err := s.repo.GetUserActiveLinkByCanonicalURL(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		s.log.Error("failed to find link for reuse", "error", err)
+		return nil, err
+	}
+	return &link, nil
+}
+
+// normalizeCode applies the slug policy to a code chosen by the user and returns the
+// form it is stored in.
+func (s *Service) normalizeCode(code string) (string, error) {
+	code = slug.Normalize(code, s.codeCfg.CaseInsensitive)
+	if !slug.Valid(code) {
+		return "", commons.ErrInvalidShortCode
+	}
+	return code, nil
+}
+
+// generateCode creates a free code for a new link. A taken or reserved code is retried
+// one character longer, up to the configured maximum length.
+func (s *Service) generateCode(ctx context.Context, linkID uuid.UUID, domainID *uuid.UUID) (string, error) {
+	length := s.codeCfg.Length
+	for attempt := 1; attempt <= s.codeCfg.MaxAttempts; attempt++ {
+		code, err := s.codes.Generate(ctx, linkID, length)
+		if err != nil {
+			s.log.Error("failed to generate short code", "error", err)
+			return "", err
+		}
+		if s.codeCfg.CaseInsensitive {
+			code = strings.ToLower(code)
+		}
+
+		err = s.reserved.CheckCode(ctx, code, false)
+		switch {
+		case err == nil:
+			exists, err := s.ShortCodeExists(ctx, domainID, code)
+			if err != nil {
+				return "", err
+			}
+			if !exists {
+				return code, nil
+			}
+		case !errors.Is(err, commons.ErrShortCodeReserved):
+			return "", err
+		}
+
+		s.log.Warn("generated short code is not available", "code", code, "attempt", attempt)
+		if length < s.codeCfg.MaxLength {
+			length++
+		}
+	}
+
+	s.log.Error("no free short code after retries", "attempts", s.codeCfg.MaxAttempts, "length", length)
+	return "", commons.ErrShortCodeExists
+}
+
+// ShortCodeExists reports whether the code is taken on the domain. A nil domainID checks
+// the default domain.
+func (s *Service) ShortCodeExists(ctx context.Context, domainID *uuid.UUID, code string) (bool, error) {
 	if code == "" {
 		return false, nil
 	}
 
-	exists, err := s.repo.CheckShortCodeExists(ctx, code)
+	params := datastore.CheckShortCodeExistsParams{ShortCode: code}
+	if domainID != nil {
+		params.DomainID = pgtype.UUID{Bytes: *domainID, Valid: true}
+	}
+	exists, err := s.repo.CheckShortCodeExists(ctx, params)
 	if err != nil {
 		s.log.Error("failed to check if short code exists: %v", err)
 		return false, err
@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"GoShort/internal/datastore"
+	"GoShort/pkg/helper"
+	"GoShort/pkg/logger"
+	"GoShort/pkg/useragent"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// BackfillUserAgents parses the stored user_agent of link_stats rows recorded before
+// browser/OS detection existed and fills in browser, browser_version, os, device_type
+// and is_bot. It walks the table in id order and returns the number of rows updated.
+func BackfillUserAgents(ctx context.Context, repo datastore.Querier, batchSize int, log *logger.Logger) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	updated := 0
+	after := uuid.Nil
+	for {
+		rows, err := repo.ListLinkStatsWithoutClientInfo(ctx, datastore.ListLinkStatsWithoutClientInfoParams{
+			ID:    after,
+			Limit: int64(batchSize),
+		})
+		if err != nil {
+			return updated, err
+		}
+		if len(rows) == 0 {
+			return updated, nil
+		}
+
+		for _, row := range rows {
+			ua := ""
+			if row.UserAgent != nil {
+				ua = *row.UserAgent
+			}
+			parsed := useragent.Parse(ua)
+
+			err := repo.UpdateLinkStatClientInfo(ctx, datastore.UpdateLinkStatClientInfoParams{
+				ID:             row.ID,
+				Browser:        helper.StringToPtr(parsed.Browser),
+				BrowserVersion: nilIfEmpty(parsed.BrowserVersion),
+				Os:             helper.StringToPtr(parsed.OS),
+				DeviceType:     helper.StringToPtr(parsed.DeviceType),
+				IsBot:          parsed.IsBot,
+			})
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+
+		after = rows[len(rows)-1].ID
+		log.Info("backfilled user agents", "updated", updated)
+	}
+}
@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"GoShort/internal/datastore"
+	"GoShort/pkg/helper"
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackfillRepo holds link_stats rows keyed by id with their parsed client info.
+type fakeBackfillRepo struct {
+	datastore.Querier
+
+	userAgents map[uuid.UUID]*string
+	updated    map[uuid.UUID]datastore.UpdateLinkStatClientInfoParams
+}
+
+func (f *fakeBackfillRepo) ListLinkStatsWithoutClientInfo(ctx context.Context, arg datastore.ListLinkStatsWithoutClientInfoParams) ([]datastore.ListLinkStatsWithoutClientInfoRow, error) {
+	var rows []datastore.ListLinkStatsWithoutClientInfoRow
+	for id, ua := range f.userAgents {
+		if _, done := f.updated[id]; done || bytes.Compare(id[:], arg.ID[:]) <= 0 {
+			continue
+		}
+		rows = append(rows, datastore.ListLinkStatsWithoutClientInfoRow{ID: id, UserAgent: ua})
+	}
+	sort.Slice(rows, func(i, j int) bool { return bytes.Compare(rows[i].ID[:], rows[j].ID[:]) < 0 })
+	if int64(len(rows)) > arg.Limit {
+		rows = rows[:arg.Limit]
+	}
+	return rows, nil
+}
+
+func (f *fakeBackfillRepo) UpdateLinkStatClientInfo(ctx context.Context, arg datastore.UpdateLinkStatClientInfoParams) error {
+	f.updated[arg.ID] = arg
+	return nil
+}
+
+func TestBackfillUserAgents(t *testing.T) {
+	repo := &fakeBackfillRepo{
+		userAgents: map[uuid.UUID]*string{},
+		updated:    map[uuid.UUID]datastore.UpdateLinkStatClientInfoParams{},
+	}
+
+	iphone := uuid.New()
+	repo.userAgents[iphone] = helper.StringToPtr("Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1")
+	bot := uuid.New()
+	repo.userAgents[bot] = helper.StringToPtr("facebookexternalhit/1.1")
+	missing := uuid.New()
+	repo.userAgents[missing] = nil
+	for i := 0; i < 5; i++ {
+		repo.userAgents[uuid.New()] = helper.StringToPtr("curl/8.5.0")
+	}
+
+	updated, err := BackfillUserAgents(context.Background(), repo, 3, newTestLogger())
+	require.NoError(t, err)
+	require.Equal(t, 8, updated)
+	require.Len(t, repo.updated, 8)
+
+	require.Equal(t, "Safari", *repo.updated[iphone].Browser)
+	require.Equal(t, "iOS", *repo.updated[iphone].Os)
+	require.Equal(t, "Mobile", *repo.updated[iphone].DeviceType)
+	require.False(t, repo.updated[iphone].IsBot)
+
+	require.True(t, repo.updated[bot].IsBot)
+	require.Equal(t, "Bot", *repo.updated[bot].DeviceType)
+
+	require.Equal(t, "Unknown", *repo.updated[missing].DeviceType)
+}
@@ -1,11 +1,24 @@
 package stats
 
+import "github.com/google/uuid"
+
 type CreateLinkStatRequest struct {
-	IpAddress  *string `json:"ip_address"`
-	UserAgent  *string `json:"user_agent"`
-	Referrer   *string `json:"referrer"`
-	Country    *string `json:"country"`
-	DeviceType *string `json:"device_type"`
+	IpAddress      *string `json:"ip_address"`
+	UserAgent      *string `json:"user_agent"`
+	Referrer       *string `json:"referrer"`
+	Country        *string `json:"country"`
+	DeviceType     *string `json:"device_type"`
+	Region         *string `json:"region"`
+	City           *string `json:"city"`
+	ASN            *int64  `json:"asn"`
+	Browser        *string `json:"browser"`
+	BrowserVersion *string `json:"browser_version"`
+	OS             *string `json:"os"`
+	IsBot          bool    `json:"is_bot"`
+	// VariantID is the A/B variant the click was sent to
+	VariantID *uuid.UUID `json:"variant_id"`
+	// AliasID is the alias code the visitor used, nil for the link's own code
+	AliasID *uuid.UUID `json:"alias_id"`
 }
 
 type StatsResponse struct {
@@ -14,3 +27,25 @@ type StatsResponse struct {
 	ActiveLinks   int64 `json:"active_links"`
 	InactiveLinks int64 `json:"inactive_links"`
 }
+
+// LinkStatsResponse summarises the clicks of one short link. Bot hits are not counted.
+type LinkStatsResponse struct {
+	LinkID         uuid.UUID `json:"link_id"`
+	TotalClicks    int64     `json:"total_clicks"`
+	UniqueVisitors int64     `json:"unique_visitors"`
+	// UnassignedClicks were not sent to a variant: before the A/B test, or a rule matched
+	UnassignedClicks  int64          `json:"unassigned_clicks"`
+	VariantAssignment string         `json:"variant_assignment"`
+	Variants          []VariantStats `json:"variants"`
+}
+
+// VariantStats are the clicks served by one A/B variant.
+type VariantStats struct {
+	VariantID      uuid.UUID `json:"variant_id"`
+	Label          string    `json:"label"`
+	DestinationURL string    `json:"destination_url"`
+	Weight         int32     `json:"weight"`
+	Clicks         int64     `json:"clicks"`
+	// Share is the percentage of all variant clicks this variant received
+	Share float64 `json:"share"`
+}
@@ -1,9 +1,12 @@
 package stats
 
 import (
+	"GoShort/internal/commons"
 	"GoShort/pkg/logger"
+	"errors"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type ShortLinksStatsHandler struct {
@@ -33,3 +36,52 @@ func (h *ShortLinksStatsHandler) GetUserStats(c *fiber.Ctx) error {
 
 	return c.JSON(nil)
 }
+
+// GetLinkStats retrieves the click statistics of a short link
+// @Godoc GetLinkStats
+// @Summary Get the click statistics of a short link
+// @Description Retrieve total and unique clicks of a short link and the clicks served by each of its A/B variants. Bot hits are not counted
+// @Tags Link Stats
+// @Accept json
+// @Produce json
+// @Param id path string true "Short link ID"
+// @Success 200 {object} dto.SuccessResponse{data=dto.LinkStatsResponse} "Link stats retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid link ID"
+// @Failure 403 {object} dto.ErrorResponse "Unauthorized access to this link"
+// @Failure 404 {object} dto.ErrorResponse "Short link not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/links/{id}/stats [get]
+// @Security ApiKeyAuth
+func (h *ShortLinksStatsHandler) GetLinkStats(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(commons.ErrorResponse{Error: "Unauthorized"})
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid user ID"})
+	}
+
+	linkUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid link ID"})
+	}
+
+	resp, err := h.svr.GetLinkStats(c.Context(), userUUID, linkUUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, commons.ErrLinkNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(commons.ErrorResponse{Error: "Short link not found"})
+		case errors.Is(err, commons.ErrUnauthorized):
+			return c.Status(fiber.StatusForbidden).JSON(commons.ErrorResponse{Error: "You are not authorized to access this link"})
+		default:
+			h.log.Error("Failed to retrieve link stats", "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{Error: "Failed to retrieve link stats"})
+		}
+	}
+
+	return c.JSON(commons.SuccessResponse{
+		Message: "Link stats retrieved successfully",
+		Data:    resp,
+	})
+}
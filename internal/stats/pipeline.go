@@ -0,0 +1,397 @@
+package stats
+
+import (
+	"GoShort/config"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/geoip"
+	"GoShort/pkg/helper"
+	"GoShort/pkg/logger"
+	"GoShort/pkg/useragent"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Reasons a request is recorded as a preview instead of a click.
+const (
+	PreviewReasonBot      = "bot"
+	PreviewReasonPrefetch = "prefetch"
+	PreviewReasonHead     = "head"
+	PreviewReasonPage     = "page"
+)
+
+// ClickEvent is a single redirect waiting to be written to link_stats. Events with a
+// PreviewReason come from unfurlers, crawlers or prefetches and go to link_previews.
+type ClickEvent struct {
+	LinkID        uuid.UUID
+	ClickTime     time.Time
+	Info          CreateLinkStatRequest
+	PreviewReason string
+}
+
+// ClickPipelineMetrics is a point-in-time snapshot of the pipeline counters.
+type ClickPipelineMetrics struct {
+	Enqueued      uint64 `json:"enqueued"`
+	Dropped       uint64 `json:"dropped"`
+	Inserted      uint64 `json:"inserted"`
+	Failed        uint64 `json:"failed"`
+	Discarded     uint64 `json:"discarded"`
+	Batches       uint64 `json:"batches"`
+	QueueLength   int    `json:"queue_length"`
+	QueueCapacity int    `json:"queue_capacity"`
+	Workers       int    `json:"workers"`
+}
+
+type IClickPipeline interface {
+	Enqueue(event ClickEvent) bool
+	Metrics() ClickPipelineMetrics
+	Close(ctx context.Context) error
+}
+
+// ClickPipeline ingests clicks through a bounded queue drained by a fixed pool of workers
+// that write link_stats rows in batches. When the queue is full new clicks are dropped
+// instead of blocking the redirect.
+type ClickPipeline struct {
+	repo datastore.Querier
+	geo  geoip.GeoResolver
+	cfg  config.ClickPipelineConfig
+	log  *logger.Logger
+
+	queue  chan ClickEvent
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+
+	enqueued  atomic.Uint64
+	dropped   atomic.Uint64
+	inserted  atomic.Uint64
+	failed    atomic.Uint64
+	discarded atomic.Uint64
+	batches   atomic.Uint64
+}
+
+// NewClickPipeline creates the pipeline and starts its workers. Clicks are geolocated
+// with geo before they are written; a nil resolver disables geolocation.
+func NewClickPipeline(repo datastore.Querier, geo geoip.GeoResolver, cfg config.ClickPipelineConfig, log *logger.Logger) *ClickPipeline {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.FlushTimeout <= 0 {
+		cfg.FlushTimeout = 10 * time.Second
+	}
+
+	p := &ClickPipeline{
+		repo:  repo,
+		geo:   geo,
+		cfg:   cfg,
+		log:   log,
+		queue: make(chan ClickEvent, cfg.QueueSize),
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+
+	return p
+}
+
+// Enqueue hands a click to the pipeline without blocking. It reports false when the
+// click was dropped because the queue is full or the pipeline is shutting down.
+func (p *ClickPipeline) Enqueue(event ClickEvent) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.dropped.Add(1)
+		return false
+	}
+
+	if event.ClickTime.IsZero() {
+		event.ClickTime = time.Now()
+	}
+
+	select {
+	case p.queue <- event:
+		p.enqueued.Add(1)
+		return true
+	default:
+		p.dropped.Add(1)
+		return false
+	}
+}
+
+// Metrics returns the current pipeline counters.
+func (p *ClickPipeline) Metrics() ClickPipelineMetrics {
+	return ClickPipelineMetrics{
+		Enqueued:      p.enqueued.Load(),
+		Dropped:       p.dropped.Load(),
+		Inserted:      p.inserted.Load(),
+		Failed:        p.failed.Load(),
+		Discarded:     p.discarded.Load(),
+		Batches:       p.batches.Load(),
+		QueueLength:   len(p.queue),
+		QueueCapacity: cap(p.queue),
+		Workers:       p.cfg.Workers,
+	}
+}
+
+// Close stops accepting clicks and waits for the workers to flush what is queued.
+func (p *ClickPipeline) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		m := p.Metrics()
+		p.log.Info("click pipeline flushed", "inserted", m.Inserted, "dropped", m.Dropped, "failed", m.Failed)
+		return nil
+	case <-ctx.Done():
+		p.log.Error("click pipeline did not flush before shutdown deadline", "queued", len(p.queue))
+		return ctx.Err()
+	}
+}
+
+func (p *ClickPipeline) worker() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]ClickEvent, 0, p.cfg.BatchSize)
+	for {
+		select {
+		case event, ok := <-p.queue:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= p.cfg.BatchSize {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+func (p *ClickPipeline) flush(batch []ClickEvent) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.FlushTimeout)
+	defer cancel()
+
+	params := make([]datastore.CreateLinkStatsParams, 0, len(batch))
+	var previews []datastore.CreateLinkPreviewsParams
+	for _, event := range batch {
+		recordUUID, err := uuid.NewV7()
+		if err != nil {
+			p.failed.Add(1)
+			p.log.Error("failed to generate UUID for link stat", "error", err, "link_id", event.LinkID)
+			continue
+		}
+
+		info := event.Info
+		applyUserAgent(&info)
+
+		if event.PreviewReason != "" {
+			var botName *string
+			if info.IsBot {
+				botName = info.Browser
+			}
+			previews = append(previews, datastore.CreateLinkPreviewsParams{
+				ID:          recordUUID,
+				LinkID:      event.LinkID,
+				PreviewTime: pgtype.Timestamptz{Time: event.ClickTime, Valid: true},
+				Reason:      event.PreviewReason,
+				BotName:     botName,
+				IpAddress:   info.IpAddress,
+				UserAgent:   info.UserAgent,
+				Referrer:    info.Referrer,
+			})
+			continue
+		}
+
+		p.enrich(&info)
+
+		params = append(params, datastore.CreateLinkStatsParams{
+			ID:             recordUUID,
+			LinkID:         event.LinkID,
+			ClickTime:      pgtype.Timestamptz{Time: event.ClickTime, Valid: true},
+			IpAddress:      info.IpAddress,
+			UserAgent:      info.UserAgent,
+			Referrer:       info.Referrer,
+			Country:        info.Country,
+			DeviceType:     info.DeviceType,
+			Region:         info.Region,
+			City:           info.City,
+			Asn:            info.ASN,
+			Browser:        info.Browser,
+			BrowserVersion: info.BrowserVersion,
+			Os:             info.OS,
+			IsBot:          info.IsBot,
+			VariantID:      optionalUUID(info.VariantID),
+			AliasID:        optionalUUID(info.AliasID),
+		})
+	}
+
+	if len(params) > 0 {
+		insertBatch(ctx, p, "link stats", params, p.repo.CreateLinkStats,
+			func(row datastore.CreateLinkStatsParams) uuid.UUID { return row.LinkID })
+	}
+
+	if len(previews) > 0 {
+		insertBatch(ctx, p, "link previews", previews, p.repo.CreateLinkPreviews,
+			func(row datastore.CreateLinkPreviewsParams) uuid.UUID { return row.LinkID })
+	}
+}
+
+// insertBatch writes a batch of rows with insert. A link deleted after its clicks were
+// queued fails the whole batch on its foreign key, so the batch is written once more
+// without the rows of links that no longer exist.
+func insertBatch[T any](ctx context.Context, p *ClickPipeline, kind string, rows []T, insert func(context.Context, []T) (int64, error), linkID func(T) uuid.UUID) {
+	p.batches.Add(1)
+	count, err := insert(ctx, rows)
+	if err != nil && commons.IsForeignKeyViolation(err) {
+		var kept []T
+		kept, err = withoutDeletedLinks(ctx, p.repo, rows, linkID)
+		if err == nil {
+			p.discarded.Add(uint64(len(rows) - len(kept)))
+			rows = kept
+			if len(rows) > 0 {
+				p.batches.Add(1)
+				count, err = insert(ctx, rows)
+			}
+		}
+	}
+
+	if err != nil {
+		p.failed.Add(uint64(len(rows)))
+		p.log.Error("failed to insert "+kind+" batch", "size", len(rows), "error", err)
+		return
+	}
+	p.inserted.Add(uint64(count))
+}
+
+// withoutDeletedLinks drops the rows whose link no longer exists.
+func withoutDeletedLinks[T any](ctx context.Context, repo datastore.Querier, rows []T, linkID func(T) uuid.UUID) ([]T, error) {
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, linkID(row))
+	}
+
+	existing, err := repo.ListExistingShortLinkIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[uuid.UUID]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	kept := make([]T, 0, len(rows))
+	for _, row := range rows {
+		if exists[linkID(row)] {
+			kept = append(kept, row)
+		}
+	}
+	return kept, nil
+}
+
+// enrich fills in the location of the visitor IP from the offline GeoIP database.
+// A country supplied by the request headers (e.g. CF-IPCountry) takes precedence.
+func (p *ClickPipeline) enrich(info *CreateLinkStatRequest) {
+	if p.geo == nil || info.IpAddress == nil || *info.IpAddress == "" {
+		return
+	}
+
+	addr, err := geoip.ParseAddr(*info.IpAddress)
+	if err != nil {
+		return
+	}
+
+	loc, err := p.geo.Lookup(addr)
+	if err != nil {
+		if !errors.Is(err, geoip.ErrNotFound) {
+			p.log.Warn("geoip lookup failed", "ip", *info.IpAddress, "error", err)
+		}
+		return
+	}
+
+	if (info.Country == nil || *info.Country == "") && loc.CountryCode != "" {
+		info.Country = helper.StringToPtr(loc.CountryCode)
+	}
+	if loc.Region != "" {
+		info.Region = helper.StringToPtr(loc.Region)
+	}
+	if loc.City != "" {
+		info.City = helper.StringToPtr(loc.City)
+	}
+	if loc.ASN != 0 {
+		asn := int64(loc.ASN)
+		info.ASN = &asn
+	}
+}
+
+// applyUserAgent fills in the browser, OS and device class parsed from the User-Agent.
+func applyUserAgent(info *CreateLinkStatRequest) {
+	ua := ""
+	if info.UserAgent != nil {
+		ua = *info.UserAgent
+	}
+
+	parsed := useragent.Parse(ua)
+	info.Browser = helper.StringToPtr(parsed.Browser)
+	info.BrowserVersion = nilIfEmpty(parsed.BrowserVersion)
+	info.OS = helper.StringToPtr(parsed.OS)
+	info.DeviceType = helper.StringToPtr(parsed.DeviceType)
+	info.IsBot = parsed.IsBot
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optionalUUID(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: *id, Valid: true}
+}
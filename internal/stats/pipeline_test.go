@@ -0,0 +1,249 @@
+package stats
+
+import (
+	"GoShort/config"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/geoip"
+	"GoShort/pkg/helper"
+	"GoShort/pkg/logger"
+	"context"
+	"io"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStatsRepo records the batches handed to CreateLinkStats. A batch with a row for
+// a deleted link fails like it would on the link_id foreign key.
+type fakeStatsRepo struct {
+	datastore.Querier
+
+	mu       sync.Mutex
+	batches  [][]datastore.CreateLinkStatsParams
+	previews []datastore.CreateLinkPreviewsParams
+	deleted  map[uuid.UUID]bool
+	block    chan struct{}
+}
+
+var errForeignKey = &pgconn.PgError{Code: "23503"}
+
+func (f *fakeStatsRepo) CreateLinkStats(ctx context.Context, arg []datastore.CreateLinkStatsParams) (int64, error) {
+	if f.block != nil {
+		<-f.block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, row := range arg {
+		if f.deleted[row.LinkID] {
+			return 0, errForeignKey
+		}
+	}
+	batch := make([]datastore.CreateLinkStatsParams, len(arg))
+	copy(batch, arg)
+	f.batches = append(f.batches, batch)
+	return int64(len(arg)), nil
+}
+
+func (f *fakeStatsRepo) CreateLinkPreviews(ctx context.Context, arg []datastore.CreateLinkPreviewsParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, row := range arg {
+		if f.deleted[row.LinkID] {
+			return 0, errForeignKey
+		}
+	}
+	f.previews = append(f.previews, arg...)
+	return int64(len(arg)), nil
+}
+
+func (f *fakeStatsRepo) ListExistingShortLinkIDs(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing := []uuid.UUID{}
+	for _, id := range ids {
+		if !f.deleted[id] {
+			existing = append(existing, id)
+		}
+	}
+	return existing, nil
+}
+
+func (f *fakeStatsRepo) rows() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, b := range f.batches {
+		n += len(b)
+	}
+	return n
+}
+
+func newTestLogger() *logger.Logger {
+	return logger.New(&config.AppConfig{Logger: config.LoggerConfig{Output: io.Discard, Level: "info"}})
+}
+
+// fakeGeoResolver resolves every address to the same location.
+type fakeGeoResolver struct{}
+
+func (fakeGeoResolver) Lookup(ip netip.Addr) (*geoip.Location, error) {
+	return &geoip.Location{CountryCode: "ID", Region: "Jakarta", City: "Jakarta", ASN: 7713}, nil
+}
+
+func newTestPipeline(repo datastore.Querier, cfg config.ClickPipelineConfig) *ClickPipeline {
+	return NewClickPipeline(repo, fakeGeoResolver{}, cfg, newTestLogger())
+}
+
+func TestClickPipeline_BatchesAndFlushesOnClose(t *testing.T) {
+	repo := &fakeStatsRepo{}
+	p := newTestPipeline(repo, config.ClickPipelineConfig{
+		QueueSize:     100,
+		Workers:       1,
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+	})
+
+	linkID := uuid.New()
+	for i := 0; i < 25; i++ {
+		require.True(t, p.Enqueue(ClickEvent{LinkID: linkID, Info: CreateLinkStatRequest{
+			IpAddress: helper.StringToPtr("8.8.8.8"),
+			UserAgent: helper.StringToPtr("Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.82 Mobile Safari/537.36"),
+		}}))
+	}
+
+	require.NoError(t, p.Close(context.Background()))
+
+	require.Equal(t, 25, repo.rows())
+	require.Len(t, repo.batches, 3)
+	require.Len(t, repo.batches[0], 10)
+
+	row := repo.batches[0][0]
+	require.Equal(t, linkID, row.LinkID)
+	require.True(t, row.ClickTime.Valid)
+	require.Equal(t, "ID", *row.Country)
+	require.Equal(t, "Jakarta", *row.Region)
+	require.Equal(t, "Jakarta", *row.City)
+	require.Equal(t, int64(7713), *row.Asn)
+	require.Equal(t, "Mobile", *row.DeviceType)
+	require.Equal(t, "Chrome", *row.Browser)
+	require.Equal(t, "124.0.6367.82", *row.BrowserVersion)
+	require.Equal(t, "Android", *row.Os)
+	require.False(t, row.IsBot)
+
+	m := p.Metrics()
+	require.Equal(t, uint64(25), m.Enqueued)
+	require.Equal(t, uint64(25), m.Inserted)
+	require.Equal(t, uint64(0), m.Dropped)
+}
+
+func TestClickPipeline_HeaderCountryWinsAndPrivateIPsAreSkipped(t *testing.T) {
+	repo := &fakeStatsRepo{}
+	p := newTestPipeline(repo, config.ClickPipelineConfig{QueueSize: 10, Workers: 1, BatchSize: 10, FlushInterval: time.Hour})
+
+	require.True(t, p.Enqueue(ClickEvent{LinkID: uuid.New(), Info: CreateLinkStatRequest{
+		IpAddress: helper.StringToPtr("8.8.8.8"),
+		Country:   helper.StringToPtr("SG"),
+	}}))
+	require.True(t, p.Enqueue(ClickEvent{LinkID: uuid.New(), Info: CreateLinkStatRequest{
+		IpAddress: helper.StringToPtr("192.168.1.10"),
+	}}))
+	require.NoError(t, p.Close(context.Background()))
+
+	rows := repo.batches[0]
+	require.Equal(t, "SG", *rows[0].Country)
+	require.Equal(t, "Jakarta", *rows[0].City)
+	require.Nil(t, rows[1].Country)
+	require.Nil(t, rows[1].City)
+}
+
+func TestClickPipeline_PreviewsAreStoredSeparately(t *testing.T) {
+	repo := &fakeStatsRepo{}
+	p := newTestPipeline(repo, config.ClickPipelineConfig{QueueSize: 10, Workers: 1, BatchSize: 10, FlushInterval: time.Hour})
+
+	linkID := uuid.New()
+	require.True(t, p.Enqueue(ClickEvent{LinkID: linkID, PreviewReason: PreviewReasonBot, Info: CreateLinkStatRequest{
+		UserAgent: helper.StringToPtr("Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"),
+	}}))
+	require.True(t, p.Enqueue(ClickEvent{LinkID: linkID, PreviewReason: PreviewReasonPrefetch, Info: CreateLinkStatRequest{
+		UserAgent: helper.StringToPtr("Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0"),
+	}}))
+	require.True(t, p.Enqueue(ClickEvent{LinkID: linkID}))
+	require.NoError(t, p.Close(context.Background()))
+
+	require.Equal(t, 1, repo.rows())
+	require.Len(t, repo.previews, 2)
+	require.Equal(t, PreviewReasonBot, repo.previews[0].Reason)
+	require.Equal(t, "Slackbot", *repo.previews[0].BotName)
+	require.Equal(t, PreviewReasonPrefetch, repo.previews[1].Reason)
+	require.Nil(t, repo.previews[1].BotName)
+	require.Equal(t, uint64(3), p.Metrics().Inserted)
+}
+
+func TestClickPipeline_SkipsDeletedLinks(t *testing.T) {
+	deletedID := uuid.New()
+	repo := &fakeStatsRepo{deleted: map[uuid.UUID]bool{deletedID: true}}
+	p := newTestPipeline(repo, config.ClickPipelineConfig{QueueSize: 10, Workers: 1, BatchSize: 10, FlushInterval: time.Hour})
+
+	linkID := uuid.New()
+	require.True(t, p.Enqueue(ClickEvent{LinkID: linkID}))
+	require.True(t, p.Enqueue(ClickEvent{LinkID: deletedID}))
+	require.True(t, p.Enqueue(ClickEvent{LinkID: linkID}))
+	require.True(t, p.Enqueue(ClickEvent{LinkID: deletedID, PreviewReason: PreviewReasonHead}))
+	require.True(t, p.Enqueue(ClickEvent{LinkID: linkID, PreviewReason: PreviewReasonHead}))
+	require.NoError(t, p.Close(context.Background()))
+
+	require.Equal(t, 2, repo.rows(), "a deleted link does not cost the rest of the batch")
+	require.Len(t, repo.previews, 1)
+
+	m := p.Metrics()
+	require.Equal(t, uint64(3), m.Inserted)
+	require.Equal(t, uint64(2), m.Discarded)
+	require.Zero(t, m.Failed)
+}
+
+func TestClickPipeline_FlushesOnInterval(t *testing.T) {
+	repo := &fakeStatsRepo{}
+	p := newTestPipeline(repo, config.ClickPipelineConfig{
+		QueueSize:     100,
+		Workers:       2,
+		BatchSize:     100,
+		FlushInterval: 10 * time.Millisecond,
+	})
+	defer p.Close(context.Background())
+
+	require.True(t, p.Enqueue(ClickEvent{LinkID: uuid.New()}))
+
+	require.Eventually(t, func() bool { return repo.rows() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestClickPipeline_DropsWhenFull(t *testing.T) {
+	repo := &fakeStatsRepo{block: make(chan struct{})}
+	p := newTestPipeline(repo, config.ClickPipelineConfig{
+		QueueSize:     2,
+		Workers:       1,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	})
+
+	// The single worker takes the first event and blocks inside CreateLinkStats,
+	// leaving room for exactly QueueSize more events.
+	require.True(t, p.Enqueue(ClickEvent{LinkID: uuid.New()}))
+	require.Eventually(t, func() bool { return len(p.queue) == 0 }, time.Second, time.Millisecond)
+	require.True(t, p.Enqueue(ClickEvent{LinkID: uuid.New()}))
+	require.True(t, p.Enqueue(ClickEvent{LinkID: uuid.New()}))
+	require.False(t, p.Enqueue(ClickEvent{LinkID: uuid.New()}))
+
+	close(repo.block)
+	require.NoError(t, p.Close(context.Background()))
+
+	m := p.Metrics()
+	require.Equal(t, uint64(3), m.Inserted)
+	require.Equal(t, uint64(1), m.Dropped)
+
+	// Clicks arriving after shutdown are dropped rather than panicking.
+	require.False(t, p.Enqueue(ClickEvent{LinkID: uuid.New()}))
+}
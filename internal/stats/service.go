@@ -1,11 +1,15 @@
 package stats
 
 import (
+	"GoShort/internal/commons"
 	"GoShort/internal/datastore"
 	"GoShort/pkg/logger"
 	"context"
+	"errors"
+	"math"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type ShortLinksStatsService struct {
@@ -22,8 +26,67 @@ func NewShortLinksStatsService(repo datastore.Querier, log *logger.Logger) IShor
 
 type IShortLinksStatsService interface {
 	GetShortLinksStats(ctx context.Context, userID uuid.UUID) ([]datastore.LinkStat, error)
+	GetLinkStats(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) (*LinkStatsResponse, error)
 }
 
 func (s *ShortLinksStatsService) GetShortLinksStats(ctx context.Context, userID uuid.UUID) ([]datastore.LinkStat, error) {
 	return []datastore.LinkStat{}, nil
 }
+
+// GetLinkStats returns the click totals of a link and the clicks served by each of its
+// A/B variants.
+func (s *ShortLinksStatsService) GetLinkStats(ctx context.Context, userID uuid.UUID, linkID uuid.UUID) (*LinkStatsResponse, error) {
+	link, err := s.repo.GetShortLink(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, commons.ErrLinkNotFound
+		}
+		s.log.Error("failed to get short link", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	if link.UserID != userID {
+		s.log.Warn("unauthorized link stats access", "user_id", userID, "link_id", linkID)
+		return nil, commons.ErrUnauthorized
+	}
+
+	totals, err := s.repo.GetLinkClickTotals(ctx, linkID)
+	if err != nil {
+		s.log.Error("failed to get link click totals", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	variants, err := s.repo.GetLinkVariantClicks(ctx, linkID)
+	if err != nil {
+		s.log.Error("failed to get link variant clicks", "link_id", linkID, "error", err)
+		return nil, err
+	}
+
+	var variantClicks int64
+	for _, variant := range variants {
+		variantClicks += variant.Clicks
+	}
+
+	response := &LinkStatsResponse{
+		LinkID:            linkID,
+		TotalClicks:       totals.TotalClicks,
+		UniqueVisitors:    totals.UniqueVisitors,
+		UnassignedClicks:  totals.UnassignedClicks,
+		VariantAssignment: link.VariantAssignment,
+		Variants:          make([]VariantStats, len(variants)),
+	}
+	for i, variant := range variants {
+		response.Variants[i] = VariantStats{
+			VariantID:      variant.ID,
+			Label:          variant.Label,
+			DestinationURL: variant.DestinationUrl,
+			Weight:         variant.Weight,
+			Clicks:         variant.Clicks,
+		}
+		if variantClicks > 0 {
+			response.Variants[i].Share = math.Round(float64(variant.Clicks)*10000/float64(variantClicks)) / 100
+		}
+	}
+
+	return response, nil
+}
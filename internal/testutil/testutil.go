@@ -0,0 +1,53 @@
+// Package testutil holds the fixtures shared by the package tests.
+package testutil
+
+import (
+	"GoShort/config"
+	"GoShort/internal/datastore"
+	"GoShort/internal/urlpolicy"
+	"GoShort/pkg/logger"
+	"context"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// NewLogger returns a logger that discards everything.
+func NewLogger() *logger.Logger {
+	return logger.New(&config.AppConfig{
+		Logger: config.LoggerConfig{Output: io.Discard, Level: "info"},
+	})
+}
+
+// LinkRepo is the base of the fake repositories of services that work on one link. It
+// serves GetShortLink from Link; the fakes embedding it add the queries their service
+// runs, and any other query panics on the nil Querier.
+type LinkRepo struct {
+	datastore.Querier
+
+	Link datastore.ShortLink
+}
+
+func (r *LinkRepo) GetShortLink(ctx context.Context, id uuid.UUID) (datastore.ShortLink, error) {
+	if id != r.Link.ID {
+		return datastore.ShortLink{}, pgx.ErrNoRows
+	}
+	return r.Link, nil
+}
+
+// Policy is a URL policy for services that check destinations. Check hands every
+// destination to CheckFunc, or accepts it unchanged when CheckFunc is nil; the other
+// methods panic on the nil IService.
+type Policy struct {
+	urlpolicy.IService
+
+	CheckFunc func(rawURL string) (*urlpolicy.Destination, error)
+}
+
+func (p *Policy) Check(ctx context.Context, rawURL string) (*urlpolicy.Destination, error) {
+	if p.CheckFunc != nil {
+		return p.CheckFunc(rawURL)
+	}
+	return &urlpolicy.Destination{URL: rawURL, Canonical: rawURL}, nil
+}
@@ -0,0 +1,113 @@
+package urlpolicy
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// blockedPrefixes are the networks a destination may not point to: loopback, private,
+// link-local, shared (CGNAT), multicast and reserved ranges.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// isBlockedAddr reports whether addr is on a private or otherwise non-public network.
+// IPv4-mapped IPv6 addresses are checked as IPv4.
+func isBlockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocalHostname reports whether the name always points at the machine itself.
+func isLocalHostname(host string) bool {
+	return host == "localhost" || strings.HasSuffix(host, ".localhost")
+}
+
+// parseHostAddr parses a URL host that is an IP address. Besides the usual notations it
+// accepts the IPv4 forms browsers still resolve, such as 2130706433, 0x7f.1 or
+// 0177.0.0.1, so they cannot be used to hide a private address.
+func parseHostAddr(host string) (netip.Addr, bool) {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr, true
+	}
+	return parseLegacyIPv4(host)
+}
+
+// parseLegacyIPv4 parses the inet_aton notation: one to four parts in decimal, octal
+// (leading 0) or hexadecimal (leading 0x), the last part filling the remaining bytes.
+func parseLegacyIPv4(host string) (netip.Addr, bool) {
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+
+	values := make([]uint64, len(parts))
+	for i, part := range parts {
+		value, ok := parseIPv4Part(part)
+		if !ok {
+			return netip.Addr{}, false
+		}
+		values[i] = value
+	}
+
+	var ip uint64
+	for i, value := range values[:len(values)-1] {
+		if value > 0xff {
+			return netip.Addr{}, false
+		}
+		ip |= value << (8 * (3 - i))
+	}
+	last := values[len(values)-1]
+	if last >= 1<<(8*(5-len(values))) {
+		return netip.Addr{}, false
+	}
+	ip |= last
+
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
+
+func parseIPv4Part(part string) (uint64, bool) {
+	base := 10
+	switch {
+	case part == "":
+		return 0, false
+	case strings.HasPrefix(part, "0x") || strings.HasPrefix(part, "0X"):
+		part, base = part[2:], 16
+		if part == "" {
+			return 0, true
+		}
+	case len(part) > 1 && part[0] == '0':
+		part, base = part[1:], 8
+	}
+
+	// ParseUint would also accept signs and underscores, browsers do not
+	for _, r := range part {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return 0, false
+		}
+	}
+	value, err := strconv.ParseUint(part, base, 32)
+	return value, err == nil
+}
@@ -0,0 +1,269 @@
+package urlpolicy
+
+import (
+	"GoShort/internal/commons"
+	"GoShort/pkg/canonurl"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const hashPrefix = "sha256:"
+
+// blocklist is the local list of blocked domains and URL hashes. It lives in a plain
+// text file with one entry per line and "#" comments, so it can be edited by hand or
+// synced from a feed; changes to the file are picked up on the next check.
+type blocklist struct {
+	path string
+	// trackingParams are left out of URLs before hashing them
+	trackingParams []string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	domains map[string]struct{}
+	hashes  map[string]struct{}
+	entries []BlocklistEntry
+}
+
+func newBlocklist(path string, trackingParams []string) *blocklist {
+	return &blocklist{path: path, trackingParams: trackingParams}
+}
+
+// hashCanonical is the hash entry blocking a single URL, given in its canonical form.
+// The fragment never reaches the destination server, so it is not part of the hash.
+func hashCanonical(canonical string) string {
+	canonical, _, _ = strings.Cut(canonical, "#")
+	sum := sha256.Sum256([]byte(canonical))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// hashURL is the hash entry blocking a single URL.
+func (b *blocklist) hashURL(raw string) (string, error) {
+	canonical, err := canonurl.Canonicalize(raw, canonurl.Options{StripParams: b.trackingParams})
+	if err != nil {
+		return "", err
+	}
+	return hashCanonical(canonical), nil
+}
+
+// parseEntry turns a line of the file or an admin's input into an entry. Comments after
+// the entry are ignored; an empty entry means the line has none.
+func (b *blocklist) parseEntry(line string) (BlocklistEntry, error) {
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+	value := strings.ToLower(strings.TrimSpace(line))
+	if value == "" {
+		return BlocklistEntry{}, nil
+	}
+
+	if strings.HasPrefix(value, hashPrefix) {
+		digest := strings.TrimPrefix(value, hashPrefix)
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+			return BlocklistEntry{}, commons.ErrInvalidBlocklistEntry
+		}
+		return BlocklistEntry{Value: value, Type: EntryHash}, nil
+	}
+
+	if strings.Contains(value, "://") {
+		// Hash the URL as given, not lowercased
+		hash, err := b.hashURL(strings.TrimSpace(line))
+		if err != nil {
+			return BlocklistEntry{}, commons.ErrInvalidBlocklistEntry
+		}
+		return BlocklistEntry{Value: hash, Type: EntryHash}, nil
+	}
+
+	value = strings.Trim(strings.TrimPrefix(value, "*."), ".")
+	if value == "" || len(value) > 253 || strings.ContainsAny(value, "/:@?# \t") {
+		return BlocklistEntry{}, commons.ErrInvalidBlocklistEntry
+	}
+	return BlocklistEntry{Value: value, Type: EntryDomain}, nil
+}
+
+// load reads the file again when it changed since the last read. A missing file is an
+// empty list.
+func (b *blocklist) load() error {
+	info, err := os.Stat(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		b.modTime, b.size = time.Time{}, 0
+		b.domains, b.hashes, b.entries = nil, nil, nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if b.entries != nil && info.ModTime().Equal(b.modTime) && info.Size() == b.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return err
+	}
+
+	domains := make(map[string]struct{})
+	hashes := make(map[string]struct{})
+	entries := []BlocklistEntry{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		entry, err := b.parseEntry(scanner.Text())
+		if err != nil || entry.Value == "" {
+			continue
+		}
+		switch entry.Type {
+		case EntryDomain:
+			if _, ok := domains[entry.Value]; ok {
+				continue
+			}
+			domains[entry.Value] = struct{}{}
+		case EntryHash:
+			if _, ok := hashes[entry.Value]; ok {
+				continue
+			}
+			hashes[entry.Value] = struct{}{}
+		}
+		entries = append(entries, entry)
+	}
+
+	b.modTime, b.size = info.ModTime(), info.Size()
+	b.domains, b.hashes, b.entries = domains, hashes, entries
+	return nil
+}
+
+// match returns the entry blocking the host or the canonical URL, if any. The host
+// matches entries for itself and for each of its parent domains.
+func (b *blocklist) match(host, canonical string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.load(); err != nil {
+		return "", err
+	}
+
+	for name := host; name != ""; {
+		if _, ok := b.domains[name]; ok {
+			return name, nil
+		}
+		_, parent, found := strings.Cut(name, ".")
+		if !found {
+			break
+		}
+		name = parent
+	}
+
+	if len(b.hashes) > 0 {
+		hash := hashCanonical(canonical)
+		if _, ok := b.hashes[hash]; ok {
+			return hash, nil
+		}
+	}
+	return "", nil
+}
+
+func (b *blocklist) list() ([]BlocklistEntry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	entries := make([]BlocklistEntry, len(b.entries))
+	copy(entries, b.entries)
+	return entries, nil
+}
+
+// add appends the entry to the file, creating it when needed.
+func (b *blocklist) add(entry BlocklistEntry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.load(); err != nil {
+		return err
+	}
+	for _, existing := range b.entries {
+		if existing == entry {
+			return commons.ErrBlocklistEntryExists
+		}
+	}
+
+	data, err := os.ReadFile(b.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	data = append(data, entry.Value+"\n"...)
+
+	return b.write(data)
+}
+
+// remove drops the lines holding the entry and keeps everything else, comments included.
+func (b *blocklist) remove(entry BlocklistEntry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return commons.ErrBlocklistEntryNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var kept []string
+	removed := false
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if parsed, err := b.parseEntry(line); err == nil && parsed == entry {
+			removed = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if !removed {
+		return commons.ErrBlocklistEntryNotFound
+	}
+
+	return b.write([]byte(strings.Join(kept, "")))
+}
+
+// write replaces the file through a rename so a concurrent reader never sees it half
+// written, and forces the next check to read it again.
+func (b *blocklist) write(data []byte) error {
+	dir := filepath.Dir(b.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(b.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), b.path); err != nil {
+		return err
+	}
+
+	b.entries = nil
+	return nil
+}
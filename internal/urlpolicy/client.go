@@ -0,0 +1,71 @@
+package urlpolicy
+
+import (
+	"GoShort/config"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxErrorLength caps the error message of a failed request, it is stored with the link.
+const maxErrorLength = 255
+
+// NewClient returns an HTTP client for requests to link destinations. It follows up to
+// maxRedirects redirects, to the allowed schemes only. When private networks are blocked
+// it refuses to connect to blocked addresses, so a host resolving differently at
+// connection time than when its link was checked gets nowhere either.
+func NewClient(cfg config.URLPolicyConfig, timeout time.Duration, maxRedirects int) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if cfg.BlockPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || isBlockedAddr(addrPort.Addr()) {
+				return fmt.Errorf("connection to %s is not allowed", address)
+			}
+			return nil
+		}
+	}
+
+	schemes := make(map[string]bool, len(cfg.AllowedSchemes))
+	for _, scheme := range cfg.AllowedSchemes {
+		schemes[strings.ToLower(scheme)] = true
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return http.ErrUseLastResponse
+			}
+			if !schemes[req.URL.Scheme] {
+				return errors.New("redirect to a scheme that is not allowed")
+			}
+			return nil
+		},
+	}
+}
+
+// ErrorMessage describes a failed request of a NewClient client without repeating the
+// method and URL, short enough to be stored with the link.
+func ErrorMessage(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	message := err.Error()
+	if len(message) > maxErrorLength {
+		message = strings.ToValidUTF8(message[:maxErrorLength], "")
+	}
+	return message
+}
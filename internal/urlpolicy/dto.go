@@ -0,0 +1,60 @@
+package urlpolicy
+
+import (
+	"GoShort/internal/commons"
+	"fmt"
+)
+
+// Reasons a destination is rejected, returned to clients in Violation.Reason.
+const (
+	ReasonInvalidURL       = "invalid_url"
+	ReasonSchemeNotAllowed = "scheme_not_allowed"
+	ReasonPrivateNetwork   = "private_network"
+	ReasonRedirectLoop     = "redirect_loop"
+	ReasonShortenerChain   = "shortener_chain"
+	ReasonBlocklisted      = "blocklisted"
+	ReasonTooLong          = "too_long"
+)
+
+// Destination is a destination accepted by the policy. URL is what visitors are sent
+// to; Canonical is the form used to compare destinations, match the blocklist and search.
+type Destination struct {
+	URL       string
+	Canonical string
+}
+
+// Violation is the error returned for a destination the policy rejects. It wraps
+// commons.ErrURLRejected.
+type Violation struct {
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+}
+
+func violation(reason, detail string, args ...any) *Violation {
+	return &Violation{Reason: reason, Detail: fmt.Sprintf(detail, args...)}
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%s (%s): %s", commons.ErrURLRejected, v.Reason, v.Detail)
+}
+
+func (v *Violation) Unwrap() error {
+	return commons.ErrURLRejected
+}
+
+// Types of blocklist entries.
+const (
+	EntryDomain = "domain"
+	EntryHash   = "hash"
+)
+
+type BlocklistEntryRequest struct {
+	// Value is a domain, which also blocks its subdomains, a URL, or the sha256:<hex>
+	// hash of a canonical URL without its fragment. URLs are stored as their hash.
+	Value string `json:"value" validate:"required,max=2048"`
+}
+
+type BlocklistEntry struct {
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
@@ -0,0 +1,132 @@
+package urlpolicy
+
+import (
+	"GoShort/internal/commons"
+	"GoShort/pkg/logger"
+	"errors"
+	"net/url"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svr       IService
+	log       *logger.Logger
+	validator *validator.Validate
+}
+
+func NewHandler(service IService, log *logger.Logger, validator *validator.Validate) *Handler {
+	return &Handler{
+		svr:       service,
+		log:       log,
+		validator: validator,
+	}
+}
+
+// ListBlocklist lists the blocked destinations
+// @Godoc ListBlocklist
+// @Summary List the URL blocklist
+// @Description Retrieve the domains and URL hashes links may not point to
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.BlocklistEntry} "URL blocklist retrieved successfully"
+// @Failure 403 {object} dto.ErrorResponse "Forbidden - Admin access required"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve URL blocklist"
+// @Router /api/v1/admin/url-blocklist [get]
+// @Security ApiKeyAuth
+func (h *Handler) ListBlocklist(c *fiber.Ctx) error {
+	entries, err := h.svr.ListBlocklist(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{
+			Error: "Failed to retrieve URL blocklist",
+		})
+	}
+
+	return c.JSON(commons.SuccessResponse{
+		Message: "URL blocklist retrieved successfully",
+		Data:    entries,
+	})
+}
+
+// AddBlocklistEntry blocks a domain or URL
+// @Godoc AddBlocklistEntry
+// @Summary Add a URL blocklist entry
+// @Description Block a domain with its subdomains, or a single URL, for new links and link updates. URLs are stored as the hash of their canonical form
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body dto.BlocklistEntryRequest true "Blocklist Entry Request"
+// @Success 201 {object} dto.SuccessResponse{data=dto.BlocklistEntry} "URL blocklist entry added successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid request body or entry"
+// @Failure 403 {object} dto.ErrorResponse "Forbidden - Admin access required"
+// @Failure 409 {object} dto.ErrorResponse "Entry is already on the blocklist"
+// @Failure 500 {object} dto.ErrorResponse "Failed to add URL blocklist entry"
+// @Router /api/v1/admin/url-blocklist [post]
+// @Security ApiKeyAuth
+func (h *Handler) AddBlocklistEntry(c *fiber.Ctx) error {
+	var req BlocklistEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{Error: "Invalid request body"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+			Message: "Validation failed",
+			Error:   commons.FormatValidationErrors(err),
+		})
+	}
+
+	entry, err := h.svr.AddBlocklistEntry(c.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, commons.ErrInvalidBlocklistEntry):
+			return c.Status(fiber.StatusBadRequest).JSON(commons.ErrorResponse{
+				Error: "Entry must be a domain, a URL or a sha256: hash",
+			})
+		case errors.Is(err, commons.ErrBlocklistEntryExists):
+			return c.Status(fiber.StatusConflict).JSON(commons.ErrorResponse{Error: "Entry is already on the blocklist"})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{
+				Error: "Failed to add URL blocklist entry",
+			})
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(commons.SuccessResponse{
+		Message: "URL blocklist entry added successfully",
+		Data:    entry,
+	})
+}
+
+// RemoveBlocklistEntry unblocks a domain or URL
+// @Godoc RemoveBlocklistEntry
+// @Summary Remove a URL blocklist entry
+// @Description Allow links to point to a blocked domain or URL again
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param value path string true "Domain or sha256: hash"
+// @Success 204 "URL blocklist entry removed successfully"
+// @Failure 403 {object} dto.ErrorResponse "Forbidden - Admin access required"
+// @Failure 404 {object} dto.ErrorResponse "Entry not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to remove URL blocklist entry"
+// @Router /api/v1/admin/url-blocklist/{value} [delete]
+// @Security ApiKeyAuth
+func (h *Handler) RemoveBlocklistEntry(c *fiber.Ctx) error {
+	value, err := url.PathUnescape(c.Params("value"))
+	if err != nil {
+		value = c.Params("value")
+	}
+
+	if err := h.svr.RemoveBlocklistEntry(c.Context(), value); err != nil {
+		if errors.Is(err, commons.ErrBlocklistEntryNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(commons.ErrorResponse{Error: "Entry not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(commons.ErrorResponse{
+			Error: "Failed to remove URL blocklist entry",
+		})
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
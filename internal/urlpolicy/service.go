@@ -0,0 +1,342 @@
+package urlpolicy
+
+import (
+	"GoShort/config"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/canonurl"
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type IService interface {
+	// Check returns the destination to store for rawURL, or a *Violation when the policy
+	// rejects it. The destination differs from rawURL when a shortener chain was flattened
+	// or tracking parameters were stripped.
+	Check(ctx context.Context, rawURL string) (*Destination, error)
+	ListBlocklist(ctx context.Context) ([]BlocklistEntry, error)
+	AddBlocklistEntry(ctx context.Context, req BlocklistEntryRequest) (*BlocklistEntry, error)
+	RemoveBlocklistEntry(ctx context.Context, value string) error
+}
+
+// Resolver looks up the addresses of a host. *net.Resolver implements it; tests use a
+// local stub.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+type Service struct {
+	repo      datastore.Querier
+	resolver  Resolver
+	client    *http.Client
+	blocklist *blocklist
+	cfg       config.URLPolicyConfig
+	// ownHost is the host of the default short domain
+	ownHost    string
+	schemes    map[string]bool
+	shorteners map[string]bool
+	log        *logger.Logger
+}
+
+// NewService creates the URL policy. A nil resolver uses the system resolver.
+func NewService(repo datastore.Querier, resolver Resolver, cfg config.URLPolicyConfig, serverCfg config.ServerConfig, log *logger.Logger) IService {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.MaxChainHops <= 0 {
+		cfg.MaxChainHops = 5
+	}
+
+	s := &Service{
+		repo:       repo,
+		resolver:   resolver,
+		blocklist:  newBlocklist(cfg.BlocklistFile, cfg.TrackingParams),
+		cfg:        cfg,
+		schemes:    make(map[string]bool, len(cfg.AllowedSchemes)),
+		shorteners: make(map[string]bool, len(cfg.ShortenerDomains)),
+		log:        log,
+	}
+	if base, err := url.Parse(serverCfg.BaseURL); err == nil {
+		s.ownHost = normalizeHost(base.Hostname())
+	}
+	for _, scheme := range cfg.AllowedSchemes {
+		s.schemes[strings.ToLower(scheme)] = true
+	}
+	for _, domain := range cfg.ShortenerDomains {
+		s.shorteners[normalizeHost(domain)] = true
+	}
+	// Each hop of a chain is checked before it is followed
+	s.client = NewClient(cfg, cfg.Timeout, 0)
+	return s
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// canonicalHost returns the host of a canonical URL, lowercased and in punycode.
+func canonicalHost(canonical string) string {
+	u, err := url.Parse(canonical)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+func (s *Service) Check(ctx context.Context, rawURL string) (*Destination, error) {
+	u, canonical, err := s.screen(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	destination := &Destination{URL: rawURL, Canonical: canonical}
+	if s.isShortener(canonicalHost(canonical)) {
+		if !s.cfg.FlattenChains {
+			return nil, violation(ReasonShortenerChain, "links to other URL shorteners are not allowed, use the final destination")
+		}
+		if destination, err = s.flatten(ctx, u); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.cfg.StripTrackingParams {
+		destination.URL = canonurl.StripParams(destination.URL, s.cfg.TrackingParams)
+	}
+	return destination, nil
+}
+
+// screen applies every rule except the shortener check to a single URL. It returns the
+// URL parsed as given and its canonical form.
+func (s *Service) screen(ctx context.Context, rawURL string) (*url.URL, string, error) {
+	// Browsers drop tabs and newlines anywhere in a URL, "java\tscript:" is "javascript:"
+	if strings.ContainsFunc(rawURL, func(r rune) bool { return r <= ' ' || r == 0x7f }) {
+		return nil, "", violation(ReasonInvalidURL, "URL contains whitespace or control characters")
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		return nil, "", violation(ReasonInvalidURL, "URL cannot be parsed")
+	}
+	if !s.schemes[u.Scheme] {
+		return nil, "", violation(ReasonSchemeNotAllowed, "scheme %q is not allowed", u.Scheme)
+	}
+	if normalizeHost(u.Hostname()) == "" {
+		return nil, "", violation(ReasonInvalidURL, "URL has no host")
+	}
+
+	canonical, err := canonurl.Canonicalize(rawURL, canonurl.Options{
+		StripParams: s.cfg.TrackingParams,
+		MaxLength:   s.cfg.MaxURLLength,
+	})
+	switch {
+	case errors.Is(err, canonurl.ErrTooLong):
+		return nil, "", violation(ReasonTooLong, "URL is longer than %d characters", s.cfg.MaxURLLength)
+	case err != nil:
+		return nil, "", violation(ReasonInvalidURL, "URL host is not valid")
+	}
+	host := canonicalHost(canonical)
+
+	blocked, err := s.blocklist.match(host, canonical)
+	if err != nil {
+		s.log.Error("failed to read URL blocklist", "error", err)
+		return nil, "", err
+	}
+	if blocked != "" {
+		return nil, "", violation(ReasonBlocklisted, "destination is on the blocklist")
+	}
+
+	if err := s.checkLoop(ctx, host); err != nil {
+		return nil, "", err
+	}
+
+	if s.cfg.BlockPrivateNetworks {
+		if err := s.checkNetwork(ctx, host); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return u, canonical, nil
+}
+
+// checkLoop rejects destinations served by this application, which would redirect to
+// itself or to another short link.
+func (s *Service) checkLoop(ctx context.Context, host string) error {
+	if host == s.ownHost {
+		return violation(ReasonRedirectLoop, "destination is on the short link domain")
+	}
+
+	_, err := s.repo.GetVerifiedDomainByHostname(ctx, host)
+	if err == nil {
+		return violation(ReasonRedirectLoop, "destination is on a custom short link domain")
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		s.log.Error("failed to look up custom domain", "hostname", host, "error", err)
+		return err
+	}
+	return nil
+}
+
+// checkNetwork rejects hosts on a private network. Hostnames that cannot be resolved
+// are accepted, the destination may just be down for now.
+func (s *Service) checkNetwork(ctx context.Context, host string) error {
+	if isLocalHostname(host) {
+		return violation(ReasonPrivateNetwork, "destination is on the local machine")
+	}
+	if addr, ok := parseHostAddr(host); ok {
+		if isBlockedAddr(addr) {
+			return violation(ReasonPrivateNetwork, "destination is a private network address")
+		}
+		return nil
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	addrs, err := s.resolver.LookupIPAddr(lookupCtx, host)
+	if err != nil {
+		s.log.Debug("failed to resolve destination host", "host", host, "error", err)
+		return nil
+	}
+	for _, ipAddr := range addrs {
+		addr, ok := netip.AddrFromSlice(ipAddr.IP)
+		if ok && isBlockedAddr(addr) {
+			return violation(ReasonPrivateNetwork, "destination resolves to a private network address")
+		}
+	}
+	return nil
+}
+
+// isShortener reports whether the host or one of its parent domains is a known URL
+// shortener.
+func (s *Service) isShortener(host string) bool {
+	for name := host; name != ""; {
+		if s.shorteners[name] {
+			return true
+		}
+		_, parent, found := strings.Cut(name, ".")
+		if !found {
+			break
+		}
+		name = parent
+	}
+	return false
+}
+
+// flatten follows the redirects of a shortener link until they leave the shorteners and
+// returns where they lead. Each hop is screened like a destination of its own.
+func (s *Service) flatten(ctx context.Context, u *url.URL) (*Destination, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	current := u
+	for hop := 0; hop < s.cfg.MaxChainHops; hop++ {
+		next, err := s.nextHop(ctx, current)
+		if err != nil {
+			s.log.Debug("failed to follow shortener link", "url", current.String(), "error", err)
+			return nil, violation(ReasonShortenerChain, "the shortener link could not be resolved")
+		}
+		if next == nil {
+			return nil, violation(ReasonShortenerChain, "the shortener link does not redirect")
+		}
+
+		_, canonical, err := s.screen(ctx, next.String())
+		if err != nil {
+			return nil, err
+		}
+		if !s.isShortener(canonicalHost(canonical)) {
+			return &Destination{URL: next.String(), Canonical: canonical}, nil
+		}
+		current = next
+	}
+	return nil, violation(ReasonShortenerChain, "the shortener link redirects more than %d times", s.cfg.MaxChainHops)
+}
+
+// nextHop returns where u redirects to, nil when it does not redirect.
+func (s *Service) nextHop(ctx context.Context, u *url.URL) (*url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	// Some shorteners only redirect GET requests
+	if resp.StatusCode == http.StatusMethodNotAllowed {
+		req.Method = http.MethodGet
+		if resp, err = s.client.Do(req); err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+	}
+
+	if resp.StatusCode < 300 || resp.StatusCode > 399 {
+		return nil, nil
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+func (s *Service) ListBlocklist(ctx context.Context) ([]BlocklistEntry, error) {
+	entries, err := s.blocklist.list()
+	if err != nil {
+		s.log.Error("failed to read URL blocklist", "error", err)
+		return nil, err
+	}
+	return entries, nil
+}
+
+// AddBlocklistEntry blocks a domain or URL for new links and link updates. Existing
+// links are not changed.
+func (s *Service) AddBlocklistEntry(ctx context.Context, req BlocklistEntryRequest) (*BlocklistEntry, error) {
+	entry, err := s.blocklist.parseEntry(req.Value)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Value == "" {
+		return nil, commons.ErrInvalidBlocklistEntry
+	}
+
+	if err := s.blocklist.add(entry); err != nil {
+		if !errors.Is(err, commons.ErrBlocklistEntryExists) {
+			s.log.Error("failed to update URL blocklist", "error", err)
+		}
+		return nil, err
+	}
+
+	s.log.Info("URL blocklist entry added", "value", entry.Value)
+	return &entry, nil
+}
+
+func (s *Service) RemoveBlocklistEntry(ctx context.Context, value string) error {
+	entry, err := s.blocklist.parseEntry(value)
+	if err != nil || entry.Value == "" {
+		return commons.ErrBlocklistEntryNotFound
+	}
+
+	if err := s.blocklist.remove(entry); err != nil {
+		if !errors.Is(err, commons.ErrBlocklistEntryNotFound) {
+			s.log.Error("failed to update URL blocklist", "error", err)
+		}
+		return err
+	}
+
+	s.log.Info("URL blocklist entry removed", "value", entry.Value)
+	return nil
+}
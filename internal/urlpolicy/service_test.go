@@ -0,0 +1,323 @@
+package urlpolicy
+
+import (
+	"GoShort/config"
+	"GoShort/internal/commons"
+	"GoShort/internal/datastore"
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDomainRepo knows the verified custom domains.
+type fakeDomainRepo struct {
+	datastore.Querier
+
+	verified map[string]bool
+}
+
+func (f *fakeDomainRepo) GetVerifiedDomainByHostname(ctx context.Context, hostname string) (datastore.Domain, error) {
+	if !f.verified[hostname] {
+		return datastore.Domain{}, pgx.ErrNoRows
+	}
+	return datastore.Domain{Hostname: hostname}, nil
+}
+
+// stubResolver answers from a fixed table; unknown hosts do not resolve.
+type stubResolver map[string]string
+
+func (r stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ip, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return []net.IPAddr{{IP: net.ParseIP(ip)}}, nil
+}
+
+func newTestService(t *testing.T, cfg config.URLPolicyConfig) *Service {
+	t.Helper()
+	if cfg.AllowedSchemes == nil {
+		cfg.AllowedSchemes = []string{"http", "https"}
+	}
+	if cfg.BlocklistFile == "" {
+		cfg.BlocklistFile = filepath.Join(t.TempDir(), "blocklist.txt")
+	}
+	resolver := stubResolver{
+		"example.com":      "93.184.216.34",
+		"example.org":      "93.184.216.35",
+		"intranet.example": "10.1.2.3",
+		"sho.rt":           "203.0.113.5",
+		"dest.example":     "203.0.113.6",
+		"internal.example": "192.168.1.10",
+	}
+	log := logger.New(&config.AppConfig{
+		Logger: config.LoggerConfig{Output: io.Discard, Level: "info"},
+	})
+	repo := &fakeDomainRepo{verified: map[string]bool{"links.brand.com": true}}
+	return NewService(repo, resolver, cfg, config.ServerConfig{BaseURL: "https://go.sh/"}, log).(*Service)
+}
+
+func mustHashURL(t *testing.T, svc *Service, raw string) string {
+	t.Helper()
+	hash, err := svc.blocklist.hashURL(raw)
+	require.NoError(t, err)
+	return hash
+}
+
+func requireReason(t *testing.T, err error, reason string) {
+	t.Helper()
+	require.ErrorIs(t, err, commons.ErrURLRejected)
+	var rejected *Violation
+	require.True(t, errors.As(err, &rejected))
+	require.Equal(t, reason, rejected.Reason)
+}
+
+func TestService_Check(t *testing.T) {
+	svc := newTestService(t, config.URLPolicyConfig{
+		BlockPrivateNetworks: true,
+		ShortenerDomains:     []string{"bit.ly", "t.co"},
+		MaxURLLength:         64,
+		TrackingParams:       []string{"fbclid", "gclid"},
+	})
+	require.NoError(t, os.WriteFile(svc.cfg.BlocklistFile, []byte(
+		"# phishing\nevil.example\n"+mustHashURL(t, svc, "https://example.org/phish?b=2&a=1")+" # reported\n",
+	), 0o644))
+
+	tests := []struct {
+		name      string
+		url       string
+		canonical string
+		reason    string
+	}{
+		{name: "Public destination", url: "https://example.com/page?q=1", canonical: "https://example.com/page?q=1"},
+		{name: "Canonical form", url: "HTTPS://Example.COM:443?b=2&a=1&gclid=x", canonical: "https://example.com/?a=1&b=2"},
+		{name: "Internationalized host", url: "https://bücher.example/", canonical: "https://xn--bcher-kva.example/"},
+		{name: "Unresolvable host", url: "https://does-not-resolve.example/", canonical: "https://does-not-resolve.example/"},
+		{name: "Too long", url: "https://example.com/" + strings.Repeat("a", 64), reason: ReasonTooLong},
+		{name: "JavaScript", url: "javascript:alert(1)", reason: ReasonSchemeNotAllowed},
+		{name: "Upper case JavaScript", url: "JAVASCRIPT:alert(1)", reason: ReasonSchemeNotAllowed},
+		{name: "Tab in scheme", url: "java\tscript:alert(1)", reason: ReasonInvalidURL},
+		{name: "Data URL", url: "data:text/html,<script>alert(1)</script>", reason: ReasonSchemeNotAllowed},
+		{name: "FTP", url: "ftp://example.com/file", reason: ReasonSchemeNotAllowed},
+		{name: "No host", url: "https:///path", reason: ReasonInvalidURL},
+		{name: "Loopback", url: "http://127.0.0.1/admin", reason: ReasonPrivateNetwork},
+		{name: "Decimal loopback", url: "http://2130706433/", reason: ReasonPrivateNetwork},
+		{name: "Hex loopback", url: "http://0x7f.1/", reason: ReasonPrivateNetwork},
+		{name: "Octal loopback", url: "http://0177.0.0.1/", reason: ReasonPrivateNetwork},
+		{name: "IPv6 loopback", url: "http://[::1]:8080/", reason: ReasonPrivateNetwork},
+		{name: "IPv4-mapped private", url: "http://[::ffff:10.0.0.1]/", reason: ReasonPrivateNetwork},
+		{name: "Cloud metadata", url: "http://169.254.169.254/latest/meta-data", reason: ReasonPrivateNetwork},
+		{name: "Localhost", url: "http://localhost:3000/", reason: ReasonPrivateNetwork},
+		{name: "Resolves to private", url: "https://intranet.example/wiki", reason: ReasonPrivateNetwork},
+		{name: "Own short domain", url: "https://GO.SH/abc", reason: ReasonRedirectLoop},
+		{name: "Custom short domain", url: "https://links.brand.com/launch", reason: ReasonRedirectLoop},
+		{name: "Shortener", url: "https://bit.ly/3abc", reason: ReasonShortenerChain},
+		{name: "Blocked domain", url: "https://evil.example/login", reason: ReasonBlocklisted},
+		{name: "Subdomain of blocked domain", url: "https://secure.evil.example/", reason: ReasonBlocklisted},
+		{name: "Blocked URL", url: "HTTPS://Example.org/phish?a=1&fbclid=x&b=2#top", reason: ReasonBlocklisted},
+		{name: "Other URL on blocked URL host", url: "https://example.org/about", canonical: "https://example.org/about"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destination, err := svc.Check(context.Background(), tt.url)
+			if tt.reason == "" {
+				require.NoError(t, err)
+				require.Equal(t, Destination{URL: tt.url, Canonical: tt.canonical}, *destination)
+				return
+			}
+			requireReason(t, err, tt.reason)
+		})
+	}
+}
+
+func TestService_Check_PrivateNetworksAllowed(t *testing.T) {
+	svc := newTestService(t, config.URLPolicyConfig{BlockPrivateNetworks: false})
+
+	_, err := svc.Check(context.Background(), "http://intranet.example/wiki")
+	require.NoError(t, err)
+}
+
+func TestService_Check_StripTrackingParams(t *testing.T) {
+	svc := newTestService(t, config.URLPolicyConfig{
+		TrackingParams:      []string{"fbclid", "gclid", "mc_eid"},
+		StripTrackingParams: true,
+	})
+
+	destination, err := svc.Check(context.Background(), "https://example.com/sale?z=1&fbclid=abc&A=2")
+	require.NoError(t, err)
+	require.Equal(t, Destination{
+		URL:       "https://example.com/sale?z=1&A=2",
+		Canonical: "https://example.com/sale?A=2&z=1",
+	}, *destination)
+}
+
+func TestService_Check_FlattenChains(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "http://sho.rt/b", http.StatusMovedPermanently)
+		case "/b":
+			http.Redirect(w, r, "https://dest.example/final?x=1", http.StatusFound)
+		case "/private":
+			http.Redirect(w, r, "http://internal.example/", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "http://sho.rt/loop", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	svc := newTestService(t, config.URLPolicyConfig{
+		BlockPrivateNetworks: true,
+		ShortenerDomains:     []string{"sho.rt"},
+		FlattenChains:        true,
+		MaxChainHops:         3,
+	})
+	// Every host is served by the test server
+	svc.client = &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+			},
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	destination, err := svc.Check(context.Background(), "http://sho.rt/a")
+	require.NoError(t, err)
+	require.Equal(t, Destination{
+		URL:       "https://dest.example/final?x=1",
+		Canonical: "https://dest.example/final?x=1",
+	}, *destination)
+
+	_, err = svc.Check(context.Background(), "http://sho.rt/private")
+	requireReason(t, err, ReasonPrivateNetwork)
+
+	_, err = svc.Check(context.Background(), "http://sho.rt/loop")
+	requireReason(t, err, ReasonShortenerChain)
+
+	_, err = svc.Check(context.Background(), "http://sho.rt/landing")
+	requireReason(t, err, ReasonShortenerChain)
+}
+
+func TestService_Blocklist(t *testing.T) {
+	svc := newTestService(t, config.URLPolicyConfig{})
+	ctx := context.Background()
+
+	entries, err := svc.ListBlocklist(ctx)
+	require.NoError(t, err)
+	require.Empty(t, entries, "a missing file is an empty list")
+
+	require.NoError(t, os.WriteFile(svc.cfg.BlocklistFile, []byte("# managed by the trust team\n"), 0o644))
+
+	entry, err := svc.AddBlocklistEntry(ctx, BlocklistEntryRequest{Value: "*.Evil.Example."})
+	require.NoError(t, err)
+	require.Equal(t, BlocklistEntry{Value: "evil.example", Type: EntryDomain}, *entry)
+
+	entry, err = svc.AddBlocklistEntry(ctx, BlocklistEntryRequest{Value: "https://example.com/phish"})
+	require.NoError(t, err)
+	require.Equal(t, EntryHash, entry.Type)
+	require.Equal(t, mustHashURL(t, svc, "https://EXAMPLE.com/phish"), entry.Value)
+
+	_, err = svc.AddBlocklistEntry(ctx, BlocklistEntryRequest{Value: "evil.example"})
+	require.ErrorIs(t, err, commons.ErrBlocklistEntryExists)
+	_, err = svc.AddBlocklistEntry(ctx, BlocklistEntryRequest{Value: "sha256:abc"})
+	require.ErrorIs(t, err, commons.ErrInvalidBlocklistEntry)
+	_, err = svc.AddBlocklistEntry(ctx, BlocklistEntryRequest{Value: "not a domain"})
+	require.ErrorIs(t, err, commons.ErrInvalidBlocklistEntry)
+
+	_, err = svc.Check(ctx, "https://example.com/phish")
+	requireReason(t, err, ReasonBlocklisted)
+
+	require.NoError(t, svc.RemoveBlocklistEntry(ctx, "evil.example"))
+	require.ErrorIs(t, svc.RemoveBlocklistEntry(ctx, "evil.example"), commons.ErrBlocklistEntryNotFound)
+
+	data, err := os.ReadFile(svc.cfg.BlocklistFile)
+	require.NoError(t, err)
+	require.Equal(t, "# managed by the trust team\n"+mustHashURL(t, svc, "https://example.com/phish")+"\n", string(data))
+
+	// Edits made to the file directly are picked up
+	require.NoError(t, os.WriteFile(svc.cfg.BlocklistFile, []byte("bad.example\nworse.example\n"), 0o644))
+	entries, err = svc.ListBlocklist(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []BlocklistEntry{
+		{Value: "bad.example", Type: EntryDomain},
+		{Value: "worse.example", Type: EntryDomain},
+	}, entries)
+}
+
+func TestParseHostAddr(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "127.0.0.1", want: "127.0.0.1"},
+		{host: "2130706433", want: "127.0.0.1"},
+		{host: "0x7f000001", want: "127.0.0.1"},
+		{host: "0x7f.1", want: "127.0.0.1"},
+		{host: "0177.0.0.1", want: "127.0.0.1"},
+		{host: "127.1", want: "127.0.0.1"},
+		{host: "10.0x10.258", want: "10.16.1.2"},
+		{host: "[::1]", want: "::1"},
+		{host: "256.1.1.1"},
+		{host: "1.2.3.4.5"},
+		{host: "1.2.3.com"},
+		{host: "0x7g.1"},
+		{host: "+1.2.3.4"},
+		{host: "example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			addr, ok := parseHostAddr(tt.host)
+			if tt.want == "" {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, netip.MustParseAddr(tt.want), addr)
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "Method and URL are dropped",
+			err:  &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("connection refused")},
+			want: "connection refused",
+		},
+		{
+			name: "Long messages are cut on a rune boundary",
+			err:  errors.New(strings.Repeat("a", maxErrorLength-1) + "é"),
+			want: strings.Repeat("a", maxErrorLength-1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, ErrorMessage(tt.err))
+		})
+	}
+}
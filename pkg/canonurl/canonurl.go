@@ -0,0 +1,199 @@
+// Package canonurl turns destination URLs into a canonical form, so that two spellings
+// of the same destination can be recognized as one.
+package canonurl
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultTrackingParams are click identifiers added by ad and mail platforms. They are
+// unique per click and say nothing about the destination.
+var DefaultTrackingParams = []string{"fbclid", "gclid", "mc_eid"}
+
+var (
+	ErrInvalid = errors.New("URL must be absolute with a scheme and a host")
+	ErrTooLong = errors.New("URL is too long")
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+type Options struct {
+	// StripParams are query parameters removed from the URL, matched case-insensitively
+	StripParams []string
+	// MaxLength rejects URLs longer than this many bytes, as typed or canonical; 0 means
+	// no limit
+	MaxLength int
+}
+
+// Canonicalize returns the canonical form of an absolute URL: scheme and host are
+// lowercased, internationalized hosts are converted to punycode, default ports are
+// removed, an empty path becomes "/" and query parameters are sorted by key, then value,
+// with a uniform percent-encoding. The path and fragment are kept as they are.
+func Canonicalize(raw string, opts Options) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if opts.MaxLength > 0 && len(raw) > opts.MaxLength {
+		return "", ErrTooLong
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", ErrInvalid
+	}
+
+	host, err := canonicalHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+	port := u.Port()
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = host
+	if u.Path == "" {
+		u.Path, u.RawPath = "/", ""
+	}
+	u.RawQuery = canonicalQuery(u.RawQuery, opts.StripParams)
+	u.ForceQuery = false
+
+	canonical := u.String()
+	if opts.MaxLength > 0 && len(canonical) > opts.MaxLength {
+		return "", ErrTooLong
+	}
+	return canonical, nil
+}
+
+// StripParams removes the given query parameters from a URL and leaves the rest of it
+// as typed. The URL is returned unchanged when it has none of them or cannot be parsed.
+func StripParams(raw string, params []string) string {
+	if len(params) == 0 {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.RawQuery == "" {
+		return raw
+	}
+
+	strip := paramSet(params)
+	pairs := splitQuery(u.RawQuery)
+	var kept []string
+	for _, pair := range pairs {
+		if !strip[strings.ToLower(queryKey(pair))] {
+			kept = append(kept, pair)
+		}
+	}
+	if len(kept) == len(pairs) {
+		return raw
+	}
+
+	u.RawQuery = strings.Join(kept, "&")
+	return u.String()
+}
+
+func canonicalHost(host string) (string, error) {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" {
+		return "", ErrInvalid
+	}
+	if !isASCII(host) {
+		ascii, err := idna.Lookup.ToASCII(host)
+		if err != nil {
+			return "", ErrInvalid
+		}
+		host = ascii
+	}
+	return strings.ToLower(host), nil
+}
+
+// canonicalQuery decodes and encodes every parameter again, so "a+b" and "a%20b" are
+// the same value, drops empty ones and the stripped ones, and sorts the rest.
+func canonicalQuery(rawQuery string, stripParams []string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	type param struct{ key, value, pair string }
+	strip := paramSet(stripParams)
+	var params []param
+	for _, pair := range splitQuery(rawQuery) {
+		key, value, hasValue := strings.Cut(pair, "=")
+		key, value = unescape(key), unescape(value)
+		if strip[strings.ToLower(key)] {
+			continue
+		}
+
+		encoded := url.QueryEscape(key)
+		if hasValue {
+			encoded += "=" + url.QueryEscape(value)
+		}
+		params = append(params, param{key: key, value: value, pair: encoded})
+	}
+
+	sort.SliceStable(params, func(i, j int) bool {
+		if params[i].key != params[j].key {
+			return params[i].key < params[j].key
+		}
+		return params[i].value < params[j].value
+	})
+
+	pairs := make([]string, len(params))
+	for i, p := range params {
+		pairs[i] = p.pair
+	}
+	return strings.Join(pairs, "&")
+}
+
+func paramSet(params []string) map[string]bool {
+	set := make(map[string]bool, len(params))
+	for _, param := range params {
+		set[strings.ToLower(param)] = true
+	}
+	return set
+}
+
+func splitQuery(rawQuery string) []string {
+	var pairs []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair != "" {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+func queryKey(pair string) string {
+	key, _, _ := strings.Cut(pair, "=")
+	return unescape(key)
+}
+
+func unescape(s string) string {
+	if unescaped, err := url.QueryUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package canonurl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		opts    Options
+		want    string
+		wantErr error
+	}{
+		{name: "Already canonical", raw: "https://example.com/docs?a=1", want: "https://example.com/docs?a=1"},
+		{name: "Upper case scheme and host", raw: "HTTPS://Example.COM/Docs", want: "https://example.com/Docs"},
+		{name: "Trailing dot in host", raw: "https://example.com./", want: "https://example.com/"},
+		{name: "Empty path", raw: "https://example.com", want: "https://example.com/"},
+		{name: "Default HTTPS port", raw: "https://example.com:443/docs", want: "https://example.com/docs"},
+		{name: "Default HTTP port", raw: "http://example.com:80/", want: "http://example.com/"},
+		{name: "Other port kept", raw: "https://example.com:8443/", want: "https://example.com:8443/"},
+		{name: "IDN host", raw: "https://Bücher.example/katalog", want: "https://xn--bcher-kva.example/katalog"},
+		{name: "IDN host with port", raw: "http://münchen.de:8080", want: "http://xn--mnchen-3ya.de:8080/"},
+		{name: "IPv6 host", raw: "http://[::1]:80/a", want: "http://[::1]/a"},
+		{name: "Query sorted", raw: "https://example.com/?b=2&a=2&a=1", want: "https://example.com/?a=1&a=2&b=2"},
+		{name: "Query encoding", raw: "https://example.com/?q=a+b&r=a%20b&empty=&flag", want: "https://example.com/?empty=&flag&q=a+b&r=a+b"},
+		{name: "Empty query pairs", raw: "https://example.com/?&a=1&&", want: "https://example.com/?a=1"},
+		{name: "Empty query", raw: "https://example.com/?", want: "https://example.com/"},
+		{name: "Fragment kept", raw: "https://example.com/docs#Install", want: "https://example.com/docs#Install"},
+		{name: "Tracking kept by default", raw: "https://example.com/?gclid=x&id=1", want: "https://example.com/?gclid=x&id=1"},
+		{
+			name: "Tracking stripped",
+			raw:  "https://example.com/?FBCLID=x&id=1&gclid=y&mc_eid=z",
+			opts: Options{StripParams: DefaultTrackingParams},
+			want: "https://example.com/?id=1",
+		},
+		{name: "Too long", raw: "https://example.com/" + strings.Repeat("a", 100), opts: Options{MaxLength: 100}, wantErr: ErrTooLong},
+		{name: "Too long once encoded", raw: "https://例え.テスト/", opts: Options{MaxLength: 24}, wantErr: ErrTooLong},
+		{name: "Relative", raw: "/docs", wantErr: ErrInvalid},
+		{name: "No host", raw: "mailto:someone@example.com", wantErr: ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Canonicalize(tt.raw, tt.opts)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStripParams(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "Nothing to strip", raw: "https://Example.com/a?b=2&a=1", want: "https://Example.com/a?b=2&a=1"},
+		{name: "Order kept", raw: "https://example.com/a?b=2&fbclid=x&a=1#top", want: "https://example.com/a?b=2&a=1#top"},
+		{name: "Only tracking", raw: "https://example.com/a?gclid=x", want: "https://example.com/a"},
+		{name: "No query", raw: "https://example.com/a", want: "https://example.com/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, StripParams(tt.raw, DefaultTrackingParams))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package geoip
+
+import (
+	"container/list"
+	"net/netip"
+	"sync"
+)
+
+// CachedResolver keeps the most recently looked-up addresses in an LRU cache
+// in front of another resolver. Misses are cached as well.
+type CachedResolver struct {
+	next     GeoResolver
+	capacity int
+
+	mu    sync.Mutex
+	order *list.List
+	items map[netip.Addr]*list.Element
+}
+
+type cacheEntry struct {
+	addr     netip.Addr
+	location *Location
+	err      error
+}
+
+func NewCachedResolver(next GeoResolver, capacity int) *CachedResolver {
+	return &CachedResolver{
+		next:     next,
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[netip.Addr]*list.Element, capacity),
+	}
+}
+
+func (c *CachedResolver) Lookup(ip netip.Addr) (*Location, error) {
+	c.mu.Lock()
+	if el, ok := c.items[ip]; ok {
+		c.order.MoveToFront(el)
+		entry := el.Value.(*cacheEntry)
+		c.mu.Unlock()
+		return entry.location, entry.err
+	}
+	c.mu.Unlock()
+
+	location, err := c.next.Lookup(ip)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[ip]; ok {
+		c.order.MoveToFront(el)
+		return location, err
+	}
+	c.items[ip] = c.order.PushFront(&cacheEntry{addr: ip, location: location, err: err})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).addr)
+	}
+
+	return location, err
+}
+
+// Len reports how many addresses are currently cached.
+func (c *CachedResolver) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
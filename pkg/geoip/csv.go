@@ -0,0 +1,124 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CSVResolver serves lookups from a CIDR CSV file with the columns
+// network,country_code,region,city,asn. A header row and trailing columns are allowed.
+// Networks are indexed by prefix length so a lookup is a longest-prefix match of at
+// most 33 (IPv4) or 129 (IPv6) map probes.
+type CSVResolver struct {
+	networks map[netip.Prefix]Location
+	v4Bits   []int
+	v6Bits   []int
+}
+
+// NewCSVResolver loads the CIDR CSV file at path into memory.
+func NewCSVResolver(path string) (*CSVResolver, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: open csv %s: %w", path, err)
+	}
+	defer f.Close()
+
+	return ParseCSV(f)
+}
+
+// ParseCSV builds a CSVResolver from CIDR CSV data.
+func ParseCSV(src io.Reader) (*CSVResolver, error) {
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	resolver := &CSVResolver{networks: make(map[netip.Prefix]Location)}
+	v4Bits := map[int]bool{}
+	v6Bits := map[int]bool{}
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("geoip: csv line %d: %w", line, err)
+		}
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				// header row
+				continue
+			}
+			return nil, fmt.Errorf("geoip: csv line %d: %w", line, err)
+		}
+
+		var loc Location
+		if len(record) > 1 {
+			loc.CountryCode = strings.ToUpper(strings.TrimSpace(record[1]))
+		}
+		if len(record) > 2 {
+			loc.Region = strings.TrimSpace(record[2])
+		}
+		if len(record) > 3 {
+			loc.City = strings.TrimSpace(record[3])
+		}
+		if len(record) > 4 && strings.TrimSpace(record[4]) != "" {
+			asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(record[4])), "AS"), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("geoip: csv line %d: invalid asn %q", line, record[4])
+			}
+			loc.ASN = uint32(asn)
+		}
+		prefix = prefix.Masked()
+		resolver.networks[prefix] = loc
+		if prefix.Addr().Is4() {
+			v4Bits[prefix.Bits()] = true
+		} else {
+			v6Bits[prefix.Bits()] = true
+		}
+	}
+
+	resolver.v4Bits = sortedDesc(v4Bits)
+	resolver.v6Bits = sortedDesc(v6Bits)
+	return resolver, nil
+}
+
+func sortedDesc(set map[int]bool) []int {
+	bits := make([]int, 0, len(set))
+	for b := range set {
+		bits = append(bits, b)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(bits)))
+	return bits
+}
+
+func (r *CSVResolver) Lookup(ip netip.Addr) (*Location, error) {
+	bits := r.v6Bits
+	if ip.Is4() {
+		bits = r.v4Bits
+	}
+
+	for _, b := range bits {
+		prefix, err := ip.Prefix(b)
+		if err != nil {
+			continue
+		}
+		if loc, ok := r.networks[prefix]; ok {
+			return &loc, nil
+		}
+	}
+	return nil, ErrNotFound
+}
@@ -0,0 +1,91 @@
+package geoip
+
+import (
+	"GoShort/config"
+	"GoShort/pkg/logger"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when the resolver has no data for an address.
+	ErrNotFound = errors.New("geoip: address not found")
+	// ErrPrivateAddress is returned for loopback, private and otherwise non-routable addresses.
+	ErrPrivateAddress = errors.New("geoip: private address")
+)
+
+// Location is the geographic and network information known about an IP address.
+type Location struct {
+	CountryCode string
+	Region      string
+	City        string
+	ASN         uint32
+}
+
+// GeoResolver maps a visitor IP address to a Location without leaving the process.
+type GeoResolver interface {
+	Lookup(ip netip.Addr) (*Location, error)
+}
+
+// New builds the resolver selected in the configuration, wrapped in an LRU cache.
+// Provider "none" (or an empty provider) disables GeoIP lookups entirely.
+func New(cfg config.GeoIPConfig, log *logger.Logger) (GeoResolver, error) {
+	var (
+		resolver GeoResolver
+		err      error
+	)
+
+	switch strings.ToLower(cfg.Provider) {
+	case "", "none":
+		log.Info("GeoIP lookups disabled")
+		return NopResolver{}, nil
+	case "mmdb":
+		resolver, err = NewMMDBResolver(cfg.DatabasePath, cfg.ASNDatabasePath)
+	case "csv":
+		resolver, err = NewCSVResolver(cfg.DatabasePath)
+	default:
+		return nil, fmt.Errorf("geoip: unknown provider %q", cfg.Provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("GeoIP lookups enabled using %s database %s", cfg.Provider, cfg.DatabasePath)
+
+	if cfg.CacheSize > 0 {
+		resolver = NewCachedResolver(resolver, cfg.CacheSize)
+	}
+	return resolver, nil
+}
+
+// ParseAddr parses a textual IP address, rejecting addresses that cannot be geolocated.
+func ParseAddr(ipAddress string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ipAddress))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	addr = addr.Unmap()
+	if !isPublic(addr) {
+		return netip.Addr{}, ErrPrivateAddress
+	}
+	return addr, nil
+}
+
+func isPublic(addr netip.Addr) bool {
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// NopResolver never finds a location. It is used when GeoIP is disabled.
+type NopResolver struct{}
+
+func (NopResolver) Lookup(ip netip.Addr) (*Location, error) {
+	return nil, ErrNotFound
+}
@@ -0,0 +1,123 @@
+package geoip
+
+import (
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCSV = `network,country_code,region,city,asn
+8.8.0.0/16,US,California,Mountain View,AS15169
+8.8.8.0/24,US,California,Mountain View,15169
+103.10.0.0/16,ID,Jakarta,Jakarta,7713
+2001:db8::/32,NL,North Holland,Amsterdam,
+`
+
+func TestCSVResolver_Lookup(t *testing.T) {
+	r, err := ParseCSV(strings.NewReader(testCSV))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		ip      string
+		want    *Location
+		wantErr error
+	}{
+		{
+			name: "longest prefix wins",
+			ip:   "8.8.8.8",
+			want: &Location{CountryCode: "US", Region: "California", City: "Mountain View", ASN: 15169},
+		},
+		{
+			name: "covering prefix",
+			ip:   "8.8.4.4",
+			want: &Location{CountryCode: "US", Region: "California", City: "Mountain View", ASN: 15169},
+		},
+		{
+			name: "ipv4",
+			ip:   "103.10.20.30",
+			want: &Location{CountryCode: "ID", Region: "Jakarta", City: "Jakarta", ASN: 7713},
+		},
+		{
+			name: "ipv6 without asn",
+			ip:   "2001:db8::1",
+			want: &Location{CountryCode: "NL", Region: "North Holland", City: "Amsterdam"},
+		},
+		{
+			name:    "unknown",
+			ip:      "1.1.1.1",
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Lookup(netip.MustParseAddr(tt.ip))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseCSV_InvalidNetwork(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("8.8.8.0/24,US\nnot-a-network,US\n"))
+	require.Error(t, err)
+}
+
+func TestParseAddr(t *testing.T) {
+	for _, ip := range []string{"127.0.0.1", "::1", "10.0.0.1", "172.16.0.5", "192.168.1.1", "fe80::1", "garbage"} {
+		_, err := ParseAddr(ip)
+		require.Error(t, err, ip)
+	}
+
+	addr, err := ParseAddr("::ffff:8.8.8.8")
+	require.NoError(t, err)
+	require.True(t, addr.Is4())
+}
+
+type countingResolver struct {
+	calls atomic.Int32
+}
+
+func (c *countingResolver) Lookup(ip netip.Addr) (*Location, error) {
+	c.calls.Add(1)
+	if ip.Is6() {
+		return nil, ErrNotFound
+	}
+	return &Location{CountryCode: "US"}, nil
+}
+
+func TestCachedResolver_LRU(t *testing.T) {
+	next := &countingResolver{}
+	c := NewCachedResolver(next, 2)
+
+	a := netip.MustParseAddr("8.8.8.8")
+	b := netip.MustParseAddr("1.1.1.1")
+	v6 := netip.MustParseAddr("2001:db8::1")
+
+	_, err := c.Lookup(a)
+	require.NoError(t, err)
+	_, err = c.Lookup(a)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), next.calls.Load())
+
+	// Misses are cached too.
+	_, err = c.Lookup(v6)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = c.Lookup(v6)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Equal(t, int32(2), next.calls.Load())
+
+	// a was used least recently and is evicted.
+	_, _ = c.Lookup(b)
+	require.Equal(t, 2, c.Len())
+	_, _ = c.Lookup(a)
+	require.Equal(t, int32(4), next.calls.Load())
+}
@@ -0,0 +1,90 @@
+package geoip
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// MMDBResolver reads MaxMind-format databases (GeoLite2/GeoIP2 City and ASN).
+type MMDBResolver struct {
+	city *maxminddb.Reader
+	asn  *maxminddb.Reader
+}
+
+type mmdbCityRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	// ASN fields are present in combined databases such as GeoIP2 Enterprise.
+	AutonomousSystemNumber uint32 `maxminddb:"autonomous_system_number"`
+}
+
+type mmdbASNRecord struct {
+	AutonomousSystemNumber uint32 `maxminddb:"autonomous_system_number"`
+}
+
+// NewMMDBResolver opens the city database and, optionally, a separate ASN database.
+func NewMMDBResolver(cityPath, asnPath string) (*MMDBResolver, error) {
+	city, err := maxminddb.Open(cityPath)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: open mmdb %s: %w", cityPath, err)
+	}
+
+	r := &MMDBResolver{city: city}
+
+	if asnPath != "" {
+		asn, err := maxminddb.Open(asnPath)
+		if err != nil {
+			_ = city.Close()
+			return nil, fmt.Errorf("geoip: open asn mmdb %s: %w", asnPath, err)
+		}
+		r.asn = asn
+	}
+
+	return r, nil
+}
+
+func (r *MMDBResolver) Lookup(ip netip.Addr) (*Location, error) {
+	var record mmdbCityRecord
+	_, found, err := r.city.LookupNetwork(ip.AsSlice(), &record)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	loc := &Location{
+		CountryCode: record.Country.ISOCode,
+		City:        record.City.Names["en"],
+		ASN:         record.AutonomousSystemNumber,
+	}
+	if len(record.Subdivisions) > 0 {
+		loc.Region = record.Subdivisions[0].Names["en"]
+	}
+
+	if r.asn != nil && loc.ASN == 0 {
+		var asnRecord mmdbASNRecord
+		if _, ok, err := r.asn.LookupNetwork(ip.AsSlice(), &asnRecord); err == nil && ok {
+			loc.ASN = asnRecord.AutonomousSystemNumber
+		}
+	}
+
+	return loc, nil
+}
+
+// Close releases the memory-mapped database files.
+func (r *MMDBResolver) Close() error {
+	if r.asn != nil {
+		_ = r.asn.Close()
+	}
+	return r.city.Close()
+}
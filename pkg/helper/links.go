@@ -1,38 +1,95 @@
 package helper
 
 import (
-	"crypto/rand"
-
-	"regexp"
-	"time"
+	"net/url"
+	"strings"
 )
 
-func GenerateShortCode(length int) (string, error) {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+// IsRedirectStatus reports whether status is one of the redirects a link can use:
+// 301 and 308 are permanent, 302 and 307 temporary, and 307 and 308 keep the method
+// and body of the request.
+func IsRedirectStatus(status int) bool {
+	switch status {
+	case 301, 302, 307, 308:
+		return true
+	default:
+		return false
+	}
+}
+
+// QueryParam is one key and value of a query string.
+type QueryParam struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
 
-	// Add time-based prefix (first 2 characters)
-	timePrefix := make([]byte, 2)
-	now := time.Now().UnixNano()
-	timePrefix[0] = charset[now%int64(len(charset))]
-	timePrefix[1] = charset[(now/int64(len(charset)))%int64(len(charset))]
+// MergeQuery adds query parameters to a destination URL without reordering the ones it
+// already has. Parameters in set replace those of the same key in the destination;
+// those in passthrough, a raw query string, are only added when the key is still free.
+// The destination is returned unchanged when it cannot be parsed.
+func MergeQuery(destination string, set []QueryParam, passthrough string) string {
+	if len(set) == 0 && passthrough == "" {
+		return destination
+	}
 
-	// Generate remaining random characters
-	randomLength := length - len(timePrefix)
-	randomBytes := make([]byte, randomLength)
-	_, err := rand.Read(randomBytes)
+	u, err := url.Parse(destination)
 	if err != nil {
-		return "", err
+		return destination
+	}
+
+	replaced := make(map[string]bool, len(set))
+	for _, param := range set {
+		replaced[param.Key] = true
 	}
 
-	for i, b := range randomBytes {
-		randomBytes[i] = charset[b%byte(len(charset))]
+	var pairs []string
+	taken := make(map[string]bool)
+	for _, pair := range splitQuery(u.RawQuery) {
+		key := queryKey(pair)
+		if replaced[key] {
+			continue
+		}
+		taken[key] = true
+		pairs = append(pairs, pair)
 	}
 
-	return string(timePrefix) + string(randomBytes), nil
+	for _, param := range set {
+		taken[param.Key] = true
+		pairs = append(pairs, url.QueryEscape(param.Key)+"="+url.QueryEscape(param.Value))
+	}
+
+	// Passthrough comes from the visitor, so it is decoded and escaped again
+	for _, pair := range splitQuery(passthrough) {
+		key := queryKey(pair)
+		if key == "" || taken[key] {
+			continue
+		}
+		_, value, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(value))
+	}
+
+	u.RawQuery = strings.Join(pairs, "&")
+	u.ForceQuery = false
+	return u.String()
 }
 
-// IsValidShortCode ensures the short code follows allowed format
-func IsValidShortCode(code string) bool {
-	regex := regexp.MustCompile("^[a-zA-Z0-9_-]{3,16}$")
-	return regex.MatchString(code)
+func splitQuery(rawQuery string) []string {
+	var pairs []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair != "" {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+func queryKey(pair string) string {
+	key, _, _ := strings.Cut(pair, "=")
+	if unescaped, err := url.QueryUnescape(key); err == nil {
+		return unescaped
+	}
+	return key
 }
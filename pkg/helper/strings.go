@@ -14,3 +14,22 @@ func StringJoin(messages []string, s string) string {
 	}
 	return result
 }
+
+// EmptyToNil returns nil for a nil or empty string so it is stored as NULL.
+func EmptyToNil(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
+
+// FirstNonEmpty returns the first string that is neither nil nor empty, or an empty
+// string when there is none.
+func FirstNonEmpty(values ...*string) string {
+	for _, s := range values {
+		if s != nil && *s != "" {
+			return *s
+		}
+	}
+	return ""
+}
@@ -14,9 +14,16 @@ type RdsClient interface {
 	Ping(ctx context.Context) error
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+	Del(ctx context.Context, keys ...string) error
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+	Decr(ctx context.Context, key string) (int64, error)
 	Close() error
 }
 
+// ErrNil is returned by Get when the key does not exist.
+var ErrNil = redis.Nil
+
 type Redis struct {
 	Client *redis.Client
 	Config *config.AppConfig
@@ -72,6 +79,38 @@ func (r *Redis) Set(ctx context.Context, key string, value interface{}, expirati
 	return r.Client.Set(ctx, key, value, expiration).Err()
 }
 
+// SetNX sets key only when it does not exist yet and reports whether it did.
+func (r *Redis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.Client.SetNX(ctx, key, value, expiration).Result()
+}
+
+func (r *Redis) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.Client.Del(ctx, keys...).Err()
+}
+
+// Incr increments the counter stored at key. The first increment starts an expiration
+// window, so the counter resets once window has passed.
+func (r *Redis) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	n, err := r.Client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 && window > 0 {
+		if err := r.Client.Expire(ctx, key, window).Err(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Decr decrements the counter stored at key, leaving its expiration as it is.
+func (r *Redis) Decr(ctx context.Context, key string) (int64, error) {
+	return r.Client.Decr(ctx, key).Result()
+}
+
 func (r *Redis) Close() error {
 	if err := r.Client.Close(); err != nil {
 		r.logger.Errorf("Error closing Redis connection: %v", err)
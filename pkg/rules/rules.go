@@ -0,0 +1,217 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // The runtime image has no zoneinfo database
+
+	"github.com/google/uuid"
+)
+
+// Actions a matching rule can take.
+const (
+	ActionRedirect = "redirect"
+	ActionBlock    = "block"
+)
+
+// Rule routes visitors that match all of its conditions. A condition that is not set
+// matches every visitor, so a rule without conditions always matches.
+type Rule struct {
+	ID               uuid.UUID `json:"id"`
+	Countries        []string  `json:"countries,omitempty"`
+	DeviceTypes      []string  `json:"device_types,omitempty"`
+	OperatingSystems []string  `json:"operating_systems,omitempty"`
+	Languages        []string  `json:"languages,omitempty"`
+	// Days are weekdays, 0 is Sunday
+	Days []int `json:"days,omitempty"`
+	// StartTime and EndTime bound a daily "15:04" window; a window may wrap past midnight
+	StartTime string `json:"start_time,omitempty"`
+	EndTime   string `json:"end_time,omitempty"`
+	// Timezone is the IANA zone Days and the time window are evaluated in, UTC when empty
+	Timezone       string `json:"timezone,omitempty"`
+	Action         string `json:"action"`
+	DestinationURL string `json:"destination_url,omitempty"`
+}
+
+// Visitor is the request a rule is matched against.
+type Visitor struct {
+	// Country is an ISO 3166-1 alpha-2 code
+	Country    string
+	DeviceType string
+	OS         string
+	// Language is the visitor's preferred language tag, such as "id-ID"
+	Language string
+	Time     time.Time
+}
+
+// Match returns the first rule that matches the visitor, or nil when none does.
+func Match(rules []Rule, v Visitor) *Rule {
+	for i := range rules {
+		if rules[i].Matches(v) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// Matches reports whether every condition of the rule holds for the visitor.
+func (r Rule) Matches(v Visitor) bool {
+	if len(r.Countries) > 0 && !containsFold(r.Countries, v.Country) {
+		return false
+	}
+	if len(r.DeviceTypes) > 0 && !containsFold(r.DeviceTypes, v.DeviceType) {
+		return false
+	}
+	if len(r.OperatingSystems) > 0 && !containsFold(r.OperatingSystems, v.OS) {
+		return false
+	}
+	if len(r.Languages) > 0 && !matchLanguage(r.Languages, v.Language) {
+		return false
+	}
+	if len(r.Days) > 0 || r.StartTime != "" || r.EndTime != "" {
+		return r.matchTime(v.Time)
+	}
+	return true
+}
+
+func (r Rule) matchTime(t time.Time) bool {
+	loc, err := loadLocation(r.Timezone)
+	if err != nil {
+		return false
+	}
+	t = t.In(loc)
+
+	if len(r.Days) > 0 {
+		weekday := int(t.Weekday())
+		found := false
+		for _, day := range r.Days {
+			if day == weekday {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	start, hasStart := -1, r.StartTime != ""
+	end, hasEnd := -1, r.EndTime != ""
+	if hasStart {
+		if start, err = ParseClock(r.StartTime); err != nil {
+			return false
+		}
+	}
+	if hasEnd {
+		if end, err = ParseClock(r.EndTime); err != nil {
+			return false
+		}
+	}
+
+	switch {
+	case hasStart && hasEnd && start > end:
+		// The window wraps past midnight, e.g. 22:00-06:00
+		return now >= start || now < end
+	case hasStart && hasEnd:
+		return now >= start && now < end
+	case hasStart:
+		return now >= start
+	case hasEnd:
+		return now < end
+	default:
+		return true
+	}
+}
+
+// ParseClock parses a "15:04" time of day into minutes since midnight.
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: %w", s, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+var locations sync.Map
+
+// loadLocation is time.LoadLocation with a cache, it runs on every rule evaluation.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// ValidTimezone reports whether name is a known IANA time zone.
+func ValidTimezone(name string) bool {
+	_, err := loadLocation(name)
+	return err == nil
+}
+
+// PreferredLanguage returns the language tag with the highest quality value in an
+// Accept-Language header. Ties go to the tag listed first.
+func PreferredLanguage(acceptLanguage string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
+
+// matchLanguage matches a language tag against a list of tags. A rule tag without a
+// region ("id") also matches regional variants ("id-ID").
+func matchLanguage(languages []string, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	for _, language := range languages {
+		if strings.EqualFold(language, tag) {
+			return true
+		}
+		if len(tag) > len(language) && tag[len(language)] == '-' && strings.EqualFold(tag[:len(language)], language) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRule_Matches(t *testing.T) {
+	// Monday 2025-03-03 10:30 UTC is 17:30 in Jakarta
+	monday := time.Date(2025, time.March, 3, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		visitor Visitor
+		want    bool
+	}{
+		{
+			name:    "No conditions match everyone",
+			rule:    Rule{},
+			visitor: Visitor{},
+			want:    true,
+		},
+		{
+			name:    "Country is case-insensitive",
+			rule:    Rule{Countries: []string{"ID", "MY"}},
+			visitor: Visitor{Country: "id"},
+			want:    true,
+		},
+		{
+			name:    "Unknown country does not match a country rule",
+			rule:    Rule{Countries: []string{"ID"}},
+			visitor: Visitor{},
+			want:    false,
+		},
+		{
+			name:    "Device and OS must both match",
+			rule:    Rule{DeviceTypes: []string{"Mobile"}, OperatingSystems: []string{"iOS"}},
+			visitor: Visitor{DeviceType: "Mobile", OS: "Android"},
+			want:    false,
+		},
+		{
+			name:    "OS matches",
+			rule:    Rule{OperatingSystems: []string{"ios", "iPadOS"}},
+			visitor: Visitor{DeviceType: "Tablet", OS: "iOS"},
+			want:    true,
+		},
+		{
+			name:    "Base language matches regional variant",
+			rule:    Rule{Languages: []string{"id"}},
+			visitor: Visitor{Language: "id-ID"},
+			want:    true,
+		},
+		{
+			name:    "Regional language does not match other regions",
+			rule:    Rule{Languages: []string{"en-GB"}},
+			visitor: Visitor{Language: "en-US"},
+			want:    false,
+		},
+		{
+			name:    "Language prefix must end at a subtag",
+			rule:    Rule{Languages: []string{"en"}},
+			visitor: Visitor{Language: "eng"},
+			want:    false,
+		},
+		{
+			name:    "Day of week",
+			rule:    Rule{Days: []int{1, 2, 3, 4, 5}},
+			visitor: Visitor{Time: monday},
+			want:    true,
+		},
+		{
+			name:    "Weekend only",
+			rule:    Rule{Days: []int{0, 6}},
+			visitor: Visitor{Time: monday},
+			want:    false,
+		},
+		{
+			name:    "Time window in UTC",
+			rule:    Rule{StartTime: "09:00", EndTime: "17:00"},
+			visitor: Visitor{Time: monday},
+			want:    true,
+		},
+		{
+			name:    "Time window in another timezone",
+			rule:    Rule{StartTime: "09:00", EndTime: "17:00", Timezone: "Asia/Jakarta"},
+			visitor: Visitor{Time: monday},
+			want:    false,
+		},
+		{
+			name:    "Window wrapping past midnight",
+			rule:    Rule{StartTime: "17:00", EndTime: "02:00", Timezone: "Asia/Jakarta"},
+			visitor: Visitor{Time: monday},
+			want:    true,
+		},
+		{
+			name:    "End of window is exclusive",
+			rule:    Rule{EndTime: "10:30"},
+			visitor: Visitor{Time: monday},
+			want:    false,
+		},
+		{
+			name:    "Unknown timezone never matches",
+			rule:    Rule{StartTime: "00:00", Timezone: "Mars/Olympus"},
+			visitor: Visitor{Time: monday},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.rule.Matches(tt.visitor))
+		})
+	}
+}
+
+func TestMatch_FirstRuleWins(t *testing.T) {
+	rules := []Rule{
+		{OperatingSystems: []string{"iOS"}, Action: ActionRedirect, DestinationURL: "https://apps.apple.com/app"},
+		{OperatingSystems: []string{"Android"}, Action: ActionRedirect, DestinationURL: "https://play.google.com/store"},
+		{Countries: []string{"ID"}, Action: ActionRedirect, DestinationURL: "https://example.com/id"},
+		{Countries: []string{"KP"}, Action: ActionBlock},
+	}
+
+	rule := Match(rules, Visitor{Country: "ID", OS: "iOS"})
+	require.NotNil(t, rule)
+	require.Equal(t, "https://apps.apple.com/app", rule.DestinationURL)
+
+	rule = Match(rules, Visitor{Country: "ID", OS: "Windows"})
+	require.NotNil(t, rule)
+	require.Equal(t, "https://example.com/id", rule.DestinationURL)
+
+	rule = Match(rules, Visitor{Country: "KP"})
+	require.NotNil(t, rule)
+	require.Equal(t, ActionBlock, rule.Action)
+
+	require.Nil(t, Match(rules, Visitor{Country: "US", OS: "macOS"}))
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"id-ID", "id-ID"},
+		{"en-US,en;q=0.9,id;q=0.8", "en-US"},
+		{"en;q=0.5, id-ID;q=0.9", "id-ID"},
+		{"*;q=1, fr;q=0.3", "fr"},
+		{"de;q=bad, nl", "nl"},
+		{"ja;q=0", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			require.Equal(t, tt.want, PreferredLanguage(tt.header))
+		})
+	}
+}
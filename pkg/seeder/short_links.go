@@ -42,12 +42,14 @@ func (s *Seeder) SeedShortLinks() error {
 			s.log.Errorf("Failed to generate UUID for short link: %v", err)
 			return err
 		}
+		originalURL := fmt.Sprintf("https://example.com/page/%d", i)
 		params := datastore.CreateShortLinkParams{
-			ID:          linkUUID,
-			UserID:      userUUID,
-			ShortCode:   fmt.Sprintf("code%03d", i),
-			OriginalUrl: fmt.Sprintf("https://example.com/page/%d", i),
-			IsActive:    true,
+			ID:           linkUUID,
+			UserID:       userUUID,
+			ShortCode:    fmt.Sprintf("code%03d", i),
+			OriginalUrl:  originalURL,
+			CanonicalUrl: originalURL,
+			IsActive:     true,
 		}
 		_, err = s.repo.CreateShortLink(s.ctx, params)
 		if err != nil {
@@ -0,0 +1,144 @@
+package shortcode
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/google/uuid"
+)
+
+// Strategies a CodeGenerator can use.
+const (
+	// StrategyRandom picks characters uniformly from the base62 alphabet
+	StrategyRandom = "random"
+	// StrategyNoConfusables picks random characters from an alphabet without look-alikes
+	// such as 0/O and 1/l/I, for codes that are read aloud or typed from print
+	StrategyNoConfusables = "no-confusables"
+	// StrategySequential encodes a database counter in base62. Codes are short but
+	// guessable, so every link can be enumerated
+	StrategySequential = "sequential"
+	// StrategyHashID derives the code from the link ID, the same link always gets the
+	// same code
+	StrategyHashID = "hashid"
+)
+
+const (
+	// AlphabetBase62 is the alphabet of random, sequential and hashid codes
+	AlphabetBase62 = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// AlphabetNoConfusables leaves out 0, O, o, 1, l and I
+	AlphabetNoConfusables = "23456789abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+)
+
+// CodeGenerator creates short codes for new links. A code that turns out to be taken is
+// retried by the caller, usually with a longer length.
+type CodeGenerator interface {
+	Generate(ctx context.Context, linkID uuid.UUID, length int) (string, error)
+}
+
+// Counter hands out increasing values for sequential codes. The datastore implements it
+// with a Postgres sequence, so codes stay unique across instances.
+type Counter interface {
+	NextShortCodeSequence(ctx context.Context) (int64, error)
+}
+
+// New returns the generator of a strategy. The counter is only used by sequential codes.
+func New(strategy string, counter Counter) (CodeGenerator, error) {
+	switch strategy {
+	case StrategyRandom, "":
+		return NewRandom(AlphabetBase62), nil
+	case StrategyNoConfusables:
+		return NewRandom(AlphabetNoConfusables), nil
+	case StrategySequential:
+		return NewSequential(counter), nil
+	case StrategyHashID:
+		return NewHashID(), nil
+	default:
+		return nil, fmt.Errorf("unknown short code strategy %q", strategy)
+	}
+}
+
+// Random picks every character independently and uniformly from its alphabet.
+type Random struct {
+	alphabet string
+}
+
+func NewRandom(alphabet string) *Random {
+	return &Random{alphabet: alphabet}
+}
+
+func (r *Random) Generate(ctx context.Context, linkID uuid.UUID, length int) (string, error) {
+	max := big.NewInt(int64(len(r.alphabet)))
+	code := make([]byte, length)
+	for i := range code {
+		// rand.Int rejects out-of-range samples instead of folding them with a modulo,
+		// so every character is equally likely
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = r.alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// Sequential encodes the next counter value in base62, left-padded to the length.
+type Sequential struct {
+	counter Counter
+}
+
+func NewSequential(counter Counter) *Sequential {
+	return &Sequential{counter: counter}
+}
+
+func (s *Sequential) Generate(ctx context.Context, linkID uuid.UUID, length int) (string, error) {
+	n, err := s.counter.NextShortCodeSequence(ctx)
+	if err != nil {
+		return "", err
+	}
+	return pad(encode(big.NewInt(n), AlphabetBase62), length), nil
+}
+
+// HashID hashes the link ID and encodes the hash in base62. A longer length extends the
+// code of the shorter length, which keeps collisions retryable.
+type HashID struct{}
+
+func NewHashID() *HashID {
+	return &HashID{}
+}
+
+func (h *HashID) Generate(ctx context.Context, linkID uuid.UUID, length int) (string, error) {
+	sum := sha256.Sum256(linkID[:])
+	code := pad(encode(new(big.Int).SetBytes(sum[:]), AlphabetBase62), length)
+	return code[:length], nil
+}
+
+// encode writes n in the base of the alphabet, most significant digit first.
+func encode(n *big.Int, alphabet string) string {
+	base := big.NewInt(int64(len(alphabet)))
+	if n.Sign() == 0 {
+		return alphabet[:1]
+	}
+
+	n = new(big.Int).Set(n)
+	mod := new(big.Int)
+	var digits []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		digits = append(digits, alphabet[mod.Int64()])
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
+
+// pad left-pads a code with the zero digit of the base62 alphabet.
+func pad(code string, length int) string {
+	for len(code) < length {
+		code = AlphabetBase62[:1] + code
+	}
+	return code
+}
@@ -0,0 +1,101 @@
+package shortcode
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCounter struct {
+	next int64
+}
+
+func (c *fakeCounter) NextShortCodeSequence(ctx context.Context) (int64, error) {
+	c.next++
+	return c.next, nil
+}
+
+func TestNew(t *testing.T) {
+	for _, strategy := range []string{"", StrategyRandom, StrategyNoConfusables, StrategySequential, StrategyHashID} {
+		_, err := New(strategy, &fakeCounter{})
+		require.NoError(t, err, strategy)
+	}
+
+	_, err := New("uuid", nil)
+	require.Error(t, err)
+}
+
+func TestRandom_Generate(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+	}{
+		{name: "Base62", alphabet: AlphabetBase62},
+		{name: "No confusables", alphabet: AlphabetNoConfusables},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewRandom(tt.alphabet)
+			seen := map[string]bool{}
+			for i := 0; i < 200; i++ {
+				code, err := gen.Generate(context.Background(), uuid.New(), 8)
+				require.NoError(t, err)
+				require.Len(t, code, 8)
+				for _, r := range code {
+					require.True(t, strings.ContainsRune(tt.alphabet, r), "unexpected character %q", r)
+				}
+				require.False(t, seen[code], "duplicate code %s", code)
+				seen[code] = true
+			}
+		})
+	}
+
+	require.NotContains(t, AlphabetNoConfusables, "0")
+	require.NotContains(t, AlphabetNoConfusables, "O")
+	require.NotContains(t, AlphabetNoConfusables, "l")
+	require.NotContains(t, AlphabetNoConfusables, "I")
+}
+
+func TestSequential_Generate(t *testing.T) {
+	gen := NewSequential(&fakeCounter{next: 59})
+
+	want := []string{"0000Y", "0000Z", "00010"}
+	for _, code := range want {
+		got, err := gen.Generate(context.Background(), uuid.Nil, 5)
+		require.NoError(t, err)
+		require.Equal(t, code, got)
+	}
+}
+
+func TestHashID_Generate(t *testing.T) {
+	gen := NewHashID()
+	linkID := uuid.Must(uuid.NewV7())
+
+	short, err := gen.Generate(context.Background(), linkID, 7)
+	require.NoError(t, err)
+	require.Len(t, short, 7)
+
+	again, err := gen.Generate(context.Background(), linkID, 7)
+	require.NoError(t, err)
+	require.Equal(t, short, again)
+
+	// A longer code extends the shorter one, so retrying with more characters is useful
+	long, err := gen.Generate(context.Background(), linkID, 9)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(long, short))
+
+	other, err := gen.Generate(context.Background(), uuid.Must(uuid.NewV7()), 7)
+	require.NoError(t, err)
+	require.NotEqual(t, short, other)
+}
+
+func TestEncode(t *testing.T) {
+	require.Equal(t, "0", encode(big.NewInt(0), AlphabetBase62))
+	require.Equal(t, "Z", encode(big.NewInt(61), AlphabetBase62))
+	require.Equal(t, "10", encode(big.NewInt(62), AlphabetBase62))
+}
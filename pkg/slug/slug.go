@@ -0,0 +1,78 @@
+package slug
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Length limits of a custom short code, in characters. MaxLength matches the
+// short_code column.
+const (
+	MinLength = 3
+	MaxLength = 100
+)
+
+// Normalize returns the form a short code is stored and looked up in. Unicode is
+// composed (NFC), so an "é" typed as one or as two code points is the same code, and
+// the slashes around path-style codes are dropped. foldCase lowercases the code for
+// servers that match codes case-insensitively.
+func Normalize(code string, foldCase bool) string {
+	code = norm.NFC.String(strings.TrimSpace(code))
+	code = strings.Trim(code, "/")
+	if foldCase {
+		code = strings.ToLower(code)
+	}
+	return code
+}
+
+// Valid reports whether a normalized code follows the slug policy: MinLength to
+// MaxLength characters made of letters, digits, marks, emoji, "-" and "_", optionally
+// split into path segments by single slashes like "launch/2026". Characters with a
+// meaning in URLs, such as ".", "+", "?", "#" and "%", and spaces are rejected.
+func Valid(code string) bool {
+	if !utf8.ValidString(code) {
+		return false
+	}
+	length := utf8.RuneCountInString(code)
+	if length < MinLength || length > MaxLength {
+		return false
+	}
+
+	for _, segment := range strings.Split(code, "/") {
+		if segment == "" {
+			return false
+		}
+		for _, r := range segment {
+			if !validRune(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FirstSegment returns the first path segment of a code, the whole code when it has a
+// single segment.
+func FirstSegment(code string) string {
+	first, _, _ := strings.Cut(code, "/")
+	return first
+}
+
+func validRune(r rune) bool {
+	switch {
+	case r == '-' || r == '_':
+		return true
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+		return true
+	// Emoji, including skin tone modifiers, joiners and variation selectors
+	case unicode.Is(unicode.So, r) || unicode.Is(unicode.Sk, r):
+		return true
+	case r == '\u200d' || unicode.Is(unicode.Variation_Selector, r):
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,63 @@
+package slug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		foldCase bool
+		want     string
+	}{
+		{name: "Plain code", code: "Launch", want: "Launch"},
+		{name: "Folded case", code: "Launch", foldCase: true, want: "launch"},
+		{name: "Path slashes are trimmed", code: "/launch/2026/", want: "launch/2026"},
+		{name: "Decomposed accent is composed", code: "café", want: "café"},
+		{name: "Folded Unicode", code: "ÉTÉ", foldCase: true, want: "été"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Normalize(tt.code, tt.foldCase))
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{name: "Alphanumeric", code: "abc123", want: true},
+		{name: "Hyphen and underscore", code: "spring-sale_2026", want: true},
+		{name: "Path style", code: "launch/2026", want: true},
+		{name: "Unicode letters", code: "café", want: true},
+		{name: "Emoji", code: "🚀🚀🚀", want: true},
+		{name: "Emoji with joiner and skin tone", code: "👩🏽\u200d💻-jobs", want: true},
+		{name: "Longer than the old limit", code: "a-very-long-campaign-slug-for-2026", want: true},
+		{name: "Too short", code: "ab", want: false},
+		{name: "Too long", code: string(make([]byte, MaxLength+1)), want: false},
+		{name: "Empty segment", code: "launch//2026", want: false},
+		{name: "Dot", code: "file.txt", want: false},
+		{name: "Preview suffix", code: "launch+", want: false},
+		{name: "Space", code: "big sale", want: false},
+		{name: "Percent", code: "100%off", want: false},
+		{name: "Query", code: "a?b=c", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Valid(tt.code))
+		})
+	}
+}
+
+func TestFirstSegment(t *testing.T) {
+	require.Equal(t, "launch", FirstSegment("launch/2026"))
+	require.Equal(t, "launch", FirstSegment("launch"))
+}
@@ -0,0 +1,261 @@
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Device classes recorded in link_stats.device_type.
+const (
+	DeviceDesktop = "Desktop"
+	DeviceMobile  = "Mobile"
+	DeviceTablet  = "Tablet"
+	DeviceTV      = "TV"
+	DeviceConsole = "Console"
+	DeviceBot     = "Bot"
+	DeviceUnknown = "Unknown"
+)
+
+// Other is used for browsers and operating systems that are not recognised.
+const Other = "Other"
+
+// Result is the information extracted from a User-Agent header.
+type Result struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	DeviceType     string
+	IsBot          bool
+}
+
+type browserRule struct {
+	name  string
+	token string
+}
+
+// botRules are matched case-insensitively, in order. The first match names the bot.
+var botRules = []browserRule{
+	{"Googlebot", "googlebot"},
+	{"Google Inspection Tool", "google-inspectiontool"},
+	{"Bingbot", "bingbot"},
+	{"Bing Preview", "bingpreview"},
+	{"Applebot", "applebot"},
+	{"DuckDuckBot", "duckduckbot"},
+	{"YandexBot", "yandex"},
+	{"Baiduspider", "baiduspider"},
+	{"Facebook", "facebookexternalhit"},
+	{"Facebook", "facebookcatalog"},
+	{"Twitterbot", "twitterbot"},
+	{"LinkedInBot", "linkedinbot"},
+	{"Slackbot", "slackbot"},
+	{"Slack", "slack-imgproxy"},
+	{"Discordbot", "discordbot"},
+	{"TelegramBot", "telegrambot"},
+	{"WhatsApp", "whatsapp"},
+	{"Skype", "skypeuripreview"},
+	{"Pinterest", "pinterest"},
+	{"Embedly", "embedly"},
+	{"Iframely", "iframely"},
+	{"Mastodon", "mastodon"},
+	{"Headless Chrome", "headlesschrome"},
+}
+
+// unfurlers are the bots, by the name botRules gives them, that fetch a link to show a
+// preview card of it in a chat or a post.
+var unfurlers = map[string]bool{
+	"Facebook":    true,
+	"Twitterbot":  true,
+	"LinkedInBot": true,
+	"Slackbot":    true,
+	"Discordbot":  true,
+	"TelegramBot": true,
+	"WhatsApp":    true,
+	"Skype":       true,
+	"Pinterest":   true,
+	"Embedly":     true,
+	"Iframely":    true,
+	"Mastodon":    true,
+}
+
+// genericBotPattern catches the long tail of crawlers that identify themselves. Plain
+// HTTP clients such as curl or API libraries are not bots: scripts following a link are
+// clicks like any other.
+var genericBotPattern = regexp.MustCompile(`(?i)(bot|crawler|spider|crawling|scraper)\b`)
+
+// browserRules are matched in order; browsers that embed another browser's token
+// (Edge and Opera contain "Chrome/", Chrome contains "Safari/") come first.
+var browserRules = []browserRule{
+	{"Facebook", "FBAV/"},
+	{"Instagram", "Instagram "},
+	{"LINE", "Line/"},
+	{"Edge", "Edg/"},
+	{"Edge", "EdgA/"},
+	{"Edge", "EdgiOS/"},
+	{"Edge", "Edge/"},
+	{"Opera", "OPR/"},
+	{"Opera", "OPiOS/"},
+	{"Opera", "Opera/"},
+	{"Samsung Internet", "SamsungBrowser/"},
+	{"UC Browser", "UCBrowser/"},
+	{"Yandex Browser", "YaBrowser/"},
+	{"Vivaldi", "Vivaldi/"},
+	{"Firefox", "FxiOS/"},
+	{"Firefox", "Firefox/"},
+	{"Chrome", "CriOS/"},
+	{"Chromium", "Chromium/"},
+	{"Chrome", "Chrome/"},
+	{"Internet Explorer", "MSIE "},
+}
+
+var (
+	safariVersionPattern = regexp.MustCompile(`Version/([\d.]+)`)
+	tridentPattern       = regexp.MustCompile(`Trident/.*rv:([\d.]+)`)
+	tvPattern            = regexp.MustCompile(`(?i)smart-?tv|googletv|appletv|\bcrkey\b|roku|bravia|hbbtv|netcast|\baft[a-z]+\b|tizen.*tv|web0s|webos.*tv`)
+	consolePattern       = regexp.MustCompile(`(?i)playstation|xbox|nintendo`)
+)
+
+// Parse extracts the browser, operating system and device class from a User-Agent.
+func Parse(ua string) Result {
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return Result{Browser: Other, OS: Other, DeviceType: DeviceUnknown}
+	}
+
+	if name, ok := detectBot(ua); ok {
+		return Result{
+			Browser:    name,
+			OS:         detectOS(ua),
+			DeviceType: DeviceBot,
+			IsBot:      true,
+		}
+	}
+
+	browser, version := detectBrowser(ua)
+	return Result{
+		Browser:        browser,
+		BrowserVersion: version,
+		OS:             detectOS(ua),
+		DeviceType:     detectDevice(ua),
+	}
+}
+
+// IsBot reports whether the User-Agent belongs to a crawler or link unfurler.
+func IsBot(ua string) bool {
+	_, ok := detectBot(ua)
+	return ok
+}
+
+// IsUnfurler reports whether the User-Agent belongs to a known link unfurler, as
+// opposed to a search engine crawler or another bot.
+func IsUnfurler(ua string) bool {
+	name, ok := detectBot(ua)
+	return ok && unfurlers[name]
+}
+
+func detectBot(ua string) (string, bool) {
+	lower := strings.ToLower(ua)
+	for _, rule := range botRules {
+		if strings.Contains(lower, rule.token) {
+			return rule.name, true
+		}
+	}
+	if genericBotPattern.MatchString(ua) {
+		return "Other Bot", true
+	}
+	return "", false
+}
+
+func detectBrowser(ua string) (string, string) {
+	for _, rule := range browserRules {
+		if idx := strings.Index(ua, rule.token); idx >= 0 {
+			return rule.name, readVersion(ua[idx+len(rule.token):])
+		}
+	}
+
+	if m := tridentPattern.FindStringSubmatch(ua); m != nil {
+		return "Internet Explorer", m[1]
+	}
+
+	if strings.Contains(ua, "Safari/") || strings.Contains(ua, "AppleWebKit/") {
+		version := ""
+		if m := safariVersionPattern.FindStringSubmatch(ua); m != nil {
+			version = m[1]
+		}
+		if strings.Contains(ua, "Android") && strings.Contains(ua, "; wv)") {
+			return "Android WebView", version
+		}
+		return "Safari", version
+	}
+
+	return Other, ""
+}
+
+// readVersion returns the leading dotted version number of s.
+func readVersion(s string) string {
+	end := 0
+	for end < len(s) && (s[end] == '.' || (s[end] >= '0' && s[end] <= '9')) {
+		end++
+	}
+	return strings.TrimRight(s[:end], ".")
+}
+
+func detectOS(ua string) string {
+	switch {
+	case strings.Contains(ua, "Windows Phone"):
+		return "Windows Phone"
+	case strings.Contains(ua, "Xbox"):
+		return "Xbox"
+	case strings.Contains(ua, "Windows"):
+		return "Windows"
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"), strings.Contains(ua, "iPod"):
+		return "iOS"
+	case strings.Contains(ua, "AppleTV"), strings.Contains(ua, "tvOS"):
+		return "tvOS"
+	case strings.Contains(ua, "Android"):
+		return "Android"
+	case strings.Contains(ua, "CrOS"):
+		return "Chrome OS"
+	case strings.Contains(ua, "Macintosh"), strings.Contains(ua, "Mac OS X"):
+		return "macOS"
+	case strings.Contains(ua, "Tizen"):
+		return "Tizen"
+	case strings.Contains(ua, "Web0S"), strings.Contains(ua, "webOS"):
+		return "webOS"
+	case strings.Contains(ua, "PlayStation"):
+		return "PlayStation"
+	case strings.Contains(ua, "Linux"), strings.Contains(ua, "X11"):
+		return "Linux"
+	default:
+		return Other
+	}
+}
+
+func detectDevice(ua string) string {
+	switch {
+	case tvPattern.MatchString(ua):
+		return DeviceTV
+	case consolePattern.MatchString(ua):
+		return DeviceConsole
+	case isTablet(ua):
+		return DeviceTablet
+	case strings.Contains(ua, "Mobi"), strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPod"),
+		strings.Contains(ua, "Windows Phone"), strings.Contains(ua, "Opera Mini"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+func isTablet(ua string) bool {
+	lower := strings.ToLower(ua)
+	switch {
+	case strings.Contains(lower, "ipad"), strings.Contains(lower, "tablet"),
+		strings.Contains(lower, "kindle"), strings.Contains(lower, "silk/"), strings.Contains(lower, "playbook"):
+		return true
+	case strings.Contains(lower, "android") && !strings.Contains(lower, "mobile"):
+		// Android phones always send "Mobile"; Android tablets do not.
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,131 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want Result
+	}{
+		{
+			name: "chrome on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.82 Safari/537.36",
+			want: Result{Browser: "Chrome", BrowserVersion: "124.0.6367.82", OS: "Windows", DeviceType: DeviceDesktop},
+		},
+		{
+			name: "edge on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.67",
+			want: Result{Browser: "Edge", BrowserVersion: "124.0.2478.67", OS: "Windows", DeviceType: DeviceDesktop},
+		},
+		{
+			name: "safari on macos",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Safari/605.1.15",
+			want: Result{Browser: "Safari", BrowserVersion: "17.4.1", OS: "macOS", DeviceType: DeviceDesktop},
+		},
+		{
+			name: "firefox on linux",
+			ua:   "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			want: Result{Browser: "Firefox", BrowserVersion: "125.0", OS: "Linux", DeviceType: DeviceDesktop},
+		},
+		{
+			name: "safari on iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			want: Result{Browser: "Safari", BrowserVersion: "17.4", OS: "iOS", DeviceType: DeviceMobile},
+		},
+		{
+			name: "safari on ipad",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			want: Result{Browser: "Safari", BrowserVersion: "17.4", OS: "iOS", DeviceType: DeviceTablet},
+		},
+		{
+			name: "chrome on android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.82 Mobile Safari/537.36",
+			want: Result{Browser: "Chrome", BrowserVersion: "124.0.6367.82", OS: "Android", DeviceType: DeviceMobile},
+		},
+		{
+			name: "samsung internet on android tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Safari/537.36",
+			want: Result{Browser: "Samsung Internet", BrowserVersion: "24.0", OS: "Android", DeviceType: DeviceTablet},
+		},
+		{
+			name: "chrome on iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/124.0.6367.88 Mobile/15E148 Safari/604.1",
+			want: Result{Browser: "Chrome", BrowserVersion: "124.0.6367.88", OS: "iOS", DeviceType: DeviceMobile},
+		},
+		{
+			name: "samsung smart tv",
+			ua:   "Mozilla/5.0 (SMART-TV; LINUX; Tizen 6.0) AppleWebKit/537.36 (KHTML, like Gecko) 76.0.3809.146/6.0 TV Safari/537.36",
+			want: Result{Browser: "Safari", OS: "Tizen", DeviceType: DeviceTV},
+		},
+		{
+			name: "xbox",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64; Xbox; Xbox One) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/70.0.3538.102 Safari/537.36 Edge/18.19041",
+			want: Result{Browser: "Edge", BrowserVersion: "18.19041", OS: "Xbox", DeviceType: DeviceConsole},
+		},
+		{
+			name: "googlebot",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: Result{Browser: "Googlebot", OS: Other, DeviceType: DeviceBot, IsBot: true},
+		},
+		{
+			name: "slack unfurler",
+			ua:   "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)",
+			want: Result{Browser: "Slackbot", OS: Other, DeviceType: DeviceBot, IsBot: true},
+		},
+		{
+			name: "curl is not a bot",
+			ua:   "curl/8.5.0",
+			want: Result{Browser: Other, OS: Other, DeviceType: DeviceDesktop},
+		},
+		{
+			name: "uptime monitor is not a bot",
+			ua:   "Site24x7 Monitor",
+			want: Result{Browser: Other, OS: Other, DeviceType: DeviceDesktop},
+		},
+		{
+			name: "unknown crawler",
+			ua:   "Mozilla/5.0 (compatible; AhrefsBot/7.0; +http://ahrefs.com/robot/)",
+			want: Result{Browser: "Other Bot", OS: Other, DeviceType: DeviceBot, IsBot: true},
+		},
+		{
+			name: "empty",
+			ua:   "",
+			want: Result{Browser: Other, OS: Other, DeviceType: DeviceUnknown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Parse(tt.ua))
+		})
+	}
+}
+
+func TestIsUnfurler(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want bool
+	}{
+		{"facebook", "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true},
+		{"twitter", "Twitterbot/1.0", true},
+		{"slack", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true},
+		{"discord", "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", true},
+		{"whatsapp", "WhatsApp/2.23.20.0", true},
+		{"slack image proxy", "Slack-ImgProxy (+https://api.slack.com/robots)", false},
+		{"googlebot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", false},
+		{"curl", "curl/8.5.0", false},
+		{"browser", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, IsUnfurler(tt.ua))
+		})
+	}
+}